@@ -15,8 +15,13 @@ import (
 	"github.com/go-acme/lego/v4/acme/api/internal/secure"
 	"github.com/go-acme/lego/v4/acme/api/internal/sender"
 	"github.com/go-acme/lego/v4/log"
+	"github.com/go-acme/lego/v4/platform/wait"
 )
 
+// maxRetryAfter is the maximum Retry-After delay of a rate limit error or of a "503 Service Unavailable" response
+// to retry a request, longer delays are returned as errors.
+const maxRetryAfter = 10 * time.Second
+
 // Core ACME/LE core API.
 type Core struct {
 	doer         *sender.Doer
@@ -93,6 +98,11 @@ func (a *Core) retrievablePost(uri string, content []byte, response interface{})
 				return err
 			}
 
+			// Retry after the delay asked by the server if it's short enough.
+			if delay, ok := RetryDelay(resp, err); ok {
+				return wait.RetryAfter(err, delay)
+			}
+
 			return backoff.Permanent(err)
 		}
 
@@ -103,7 +113,7 @@ func (a *Core) retrievablePost(uri string, content []byte, response interface{})
 		log.Infof("retry due to: %v", err)
 	}
 
-	err := backoff.RetryNotify(operation, bo, notify)
+	err := wait.Retry(operation, bo, notify)
 	if err != nil {
 		return resp, err
 	}
@@ -111,6 +121,36 @@ func (a *Core) retrievablePost(uri string, content []byte, response interface{})
 	return resp, nil
 }
 
+// RetryDelay returns the delay before retrying a request which failed with the error 'err'
+// ('resp' is the response of the request, it can be nil):
+// the Retry-After of a rate limit error, or of a "503 Service Unavailable" response.
+// It returns false if the request must not be retried: other errors, or delays longer than maxRetryAfter
+// (the rate limit errors are returned as is to allow the caller to reschedule).
+func RetryDelay(resp *http.Response, err error) (time.Duration, bool) {
+	var delay time.Duration
+
+	var rateLimitErr *acme.RateLimitError
+	switch {
+	case errors.As(err, &rateLimitErr):
+		if rateLimitErr.RetryAfter.IsZero() {
+			return 0, false
+		}
+		delay = time.Until(rateLimitErr.RetryAfter)
+
+	case resp != nil && resp.StatusCode == http.StatusServiceUnavailable:
+		delay = wait.ParseRetryAfter(getRetryAfter(resp))
+
+	default:
+		return 0, false
+	}
+
+	if delay <= 0 || delay > maxRetryAfter {
+		return 0, false
+	}
+
+	return delay, true
+}
+
 func (a *Core) signedPost(uri string, content []byte, response interface{}) (*http.Response, error) {
 	signedContent, err := a.jws.SignContent(uri, content)
 	if err != nil {
@@ -144,6 +184,11 @@ func (a *Core) GetKeyAuthorization(token string) (string, error) {
 	return a.jws.GetKeyAuthorization(token)
 }
 
+// GetAccountURL Gets the URL of the account (the key identifier of the JWS).
+func (a *Core) GetAccountURL() string {
+	return a.jws.GetKid()
+}
+
 func (a *Core) GetDirectory() acme.Directory {
 	return a.directory
 }
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-acme/lego/v4/acme"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryDelay(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		resp     *http.Response
+		err      error
+		expected bool
+	}{
+		{
+			desc:     "rate limit with a short Retry-After",
+			err:      &acme.RateLimitError{ProblemDetails: &acme.ProblemDetails{}, RetryAfter: time.Now().Add(5 * time.Second)},
+			expected: true,
+		},
+		{
+			desc: "rate limit with a long Retry-After",
+			err:  &acme.RateLimitError{ProblemDetails: &acme.ProblemDetails{}, RetryAfter: time.Now().Add(time.Hour)},
+		},
+		{
+			desc: "rate limit without Retry-After",
+			err:  &acme.RateLimitError{ProblemDetails: &acme.ProblemDetails{}},
+		},
+		{
+			desc: "service unavailable with a short Retry-After",
+			resp: &http.Response{
+				StatusCode: http.StatusServiceUnavailable,
+				Header:     http.Header{"Retry-After": []string{"5"}},
+			},
+			err:      errors.New("unavailable"),
+			expected: true,
+		},
+		{
+			desc: "service unavailable with a long Retry-After",
+			resp: &http.Response{
+				StatusCode: http.StatusServiceUnavailable,
+				Header:     http.Header{"Retry-After": []string{"3600"}},
+			},
+			err: errors.New("unavailable"),
+		},
+		{
+			desc: "service unavailable without Retry-After",
+			resp: &http.Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{}},
+			err:  errors.New("unavailable"),
+		},
+		{
+			desc: "other error",
+			resp: &http.Response{
+				StatusCode: http.StatusInternalServerError,
+				Header:     http.Header{"Retry-After": []string{"5"}},
+			},
+			err: errors.New("internal"),
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			delay, ok := RetryDelay(test.resp, test.err)
+			assert.Equal(t, test.expected, ok)
+
+			if test.expected {
+				assert.Greater(t, int64(delay), int64(0))
+				assert.LessOrEqual(t, int64(delay), int64(maxRetryAfter))
+			} else {
+				assert.Zero(t, delay)
+			}
+		})
+	}
+}
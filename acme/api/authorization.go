@@ -8,6 +8,26 @@ import (
 
 type AuthorizationService service
 
+// New Creates a new authorization (pre-authorization).
+// - https://tools.ietf.org/html/rfc8555#section-7.4.1
+func (c *AuthorizationService) New(identifier acme.Identifier) (acme.ExtendedAuthorization, error) {
+	newAuthzURL := c.core.GetDirectory().NewAuthzURL
+	if newAuthzURL == "" {
+		return acme.ExtendedAuthorization{}, errors.New("authorization[new]: the server does not support pre-authorization")
+	}
+
+	var authz acme.Authorization
+	resp, err := c.core.post(newAuthzURL, acme.NewAuthzMessage{Identifier: identifier}, &authz)
+	if err != nil {
+		return acme.ExtendedAuthorization{}, err
+	}
+
+	return acme.ExtendedAuthorization{
+		Authorization: authz,
+		Location:      getLocation(resp),
+	}, nil
+}
+
 // Get Gets an authorization.
 func (c *AuthorizationService) Get(authzURL string) (acme.Authorization, error) {
 	if authzURL == "" {
@@ -3,6 +3,7 @@ package secure
 import (
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rsa"
 	"encoding/base64"
@@ -33,23 +34,48 @@ func (j *JWS) SetKid(kid string) {
 	j.kid = kid
 }
 
+// GetKid Gets the key identifier.
+func (j *JWS) GetKid() string {
+	return j.kid
+}
+
 // SignContent Signs a content with the JWS.
 func (j *JWS) SignContent(url string, content []byte) (*jose.JSONWebSignature, error) {
 	var alg jose.SignatureAlgorithm
+	key := j.privKey
+
 	switch k := j.privKey.(type) {
 	case *rsa.PrivateKey:
 		alg = jose.RS256
 	case *ecdsa.PrivateKey:
-		if k.Curve == elliptic.P256() {
+		switch k.Curve {
+		case elliptic.P256():
 			alg = jose.ES256
-		} else if k.Curve == elliptic.P384() {
+		case elliptic.P384():
 			alg = jose.ES384
+		case elliptic.P521():
+			alg = jose.ES512
+		}
+	case ed25519.PrivateKey:
+		alg = jose.EdDSA
+	case crypto.Signer:
+		// the private key is not accessible (ex: HSM).
+		opaque, err := newOpaqueSigner(k)
+		if err != nil {
+			return nil, err
 		}
+
+		alg = opaque.alg
+		key = opaque
+	}
+
+	if alg == "" {
+		return nil, fmt.Errorf("unsupported account key: %T", j.privKey)
 	}
 
 	signKey := jose.SigningKey{
 		Algorithm: alg,
-		Key:       jose.JSONWebKey{Key: j.privKey, KeyID: j.kid},
+		Key:       jose.JSONWebKey{Key: key, KeyID: j.kid},
 	}
 
 	options := jose.SignerOptions{
@@ -77,8 +103,8 @@ func (j *JWS) SignContent(url string, content []byte) (*jose.JSONWebSignature, e
 
 // SignEABContent Signs an external account binding content with the JWS.
 func (j *JWS) SignEABContent(url, kid string, hmac []byte) (*jose.JSONWebSignature, error) {
-	jwk := jose.JSONWebKey{Key: j.privKey}
-	jwkJSON, err := jwk.Public().MarshalJSON()
+	jwk := jose.JSONWebKey{Key: j.publicKey()}
+	jwkJSON, err := jwk.MarshalJSON()
 	if err != nil {
 		return nil, fmt.Errorf("acme: error encoding eab jwk key: %w", err)
 	}
@@ -107,16 +133,8 @@ func (j *JWS) SignEABContent(url, kid string, hmac []byte) (*jose.JSONWebSignatu
 
 // GetKeyAuthorization Gets the key authorization for a token.
 func (j *JWS) GetKeyAuthorization(token string) (string, error) {
-	var publicKey crypto.PublicKey
-	switch k := j.privKey.(type) {
-	case *ecdsa.PrivateKey:
-		publicKey = k.Public()
-	case *rsa.PrivateKey:
-		publicKey = k.Public()
-	}
-
 	// Generate the Key Authorization for the challenge
-	jwk := &jose.JSONWebKey{Key: publicKey}
+	jwk := &jose.JSONWebKey{Key: j.publicKey()}
 
 	thumbBytes, err := jwk.Thumbprint(crypto.SHA256)
 	if err != nil {
@@ -128,3 +146,12 @@ func (j *JWS) GetKeyAuthorization(token string) (string, error) {
 
 	return token + "." + keyThumb, nil
 }
+
+// publicKey returns the public key of the account key.
+func (j *JWS) publicKey() crypto.PublicKey {
+	if signer, ok := j.privKey.(crypto.Signer); ok {
+		return signer.Public()
+	}
+
+	return nil
+}
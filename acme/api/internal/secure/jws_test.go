@@ -1,6 +1,7 @@
 package secure
 
 import (
+	"crypto"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -9,7 +10,11 @@ import (
 	"github.com/go-acme/lego/v4/acme"
 	"github.com/go-acme/lego/v4/acme/api/internal/nonces"
 	"github.com/go-acme/lego/v4/acme/api/internal/sender"
+	"github.com/go-acme/lego/v4/certcrypto"
 	"github.com/go-acme/lego/v4/platform/tester"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	jose "gopkg.in/square/go-jose.v2"
 )
 
 func TestNotHoldingLockWhileMakingHTTPRequests(t *testing.T) {
@@ -54,3 +59,48 @@ func TestNotHoldingLockWhileMakingHTTPRequests(t *testing.T) {
 		t.Fatal("JWS is probably holding a lock while making HTTP request")
 	}
 }
+
+func TestJWS_SignContent(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Replay-Nonce", "12345")
+	}))
+	t.Cleanup(ts.Close)
+
+	testCases := []struct {
+		keyType  certcrypto.KeyType
+		expected string
+	}{
+		{keyType: certcrypto.EC256, expected: "ES256"},
+		{keyType: certcrypto.EC384, expected: "ES384"},
+		{keyType: certcrypto.EC521, expected: "ES512"},
+		{keyType: certcrypto.RSA2048, expected: "RS256"},
+		{keyType: certcrypto.RSA3072, expected: "RS256"},
+		{keyType: certcrypto.Ed25519, expected: "EdDSA"},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(string(test.keyType), func(t *testing.T) {
+			t.Parallel()
+
+			privateKey, err := certcrypto.GeneratePrivateKey(test.keyType)
+			require.NoError(t, err)
+
+			doer := sender.NewDoer(http.DefaultClient, "lego-test")
+			j := NewJWS(privateKey, "", nonces.NewManager(doer, ts.URL))
+
+			signed, err := j.SignContent("https://example.com", []byte("content"))
+			require.NoError(t, err)
+
+			parsed, err := jose.ParseSigned(signed.FullSerialize())
+			require.NoError(t, err)
+
+			require.Len(t, parsed.Signatures, 1)
+			assert.Equal(t, test.expected, parsed.Signatures[0].Header.Algorithm)
+
+			payload, err := parsed.Verify(privateKey.(crypto.Signer).Public())
+			require.NoError(t, err)
+			assert.Equal(t, "content", string(payload))
+		})
+	}
+}
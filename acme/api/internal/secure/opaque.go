@@ -0,0 +1,125 @@
+package secure
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// opaqueSigner a jose.OpaqueSigner based on a crypto.Signer,
+// used when the private key is not accessible (ex: a key stored in an HSM).
+type opaqueSigner struct {
+	signer crypto.Signer
+	alg    jose.SignatureAlgorithm
+}
+
+func newOpaqueSigner(signer crypto.Signer) (*opaqueSigner, error) {
+	var alg jose.SignatureAlgorithm
+
+	switch pub := signer.Public().(type) {
+	case *rsa.PublicKey:
+		alg = jose.RS256
+	case *ecdsa.PublicKey:
+		switch pub.Curve {
+		case elliptic.P256():
+			alg = jose.ES256
+		case elliptic.P384():
+			alg = jose.ES384
+		case elliptic.P521():
+			alg = jose.ES512
+		default:
+			return nil, fmt.Errorf("unsupported curve: %s", pub.Curve.Params().Name)
+		}
+	case ed25519.PublicKey:
+		alg = jose.EdDSA
+	default:
+		return nil, fmt.Errorf("unsupported public key type: %T", pub)
+	}
+
+	return &opaqueSigner{signer: signer, alg: alg}, nil
+}
+
+// Public returns the public key of the signer.
+func (s *opaqueSigner) Public() *jose.JSONWebKey {
+	return &jose.JSONWebKey{Key: s.signer.Public()}
+}
+
+// Algs returns the supported signature algorithm.
+func (s *opaqueSigner) Algs() []jose.SignatureAlgorithm {
+	return []jose.SignatureAlgorithm{s.alg}
+}
+
+// SignPayload signs a payload, the ECDSA signatures are converted from ASN.1 to the JWS format (R || S).
+func (s *opaqueSigner) SignPayload(payload []byte, alg jose.SignatureAlgorithm) ([]byte, error) {
+	if alg != s.alg {
+		return nil, jose.ErrUnsupportedAlgorithm
+	}
+
+	var hash crypto.Hash
+
+	switch alg {
+	case jose.RS256, jose.ES256:
+		hash = crypto.SHA256
+	case jose.ES384:
+		hash = crypto.SHA384
+	case jose.ES512:
+		hash = crypto.SHA512
+	case jose.EdDSA:
+		return s.signer.Sign(rand.Reader, payload, crypto.Hash(0))
+	default:
+		return nil, jose.ErrUnsupportedAlgorithm
+	}
+
+	hasher := hash.New()
+	_, _ = hasher.Write(payload)
+
+	signature, err := s.signer.Sign(rand.Reader, hasher.Sum(nil), hash)
+	if err != nil {
+		return nil, err
+	}
+
+	pub, ok := s.signer.Public().(*ecdsa.PublicKey)
+	if !ok {
+		return signature, nil
+	}
+
+	return convertECDSASignature(signature, pub.Curve)
+}
+
+// convertECDSASignature converts an ASN.1 ECDSA signature to the JWS format (RFC 7518, section 3.4).
+func convertECDSASignature(signature []byte, curve elliptic.Curve) ([]byte, error) {
+	var sig struct {
+		R, S *big.Int
+	}
+
+	rest, err := asn1.Unmarshal(signature, &sig)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rest) > 0 {
+		return nil, errors.New("trailing data after the ECDSA signature")
+	}
+
+	size := (curve.Params().BitSize + 7) / 8
+
+	r, s := sig.R.Bytes(), sig.S.Bytes()
+	if len(r) > size || len(s) > size {
+		return nil, errors.New("invalid ECDSA signature")
+	}
+
+	out := make([]byte, 2*size)
+	copy(out[size-len(r):size], r)
+	copy(out[2*size-len(s):], s)
+
+	return out, nil
+}
@@ -0,0 +1,84 @@
+package secure
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// hiddenSigner hides the concrete type of the private key, like a key stored in an HSM.
+type hiddenSigner struct {
+	crypto.Signer
+}
+
+func Test_opaqueSigner(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc     string
+		key      crypto.Signer
+		expected jose.SignatureAlgorithm
+	}{
+		{desc: "RSA", key: rsaKey, expected: jose.RS256},
+		{desc: "P-256", key: newECDSAKey(t, elliptic.P256()), expected: jose.ES256},
+		{desc: "P-384", key: newECDSAKey(t, elliptic.P384()), expected: jose.ES384},
+		{desc: "P-521", key: newECDSAKey(t, elliptic.P521()), expected: jose.ES512},
+		{desc: "Ed25519", key: edKey, expected: jose.EdDSA},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			opaque, err := newOpaqueSigner(hiddenSigner{test.key})
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, opaque.alg)
+
+			signer, err := jose.NewSigner(jose.SigningKey{Algorithm: opaque.alg, Key: jose.JSONWebKey{Key: opaque, KeyID: "kid"}}, nil)
+			require.NoError(t, err)
+
+			signed, err := signer.Sign([]byte("content"))
+			require.NoError(t, err)
+
+			payload, err := signed.Verify(test.key.Public())
+			require.NoError(t, err)
+
+			assert.Equal(t, "content", string(payload))
+		})
+	}
+}
+
+func TestJWS_GetKeyAuthorization_opaque(t *testing.T) {
+	key := newECDSAKey(t, elliptic.P256())
+
+	expected, err := NewJWS(key, "", nil).GetKeyAuthorization("token")
+	require.NoError(t, err)
+
+	actual, err := NewJWS(hiddenSigner{key}, "", nil).GetKeyAuthorization("token")
+	require.NoError(t, err)
+
+	assert.Equal(t, expected, actual)
+}
+
+func newECDSAKey(t *testing.T, curve elliptic.Curve) *ecdsa.PrivateKey {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(curve, rand.Reader)
+	require.NoError(t, err)
+
+	return key
+}
@@ -8,8 +8,10 @@ import (
 	"net/http"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/go-acme/lego/v4/acme"
+	"github.com/go-acme/lego/v4/platform/wait"
 )
 
 type RequestOption func(*http.Request) error
@@ -88,7 +90,7 @@ func (d *Doer) newRequest(method, uri string, body io.Reader, opts ...RequestOpt
 func (d *Doer) do(req *http.Request, response interface{}) (*http.Response, error) {
 	resp, err := d.httpClient.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, &acme.TransportError{Err: err}
 	}
 
 	if err = checkError(req, resp); err != nil {
@@ -98,7 +100,7 @@ func (d *Doer) do(req *http.Request, response interface{}) (*http.Response, erro
 	if response != nil {
 		raw, err := ioutil.ReadAll(resp.Body)
 		if err != nil {
-			return resp, err
+			return resp, &acme.TransportError{Err: err}
 		}
 
 		defer resp.Body.Close()
@@ -139,6 +141,20 @@ func checkError(req *http.Request, resp *http.Response) error {
 			return &acme.NonceError{ProblemDetails: errorDetails}
 		}
 
+		if errorDetails.Type == acme.BadSignatureAlgorithmErr {
+			return &acme.BadSignatureAlgorithmError{ProblemDetails: errorDetails}
+		}
+
+		if errorDetails.Type == acme.RateLimitedErr || resp.StatusCode == http.StatusTooManyRequests {
+			rateLimitErr := &acme.RateLimitError{ProblemDetails: errorDetails}
+
+			if retryAfter := wait.ParseRetryAfter(resp.Header.Get("Retry-After")); retryAfter > 0 {
+				rateLimitErr.RetryAfter = time.Now().Add(retryAfter)
+			}
+
+			return rateLimitErr
+		}
+
 		return errorDetails
 	}
 	return nil
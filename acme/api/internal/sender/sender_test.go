@@ -5,7 +5,9 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-acme/lego/v4/acme"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -65,3 +67,44 @@ func TestDo_CustomUserAgent(t *testing.T) {
 	}
 	assert.Len(t, strings.Split(ua, " "), 5)
 }
+
+func TestDo_rateLimited(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"type":"urn:ietf:params:acme:error:rateLimited","detail":"too many certificates","status":429}`))
+	}))
+	defer ts.Close()
+
+	doer := NewDoer(http.DefaultClient, "")
+
+	_, err := doer.Post(ts.URL, strings.NewReader("falalalala"), "text/plain", nil)
+	require.Error(t, err)
+
+	var rateLimitErr *acme.RateLimitError
+	require.ErrorAs(t, err, &rateLimitErr)
+
+	assert.Equal(t, acme.RateLimitedErr, rateLimitErr.Type)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), rateLimitErr.RetryAfter, time.Minute)
+}
+
+func TestDo_badSignatureAlgorithm(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"type":"urn:ietf:params:acme:error:badSignatureAlgorithm","detail":"EdDSA is not supported","status":400,"algorithms":["RS256","ES256"]}`))
+	}))
+	defer ts.Close()
+
+	doer := NewDoer(http.DefaultClient, "")
+
+	_, err := doer.Post(ts.URL, strings.NewReader("falalalala"), "text/plain", nil)
+	require.Error(t, err)
+
+	var algErr *acme.BadSignatureAlgorithmError
+	require.ErrorAs(t, err, &algErr)
+
+	assert.Equal(t, []string{"RS256", "ES256"}, algErr.Algorithms)
+	assert.Contains(t, err.Error(), "(accepted: RS256, ES256)")
+}
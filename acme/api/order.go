@@ -3,6 +3,7 @@ package api
 import (
 	"encoding/base64"
 	"errors"
+	"fmt"
 
 	"github.com/go-acme/lego/v4/acme"
 )
@@ -37,12 +38,55 @@ func (o *OrderService) Get(orderURL string) (acme.ExtendedOrder, error) {
 	}
 
 	var order acme.Order
-	_, err := o.core.postAsGet(orderURL, &order)
+	resp, err := o.core.postAsGet(orderURL, &order)
 	if err != nil {
 		return acme.ExtendedOrder{}, err
 	}
 
-	return acme.ExtendedOrder{Order: order}, nil
+	return acme.ExtendedOrder{Order: order, RetryAfter: getRetryAfter(resp)}, nil
+}
+
+// ListPage Gets a page of the orders list of an account,
+// next is the URL of the next page (empty if it's the last page).
+// - https://tools.ietf.org/html/rfc8555#section-7.1.2.1
+func (o *OrderService) ListPage(ordersURL string) (orders []string, next string, err error) {
+	if ordersURL == "" {
+		return nil, "", errors.New("order[list]: empty URL")
+	}
+
+	var list acme.OrdersList
+	resp, err := o.core.postAsGet(ordersURL, &list)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return list.Orders, getLink(resp.Header, "next"), nil
+}
+
+// List Gets all the orders of an account, the pages of the list are followed (Link rel="next").
+// - https://tools.ietf.org/html/rfc8555#section-7.1.2.1
+func (o *OrderService) List(ordersURL string) ([]string, error) {
+	var orders []string
+
+	visited := map[string]bool{}
+
+	for next := ordersURL; next != ""; {
+		if visited[next] {
+			return nil, fmt.Errorf("order[list]: loop in the pages of the orders list: %s", next)
+		}
+
+		visited[next] = true
+
+		page, nextURL, err := o.ListPage(next)
+		if err != nil {
+			return nil, err
+		}
+
+		orders = append(orders, page...)
+		next = nextURL
+	}
+
+	return orders, nil
 }
 
 // UpdateForCSR Updates an order for a CSR.
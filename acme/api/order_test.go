@@ -66,6 +66,74 @@ func TestOrderService_New(t *testing.T) {
 	assert.Equal(t, expected, order)
 }
 
+func TestOrderService_List(t *testing.T) {
+	mux, apiURL, tearDown := tester.SetupFakeAPI()
+	defer tearDown()
+
+	// small value keeps test fast
+	privateKey, errK := rsa.GenerateKey(rand.Reader, 512)
+	require.NoError(t, errK, "Could not generate test key")
+
+	mux.HandleFunc("/orders", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		list := acme.OrdersList{Orders: []string{apiURL + "/order/1", apiURL + "/order/2"}}
+		if r.URL.Query().Get("cursor") == "2" {
+			list = acme.OrdersList{Orders: []string{apiURL + "/order/3"}}
+		} else {
+			w.Header().Add("Link", `<`+apiURL+`/orders?cursor=2>;rel="next"`)
+		}
+
+		err := tester.WriteJSONResponse(w, list)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	})
+
+	core, err := New(http.DefaultClient, "lego-test", apiURL+"/dir", "", privateKey)
+	require.NoError(t, err)
+
+	orders, next, err := core.Orders.ListPage(apiURL + "/orders")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{apiURL + "/order/1", apiURL + "/order/2"}, orders)
+	assert.Equal(t, apiURL+"/orders?cursor=2", next)
+
+	orders, err = core.Orders.List(apiURL + "/orders")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{apiURL + "/order/1", apiURL + "/order/2", apiURL + "/order/3"}, orders)
+}
+
+func TestOrderService_List_loop(t *testing.T) {
+	mux, apiURL, tearDown := tester.SetupFakeAPI()
+	defer tearDown()
+
+	// small value keeps test fast
+	privateKey, errK := rsa.GenerateKey(rand.Reader, 512)
+	require.NoError(t, errK, "Could not generate test key")
+
+	mux.HandleFunc("/orders", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Add("Link", `<`+apiURL+`/orders>;rel="next"`)
+
+		err := tester.WriteJSONResponse(w, acme.OrdersList{Orders: []string{apiURL + "/order/1"}})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	})
+
+	core, err := New(http.DefaultClient, "lego-test", apiURL+"/dir", "", privateKey)
+	require.NoError(t, err)
+
+	_, err = core.Orders.List(apiURL + "/orders")
+	require.EqualError(t, err, "order[list]: loop in the pages of the orders list: "+apiURL+"/orders")
+}
+
 func readSignedBody(r *http.Request, privateKey *rsa.PrivateKey) ([]byte, error) {
 	reqBody, err := ioutil.ReadAll(r.Body)
 	if err != nil {
@@ -11,6 +11,7 @@ import (
 // https://tools.ietf.org/html/rfc8555#section-7.1.6
 const (
 	StatusPending     = "pending"
+	StatusReady       = "ready"
 	StatusInvalid     = "invalid"
 	StatusValid       = "valid"
 	StatusProcessing  = "processing"
@@ -109,6 +110,9 @@ type ExtendedOrder struct {
 
 	// The order URL, contains the value of the response header `Location`
 	Location string `json:"-"`
+
+	// Contains the value of the response header `Retry-After`
+	RetryAfter string `json:"-"`
 }
 
 // Order the ACME order Object.
@@ -164,6 +168,25 @@ type Order struct {
 	Certificate string `json:"certificate,omitempty"`
 }
 
+// OrdersList the ACME orders list object.
+// - https://tools.ietf.org/html/rfc8555#section-7.1.2.1
+type OrdersList struct {
+	// orders (required, array of string):
+	// An array of URLs, each identifying an order belonging to the account.
+	// The server SHOULD include pending orders and SHOULD NOT include orders that are invalid in the array of URLs.
+	// The server MAY return an incomplete list, along with a Link header field with a "next" link relation
+	// indicating where further entries can be acquired.
+	Orders []string `json:"orders"`
+}
+
+// ExtendedAuthorization a extended Authorization.
+type ExtendedAuthorization struct {
+	Authorization
+
+	// The authorization URL, contains the value of the response header `Location`
+	Location string `json:"-"`
+}
+
 // Authorization the ACME authorization object.
 // - https://tools.ietf.org/html/rfc8555#section-7.1.4
 type Authorization struct {
@@ -247,6 +270,11 @@ type Challenge struct {
 
 	// https://tools.ietf.org/html/rfc8555#section-8.1
 	KeyAuthorization string `json:"keyAuthorization"`
+
+	// issuer-domain-names (required for dns-persist-01, array of string):
+	// The issuer domain names of the CA, one of them must be used in the dns-persist-01 record.
+	// https://datatracker.ietf.org/doc/draft-ietf-acme-dns-persist/
+	IssuerDomainNames []string `json:"issuer-domain-names,omitempty"`
 }
 
 // Identifier the ACME identifier object.
@@ -256,6 +284,14 @@ type Identifier struct {
 	Value string `json:"value"`
 }
 
+// NewAuthzMessage a pre-authorization request.
+// - https://tools.ietf.org/html/rfc8555#section-7.4.1
+type NewAuthzMessage struct {
+	// identifier (required, object):
+	// The identifier that the account is authorized to represent.
+	Identifier Identifier `json:"identifier"`
+}
+
 // CSRMessage Certificate Signing Request.
 // - https://tools.ietf.org/html/rfc8555#section-7.4
 type CSRMessage struct {
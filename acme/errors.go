@@ -2,12 +2,18 @@ package acme
 
 import (
 	"fmt"
+	"strings"
+	"time"
 )
 
 // Errors types.
 const (
-	errNS       = "urn:ietf:params:acme:error:"
-	BadNonceErr = errNS + "badNonce"
+	errNS                    = "urn:ietf:params:acme:error:"
+	BadCSRErr                = errNS + "badCSR"
+	BadNonceErr              = errNS + "badNonce"
+	BadSignatureAlgorithmErr = errNS + "badSignatureAlgorithm"
+	RateLimitedErr           = errNS + "rateLimited"
+	ServerInternalErr        = errNS + "serverInternal"
 )
 
 // ProblemDetails the problem details object.
@@ -20,6 +26,10 @@ type ProblemDetails struct {
 	Instance    string       `json:"instance,omitempty"`
 	SubProblems []SubProblem `json:"subproblems,omitempty"`
 
+	// Algorithms the signature algorithms accepted by the server (badSignatureAlgorithm error).
+	// - https://tools.ietf.org/html/rfc8555#section-6.2
+	Algorithms []string `json:"algorithms,omitempty"`
+
 	// additional values to have a better error message (Not defined by the RFC)
 	Method string `json:"method,omitempty"`
 	URL    string `json:"url,omitempty"`
@@ -56,3 +66,42 @@ func (p ProblemDetails) Error() string {
 type NonceError struct {
 	*ProblemDetails
 }
+
+// RateLimitError represents the error which is returned
+// if the client has exceeded a rate limit of the server.
+type RateLimitError struct {
+	*ProblemDetails
+
+	// RetryAfter the time after which the request can be retried (from the header `Retry-After`).
+	// Zero if the server didn't provide it.
+	RetryAfter time.Time
+}
+
+// TransportError represents the error which is returned
+// if the communication with the server failed (ex: connection refused, timeout, TLS error).
+type TransportError struct {
+	Err error
+}
+
+func (e *TransportError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// BadSignatureAlgorithmError represents the error which is returned
+// if the server doesn't accept the signature algorithm of the account key.
+type BadSignatureAlgorithmError struct {
+	*ProblemDetails
+}
+
+func (e *BadSignatureAlgorithmError) Error() string {
+	if len(e.Algorithms) == 0 {
+		return "acme: the signature algorithm of the account key is not accepted by the server: " + e.ProblemDetails.Error()
+	}
+
+	return fmt.Sprintf("acme: the signature algorithm of the account key is not accepted by the server (accepted: %s): %v",
+		strings.Join(e.Algorithms, ", "), e.ProblemDetails)
+}
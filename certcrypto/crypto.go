@@ -1,6 +1,7 @@
 package certcrypto
 
 import (
+	"bytes"
 	"crypto"
 	"crypto/ecdsa"
 	"crypto/ed25519"
@@ -24,9 +25,12 @@ import (
 const (
 	EC256   = KeyType("P256")
 	EC384   = KeyType("P384")
+	EC521   = KeyType("P521")
 	RSA2048 = KeyType("2048")
+	RSA3072 = KeyType("3072")
 	RSA4096 = KeyType("4096")
 	RSA8192 = KeyType("8192")
+	Ed25519 = KeyType("Ed25519")
 )
 
 const (
@@ -116,37 +120,92 @@ func GeneratePrivateKey(keyType KeyType) (crypto.PrivateKey, error) {
 		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
 	case EC384:
 		return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case EC521:
+		return ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
 	case RSA2048:
 		return rsa.GenerateKey(rand.Reader, 2048)
+	case RSA3072:
+		return rsa.GenerateKey(rand.Reader, 3072)
 	case RSA4096:
 		return rsa.GenerateKey(rand.Reader, 4096)
 	case RSA8192:
 		return rsa.GenerateKey(rand.Reader, 8192)
+	case Ed25519:
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		return privateKey, err
 	}
 
 	return nil, fmt.Errorf("invalid KeyType: %s", keyType)
 }
 
-func GenerateCSR(privateKey crypto.PrivateKey, domain string, san []string, mustStaple bool) ([]byte, error) {
-	template := x509.CertificateRequest{
-		Subject:  pkix.Name{CommonName: domain},
-		DNSNames: san,
+// GetKeyType returns the key type of a public key (or of the public key of a private key).
+func GetKeyType(key interface{}) (KeyType, error) {
+	if signer, ok := key.(crypto.Signer); ok {
+		key = signer.Public()
 	}
 
-	if mustStaple {
-		template.ExtraExtensions = append(template.ExtraExtensions, pkix.Extension{
-			Id:    tlsFeatureExtensionOID,
-			Value: ocspMustStapleFeature,
-		})
+	switch pub := key.(type) {
+	case *ecdsa.PublicKey:
+		switch pub.Curve {
+		case elliptic.P256():
+			return EC256, nil
+		case elliptic.P384():
+			return EC384, nil
+		case elliptic.P521():
+			return EC521, nil
+		}
+
+		return "", fmt.Errorf("unsupported curve: %s", pub.Curve.Params().Name)
+
+	case *rsa.PublicKey:
+		switch pub.N.BitLen() {
+		case 2048:
+			return RSA2048, nil
+		case 3072:
+			return RSA3072, nil
+		case 4096:
+			return RSA4096, nil
+		case 8192:
+			return RSA8192, nil
+		}
+
+		return "", fmt.Errorf("unsupported RSA key size: %d", pub.N.BitLen())
+
+	case ed25519.PublicKey:
+		return Ed25519, nil
 	}
 
-	return x509.CreateCertificateRequest(rand.Reader, &template, privateKey)
+	return "", fmt.Errorf("unsupported key type: %T", key)
 }
 
+// GenerateCSR generates a CSR for the domain (common name) and the SANs (see GenerateCSRWithOptions).
+func GenerateCSR(privateKey crypto.PrivateKey, domain string, san []string, mustStaple bool) ([]byte, error) {
+	return GenerateCSRWithOptions(privateKey, domain, san, CSROptions{MustStaple: mustStaple})
+}
+
+// HasMustStaple reports whether the certificate contains the OCSP must staple TLS feature extension.
+func HasMustStaple(cert *x509.Certificate) bool {
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(tlsFeatureExtensionOID) && bytes.Equal(ext.Value, ocspMustStapleFeature) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PEMEncode encodes the data to PEM.
+// Returns nil if the data cannot be encoded (ex: a crypto.Signer backed by an HSM).
 func PEMEncode(data interface{}) []byte {
-	return pem.EncodeToMemory(PEMBlock(data))
+	pemBlock := PEMBlock(data)
+	if pemBlock == nil {
+		return nil
+	}
+
+	return pem.EncodeToMemory(pemBlock)
 }
 
+// PEMBlock returns the PEM block of the data, or nil if the data cannot be encoded.
 func PEMBlock(data interface{}) *pem.Block {
 	var pemBlock *pem.Block
 	switch key := data.(type) {
@@ -155,6 +214,10 @@ func PEMBlock(data interface{}) *pem.Block {
 		pemBlock = &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes}
 	case *rsa.PrivateKey:
 		pemBlock = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	case ed25519.PrivateKey:
+		// Ed25519 keys only have a PKCS#8 encoding.
+		keyBytes, _ := x509.MarshalPKCS8PrivateKey(key)
+		pemBlock = &pem.Block{Type: "PRIVATE KEY", Bytes: keyBytes}
 	case *x509.CertificateRequest:
 		pemBlock = &pem.Block{Type: "CERTIFICATE REQUEST", Bytes: key.Raw}
 	case DERCertificateBytes:
@@ -5,6 +5,7 @@ import (
 	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/x509"
 	"testing"
 	"time"
 
@@ -19,6 +20,48 @@ func TestGeneratePrivateKey(t *testing.T) {
 	assert.NotNil(t, key)
 }
 
+func TestGeneratePrivateKey_keyTypes(t *testing.T) {
+	keyTypes := []KeyType{EC256, EC384, EC521, RSA2048, RSA3072, Ed25519}
+
+	for _, keyType := range keyTypes {
+		keyType := keyType
+		t.Run(string(keyType), func(t *testing.T) {
+			t.Parallel()
+
+			key, err := GeneratePrivateKey(keyType)
+			require.NoError(t, err)
+
+			actual, err := GetKeyType(key)
+			require.NoError(t, err)
+			assert.Equal(t, keyType, actual)
+
+			// PEM round trip.
+			parsed, err := ParsePEMPrivateKey(PEMEncode(key))
+			require.NoError(t, err)
+			// the keys are compared with their Equal method: the internal precomputed values are not comparable.
+			privateKey, ok := key.(interface{ Equal(crypto.PrivateKey) bool })
+			require.True(t, ok)
+			assert.True(t, privateKey.Equal(parsed))
+
+			// CSR signed by the key.
+			der, err := GenerateCSR(key, "lego.acme", []string{"lego.acme"}, false)
+			require.NoError(t, err)
+
+			csr, err := x509.ParseCertificateRequest(der)
+			require.NoError(t, err)
+			require.NoError(t, csr.CheckSignature())
+		})
+	}
+}
+
+func TestGetKeyType_unsupported(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+
+	_, err = GetKeyType(privateKey)
+	require.Error(t, err)
+}
+
 func TestGenerateCSR(t *testing.T) {
 	privateKey, err := rsa.GenerateKey(rand.Reader, 512)
 	require.NoError(t, err, "Error generating private key")
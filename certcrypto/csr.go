@@ -0,0 +1,184 @@
+package certcrypto
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The OIDs of the key usage extensions.
+var (
+	keyUsageExtensionOID    = asn1.ObjectIdentifier{2, 5, 29, 15}
+	extKeyUsageExtensionOID = asn1.ObjectIdentifier{2, 5, 29, 37}
+)
+
+// keyUsages the names of the key usages (RFC 5280 section 4.2.1.3).
+var keyUsages = map[string]x509.KeyUsage{
+	"digitalSignature":  x509.KeyUsageDigitalSignature,
+	"contentCommitment": x509.KeyUsageContentCommitment,
+	"keyEncipherment":   x509.KeyUsageKeyEncipherment,
+	"dataEncipherment":  x509.KeyUsageDataEncipherment,
+	"keyAgreement":      x509.KeyUsageKeyAgreement,
+	"keyCertSign":       x509.KeyUsageCertSign,
+	"cRLSign":           x509.KeyUsageCRLSign,
+	"encipherOnly":      x509.KeyUsageEncipherOnly,
+	"decipherOnly":      x509.KeyUsageDecipherOnly,
+}
+
+// extKeyUsages the names and the OIDs of the extended key usages (RFC 5280 section 4.2.1.12).
+var extKeyUsages = map[string]asn1.ObjectIdentifier{
+	"serverAuth":      {1, 3, 6, 1, 5, 5, 7, 3, 1},
+	"clientAuth":      {1, 3, 6, 1, 5, 5, 7, 3, 2},
+	"codeSigning":     {1, 3, 6, 1, 5, 5, 7, 3, 3},
+	"emailProtection": {1, 3, 6, 1, 5, 5, 7, 3, 4},
+	"timeStamping":    {1, 3, 6, 1, 5, 5, 7, 3, 8},
+	"OCSPSigning":     {1, 3, 6, 1, 5, 5, 7, 3, 9},
+}
+
+// CSROptions the options of the CSR generated by GenerateCSRWithOptions.
+type CSROptions struct {
+	// Template the template of the CSR (optional):
+	// the subject fields (except the common name), the URI and email SANs, and the extra extensions are used.
+	// The DNS names of the CSR are always the domains of the certificate.
+	Template *x509.CertificateRequest
+
+	// OmitCommonName creates a CSR without common name (the domains are only in the SANs).
+	OmitCommonName bool
+
+	// MustStaple adds the OCSP must staple TLS feature extension.
+	MustStaple bool
+}
+
+// GenerateCSRWithOptions generates a CSR for the domain (common name) and the SANs, customized by the options.
+func GenerateCSRWithOptions(privateKey crypto.PrivateKey, domain string, san []string, options CSROptions) ([]byte, error) {
+	var template x509.CertificateRequest
+	if options.Template != nil {
+		template = x509.CertificateRequest{
+			Subject:         options.Template.Subject,
+			EmailAddresses:  options.Template.EmailAddresses,
+			URIs:            options.Template.URIs,
+			ExtraExtensions: append([]pkix.Extension{}, options.Template.ExtraExtensions...),
+		}
+	}
+
+	template.Subject.CommonName = domain
+	if options.OmitCommonName {
+		template.Subject.CommonName = ""
+	}
+
+	template.DNSNames = san
+
+	if options.MustStaple {
+		template.ExtraExtensions = append(template.ExtraExtensions, pkix.Extension{
+			Id:    tlsFeatureExtensionOID,
+			Value: ocspMustStapleFeature,
+		})
+	}
+
+	return x509.CreateCertificateRequest(rand.Reader, &template, privateKey)
+}
+
+// KeyUsageExtension returns the key usage extension (critical) of the key usages (ex: "digitalSignature", "keyEncipherment").
+func KeyUsageExtension(names []string) (pkix.Extension, error) {
+	var usage x509.KeyUsage
+
+	for _, name := range names {
+		u, ok := findKeyUsage(name)
+		if !ok {
+			return pkix.Extension{}, fmt.Errorf("unknown key usage: %q", name)
+		}
+
+		usage |= u
+	}
+
+	// the bits of the BIT STRING are numbered from the most significant bit of the first byte.
+	var bits [2]byte
+	bitLength := 0
+
+	for i := 0; i < 9; i++ {
+		if usage&(1<<uint(i)) != 0 {
+			bits[i/8] |= 0x80 >> uint(i%8)
+			bitLength = i + 1
+		}
+	}
+
+	value, err := asn1.Marshal(asn1.BitString{Bytes: bits[:(bitLength+7)/8], BitLength: bitLength})
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+
+	return pkix.Extension{Id: keyUsageExtensionOID, Critical: true, Value: value}, nil
+}
+
+// ExtKeyUsageExtension returns the extended key usage extension of the extended key usages (ex: "serverAuth", "clientAuth").
+// The OIDs (ex: "1.3.6.1.5.5.7.3.1") are also accepted.
+func ExtKeyUsageExtension(names []string) (pkix.Extension, error) {
+	var oids []asn1.ObjectIdentifier
+
+	for _, name := range names {
+		if oid, ok := findExtKeyUsage(name); ok {
+			oids = append(oids, oid)
+			continue
+		}
+
+		o, err := ParseOID(name)
+		if err != nil {
+			return pkix.Extension{}, fmt.Errorf("unknown extended key usage: %q", name)
+		}
+
+		oids = append(oids, o)
+	}
+
+	value, err := asn1.Marshal(oids)
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+
+	return pkix.Extension{Id: extKeyUsageExtensionOID, Value: value}, nil
+}
+
+// ParseOID parses an OID in the dotted notation (ex: "1.3.6.1.5.5.7.3.1").
+func ParseOID(value string) (asn1.ObjectIdentifier, error) {
+	parts := strings.Split(value, ".")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("invalid OID: %q", value)
+	}
+
+	oid := make(asn1.ObjectIdentifier, len(parts))
+
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid OID: %q", value)
+		}
+
+		oid[i] = n
+	}
+
+	return oid, nil
+}
+
+func findKeyUsage(name string) (x509.KeyUsage, bool) {
+	for k, usage := range keyUsages {
+		if strings.EqualFold(k, name) {
+			return usage, true
+		}
+	}
+
+	return 0, false
+}
+
+func findExtKeyUsage(name string) (asn1.ObjectIdentifier, bool) {
+	for k, oid := range extKeyUsages {
+		if strings.EqualFold(k, name) {
+			return oid, true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,196 @@
+package certcrypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateCSRWithOptions(t *testing.T) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	uri, err := url.Parse("spiffe://example.com/service")
+	require.NoError(t, err)
+
+	extension := pkix.Extension{Id: asn1.ObjectIdentifier{1, 2, 3, 4}, Value: []byte{0x05, 0x00}}
+
+	template := &x509.CertificateRequest{
+		Subject: pkix.Name{
+			CommonName:         "ignored.example.com",
+			Organization:       []string{"Lego"},
+			OrganizationalUnit: []string{"Ops"},
+			Country:            []string{"FR"},
+		},
+		DNSNames:        []string{"ignored.example.com"},
+		EmailAddresses:  []string{"admin@example.com"},
+		URIs:            []*url.URL{uri},
+		ExtraExtensions: []pkix.Extension{extension},
+	}
+
+	testCases := []struct {
+		desc       string
+		options    CSROptions
+		commonName string
+		extensions int
+	}{
+		{
+			desc:       "template",
+			options:    CSROptions{Template: template},
+			commonName: "example.com",
+			extensions: 1,
+		},
+		{
+			desc:       "template and must staple",
+			options:    CSROptions{Template: template, MustStaple: true},
+			commonName: "example.com",
+			extensions: 2,
+		},
+		{
+			desc:       "without common name",
+			options:    CSROptions{Template: template, OmitCommonName: true},
+			extensions: 1,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			der, err := GenerateCSRWithOptions(privateKey, "example.com", []string{"example.com", "www.example.com"}, test.options)
+			require.NoError(t, err)
+
+			csr, err := x509.ParseCertificateRequest(der)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.commonName, csr.Subject.CommonName)
+			assert.Equal(t, []string{"Lego"}, csr.Subject.Organization)
+			assert.Equal(t, []string{"Ops"}, csr.Subject.OrganizationalUnit)
+			assert.Equal(t, []string{"FR"}, csr.Subject.Country)
+			assert.Equal(t, []string{"example.com", "www.example.com"}, csr.DNSNames)
+			assert.Equal(t, []string{"admin@example.com"}, csr.EmailAddresses)
+			assert.Equal(t, []*url.URL{uri}, csr.URIs)
+
+			var extensions int
+			for _, ext := range csr.Extensions {
+				if ext.Id.Equal(extension.Id) || ext.Id.Equal(tlsFeatureExtensionOID) {
+					extensions++
+				}
+			}
+			assert.Equal(t, test.extensions, extensions)
+		})
+	}
+
+	// the template is not modified.
+	assert.Equal(t, "ignored.example.com", template.Subject.CommonName)
+	assert.Len(t, template.ExtraExtensions, 1)
+}
+
+func TestKeyUsageExtension(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		names    []string
+		expected x509.KeyUsage
+	}{
+		{
+			desc:     "one usage",
+			names:    []string{"digitalSignature"},
+			expected: x509.KeyUsageDigitalSignature,
+		},
+		{
+			desc:     "several usages (case-insensitive)",
+			names:    []string{"DigitalSignature", "keyencipherment"},
+			expected: x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		},
+		{
+			desc:     "second byte",
+			names:    []string{"keyAgreement", "decipherOnly"},
+			expected: x509.KeyUsageKeyAgreement | x509.KeyUsageDecipherOnly,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			ext, err := KeyUsageExtension(test.names)
+			require.NoError(t, err)
+
+			assert.True(t, ext.Critical)
+
+			// same encoding as the standard library.
+			cert := createTestCertificate(t, &x509.Certificate{KeyUsage: test.expected})
+			assert.Equal(t, findExtension(t, cert, keyUsageExtensionOID).Value, ext.Value)
+		})
+	}
+
+	_, err := KeyUsageExtension([]string{"unknown"})
+	require.EqualError(t, err, `unknown key usage: "unknown"`)
+}
+
+func TestExtKeyUsageExtension(t *testing.T) {
+	ext, err := ExtKeyUsageExtension([]string{"serverAuth", "1.3.6.1.5.5.7.3.2"})
+	require.NoError(t, err)
+
+	assert.False(t, ext.Critical)
+
+	cert := createTestCertificate(t, &x509.Certificate{ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}})
+	assert.Equal(t, findExtension(t, cert, extKeyUsageExtensionOID).Value, ext.Value)
+
+	_, err = ExtKeyUsageExtension([]string{"unknown"})
+	require.EqualError(t, err, `unknown extended key usage: "unknown"`)
+}
+
+func TestParseOID(t *testing.T) {
+	oid, err := ParseOID("1.3.6.1.5.5.7.3.1")
+	require.NoError(t, err)
+
+	assert.Equal(t, asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 1}, oid)
+
+	for _, value := range []string{"", "1", "1.a", "1.-2", "1..2"} {
+		_, err = ParseOID(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func createTestCertificate(t *testing.T, template *x509.Certificate) *x509.Certificate {
+	t.Helper()
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template.SerialNumber = big.NewInt(1)
+
+	raw, err := x509.CreateCertificate(rand.Reader, template, template, privateKey.Public(), privateKey)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(raw)
+	require.NoError(t, err)
+
+	return cert
+}
+
+func findExtension(t *testing.T, cert *x509.Certificate, oid asn1.ObjectIdentifier) pkix.Extension {
+	t.Helper()
+
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oid) {
+			return ext
+		}
+	}
+
+	require.Failf(t, "extension not found", "%v", oid)
+
+	return pkix.Extension{}
+}
@@ -0,0 +1,78 @@
+//go:build cgo
+// +build cgo
+
+package pkcs11
+
+import (
+	"crypto"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/ThalesIgnite/crypto11"
+)
+
+// The contexts are shared by the keys of the same token, and kept open for the lifetime of the process.
+var (
+	contexts   = map[string]*crypto11.Context{}
+	contextsMu sync.Mutex
+)
+
+// LoadSigner loads the private key identified by a PKCS#11 URI (RFC 7512).
+// The private key never leaves the token, the signatures are computed by the token.
+func LoadSigner(rawURI string) (crypto.Signer, error) {
+	uri, err := ParseURI(rawURI)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, err := getContext(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	var label []byte
+	if uri.Object != "" {
+		label = []byte(uri.Object)
+	}
+
+	signer, err := ctx.FindKeyPair(uri.ID, label)
+	if err != nil {
+		return nil, fmt.Errorf("pkcs11: %w", err)
+	}
+
+	if signer == nil {
+		return nil, fmt.Errorf("pkcs11: key not found: %s", uri)
+	}
+
+	return signer, nil
+}
+
+func getContext(uri *URI) (*crypto11.Context, error) {
+	key := uri.ModulePath + "|" + uri.Token + "|" + uri.Serial
+	if uri.SlotID != nil {
+		key += "|" + strconv.Itoa(*uri.SlotID)
+	}
+
+	contextsMu.Lock()
+	defer contextsMu.Unlock()
+
+	if ctx, ok := contexts[key]; ok {
+		return ctx, nil
+	}
+
+	ctx, err := crypto11.Configure(&crypto11.Config{
+		Path:        uri.ModulePath,
+		TokenLabel:  uri.Token,
+		TokenSerial: uri.Serial,
+		SlotNumber:  uri.SlotID,
+		Pin:         uri.PIN,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("pkcs11: %w", err)
+	}
+
+	contexts[key] = ctx
+
+	return ctx, nil
+}
@@ -0,0 +1,19 @@
+//go:build !cgo
+// +build !cgo
+
+package pkcs11
+
+import (
+	"crypto"
+	"errors"
+)
+
+// LoadSigner loads the private key identified by a PKCS#11 URI (RFC 7512).
+// PKCS#11 requires cgo: this build always returns an error.
+func LoadSigner(rawURI string) (crypto.Signer, error) {
+	if _, err := ParseURI(rawURI); err != nil {
+		return nil, err
+	}
+
+	return nil, errors.New("pkcs11: PKCS#11 is not supported by this build (cgo is disabled)")
+}
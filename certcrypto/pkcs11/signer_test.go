@@ -0,0 +1,114 @@
+//go:build cgo
+// +build cgo
+
+package pkcs11
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/ThalesIgnite/crypto11"
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The SoftHSM module, can be defined with the SOFTHSM2_MODULE environment variable.
+var softHSMModules = []string{
+	"/usr/lib/softhsm/libsofthsm2.so",
+	"/usr/lib/x86_64-linux-gnu/softhsm/libsofthsm2.so",
+	"/usr/local/lib/softhsm/libsofthsm2.so",
+	"/opt/homebrew/lib/softhsm/libsofthsm2.so",
+}
+
+func TestLoadSigner_softHSM(t *testing.T) {
+	module := setupSoftHSM(t)
+
+	ctx, err := crypto11.Configure(&crypto11.Config{Path: module, TokenLabel: "lego", Pin: "1234"})
+	require.NoError(t, err)
+
+	defer func() { _ = ctx.Close() }()
+
+	key, err := ctx.GenerateECDSAKeyPairWithLabel([]byte{1}, []byte("account"), elliptic.P256())
+	require.NoError(t, err)
+
+	signer, err := LoadSigner("pkcs11:token=lego;object=account?pin-value=1234&module-path=" + module)
+	require.NoError(t, err)
+
+	assert.Equal(t, key.Public(), signer.Public())
+
+	digest := sha256.Sum256([]byte("content"))
+
+	signature, err := signer.Sign(nil, digest[:], crypto.SHA256)
+	require.NoError(t, err)
+
+	assert.True(t, ecdsa.VerifyASN1(signer.Public().(*ecdsa.PublicKey), digest[:], signature))
+
+	// the key can be used to generate a CSR.
+	der, err := certcrypto.GenerateCSR(signer, "example.com", []string{"example.com"}, false)
+	require.NoError(t, err)
+
+	csr, err := x509.ParseCertificateRequest(der)
+	require.NoError(t, err)
+	require.NoError(t, csr.CheckSignature())
+
+	// the key is not exportable.
+	assert.Nil(t, certcrypto.PEMEncode(signer))
+
+	_, err = LoadSigner("pkcs11:token=lego;object=unknown?pin-value=1234&module-path=" + module)
+	require.Error(t, err)
+}
+
+// setupSoftHSM creates a SoftHSM token (label: lego, PIN: 1234), the test is skipped if SoftHSM is not installed.
+func setupSoftHSM(t *testing.T) string {
+	t.Helper()
+
+	module := os.Getenv("SOFTHSM2_MODULE")
+	if module == "" {
+		for _, candidate := range softHSMModules {
+			if _, err := os.Stat(candidate); err == nil {
+				module = candidate
+				break
+			}
+		}
+	}
+
+	if module == "" {
+		t.Skip("SoftHSM is not installed")
+	}
+
+	util, err := exec.LookPath("softhsm2-util")
+	if err != nil {
+		t.Skip("softhsm2-util is not installed")
+	}
+
+	dir := t.TempDir()
+
+	config := filepath.Join(dir, "softhsm2.conf")
+	err = ioutil.WriteFile(config, []byte("directories.tokendir = "+dir+"\nobjectstore.backend = file\n"), 0o600)
+	require.NoError(t, err)
+
+	previous, exists := os.LookupEnv("SOFTHSM2_CONF")
+	require.NoError(t, os.Setenv("SOFTHSM2_CONF", config))
+
+	t.Cleanup(func() {
+		if exists {
+			_ = os.Setenv("SOFTHSM2_CONF", previous)
+		} else {
+			_ = os.Unsetenv("SOFTHSM2_CONF")
+		}
+	})
+
+	output, err := exec.Command(util, "--init-token", "--free", "--label", "lego", "--pin", "1234", "--so-pin", "5678").CombinedOutput()
+	require.NoError(t, err, string(output))
+
+	return module
+}
@@ -0,0 +1,210 @@
+// Package pkcs11 loads private keys stored in a PKCS#11 token (HSM, smart card, SoftHSM) as crypto.Signer.
+package pkcs11
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Scheme the scheme of the PKCS#11 URIs.
+const Scheme = "pkcs11"
+
+// URI a PKCS#11 URI (RFC 7512) identifying a private key.
+//
+// Example:
+//
+//	pkcs11:token=lego;object=account?module-path=/usr/lib/softhsm/libsofthsm2.so&pin-source=/etc/lego/pin
+//
+// Supported attributes:
+//   - path: token, serial, slot-id, object, id.
+//   - query: module-path, pin-value, pin-source (a file containing the PIN).
+type URI struct {
+	// The token is defined by its label, its serial number, or its slot ID.
+	Token  string
+	Serial string
+	SlotID *int
+
+	// The key is defined by its label (object) and/or its ID.
+	Object string
+	ID     []byte
+
+	ModulePath string
+	PIN        string
+}
+
+// IsURI reports whether the value is a PKCS#11 URI.
+func IsURI(value string) bool {
+	return strings.HasPrefix(value, Scheme+":")
+}
+
+// ParseURI parses a PKCS#11 URI.
+func ParseURI(raw string) (*URI, error) {
+	if !IsURI(raw) {
+		return nil, fmt.Errorf("pkcs11: not a PKCS#11 URI: %s", raw)
+	}
+
+	opaque := strings.TrimPrefix(raw, Scheme+":")
+
+	var query string
+	if i := strings.Index(opaque, "?"); i >= 0 {
+		opaque, query = opaque[:i], opaque[i+1:]
+	}
+
+	uri := &URI{}
+
+	path, err := parseAttributes(opaque, ";")
+	if err != nil {
+		return nil, err
+	}
+
+	for name, value := range path {
+		switch name {
+		case "token":
+			uri.Token = value
+		case "serial":
+			uri.Serial = value
+		case "slot-id":
+			slotID, errS := strconv.Atoi(value)
+			if errS != nil {
+				return nil, fmt.Errorf("pkcs11: invalid slot-id: %w", errS)
+			}
+			uri.SlotID = &slotID
+		case "object":
+			uri.Object = value
+		case "id":
+			uri.ID = []byte(value)
+		case "type":
+			if value != "private" {
+				return nil, fmt.Errorf("pkcs11: unsupported object type: %s", value)
+			}
+		default:
+			// the other attributes (manufacturer, model, library-*, ...) don't identify the key.
+		}
+	}
+
+	attributes, err := parseAttributes(query, "&")
+	if err != nil {
+		return nil, err
+	}
+
+	uri.ModulePath = attributes["module-path"]
+	uri.PIN = attributes["pin-value"]
+
+	if source, ok := attributes["pin-source"]; ok {
+		pin, errP := ioutil.ReadFile(strings.TrimPrefix(source, "file:"))
+		if errP != nil {
+			return nil, fmt.Errorf("pkcs11: unable to read the pin-source: %w", errP)
+		}
+
+		uri.PIN = strings.TrimSpace(string(pin))
+	}
+
+	err = uri.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return uri, nil
+}
+
+func (u *URI) validate() error {
+	if u.ModulePath == "" {
+		return errors.New("pkcs11: the module-path is required")
+	}
+
+	if u.Token == "" && u.Serial == "" && u.SlotID == nil {
+		return errors.New("pkcs11: the token is required (token, serial, or slot-id)")
+	}
+
+	if u.Object == "" && len(u.ID) == 0 {
+		return errors.New("pkcs11: the key is required (object or id)")
+	}
+
+	return nil
+}
+
+// String returns the URI without the PIN.
+func (u *URI) String() string {
+	var path []string
+
+	if u.Token != "" {
+		path = append(path, "token="+escape(u.Token))
+	}
+
+	if u.Serial != "" {
+		path = append(path, "serial="+escape(u.Serial))
+	}
+
+	if u.SlotID != nil {
+		path = append(path, "slot-id="+strconv.Itoa(*u.SlotID))
+	}
+
+	if u.Object != "" {
+		path = append(path, "object="+escape(u.Object))
+	}
+
+	if len(u.ID) > 0 {
+		path = append(path, "id="+escape(string(u.ID)))
+	}
+
+	return Scheme + ":" + strings.Join(path, ";") + "?module-path=" + strings.ReplaceAll(escape(u.ModulePath), "%2F", "/")
+}
+
+// StripPINValue returns the URI without the pin-value attribute (the other attributes, including pin-source, are kept).
+func StripPINValue(raw string) string {
+	i := strings.Index(raw, "?")
+	if i < 0 {
+		return raw
+	}
+
+	var attributes []string
+	for _, attr := range strings.Split(raw[i+1:], "&") {
+		if attr == "pin-value" || strings.HasPrefix(attr, "pin-value=") {
+			continue
+		}
+
+		attributes = append(attributes, attr)
+	}
+
+	if len(attributes) == 0 {
+		return raw[:i]
+	}
+
+	return raw[:i+1] + strings.Join(attributes, "&")
+}
+
+func parseAttributes(raw, separator string) (map[string]string, error) {
+	attributes := make(map[string]string)
+
+	if raw == "" {
+		return attributes, nil
+	}
+
+	for _, attr := range strings.Split(raw, separator) {
+		parts := strings.SplitN(attr, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("pkcs11: invalid attribute: %q", attr)
+		}
+
+		value, err := url.PathUnescape(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("pkcs11: invalid attribute %s: %w", parts[0], err)
+		}
+
+		if _, ok := attributes[parts[0]]; ok {
+			return nil, fmt.Errorf("pkcs11: duplicated attribute: %s", parts[0])
+		}
+
+		attributes[parts[0]] = value
+	}
+
+	return attributes, nil
+}
+
+func escape(value string) string {
+	return strings.ReplaceAll(url.PathEscape(value), "%3A", ":")
+}
@@ -0,0 +1,144 @@
+package pkcs11
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseURI(t *testing.T) {
+	pinFile := filepath.Join(t.TempDir(), "pin")
+	err := ioutil.WriteFile(pinFile, []byte("5678\n"), 0o600)
+	require.NoError(t, err)
+
+	slotID := 3
+
+	testCases := []struct {
+		desc     string
+		uri      string
+		expected *URI
+	}{
+		{
+			desc: "token and object",
+			uri:  "pkcs11:token=lego;object=account?module-path=/usr/lib/softhsm/libsofthsm2.so&pin-value=1234",
+			expected: &URI{
+				Token:      "lego",
+				Object:     "account",
+				ModulePath: "/usr/lib/softhsm/libsofthsm2.so",
+				PIN:        "1234",
+			},
+		},
+		{
+			desc: "slot and binary ID",
+			uri:  "pkcs11:slot-id=3;id=%01%02;type=private;manufacturer=SoftHSM?module-path=/usr/lib/softhsm/libsofthsm2.so",
+			expected: &URI{
+				SlotID:     &slotID,
+				ID:         []byte{1, 2},
+				ModulePath: "/usr/lib/softhsm/libsofthsm2.so",
+			},
+		},
+		{
+			desc: "escaped label and pin source",
+			uri:  "pkcs11:serial=abc;object=my%20key?module-path=/usr/lib/softhsm/libsofthsm2.so&pin-source=file:" + pinFile,
+			expected: &URI{
+				Serial:     "abc",
+				Object:     "my key",
+				ModulePath: "/usr/lib/softhsm/libsofthsm2.so",
+				PIN:        "5678",
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			uri, err := ParseURI(test.uri)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, uri)
+
+			// the string form can be parsed again (without the PIN).
+			parsed, err := ParseURI(uri.String())
+			require.NoError(t, err)
+
+			expected := *test.expected
+			expected.PIN = ""
+			assert.Equal(t, &expected, parsed)
+		})
+	}
+}
+
+func TestParseURI_errors(t *testing.T) {
+	testCases := []struct {
+		desc string
+		uri  string
+	}{
+		{desc: "not a PKCS#11 URI", uri: "/etc/lego/account.key"},
+		{desc: "missing module", uri: "pkcs11:token=lego;object=account"},
+		{desc: "missing token", uri: "pkcs11:object=account?module-path=/lib/p11.so"},
+		{desc: "missing key", uri: "pkcs11:token=lego?module-path=/lib/p11.so"},
+		{desc: "public key", uri: "pkcs11:token=lego;object=account;type=public?module-path=/lib/p11.so"},
+		{desc: "invalid slot", uri: "pkcs11:slot-id=a;object=account?module-path=/lib/p11.so"},
+		{desc: "duplicated attribute", uri: "pkcs11:token=a;token=b;object=account?module-path=/lib/p11.so"},
+		{desc: "invalid attribute", uri: "pkcs11:token;object=account?module-path=/lib/p11.so"},
+		{desc: "missing pin source", uri: "pkcs11:token=lego;object=account?module-path=/lib/p11.so&pin-source=/missing"},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := ParseURI(test.uri)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestStripPINValue(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		uri      string
+		expected string
+	}{
+		{
+			desc:     "pin-value",
+			uri:      "pkcs11:token=lego;object=account?module-path=/usr/lib/softhsm/libsofthsm2.so&pin-value=1234",
+			expected: "pkcs11:token=lego;object=account?module-path=/usr/lib/softhsm/libsofthsm2.so",
+		},
+		{
+			desc:     "pin-value first",
+			uri:      "pkcs11:token=lego;object=account?pin-value=1234&module-path=/usr/lib/softhsm/libsofthsm2.so",
+			expected: "pkcs11:token=lego;object=account?module-path=/usr/lib/softhsm/libsofthsm2.so",
+		},
+		{
+			desc:     "only pin-value",
+			uri:      "pkcs11:token=lego;object=account?pin-value=1234",
+			expected: "pkcs11:token=lego;object=account",
+		},
+		{
+			desc:     "pin-source",
+			uri:      "pkcs11:token=lego;object=account?module-path=/usr/lib/softhsm/libsofthsm2.so&pin-source=/etc/lego/pin",
+			expected: "pkcs11:token=lego;object=account?module-path=/usr/lib/softhsm/libsofthsm2.so&pin-source=/etc/lego/pin",
+		},
+		{
+			desc:     "no query",
+			uri:      "pkcs11:token=lego;object=account",
+			expected: "pkcs11:token=lego;object=account",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, StripPINValue(test.uri))
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package certcrypto
+
+import (
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"fmt"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// PFXProfile the encryption profile of a PKCS#12 (PFX) file.
+type PFXProfile string
+
+const (
+	// PFXLegacy 3DES (key) and RC2-40 (certificates) with a SHA-1 MAC.
+	// Compatible with old systems (Windows Server 2016 and before, Java 8 before 8u301, OpenSSL 1.x).
+	PFXLegacy = PFXProfile("legacy")
+
+	// PFXModern AES-256-CBC (PBES2 with PBKDF2-HMAC-SHA256) with a SHA-256 MAC.
+	// The default profile of OpenSSL 3, supported by Windows Server 2019 and later, and Java 8u301 and later.
+	PFXModern = PFXProfile("modern")
+)
+
+// EncodePFX encodes a private key and a certificate chain (the leaf certificate first) into a PKCS#12 (PFX) file.
+func EncodePFX(privateKey crypto.PrivateKey, certificates []*x509.Certificate, password string, profile PFXProfile) ([]byte, error) {
+	if len(certificates) == 0 {
+		return nil, errors.New("no certificates")
+	}
+
+	switch profile {
+	case PFXLegacy:
+		return pkcs12.LegacyRC2.Encode(privateKey, certificates[0], certificates[1:], password)
+	case PFXModern:
+		return pkcs12.Modern2023.Encode(privateKey, certificates[0], certificates[1:], password)
+	default:
+		return nil, fmt.Errorf("unsupported PFX profile: %q", profile)
+	}
+}
+
+// DecodePFX decodes a PKCS#12 (PFX) file (legacy or modern profile),
+// returns the private key and the certificate chain (the leaf certificate first).
+func DecodePFX(data []byte, password string) (crypto.PrivateKey, []*x509.Certificate, error) {
+	privateKey, certificate, caCerts, err := pkcs12.DecodeChain(data, password)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return privateKey, append([]*x509.Certificate{certificate}, caCerts...), nil
+}
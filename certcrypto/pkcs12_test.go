@@ -0,0 +1,67 @@
+package certcrypto
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodePFX(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err, "Error generating private key")
+
+	leafDER, err := generateDerCert(privateKey, time.Now().Add(24*time.Hour), "example.com", nil)
+	require.NoError(t, err)
+
+	issuerDER, err := generateDerCert(privateKey, time.Now().Add(24*time.Hour), "issuer.example.com", nil)
+	require.NoError(t, err)
+
+	certificates, err := ParsePEMBundle(append(
+		PEMEncode(DERCertificateBytes(leafDER)),
+		PEMEncode(DERCertificateBytes(issuerDER))...,
+	))
+	require.NoError(t, err)
+
+	for _, profile := range []PFXProfile{PFXLegacy, PFXModern} {
+		profile := profile
+		t.Run(string(profile), func(t *testing.T) {
+			t.Parallel()
+
+			data, err := EncodePFX(privateKey, certificates, "secret", profile)
+			require.NoError(t, err)
+
+			_, _, err = DecodePFX(data, "wrong")
+			require.Error(t, err)
+
+			key, chain, err := DecodePFX(data, "secret")
+			require.NoError(t, err)
+
+			assert.Equal(t, privateKey, key)
+			require.Len(t, chain, 2)
+			assert.Equal(t, leafDER, chain[0].Raw)
+			assert.Equal(t, issuerDER, chain[1].Raw)
+		})
+	}
+}
+
+func TestEncodePFX_unsupportedProfile(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err, "Error generating private key")
+
+	leafDER, err := generateDerCert(privateKey, time.Now().Add(24*time.Hour), "example.com", nil)
+	require.NoError(t, err)
+
+	leaf, err := ParsePEMCertificate(PEMEncode(DERCertificateBytes(leafDER)))
+	require.NoError(t, err)
+
+	_, err = EncodePFX(privateKey, nil, "secret", PFXModern)
+	require.Error(t, err)
+
+	_, err = EncodePFX(privateKey, []*x509.Certificate{leaf}, "secret", "foo")
+	require.Error(t, err)
+}
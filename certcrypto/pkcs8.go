@@ -0,0 +1,250 @@
+package certcrypto
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const encryptedPrivateKeyType = "ENCRYPTED PRIVATE KEY"
+
+// keyEncryptionIterations the PBKDF2-HMAC-SHA256 iteration count used to encrypt the stored private keys
+// (OWASP recommendation), the iteration count is stored in the parameters of the encrypted key.
+const keyEncryptionIterations = 600_000
+
+// PBES2 object identifiers.
+// - https://tools.ietf.org/html/rfc8018#appendix-C
+var (
+	oidPBES2  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHMACWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+
+	oidAES128CBC = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+// - https://tools.ietf.org/html/rfc5208#section-6
+type encryptedPrivateKeyInfo struct {
+	AlgorithmIdentifier pkix.AlgorithmIdentifier
+	EncryptedData       []byte
+}
+
+// - https://tools.ietf.org/html/rfc8018#appendix-A.4
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+// - https://tools.ietf.org/html/rfc8018#appendix-A.2
+type pbkdf2Params struct {
+	Salt       []byte
+	Iterations int
+	KeyLength  int                      `asn1:"optional"`
+	Prf        pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// IsEncryptedPEMPrivateKey returns true if the PEM data is an encrypted PKCS#8 private key.
+func IsEncryptedPEMPrivateKey(key []byte) bool {
+	block, _ := pem.Decode(key)
+	return block != nil && block.Type == encryptedPrivateKeyType
+}
+
+// EncryptPEMPrivateKey encodes a private key as an encrypted PKCS#8 PEM block ("ENCRYPTED PRIVATE KEY").
+// The key is encrypted with AES-256-CBC, the encryption key is derived from the passphrase with PBKDF2-HMAC-SHA256.
+func EncryptPEMPrivateKey(privateKey crypto.PrivateKey, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, errors.New("empty passphrase")
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	algorithm, encrypted, err := pbes2Encrypt(der, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := asn1.Marshal(encryptedPrivateKeyInfo{AlgorithmIdentifier: algorithm, EncryptedData: encrypted})
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: encryptedPrivateKeyType, Bytes: data}), nil
+}
+
+// DecryptPEMPrivateKey decodes an encrypted PKCS#8 PEM block ("ENCRYPTED PRIVATE KEY").
+// Supports PBES2 with PBKDF2 (HMAC-SHA1, HMAC-SHA256, HMAC-SHA512) and AES-CBC (128, 192, 256),
+// i.e. the keys encrypted by lego and by `openssl pkcs8 -topk8 -v2 aes-256-cbc`.
+func DecryptPEMPrivateKey(key []byte, passphrase string) (crypto.PrivateKey, error) {
+	block, _ := pem.Decode(key)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	if block.Type != encryptedPrivateKeyType {
+		return nil, fmt.Errorf("unexpected PEM header %q", block.Type)
+	}
+
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(block.Bytes, &info); err != nil {
+		return nil, fmt.Errorf("invalid encrypted private key: %w", err)
+	}
+
+	der, err := pbes2Decrypt(info.AlgorithmIdentifier, info.EncryptedData, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	privateKey, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		// a wrong passphrase can lead to a valid padding.
+		return nil, errors.New("failed to decrypt private key: wrong passphrase?")
+	}
+
+	return privateKey, nil
+}
+
+// pbes2Encrypt encrypts data with AES-256-CBC, the key is derived from the password with PBKDF2-HMAC-SHA256.
+// - https://tools.ietf.org/html/rfc8018#section-6.2
+func pbes2Encrypt(data []byte, password string) (pkix.AlgorithmIdentifier, []byte, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, err
+	}
+
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(iv); err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, err
+	}
+
+	// PBES2 uses the UTF-8 password (not the BMPString used by the PKCS#12 KDF).
+	key := pbkdf2.Key([]byte(password), salt, keyEncryptionIterations, 32, sha256.New)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, err
+	}
+
+	// PKCS#7 padding.
+	padding := aes.BlockSize - len(data)%aes.BlockSize
+	encrypted := append(append([]byte{}, data...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, encrypted)
+
+	kdfParams, err := asn1.Marshal(pbkdf2Params{
+		Salt:       salt,
+		Iterations: keyEncryptionIterations,
+		Prf:        pkix.AlgorithmIdentifier{Algorithm: oidHMACWithSHA256, Parameters: asn1.NullRawValue},
+	})
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, err
+	}
+
+	ivParams, err := asn1.Marshal(iv)
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, err
+	}
+
+	params, err := asn1.Marshal(pbes2Params{
+		KeyDerivationFunc: pkix.AlgorithmIdentifier{Algorithm: oidPBKDF2, Parameters: asn1.RawValue{FullBytes: kdfParams}},
+		EncryptionScheme:  pkix.AlgorithmIdentifier{Algorithm: oidAES256CBC, Parameters: asn1.RawValue{FullBytes: ivParams}},
+	})
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, err
+	}
+
+	return pkix.AlgorithmIdentifier{Algorithm: oidPBES2, Parameters: asn1.RawValue{FullBytes: params}}, encrypted, nil
+}
+
+// pbes2Decrypt decrypts data encrypted with PBES2 (PBKDF2 and AES-CBC).
+// - https://tools.ietf.org/html/rfc8018#section-6.2
+func pbes2Decrypt(algorithm pkix.AlgorithmIdentifier, data []byte, password string) ([]byte, error) {
+	if !algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, fmt.Errorf("unsupported encryption algorithm: %s", algorithm.Algorithm)
+	}
+
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, err
+	}
+
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, fmt.Errorf("unsupported key derivation function: %s", params.KeyDerivationFunc.Algorithm)
+	}
+
+	var kdfParams pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdfParams); err != nil {
+		return nil, err
+	}
+
+	var prf func() hash.Hash
+	switch {
+	case len(kdfParams.Prf.Algorithm) == 0, kdfParams.Prf.Algorithm.Equal(oidHMACWithSHA1):
+		prf = sha1.New
+	case kdfParams.Prf.Algorithm.Equal(oidHMACWithSHA256):
+		prf = sha256.New
+	case kdfParams.Prf.Algorithm.Equal(oidHMACWithSHA512):
+		prf = sha512.New
+	default:
+		return nil, fmt.Errorf("unsupported PBKDF2 PRF: %s", kdfParams.Prf.Algorithm)
+	}
+
+	var keySize int
+	switch {
+	case params.EncryptionScheme.Algorithm.Equal(oidAES128CBC):
+		keySize = 16
+	case params.EncryptionScheme.Algorithm.Equal(oidAES192CBC):
+		keySize = 24
+	case params.EncryptionScheme.Algorithm.Equal(oidAES256CBC):
+		keySize = 32
+	default:
+		return nil, fmt.Errorf("unsupported encryption scheme: %s", params.EncryptionScheme.Algorithm)
+	}
+
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, err
+	}
+
+	if len(iv) != aes.BlockSize || len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, errors.New("invalid encrypted data")
+	}
+
+	key := pbkdf2.Key([]byte(password), kdfParams.Salt, kdfParams.Iterations, keySize, prf)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	decrypted := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(decrypted, data)
+
+	// PKCS#7 padding.
+	padding := int(decrypted[len(decrypted)-1])
+	if padding == 0 || padding > aes.BlockSize || !bytes.Equal(decrypted[len(decrypted)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, errors.New("failed to decrypt private key: wrong passphrase?")
+	}
+
+	return decrypted[:len(decrypted)-padding], nil
+}
@@ -1,6 +1,9 @@
 package certificate
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/go-acme/lego/v4/acme"
@@ -15,6 +18,237 @@ const (
 	overallRequestLimit = 18
 )
 
+// AuthorizationResource represents a pre-authorization.
+type AuthorizationResource struct {
+	Domain  string    `json:"domain"`
+	URL     string    `json:"url"`
+	Status  string    `json:"status"`
+	Expires time.Time `json:"expires,omitempty"`
+}
+
+// AuthorizationStorage persists the pre-authorizations, indexed by domain (ASCII form).
+type AuthorizationStorage interface {
+	LoadAuthorizations() (map[string]AuthorizationResource, error)
+	SaveAuthorizations(authorizations map[string]AuthorizationResource) error
+}
+
+// Authorize pre-authorizes the domains (without creating an order) and solves the related challenges.
+//
+// The CA reuses the valid authorizations for the next orders containing these domains,
+// so a later call to Obtain will not have to solve the challenges again.
+// Wildcard domains cannot be pre-authorized.
+//
+// If the Certifier has an AuthorizationStorage, the pre-authorizations are stored:
+// Obtain checks them (the expired and invalid ones are removed), and DeactivatePreAuthorizations deactivates them.
+//
+// If one domain in the list fails, all the pending authorizations are deactivated.
+// - https://tools.ietf.org/html/rfc8555#section-7.4.1
+func (c *Certifier) Authorize(domains []string) ([]AuthorizationResource, error) {
+	if len(domains) == 0 {
+		return nil, errors.New("no domains to authorize")
+	}
+
+	domains = sanitizeDomain(domains)
+
+	log.Infof("[%s] acme: Pre-authorizing domains", strings.Join(domains, ", "))
+
+	var authzURLs []string
+	var authorizations []acme.Authorization
+	failures := make(obtainError)
+
+	for _, domain := range domains {
+		if strings.HasPrefix(domain, "*.") {
+			failures[domain] = fmt.Errorf("[%s] acme: wildcard domains cannot be pre-authorized", domain)
+			continue
+		}
+
+		authz, err := c.core.Authorizations.New(acme.Identifier{Type: "dns", Value: domain})
+		if err != nil {
+			failures[domain] = err
+			continue
+		}
+
+		log.Infof("[%s] AuthURL: %s", domain, authz.Location)
+
+		authzURLs = append(authzURLs, authz.Location)
+		authorizations = append(authorizations, authz.Authorization)
+	}
+
+	if len(failures) > 0 {
+		c.deactivateAuthorizationURLs(authzURLs)
+		return nil, failures
+	}
+
+	err := c.resolver.Solve(authorizations)
+	if err != nil {
+		c.deactivateAuthorizationURLs(authzURLs)
+		return nil, err
+	}
+
+	log.Infof("[%s] acme: Validations succeeded", strings.Join(domains, ", "))
+
+	var resources []AuthorizationResource
+	for i, authzURL := range authzURLs {
+		authz, err := c.core.Authorizations.Get(authzURL)
+		if err != nil {
+			return nil, err
+		}
+
+		resources = append(resources, AuthorizationResource{
+			Domain:  domains[i],
+			URL:     authzURL,
+			Status:  authz.Status,
+			Expires: authz.Expires,
+		})
+	}
+
+	if c.options.AuthorizationStorage == nil {
+		return resources, nil
+	}
+
+	stored, _, err := c.loadPreAuthorizations()
+	if err != nil {
+		return resources, fmt.Errorf("could not load the pre-authorizations: %w", err)
+	}
+
+	for _, res := range resources {
+		stored[res.Domain] = res
+	}
+
+	err = c.options.AuthorizationStorage.SaveAuthorizations(stored)
+	if err != nil {
+		return resources, fmt.Errorf("could not store the pre-authorizations: %w", err)
+	}
+
+	return resources, nil
+}
+
+// DeactivatePreAuthorizations deactivates the stored pre-authorizations of the domains (see Authorize),
+// and removes them from the AuthorizationStorage.
+func (c *Certifier) DeactivatePreAuthorizations(domains []string) error {
+	if c.options.AuthorizationStorage == nil {
+		return errors.New("no storage of the pre-authorizations")
+	}
+
+	authorizations, _, err := c.loadPreAuthorizations()
+	if err != nil {
+		return fmt.Errorf("could not load the pre-authorizations: %w", err)
+	}
+
+	var authzURLs []string
+	for _, domain := range sanitizeDomain(domains) {
+		authz, ok := authorizations[domain]
+		if !ok {
+			log.Infof("[%s] acme: No pre-authorization found", domain)
+			continue
+		}
+
+		authzURLs = append(authzURLs, authz.URL)
+		delete(authorizations, domain)
+	}
+
+	err = c.DeactivateAuthorizations(authzURLs)
+	if err != nil {
+		return err
+	}
+
+	return c.options.AuthorizationStorage.SaveAuthorizations(authorizations)
+}
+
+// checkPreAuthorizations reports the stored pre-authorizations reused by the CA for an order,
+// and removes the expired pre-authorizations and the ones the CA doesn't consider valid anymore.
+func (c *Certifier) checkPreAuthorizations(authorizations []acme.Authorization) {
+	if c.options.AuthorizationStorage == nil {
+		return
+	}
+
+	stored, changed, err := c.loadPreAuthorizations()
+	if err != nil {
+		log.Warnf("acme: Unable to load the pre-authorizations: %v", err)
+		return
+	}
+
+	for _, authz := range authorizations {
+		if authz.Wildcard {
+			continue
+		}
+
+		domain := authz.Identifier.Value
+
+		pre, ok := stored[domain]
+		if !ok {
+			continue
+		}
+
+		if authz.Status == acme.StatusValid {
+			log.Infof("[%s] acme: Using the pre-authorization %s", domain, pre.URL)
+			continue
+		}
+
+		// the CA has not reused the pre-authorization: it's probably not valid anymore.
+		current, err := c.core.Authorizations.Get(pre.URL)
+		if err != nil || current.Status != acme.StatusValid {
+			log.Infof("[%s] acme: The pre-authorization is not valid anymore: %s", domain, pre.URL)
+			delete(stored, domain)
+			changed = true
+		}
+	}
+
+	if !changed {
+		return
+	}
+
+	err = c.options.AuthorizationStorage.SaveAuthorizations(stored)
+	if err != nil {
+		log.Warnf("acme: Unable to store the pre-authorizations: %v", err)
+	}
+}
+
+// loadPreAuthorizations loads the stored pre-authorizations without the expired ones,
+// changed is true if expired pre-authorizations have been removed.
+func (c *Certifier) loadPreAuthorizations() (authorizations map[string]AuthorizationResource, changed bool, err error) {
+	authorizations, err = c.options.AuthorizationStorage.LoadAuthorizations()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if authorizations == nil {
+		authorizations = make(map[string]AuthorizationResource)
+	}
+
+	now := time.Now()
+
+	for domain, authz := range authorizations {
+		if !authz.Expires.IsZero() && authz.Expires.Before(now) {
+			log.Infof("[%s] acme: The pre-authorization has expired: %s", domain, authz.URL)
+			delete(authorizations, domain)
+			changed = true
+		}
+	}
+
+	return authorizations, changed, nil
+}
+
+// DeactivateAuthorizations deactivates authorizations (ex: pre-authorizations that are not wanted anymore).
+// - https://tools.ietf.org/html/rfc8555#section-7.5.2
+func (c *Certifier) DeactivateAuthorizations(authzURLs []string) error {
+	failures := make(obtainError)
+
+	for _, authzURL := range authzURLs {
+		log.Infof("Deactivating auth: %s", authzURL)
+
+		err := c.core.Authorizations.Deactivate(authzURL)
+		if err != nil {
+			failures[authzURL] = err
+		}
+	}
+
+	if len(failures) > 0 {
+		return failures
+	}
+	return nil
+}
+
 func (c *Certifier) getAuthorizations(order acme.ExtendedOrder) ([]acme.Authorization, error) {
 	resc, errc := make(chan acme.Authorization), make(chan domainError)
 
@@ -61,7 +295,11 @@ func (c *Certifier) getAuthorizations(order acme.ExtendedOrder) ([]acme.Authoriz
 }
 
 func (c *Certifier) deactivateAuthorizations(order acme.ExtendedOrder) {
-	for _, authzURL := range order.Authorizations {
+	c.deactivateAuthorizationURLs(order.Authorizations)
+}
+
+func (c *Certifier) deactivateAuthorizationURLs(authzURLs []string) {
+	for _, authzURL := range authzURLs {
 		auth, err := c.core.Authorizations.Get(authzURL)
 		if err != nil {
 			log.Infof("Unable to get the authorization for: %s", authzURL)
@@ -0,0 +1,95 @@
+package certificate
+
+import (
+	"strings"
+
+	"github.com/go-acme/lego/v4/challenge"
+	"github.com/go-acme/lego/v4/challenge/dns01"
+	"github.com/go-acme/lego/v4/log"
+)
+
+// challengeTypes is implemented by resolvers which know the challenge types they can solve,
+// by order of preference.
+type challengeTypes interface {
+	GetChallengeTypes() []challenge.Type
+}
+
+// challengeRestrictor is implemented by resolvers which can restrict the challenge types used for a domain.
+type challengeRestrictor interface {
+	SetAllowedChallengeTypes(domain string, types []challenge.Type)
+}
+
+// checkCAA checks the CAA records of the domains before creating an order.
+// - https://tools.ietf.org/html/rfc8659
+// - https://tools.ietf.org/html/rfc8657
+func (c *Certifier) checkCAA(domains []string) error {
+	if !c.options.CheckCAA {
+		return nil
+	}
+
+	identities := c.core.GetDirectory().Meta.CaaIdentities
+	if len(identities) == 0 {
+		log.Warnf("acme: the CA doesn't provide CAA identities: skipping CAA check")
+		return nil
+	}
+
+	opts := dns01.CAAOptions{
+		CAAIdentities: identities,
+		AccountURI:    c.core.GetAccountURL(),
+	}
+
+	var types []challenge.Type
+	if r, ok := c.resolver.(challengeTypes); ok {
+		types = r.GetChallengeTypes()
+	}
+
+	failures := make(obtainError)
+	for _, domain := range domains {
+		opts.ValidationMethods = validationMethods(domain, types)
+
+		methods, err := dns01.CheckCAAValidationMethods(domain, opts)
+		if err != nil {
+			failures[domain] = err
+			continue
+		}
+
+		// the solver is chosen among the challenge types allowed by the CAA records.
+		if r, ok := c.resolver.(challengeRestrictor); ok && len(types) > 0 {
+			r.SetAllowedChallengeTypes(domain, toChallengeTypes(methods))
+		}
+	}
+
+	// be careful to not return an empty failures map;
+	// even if empty, they become non-nil error values
+	if len(failures) > 0 {
+		return failures
+	}
+	return nil
+}
+
+// validationMethods returns the challenge types which can be used to validate the domain, by order of preference.
+// The wildcard domains can only be validated with the DNS challenges.
+// - https://tools.ietf.org/html/rfc8555#section-7.1.3
+func validationMethods(domain string, types []challenge.Type) []string {
+	wildcard := strings.HasPrefix(domain, "*.")
+
+	var methods []string
+	for _, chlgType := range types {
+		if wildcard && (chlgType == challenge.HTTP01 || chlgType == challenge.TLSALPN01) {
+			continue
+		}
+
+		methods = append(methods, chlgType.String())
+	}
+
+	return methods
+}
+
+func toChallengeTypes(methods []string) []challenge.Type {
+	types := make([]challenge.Type, 0, len(methods))
+	for _, method := range methods {
+		types = append(types, challenge.Type(method))
+	}
+
+	return types
+}
@@ -0,0 +1,38 @@
+package certificate
+
+import (
+	"testing"
+
+	"github.com/go-acme/lego/v4/challenge"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_validationMethods(t *testing.T) {
+	types := []challenge.Type{challenge.TLSALPN01, challenge.HTTP01, challenge.DNS01}
+
+	testCases := []struct {
+		desc     string
+		domain   string
+		expected []string
+	}{
+		{
+			desc:     "domain",
+			domain:   "example.com",
+			expected: []string{"tls-alpn-01", "http-01", "dns-01"},
+		},
+		{
+			desc:     "wildcard",
+			domain:   "*.example.com",
+			expected: []string{"dns-01"},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, validationMethods(test.domain, types))
+		})
+	}
+}
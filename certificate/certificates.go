@@ -1,17 +1,15 @@
 package certificate
 
 import (
-	"bytes"
 	"crypto"
 	"crypto/x509"
 	"encoding/base64"
 	"errors"
 	"fmt"
-	"io/ioutil"
-	"net/http"
 	"strings"
 	"time"
 
+	"github.com/cenkalti/backoff/v4"
 	"github.com/go-acme/lego/v4/acme"
 	"github.com/go-acme/lego/v4/acme/api"
 	"github.com/go-acme/lego/v4/certcrypto"
@@ -34,10 +32,18 @@ type Resource struct {
 	Domain            string `json:"domain"`
 	CertURL           string `json:"certUrl"`
 	CertStableURL     string `json:"certStableUrl"`
+	CADirURL          string `json:"caDirUrl,omitempty"`
 	PrivateKey        []byte `json:"-"`
 	Certificate       []byte `json:"-"`
 	IssuerCertificate []byte `json:"-"`
 	CSR               []byte `json:"-"`
+
+	// PrivateKeyURI the reference of a private key not stored in PrivateKey (ex: a PKCS#11 URI),
+	// defined by the caller when the private key is not exportable.
+	PrivateKeyURI string `json:"privateKeyUri,omitempty"`
+
+	// AlternateChains the chains offered by the CA and not selected (alternate links).
+	AlternateChains []Chain `json:"alternateChains,omitempty"`
 }
 
 // ObtainRequest The request to obtain certificate.
@@ -48,23 +54,44 @@ type Resource struct {
 // A new private key is generated for every invocation of the function Obtain.
 // If you do not want that you can supply your own private key in the privateKey parameter.
 // If this parameter is non-nil it will be used instead of generating a new one.
+// The private key can be any crypto.Signer (ex: a key stored in an HSM),
+// in this case the PrivateKey field of the Resource is empty and the CSR field is populated.
 //
 // If bundle is true, the []byte contains both the issuer certificate and your issued certificate as a bundle.
+//
+// PreferredChain is a shortcut for ChainPolicy.IssuerCommonName.
+//
+// If Verify is defined, the issued certificate is verified (see Verify),
+// the must staple extension is required if MustStaple is true.
+//
+// CSRTemplate customizes the CSR (subject fields, URI and email SANs, extra extensions),
+// the DNS names of the CSR are always the domains (see certcrypto.CSROptions).
+// If OmitCommonName is true, the CSR doesn't have a common name (the domains are only in the SANs).
 type ObtainRequest struct {
 	Domains        []string
 	Bundle         bool
 	PrivateKey     crypto.PrivateKey
 	MustStaple     bool
 	PreferredChain string
+	ChainPolicy    ChainPolicy
+	Verify         *VerifyOptions
+	CSRTemplate    *x509.CertificateRequest
+	OmitCommonName bool
 }
 
 // ObtainForCSRRequest The request to obtain a certificate matching the CSR passed into it.
 //
 // If bundle is true, the []byte contains both the issuer certificate and your issued certificate as a bundle.
+//
+// PreferredChain is a shortcut for ChainPolicy.IssuerCommonName.
+//
+// If Verify is defined, the issued certificate is verified (see Verify).
 type ObtainForCSRRequest struct {
 	CSR            *x509.CertificateRequest
 	Bundle         bool
 	PreferredChain string
+	ChainPolicy    ChainPolicy
+	Verify         *VerifyOptions
 }
 
 type resolver interface {
@@ -74,21 +101,29 @@ type resolver interface {
 type CertifierOptions struct {
 	KeyType certcrypto.KeyType
 	Timeout time.Duration
+	// CheckCAA checks the CAA records of the domains before creating an order.
+	CheckCAA bool
+	// OrderStorage persists the state of the in-flight orders (optional).
+	OrderStorage OrderStorage
+	// AuthorizationStorage persists the pre-authorizations (optional).
+	AuthorizationStorage AuthorizationStorage
 }
 
 // Certifier A service to obtain/renew/revoke certificates.
 type Certifier struct {
-	core     *api.Core
-	resolver resolver
-	options  CertifierOptions
+	core       *api.Core
+	resolver   resolver
+	options    CertifierOptions
+	revocation *RevocationChecker
 }
 
 // NewCertifier creates a Certifier.
 func NewCertifier(core *api.Core, resolver resolver, options CertifierOptions) *Certifier {
 	return &Certifier{
-		core:     core,
-		resolver: resolver,
-		options:  options,
+		core:       core,
+		resolver:   resolver,
+		options:    options,
+		revocation: NewRevocationChecker(core.HTTPClient),
 	}
 }
 
@@ -101,6 +136,11 @@ func (c *Certifier) Obtain(request ObtainRequest) (*Resource, error) {
 		return nil, errors.New("no domains to obtain a certificate for")
 	}
 
+	err := request.ChainPolicy.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("chain policy: %w", err)
+	}
+
 	domains := sanitizeDomain(request.Domains)
 
 	if request.Bundle {
@@ -109,11 +149,18 @@ func (c *Certifier) Obtain(request ObtainRequest) (*Resource, error) {
 		log.Infof("[%s] acme: Obtaining SAN certificate", strings.Join(domains, ", "))
 	}
 
-	order, err := c.core.Orders.New(domains)
+	err = c.checkCAA(domains)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := c.createOrder(domains)
 	if err != nil {
 		return nil, err
 	}
 
+	defer c.deleteOrderState(domains[0])
+
 	authz, err := c.getAuthorizations(order)
 	if err != nil {
 		// If any challenge fails, return. Do not generate partial SAN certificates.
@@ -121,6 +168,10 @@ func (c *Certifier) Obtain(request ObtainRequest) (*Resource, error) {
 		return nil, err
 	}
 
+	c.saveOrderState(domains[0], c.newOrderState(domains, order, authz))
+
+	c.checkPreAuthorizations(authz)
+
 	err = c.resolver.Solve(authz)
 	if err != nil {
 		// If any challenge fails, return. Do not generate partial SAN certificates.
@@ -131,7 +182,17 @@ func (c *Certifier) Obtain(request ObtainRequest) (*Resource, error) {
 	log.Infof("[%s] acme: Validations succeeded; requesting certificates", strings.Join(domains, ", "))
 
 	failures := make(obtainError)
-	cert, err := c.getForOrder(domains, order, request.Bundle, request.PrivateKey, request.MustStaple, request.PreferredChain)
+	csrOptions := certcrypto.CSROptions{
+		Template:       request.CSRTemplate,
+		OmitCommonName: request.OmitCommonName,
+		MustStaple:     request.MustStaple,
+	}
+
+	cert, err := c.getForOrder(domains, order, request.Bundle, request.PrivateKey, csrOptions, newChainPolicy(request.ChainPolicy, request.PreferredChain))
+	if isRateLimitError(err) {
+		return nil, err
+	}
+
 	if err != nil {
 		for _, auth := range authz {
 			failures[challenge.GetTargetedDomain(auth)] = err
@@ -143,6 +204,17 @@ func (c *Certifier) Obtain(request ObtainRequest) (*Resource, error) {
 	if len(failures) > 0 {
 		return cert, failures
 	}
+
+	if request.Verify != nil {
+		options := *request.Verify
+		options.MustStaple = options.MustStaple || request.MustStaple
+
+		err = Verify(cert, domains, options)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return cert, nil
 }
 
@@ -160,6 +232,11 @@ func (c *Certifier) ObtainForCSR(request ObtainForCSRRequest) (*Resource, error)
 		return nil, errors.New("cannot obtain resource for CSR: CSR is missing")
 	}
 
+	err := request.ChainPolicy.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("chain policy: %w", err)
+	}
+
 	// figure out what domains it concerns
 	// start with the common name
 	domains := certcrypto.ExtractDomainsCSR(request.CSR)
@@ -170,11 +247,18 @@ func (c *Certifier) ObtainForCSR(request ObtainForCSRRequest) (*Resource, error)
 		log.Infof("[%s] acme: Obtaining SAN certificate given a CSR", strings.Join(domains, ", "))
 	}
 
-	order, err := c.core.Orders.New(domains)
+	err = c.checkCAA(domains)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := c.createOrder(domains)
 	if err != nil {
 		return nil, err
 	}
 
+	defer c.deleteOrderState(domains[0])
+
 	authz, err := c.getAuthorizations(order)
 	if err != nil {
 		// If any challenge fails, return. Do not generate partial SAN certificates.
@@ -182,6 +266,10 @@ func (c *Certifier) ObtainForCSR(request ObtainForCSRRequest) (*Resource, error)
 		return nil, err
 	}
 
+	c.saveOrderState(domains[0], c.newOrderState(domains, order, authz))
+
+	c.checkPreAuthorizations(authz)
+
 	err = c.resolver.Solve(authz)
 	if err != nil {
 		// If any challenge fails, return. Do not generate partial SAN certificates.
@@ -192,7 +280,11 @@ func (c *Certifier) ObtainForCSR(request ObtainForCSRRequest) (*Resource, error)
 	log.Infof("[%s] acme: Validations succeeded; requesting certificates", strings.Join(domains, ", "))
 
 	failures := make(obtainError)
-	cert, err := c.getForCSR(domains, order, request.Bundle, request.CSR.Raw, nil, request.PreferredChain)
+	cert, err := c.getForCSR(domains, order, request.Bundle, request.CSR.Raw, nil, newChainPolicy(request.ChainPolicy, request.PreferredChain))
+	if isRateLimitError(err) {
+		return nil, err
+	}
+
 	if err != nil {
 		for _, auth := range authz {
 			failures[challenge.GetTargetedDomain(auth)] = err
@@ -209,10 +301,18 @@ func (c *Certifier) ObtainForCSR(request ObtainForCSRRequest) (*Resource, error)
 	if len(failures) > 0 {
 		return cert, failures
 	}
+
+	if request.Verify != nil {
+		err = Verify(cert, domains, *request.Verify)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return cert, nil
 }
 
-func (c *Certifier) getForOrder(domains []string, order acme.ExtendedOrder, bundle bool, privateKey crypto.PrivateKey, mustStaple bool, preferredChain string) (*Resource, error) {
+func (c *Certifier) getForOrder(domains []string, order acme.ExtendedOrder, bundle bool, privateKey crypto.PrivateKey, csrOptions certcrypto.CSROptions, chainPolicy ChainPolicy) (*Resource, error) {
 	if privateKey == nil {
 		var err error
 		privateKey, err = certcrypto.GeneratePrivateKey(c.options.KeyType)
@@ -237,16 +337,32 @@ func (c *Certifier) getForOrder(domains []string, order acme.ExtendedOrder, bund
 		}
 	}
 
-	// TODO: should the CSR be customizable?
-	csr, err := certcrypto.GenerateCSR(privateKey, commonName, san, mustStaple)
+	csr, err := certcrypto.GenerateCSRWithOptions(privateKey, commonName, san, csrOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := c.getForCSR(domains, order, bundle, csr, certcrypto.PEMEncode(privateKey), chainPolicy)
 	if err != nil {
+		var problem *acme.ProblemDetails
+		if errors.As(err, &problem) && problem.Type == acme.BadCSRErr {
+			if keyType, errK := certcrypto.GetKeyType(privateKey); errK == nil {
+				return nil, fmt.Errorf("the CSR has been rejected, the %s private keys may not be accepted by the CA: %w", keyType, err)
+			}
+		}
+
 		return nil, err
 	}
 
-	return c.getForCSR(domains, order, bundle, csr, certcrypto.PEMEncode(privateKey), preferredChain)
+	if cert.PrivateKey == nil {
+		// the private key is not exportable (ex: HSM).
+		cert.CSR = certcrypto.PEMEncode(&x509.CertificateRequest{Raw: csr})
+	}
+
+	return cert, nil
 }
 
-func (c *Certifier) getForCSR(domains []string, order acme.ExtendedOrder, bundle bool, csr, privateKeyPem []byte, preferredChain string) (*Resource, error) {
+func (c *Certifier) getForCSR(domains []string, order acme.ExtendedOrder, bundle bool, csr, privateKeyPem []byte, chainPolicy ChainPolicy) (*Resource, error) {
 	respOrder, err := c.core.Orders.UpdateForCSR(order.Finalize, csr)
 	if err != nil {
 		return nil, err
@@ -261,7 +377,7 @@ func (c *Certifier) getForCSR(domains []string, order acme.ExtendedOrder, bundle
 
 	if respOrder.Status == acme.StatusValid {
 		// if the certificate is available right away, short cut!
-		ok, errR := c.checkResponse(respOrder, certRes, bundle, preferredChain)
+		ok, errR := c.checkResponse(respOrder, certRes, bundle, chainPolicy)
 		if errR != nil {
 			return nil, errR
 		}
@@ -276,18 +392,25 @@ func (c *Certifier) getForCSR(domains []string, order acme.ExtendedOrder, bundle
 		timeout = 30 * time.Second
 	}
 
-	err = wait.For("certificate", timeout, timeout/60, func() (bool, error) {
+	err = wait.ForRetryAfter("certificate", timeout, timeout/60, func() (bool, time.Duration, error) {
 		ord, errW := c.core.Orders.Get(order.Location)
 		if errW != nil {
-			return false, errW
+			if isRateLimitError(errW) {
+				return false, 0, backoff.Permanent(errW)
+			}
+
+			return false, 0, errW
 		}
 
-		done, errW := c.checkResponse(ord, certRes, bundle, preferredChain)
+		// The server can ask to wait before polling again (ex: order in "processing" state).
+		retryAfter := wait.ParseRetryAfter(ord.RetryAfter)
+
+		done, errW := c.checkResponse(ord, certRes, bundle, chainPolicy)
 		if errW != nil {
-			return false, errW
+			return false, retryAfter, errW
 		}
 
-		return done, nil
+		return done, retryAfter, nil
 	})
 
 	return certRes, err
@@ -301,7 +424,7 @@ func (c *Certifier) getForCSR(domains []string, order acme.ExtendedOrder, bundle
 // The certRes input should already have the Domain (common name) field populated.
 //
 // If bundle is true, the certificate will be bundled with the issuer's cert.
-func (c *Certifier) checkResponse(order acme.ExtendedOrder, certRes *Resource, bundle bool, preferredChain string) (bool, error) {
+func (c *Certifier) checkResponse(order acme.ExtendedOrder, certRes *Resource, bundle bool, chainPolicy ChainPolicy) (bool, error) {
 	valid, err := checkOrderStatus(order)
 	if err != nil || !valid {
 		return valid, err
@@ -312,37 +435,20 @@ func (c *Certifier) checkResponse(order acme.ExtendedOrder, certRes *Resource, b
 		return false, err
 	}
 
-	// Set the default certificate
-	certRes.IssuerCertificate = certs[order.Certificate].Issuer
-	certRes.Certificate = certs[order.Certificate].Cert
-	certRes.CertURL = order.Certificate
-	certRes.CertStableURL = order.Certificate
-
-	if preferredChain == "" {
-		log.Infof("[%s] Server responded with a certificate.", certRes.Domain)
-
-		return true, nil
+	selected, alternates, err := selectChain(certRes.Domain, order.Certificate, certs, chainPolicy)
+	if err != nil {
+		return false, err
 	}
 
-	for link, cert := range certs {
-		ok, err := hasPreferredChain(cert.Issuer, preferredChain)
-		if err != nil {
-			return false, err
-		}
-
-		if ok {
-			log.Infof("[%s] Server responded with a certificate for the preferred certificate chains %q.", certRes.Domain, preferredChain)
-
-			certRes.IssuerCertificate = cert.Issuer
-			certRes.Certificate = cert.Cert
-			certRes.CertURL = link
-			certRes.CertStableURL = link
-
-			return true, nil
-		}
+	if chainPolicy.isEmpty() {
+		log.Infof("[%s] Server responded with a certificate.", certRes.Domain)
 	}
 
-	log.Infof("lego has been configured to prefer certificate chains with issuer %q, but no chain from the CA matched this issuer. Using the default certificate chain instead.", preferredChain)
+	certRes.IssuerCertificate = selected.IssuerCertificate
+	certRes.Certificate = selected.Certificate
+	certRes.CertURL = selected.URL
+	certRes.CertStableURL = selected.URL
+	certRes.AlternateChains = alternates
 
 	return true, nil
 }
@@ -436,75 +542,16 @@ func (c *Certifier) Renew(certRes Resource, bundle, mustStaple bool, preferredCh
 //
 // If the []byte and/or ocsp.Response return values are nil, the OCSP status may be assumed OCSPUnknown.
 func (c *Certifier) GetOCSP(bundle []byte) ([]byte, *ocsp.Response, error) {
-	certificates, err := certcrypto.ParsePEMBundle(bundle)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	// We expect the certificate slice to be ordered downwards the chain.
-	// SRV CRT -> CA. We need to pull the leaf and issuer certs out of it,
-	// which should always be the first two certificates.
-	// If there's no OCSP server listed in the leaf cert, there's nothing to do.
-	// And if we have only one certificate so far, we need to get the issuer cert.
-
-	issuedCert := certificates[0]
-
-	if len(issuedCert.OCSPServer) == 0 {
-		return nil, nil, errors.New("no OCSP server specified in cert")
-	}
-
-	if len(certificates) == 1 {
-		// TODO: build fallback. If this fails, check the remaining array entries.
-		if len(issuedCert.IssuingCertificateURL) == 0 {
-			return nil, nil, errors.New("no issuing certificate URL")
-		}
-
-		resp, errC := c.core.HTTPClient.Get(issuedCert.IssuingCertificateURL[0])
-		if errC != nil {
-			return nil, nil, errC
-		}
-		defer resp.Body.Close()
-
-		issuerBytes, errC := ioutil.ReadAll(http.MaxBytesReader(nil, resp.Body, maxBodySize))
-		if errC != nil {
-			return nil, nil, errC
-		}
-
-		issuerCert, errC := x509.ParseCertificate(issuerBytes)
-		if errC != nil {
-			return nil, nil, errC
-		}
-
-		// Insert it into the slice on position 0
-		// We want it ordered right SRV CRT -> CA
-		certificates = append(certificates, issuerCert)
-	}
-
-	issuerCert := certificates[1]
-
-	// Finally kick off the OCSP request.
-	ocspReq, err := ocsp.CreateRequest(issuedCert, issuerCert, nil)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	resp, err := c.core.HTTPClient.Post(issuedCert.OCSPServer[0], "application/ocsp-request", bytes.NewReader(ocspReq))
-	if err != nil {
-		return nil, nil, err
-	}
-	defer resp.Body.Close()
-
-	ocspResBytes, err := ioutil.ReadAll(http.MaxBytesReader(nil, resp.Body, maxBodySize))
-	if err != nil {
-		return nil, nil, err
-	}
-
-	ocspRes, err := ocsp.ParseResponse(ocspResBytes, issuerCert)
-	if err != nil {
-		return nil, nil, err
-	}
+	return c.revocation.GetOCSP(bundle)
+}
 
-	return ocspResBytes, ocspRes, nil
+// GetRevocationStatus takes a PEM encoded cert or cert bundle and returns its revocation status.
+// The status is requested to the OCSP responder of the certificate,
+// and falls back to the CRL distribution points of the certificate if there is no OCSP responder or if the request fails.
+//
+// The CRLs are kept in memory until their next update.
+func (c *Certifier) GetRevocationStatus(bundle []byte) (*RevocationStatus, error) {
+	return c.revocation.Check(bundle)
 }
 
 // Get attempts to fetch the certificate at the supplied URL.
@@ -534,19 +581,11 @@ func (c *Certifier) Get(url string, bundle bool) (*Resource, error) {
 	}, nil
 }
 
-func hasPreferredChain(issuer []byte, preferredChain string) (bool, error) {
-	certs, err := certcrypto.ParsePEMBundle(issuer)
-	if err != nil {
-		return false, err
-	}
-
-	topCert := certs[len(certs)-1]
-
-	if topCert.Issuer.CommonName == preferredChain {
-		return true, nil
-	}
-
-	return false, nil
+// isRateLimitError returns true if the error is an acme.RateLimitError,
+// these errors are not related to a domain and must be returned as is to allow the caller to handle the retry.
+func isRateLimitError(err error) bool {
+	var rateLimitErr *acme.RateLimitError
+	return errors.As(err, &rateLimitErr)
 }
 
 func checkOrderStatus(order acme.ExtendedOrder) (bool, error) {
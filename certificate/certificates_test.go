@@ -181,7 +181,7 @@ func Test_checkResponse(t *testing.T) {
 	certRes := &Resource{}
 	bundle := false
 
-	valid, err := certifier.checkResponse(order, certRes, bundle, "")
+	valid, err := certifier.checkResponse(order, certRes, bundle, ChainPolicy{})
 	require.NoError(t, err)
 	assert.True(t, valid)
 	assert.NotNil(t, certRes)
@@ -231,7 +231,7 @@ func Test_checkResponse_issuerRelUp(t *testing.T) {
 	certRes := &Resource{}
 	bundle := false
 
-	valid, err := certifier.checkResponse(order, certRes, bundle, "")
+	valid, err := certifier.checkResponse(order, certRes, bundle, ChainPolicy{})
 	require.NoError(t, err)
 	assert.True(t, valid)
 	assert.NotNil(t, certRes)
@@ -272,7 +272,7 @@ func Test_checkResponse_embeddedIssuer(t *testing.T) {
 	certRes := &Resource{}
 	bundle := false
 
-	valid, err := certifier.checkResponse(order, certRes, bundle, "")
+	valid, err := certifier.checkResponse(order, certRes, bundle, ChainPolicy{})
 	require.NoError(t, err)
 	assert.True(t, valid)
 	assert.NotNil(t, certRes)
@@ -324,7 +324,7 @@ func Test_checkResponse_alternate(t *testing.T) {
 	}
 	bundle := false
 
-	valid, err := certifier.checkResponse(order, certRes, bundle, "DST Root CA X3")
+	valid, err := certifier.checkResponse(order, certRes, bundle, ChainPolicy{IssuerCommonName: "DST Root CA X3"})
 	require.NoError(t, err)
 
 	assert.True(t, valid)
@@ -336,6 +336,10 @@ func Test_checkResponse_alternate(t *testing.T) {
 	assert.Nil(t, certRes.PrivateKey)
 	assert.Equal(t, certResponseMock2, string(certRes.Certificate), "Certificate")
 	assert.Equal(t, issuerMock2, string(certRes.IssuerCertificate), "IssuerCertificate")
+
+	require.Len(t, certRes.AlternateChains, 1)
+	assert.Equal(t, apiURL+"/certificate", certRes.AlternateChains[0].URL)
+	assert.Equal(t, certResponseMock, string(certRes.AlternateChains[0].Certificate))
 }
 
 func Test_Get(t *testing.T) {
@@ -0,0 +1,262 @@
+package certificate
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-acme/lego/v4/acme"
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/log"
+)
+
+// ChainPolicy the policy used to select a certificate chain when the CA offers multiple chains (alternate links).
+//
+// The chains are filtered by the root pool, then by the matchers (IssuerCommonName, Fingerprints, SubjectKeyIDs),
+// then the shortest chain is selected if Shortest is true.
+// If no chain satisfies a criterion, the criterion is ignored.
+// The default chain offered by the CA wins the ties.
+type ChainPolicy struct {
+	// IssuerCommonName matches the chains with a top certificate issued by this Subject Common Name.
+	IssuerCommonName string
+
+	// Fingerprints matches the chains containing a certificate with one of these SHA-256 fingerprints (hex encoded, colons are allowed).
+	Fingerprints []string
+
+	// SubjectKeyIDs matches the chains containing a certificate with one of these subject key IDs (hex encoded, colons are allowed).
+	SubjectKeyIDs []string
+
+	// Shortest prefers the shortest chain.
+	Shortest bool
+
+	// Roots prefers the chains verifying against this pool.
+	// The roots of the verified chains are also used by Fingerprints and SubjectKeyIDs.
+	Roots *x509.CertPool
+}
+
+// newChainPolicy returns the policy, the preferred chain is used as IssuerCommonName if not defined.
+func newChainPolicy(policy ChainPolicy, preferredChain string) ChainPolicy {
+	if policy.IssuerCommonName == "" {
+		policy.IssuerCommonName = preferredChain
+	}
+
+	return policy
+}
+
+// Validate checks the values of the policy.
+func (p ChainPolicy) Validate() error {
+	for _, value := range p.Fingerprints {
+		if _, err := decodeHex(value); err != nil {
+			return fmt.Errorf("invalid fingerprint %q: %w", value, err)
+		}
+	}
+
+	for _, value := range p.SubjectKeyIDs {
+		if _, err := decodeHex(value); err != nil {
+			return fmt.Errorf("invalid subject key ID %q: %w", value, err)
+		}
+	}
+
+	return nil
+}
+
+func (p ChainPolicy) isEmpty() bool {
+	return p.IssuerCommonName == "" && len(p.Fingerprints) == 0 && len(p.SubjectKeyIDs) == 0 && !p.Shortest && p.Roots == nil
+}
+
+func (p ChainPolicy) hasMatchers() bool {
+	return p.IssuerCommonName != "" || len(p.Fingerprints) > 0 || len(p.SubjectKeyIDs) > 0
+}
+
+// Chain a certificate chain offered by the CA.
+type Chain struct {
+	URL string `json:"url"`
+	// Issuer the Subject Common Name of the issuer of the top certificate of the chain.
+	Issuer string `json:"issuer"`
+	// Length the number of certificates of the chain (including the certificate).
+	Length int `json:"length"`
+
+	Certificate       []byte `json:"-"`
+	IssuerCertificate []byte `json:"-"`
+}
+
+type candidateChain struct {
+	Chain
+
+	certificates []*x509.Certificate
+	roots        []*x509.Certificate
+}
+
+// selectChain selects a chain according to the policy.
+// Returns the selected chain and the other chains.
+func selectChain(domain, defaultURL string, certs map[string]*acme.RawCertificate, policy ChainPolicy) (*Chain, []Chain, error) {
+	// the default chain first, then the alternate chains.
+	urls := make([]string, 0, len(certs))
+	for link := range certs {
+		if link != defaultURL {
+			urls = append(urls, link)
+		}
+	}
+
+	sort.Strings(urls)
+
+	urls = append([]string{defaultURL}, urls...)
+
+	var candidates []*candidateChain
+
+	for _, link := range urls {
+		candidate, err := newCandidateChain(link, certs[link])
+		if err != nil {
+			return nil, nil, err
+		}
+
+		candidates = append(candidates, candidate)
+	}
+
+	selected := candidates
+
+	if policy.Roots != nil {
+		selected = filterChains(selected, func(c *candidateChain) bool { return c.verify(policy.Roots) })
+		if len(selected) == 0 {
+			log.Infof("[%s] No certificate chain from the CA verifies against the preferred roots: the roots are ignored.", domain)
+			selected = candidates
+		}
+	}
+
+	if policy.hasMatchers() {
+		matched := filterChains(selected, func(c *candidateChain) bool { return c.matches(policy) })
+		if len(matched) == 0 {
+			log.Infof("[%s] No certificate chain from the CA matches the preferred chain: the matchers are ignored.", domain)
+		} else {
+			selected = matched
+		}
+	}
+
+	best := selected[0]
+
+	if policy.Shortest {
+		for _, c := range selected[1:] {
+			if c.Length < best.Length {
+				best = c
+			}
+		}
+	}
+
+	if !policy.isEmpty() {
+		log.Infof("[%s] Server responded with a certificate for the preferred certificate chain (issuer: %q, length: %d).", domain, best.Issuer, best.Length)
+	}
+
+	var others []Chain
+	for _, c := range candidates {
+		if c != best {
+			others = append(others, c.Chain)
+		}
+	}
+
+	return &best.Chain, others, nil
+}
+
+func newCandidateChain(link string, raw *acme.RawCertificate) (*candidateChain, error) {
+	certificates, err := certcrypto.ParsePEMBundle(raw.Cert)
+	if err != nil {
+		return nil, err
+	}
+
+	// the issuer can be embedded in the certificate (bundle).
+	if len(certificates) == 1 && len(raw.Issuer) > 0 {
+		issuers, errP := certcrypto.ParsePEMBundle(raw.Issuer)
+		if errP != nil {
+			return nil, errP
+		}
+
+		certificates = append(certificates, issuers...)
+	}
+
+	return &candidateChain{
+		Chain: Chain{
+			URL:               link,
+			Issuer:            certificates[len(certificates)-1].Issuer.CommonName,
+			Length:            len(certificates),
+			Certificate:       raw.Cert,
+			IssuerCertificate: raw.Issuer,
+		},
+		certificates: certificates,
+	}, nil
+}
+
+// verify verifies the chain against the roots, and keeps the roots of the verified chains.
+func (c *candidateChain) verify(roots *x509.CertPool) bool {
+	intermediates := x509.NewCertPool()
+	for _, cert := range c.certificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	verified, err := c.certificates[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return false
+	}
+
+	for _, chain := range verified {
+		c.roots = append(c.roots, chain[len(chain)-1])
+	}
+
+	return true
+}
+
+func (c *candidateChain) matches(policy ChainPolicy) bool {
+	if policy.IssuerCommonName != "" && c.Issuer == policy.IssuerCommonName {
+		return true
+	}
+
+	for _, cert := range append(append([]*x509.Certificate{}, c.certificates...), c.roots...) {
+		fingerprint := sha256.Sum256(cert.Raw)
+
+		for _, value := range policy.Fingerprints {
+			if raw, err := decodeHex(value); err == nil && bytes.Equal(raw, fingerprint[:]) {
+				return true
+			}
+		}
+
+		for _, value := range policy.SubjectKeyIDs {
+			if raw, err := decodeHex(value); err == nil && len(cert.SubjectKeyId) > 0 && bytes.Equal(raw, cert.SubjectKeyId) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func filterChains(chains []*candidateChain, keep func(*candidateChain) bool) []*candidateChain {
+	var filtered []*candidateChain
+	for _, c := range chains {
+		if keep(c) {
+			filtered = append(filtered, c)
+		}
+	}
+
+	return filtered
+}
+
+// decodeHex decodes a hex encoded value (ex: "AB:CD:EF" or "abcdef").
+func decodeHex(value string) ([]byte, error) {
+	raw, err := hex.DecodeString(strings.ReplaceAll(strings.TrimSpace(value), ":", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(raw) == 0 {
+		return nil, errors.New("empty value")
+	}
+
+	return raw, nil
+}
@@ -0,0 +1,259 @@
+package certificate
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/go-acme/lego/v4/acme"
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func Test_selectChain(t *testing.T) {
+	rootA := newTestCA(t, "Root", nil)
+	rootB := newTestCA(t, "Root", nil)
+
+	// the same intermediate key, issued by the two roots.
+	intermediateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	intermediateA := newTestCAWithKey(t, "Intermediate", rootA, intermediateKey)
+	intermediateB := newTestCAWithKey(t, "Intermediate", rootB, intermediateKey)
+
+	// rootB cross-signed by rootA.
+	crossB := newTestCAWithKey(t, "Root", rootA, rootB.key)
+
+	leaf := issueTestLeaf(t, intermediateA)
+
+	certs := map[string]*acme.RawCertificate{
+		// default: leaf -> intermediateB -> crossB (-> rootA).
+		"https://example.com/cert": {Cert: leaf, Issuer: encodeTestCerts(intermediateB, crossB)},
+		// alternate: leaf -> intermediateA (-> rootA).
+		"https://example.com/cert/1": {Cert: leaf, Issuer: encodeTestCerts(intermediateA)},
+		// alternate: leaf -> intermediateB (-> rootB).
+		"https://example.com/cert/2": {Cert: leaf, Issuer: encodeTestCerts(intermediateB)},
+	}
+
+	crossFingerprint := sha256.Sum256(crossB.cert.Raw)
+	rootFingerprint := sha256.Sum256(rootB.cert.Raw)
+
+	rootsB := x509.NewCertPool()
+	rootsB.AddCert(rootB.cert)
+
+	rootsAB := x509.NewCertPool()
+	rootsAB.AddCert(rootA.cert)
+	rootsAB.AddCert(rootB.cert)
+
+	testCases := []struct {
+		desc     string
+		policy   ChainPolicy
+		expected string
+	}{
+		{
+			desc:     "default",
+			expected: "https://example.com/cert",
+		},
+		{
+			desc:     "no match",
+			policy:   ChainPolicy{IssuerCommonName: "Unknown"},
+			expected: "https://example.com/cert",
+		},
+		{
+			desc:     "shortest",
+			policy:   ChainPolicy{Shortest: true},
+			expected: "https://example.com/cert/1",
+		},
+		{
+			desc:     "issuer common name",
+			policy:   ChainPolicy{IssuerCommonName: "Root"},
+			expected: "https://example.com/cert",
+		},
+		{
+			desc:     "fingerprint of a root not in the chains",
+			policy:   ChainPolicy{Fingerprints: []string{hex.EncodeToString(rootFingerprint[:])}},
+			expected: "https://example.com/cert",
+		},
+		{
+			desc:     "fingerprint and shortest",
+			policy:   ChainPolicy{Fingerprints: []string{formatTestFingerprint(crossFingerprint[:])}, Shortest: true},
+			expected: "https://example.com/cert",
+		},
+		{
+			desc:     "subject key ID and shortest",
+			policy:   ChainPolicy{SubjectKeyIDs: []string{hex.EncodeToString(crossB.cert.SubjectKeyId)}, Shortest: true},
+			expected: "https://example.com/cert",
+		},
+		{
+			desc:     "subject key ID shared by the intermediates",
+			policy:   ChainPolicy{SubjectKeyIDs: []string{hex.EncodeToString(intermediateA.cert.SubjectKeyId)}, Shortest: true},
+			expected: "https://example.com/cert/1",
+		},
+		{
+			desc:     "roots and shortest",
+			policy:   ChainPolicy{Roots: rootsB, Shortest: true},
+			expected: "https://example.com/cert/2",
+		},
+		{
+			desc:     "roots, fingerprint of the verified root, and shortest",
+			policy:   ChainPolicy{Roots: rootsAB, Fingerprints: []string{hex.EncodeToString(rootFingerprint[:])}, Shortest: true},
+			expected: "https://example.com/cert/2",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			selected, others, err := selectChain("example.com", "https://example.com/cert", certs, test.policy)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, selected.URL)
+			assert.Len(t, others, 2)
+
+			for _, other := range others {
+				assert.NotEqual(t, selected.URL, other.URL)
+			}
+		})
+	}
+}
+
+func formatTestFingerprint(fingerprint []byte) string {
+	var value string
+	for i, b := range fingerprint {
+		if i > 0 {
+			value += ":"
+		}
+		value += hex.EncodeToString([]byte{b})
+	}
+
+	return value
+}
+
+func newTestCA(t *testing.T, name string, parent *testCA) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	return newTestCAWithKey(t, name, parent, key)
+}
+
+func newTestCAWithKey(t *testing.T, name string, parent *testCA, key *ecdsa.PrivateKey) *testCA {
+	t.Helper()
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	issuer, signer := template, key
+	if parent != nil {
+		issuer, signer = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, key.Public(), signer)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCA{cert: cert, key: key}
+}
+
+func TestChainPolicy_Validate(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		policy   ChainPolicy
+		expected string
+	}{
+		{
+			desc: "valid values",
+			policy: ChainPolicy{
+				Fingerprints:  []string{"AB:CD:EF", "abcdef"},
+				SubjectKeyIDs: []string{" 0102 "},
+			},
+		},
+		{
+			desc:     "invalid fingerprint",
+			policy:   ChainPolicy{Fingerprints: []string{"AB:CD:EG"}},
+			expected: `invalid fingerprint "AB:CD:EG": encoding/hex: invalid byte: U+0047 'G'`,
+		},
+		{
+			desc:     "odd length subject key ID",
+			policy:   ChainPolicy{SubjectKeyIDs: []string{"abc"}},
+			expected: `invalid subject key ID "abc": encoding/hex: odd length hex string`,
+		},
+		{
+			desc:     "empty subject key ID",
+			policy:   ChainPolicy{SubjectKeyIDs: []string{""}},
+			expected: `invalid subject key ID "": empty value`,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			err := test.policy.Validate()
+			if test.expected == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			require.EqualError(t, err, test.expected)
+		})
+	}
+}
+
+func issueTestLeaf(t *testing.T, ca *testCA) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		DNSNames:     []string{"example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, key.Public(), ca.key)
+	require.NoError(t, err)
+
+	return certcrypto.PEMEncode(certcrypto.DERCertificateBytes(der))
+}
+
+func encodeTestCerts(cas ...*testCA) []byte {
+	var raw []byte
+	for _, ca := range cas {
+		raw = append(raw, certcrypto.PEMEncode(certcrypto.DERCertificateBytes(ca.cert.Raw))...)
+	}
+
+	return raw
+}
@@ -0,0 +1,155 @@
+package certificate
+
+import (
+	"github.com/go-acme/lego/v4/acme"
+	"github.com/go-acme/lego/v4/challenge"
+	"github.com/go-acme/lego/v4/log"
+)
+
+// OrderState the state of an in-flight order.
+type OrderState struct {
+	Domains  []string `json:"domains"`
+	OrderURL string   `json:"orderUrl"`
+
+	// The authorizations of the order, as they were before solving the challenges.
+	// Contains the challenge tokens, used to clean the challenges after an interruption.
+	Authorizations []acme.Authorization `json:"authorizations,omitempty"`
+
+	// The DNS records presented for the challenges, indexed by domain.
+	// Used to clean the records after an interruption, without deriving them again.
+	Records map[string]challenge.Record `json:"records,omitempty"`
+}
+
+// OrderStorage persists the state of the in-flight orders,
+// allows to resume an order (or at least to clean the challenges) after an interruption.
+//
+// The key is the first domain of the order.
+type OrderStorage interface {
+	// LoadOrderState returns nil if there is no state for the key.
+	LoadOrderState(key string) (*OrderState, error)
+	SaveOrderState(key string, state *OrderState) error
+	DeleteOrderState(key string) error
+}
+
+// cleaner is implemented by resolvers which can clean the challenges of authorizations.
+type cleaner interface {
+	CleanUp(authorizations []acme.Authorization, records map[string]challenge.Record)
+}
+
+// recorder is implemented by resolvers which present DNS records.
+type recorder interface {
+	Records(authorizations []acme.Authorization) map[string]challenge.Record
+}
+
+// createOrder resumes the previous in-flight order for these domains if it's still pending or ready,
+// otherwise creates a new order.
+func (c *Certifier) createOrder(domains []string) (acme.ExtendedOrder, error) {
+	if c.options.OrderStorage == nil {
+		return c.core.Orders.New(domains)
+	}
+
+	key := domains[0]
+
+	order, ok := c.resumeOrder(key, domains)
+	if ok {
+		return order, nil
+	}
+
+	order, err := c.core.Orders.New(domains)
+	if err != nil {
+		return acme.ExtendedOrder{}, err
+	}
+
+	c.saveOrderState(key, &OrderState{Domains: domains, OrderURL: order.Location})
+
+	return order, nil
+}
+
+func (c *Certifier) resumeOrder(key string, domains []string) (acme.ExtendedOrder, bool) {
+	state, err := c.options.OrderStorage.LoadOrderState(key)
+	if err != nil {
+		log.Warnf("[%s] acme: unable to load the state of the previous order: %v", key, err)
+		return acme.ExtendedOrder{}, false
+	}
+
+	if state == nil {
+		return acme.ExtendedOrder{}, false
+	}
+
+	// The challenges can have been presented before the interruption.
+	if r, ok := c.resolver.(cleaner); ok && len(state.Authorizations) > 0 {
+		log.Infof("[%s] acme: Cleaning the challenges of the previous order", key)
+		r.CleanUp(state.Authorizations, state.Records)
+	}
+
+	order, err := c.core.Orders.Get(state.OrderURL)
+	if err == nil && sameDomains(state.Domains, domains) &&
+		(order.Status == acme.StatusPending || order.Status == acme.StatusReady) {
+		log.Infof("[%s] acme: Resuming the previous order: %s", key, state.OrderURL)
+
+		order.Location = state.OrderURL
+
+		return order, true
+	}
+
+	c.deleteOrderState(key)
+
+	return acme.ExtendedOrder{}, false
+}
+
+// newOrderState returns the state of an order, with the records which will be presented for its authorizations.
+func (c *Certifier) newOrderState(domains []string, order acme.ExtendedOrder, authz []acme.Authorization) *OrderState {
+	state := &OrderState{Domains: domains, OrderURL: order.Location, Authorizations: authz}
+
+	if r, ok := c.resolver.(recorder); ok && c.options.OrderStorage != nil {
+		state.Records = r.Records(authz)
+	}
+
+	return state
+}
+
+func (c *Certifier) saveOrderState(key string, state *OrderState) {
+	if c.options.OrderStorage == nil {
+		return
+	}
+
+	err := c.options.OrderStorage.SaveOrderState(key, state)
+	if err != nil {
+		log.Warnf("[%s] acme: unable to save the state of the order: %v", key, err)
+	}
+}
+
+func (c *Certifier) deleteOrderState(key string) {
+	if c.options.OrderStorage == nil {
+		return
+	}
+
+	err := c.options.OrderStorage.DeleteOrderState(key)
+	if err != nil {
+		log.Warnf("[%s] acme: unable to delete the state of the order: %v", key, err)
+	}
+}
+
+func sameDomains(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for _, domain := range a {
+		if !containsDomain(b, domain) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func containsDomain(domains []string, domain string) bool {
+	for _, d := range domains {
+		if d == domain {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,166 @@
+package certificate
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"testing"
+
+	"github.com/go-acme/lego/v4/acme"
+	"github.com/go-acme/lego/v4/acme/api"
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/challenge"
+	"github.com/go-acme/lego/v4/platform/tester"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCertifier_createOrder(t *testing.T) {
+	testCases := []struct {
+		desc             string
+		previousStatus   string
+		previousDomains  []string
+		expectedLocation string
+	}{
+		{
+			desc:             "resume pending order",
+			previousStatus:   acme.StatusPending,
+			previousDomains:  []string{"example.com"},
+			expectedLocation: "/order/previous",
+		},
+		{
+			desc:             "resume ready order",
+			previousStatus:   acme.StatusReady,
+			previousDomains:  []string{"example.com"},
+			expectedLocation: "/order/previous",
+		},
+		{
+			desc:             "invalid previous order",
+			previousStatus:   acme.StatusInvalid,
+			previousDomains:  []string{"example.com"},
+			expectedLocation: "/order/new",
+		},
+		{
+			desc:             "previous order with other domains",
+			previousStatus:   acme.StatusPending,
+			previousDomains:  []string{"example.com", "www.example.com"},
+			expectedLocation: "/order/new",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			mux, apiURL, tearDown := tester.SetupFakeAPI()
+			defer tearDown()
+
+			mux.HandleFunc("/order/previous", func(w http.ResponseWriter, _ *http.Request) {
+				err := tester.WriteJSONResponse(w, acme.Order{Status: test.previousStatus})
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+				}
+			})
+
+			mux.HandleFunc("/newOrder", func(w http.ResponseWriter, _ *http.Request) {
+				w.Header().Set("Location", apiURL+"/order/new")
+				err := tester.WriteJSONResponse(w, acme.Order{Status: acme.StatusPending})
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+				}
+			})
+
+			key, err := rsa.GenerateKey(rand.Reader, 2048)
+			require.NoError(t, err, "Could not generate test key")
+
+			core, err := api.New(http.DefaultClient, "lego-test", apiURL+"/dir", "", key)
+			require.NoError(t, err)
+
+			storage := &orderStorageMock{states: map[string]*OrderState{
+				"example.com": {
+					Domains:  test.previousDomains,
+					OrderURL: apiURL + "/order/previous",
+					Authorizations: []acme.Authorization{
+						{Status: acme.StatusPending, Identifier: acme.Identifier{Type: "dns", Value: "example.com"}},
+					},
+					Records: map[string]challenge.Record{
+						"example.com": {Type: challenge.DNS01, FQDN: "_acme-challenge.example.com.", Value: "stored"},
+					},
+				},
+			}}
+
+			resolver := &cleanerResolverMock{}
+
+			certifier := NewCertifier(core, resolver, CertifierOptions{KeyType: certcrypto.RSA2048, OrderStorage: storage})
+
+			order, err := certifier.createOrder([]string{"example.com"})
+			require.NoError(t, err)
+
+			assert.Equal(t, apiURL+test.expectedLocation, order.Location)
+			assert.Len(t, resolver.cleaned, 1)
+			assert.Equal(t, "stored", resolver.cleanedRecords["example.com"].Value)
+
+			require.Contains(t, storage.states, "example.com")
+			assert.Equal(t, apiURL+test.expectedLocation, storage.states["example.com"].OrderURL)
+		})
+	}
+}
+
+type orderStorageMock struct {
+	states map[string]*OrderState
+}
+
+func (s *orderStorageMock) LoadOrderState(key string) (*OrderState, error) {
+	return s.states[key], nil
+}
+
+func (s *orderStorageMock) SaveOrderState(key string, state *OrderState) error {
+	s.states[key] = state
+	return nil
+}
+
+func (s *orderStorageMock) DeleteOrderState(key string) error {
+	delete(s.states, key)
+	return nil
+}
+
+func TestCertifier_newOrderState(t *testing.T) {
+	resolver := &cleanerResolverMock{}
+
+	authz := []acme.Authorization{
+		{Status: acme.StatusPending, Identifier: acme.Identifier{Type: "dns", Value: "example.com"}},
+	}
+
+	certifier := &Certifier{resolver: resolver, options: CertifierOptions{OrderStorage: &orderStorageMock{}}}
+
+	state := certifier.newOrderState([]string{"example.com"}, acme.ExtendedOrder{Location: "/order/1"}, authz)
+
+	expected := &OrderState{
+		Domains:        []string{"example.com"},
+		OrderURL:       "/order/1",
+		Authorizations: authz,
+		Records: map[string]challenge.Record{
+			"example.com": {Type: challenge.DNS01, FQDN: "_acme-challenge.example.com.", Value: "current"},
+		},
+	}
+	assert.Equal(t, expected, state)
+}
+
+type cleanerResolverMock struct {
+	resolverMock
+	cleaned        []acme.Authorization
+	cleanedRecords map[string]challenge.Record
+}
+
+func (r *cleanerResolverMock) CleanUp(authorizations []acme.Authorization, records map[string]challenge.Record) {
+	r.cleaned = append(r.cleaned, authorizations...)
+	r.cleanedRecords = records
+}
+
+func (r *cleanerResolverMock) Records(authorizations []acme.Authorization) map[string]challenge.Record {
+	records := make(map[string]challenge.Record)
+	for _, authz := range authorizations {
+		records[authz.Identifier.Value] = challenge.Record{Type: challenge.DNS01, FQDN: "_acme-challenge." + authz.Identifier.Value + ".", Value: "current"}
+	}
+
+	return records
+}
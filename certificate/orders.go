@@ -0,0 +1,67 @@
+package certificate
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-acme/lego/v4/acme"
+)
+
+// OrderDetails an order and its authorizations.
+type OrderDetails struct {
+	acme.ExtendedOrder
+
+	// Authorizations the authorizations of the order (in the order of Order.Authorizations).
+	Authorizations []acme.Authorization `json:"authorizationDetails,omitempty"`
+}
+
+// ListOrders returns the URLs of the orders of the account,
+// all the pages of the orders list are fetched.
+//
+// The server SHOULD include the pending orders and SHOULD NOT include the invalid orders.
+// - https://tools.ietf.org/html/rfc8555#section-7.1.2.1
+func (c *Certifier) ListOrders() ([]string, error) {
+	account, err := c.core.Accounts.Get(c.core.GetAccountURL())
+	if err != nil {
+		return nil, fmt.Errorf("could not get the account: %w", err)
+	}
+
+	if account.Orders == "" {
+		return nil, errors.New("the server doesn't provide the orders list of the account")
+	}
+
+	return c.core.Orders.List(account.Orders)
+}
+
+// GetOrder returns the order.
+func (c *Certifier) GetOrder(orderURL string) (acme.ExtendedOrder, error) {
+	order, err := c.core.Orders.Get(orderURL)
+	if err != nil {
+		return acme.ExtendedOrder{}, err
+	}
+
+	order.Location = orderURL
+
+	return order, nil
+}
+
+// GetOrderDetails returns the order and its authorizations (with the status and the error of the challenges).
+func (c *Certifier) GetOrderDetails(orderURL string) (*OrderDetails, error) {
+	order, err := c.GetOrder(orderURL)
+	if err != nil {
+		return nil, err
+	}
+
+	details := &OrderDetails{ExtendedOrder: order}
+
+	for _, authzURL := range order.Authorizations {
+		authz, err := c.core.Authorizations.Get(authzURL)
+		if err != nil {
+			return nil, fmt.Errorf("could not get the authorization %s: %w", authzURL, err)
+		}
+
+		details.Authorizations = append(details.Authorizations, authz)
+	}
+
+	return details, nil
+}
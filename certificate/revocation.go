@@ -0,0 +1,440 @@
+package certificate
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/log"
+	"golang.org/x/crypto/ocsp"
+)
+
+// maxCRLSize is the maximum size of a CRL that we will read.
+const maxCRLSize = 20 * 1024 * 1024
+
+// The sources of a revocation status.
+const (
+	RevocationSourceOCSP = "ocsp"
+	RevocationSourceCRL  = "crl"
+)
+
+// The CRL reason code extension (RFC 5280, section 5.3.1).
+var oidExtensionReasonCode = asn1.ObjectIdentifier{2, 5, 29, 21}
+
+// RevocationStatus the revocation status of a certificate.
+type RevocationStatus struct {
+	// Status is certcrypto.OCSPGood, certcrypto.OCSPRevoked, or certcrypto.OCSPUnknown.
+	Status int
+	// Source is the source of the status: RevocationSourceOCSP or RevocationSourceCRL.
+	Source string
+	// RevokedAt is the date of the revocation (revoked certificate only).
+	RevokedAt time.Time
+	// Reason is the revocation reason code (ocsp.Unspecified, ocsp.KeyCompromise, ...).
+	Reason int
+	// NextUpdate is the date of the next update of the OCSP response or of the CRL.
+	NextUpdate time.Time
+}
+
+// RevocationChecker checks the revocation status of certificates through OCSP,
+// or through the CRL distribution points of the certificates.
+type RevocationChecker struct {
+	httpClient *http.Client
+
+	// crlDir the directory where the CRLs are stored (optional, see WithCRLDir).
+	crlDir string
+
+	mu   sync.Mutex
+	crls map[string]*x509.RevocationList
+}
+
+// RevocationOption an option of the RevocationChecker.
+type RevocationOption func(*RevocationChecker)
+
+// WithCRLDir stores the downloaded CRLs in the directory, they are reused until their next update.
+// Without this option, the CRLs are only kept in memory by the RevocationChecker.
+func WithCRLDir(dir string) RevocationOption {
+	return func(r *RevocationChecker) {
+		r.crlDir = dir
+	}
+}
+
+// NewRevocationChecker creates a RevocationChecker.
+func NewRevocationChecker(httpClient *http.Client, opts ...RevocationOption) *RevocationChecker {
+	checker := &RevocationChecker{
+		httpClient: httpClient,
+		crls:       map[string]*x509.RevocationList{},
+	}
+
+	for _, opt := range opts {
+		opt(checker)
+	}
+
+	return checker
+}
+
+// Check takes a PEM encoded cert or cert bundle and returns its revocation status.
+// The status is requested to the OCSP responder of the certificate,
+// and falls back to the CRL distribution points of the certificate if there is no OCSP responder or if the request fails.
+//
+// If the bundle contains only the certificate, the issuer certificate is fetched from the IssuingCertificateURL of the certificate.
+func (r *RevocationChecker) Check(bundle []byte) (*RevocationStatus, error) {
+	certificates, err := certcrypto.ParsePEMBundle(bundle)
+	if err != nil {
+		return nil, err
+	}
+
+	issuedCert := certificates[0]
+
+	if len(issuedCert.OCSPServer) == 0 && len(issuedCert.CRLDistributionPoints) == 0 {
+		return nil, errors.New("no OCSP server and no CRL distribution point specified in cert")
+	}
+
+	var issuerCert *x509.Certificate
+	if len(certificates) > 1 {
+		issuerCert = certificates[1]
+	} else {
+		issuerCert, err = fetchIssuer(r.httpClient, issuedCert)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(issuedCert.OCSPServer) > 0 {
+		var status *RevocationStatus
+		status, err = r.checkOCSP(issuedCert, issuerCert)
+		if err == nil {
+			return status, nil
+		}
+
+		if len(issuedCert.CRLDistributionPoints) == 0 {
+			return nil, err
+		}
+
+		log.Warnf("OCSP request failed, falling back to the CRL: %v", err)
+	}
+
+	return r.checkCRL(issuedCert, issuerCert)
+}
+
+// GetOCSP takes a PEM encoded cert or cert bundle returning the raw OCSP response,
+// the parsed response, and an error, if any (see Certifier.GetOCSP).
+func (r *RevocationChecker) GetOCSP(bundle []byte) ([]byte, *ocsp.Response, error) {
+	certificates, err := certcrypto.ParsePEMBundle(bundle)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// We expect the certificate slice to be ordered downwards the chain.
+	// SRV CRT -> CA. We need to pull the leaf and issuer certs out of it,
+	// which should always be the first two certificates.
+	// If there's no OCSP server listed in the leaf cert, there's nothing to do.
+	// And if we have only one certificate so far, we need to get the issuer cert.
+
+	issuedCert := certificates[0]
+
+	if len(issuedCert.OCSPServer) == 0 {
+		return nil, nil, errors.New("no OCSP server specified in cert")
+	}
+
+	if len(certificates) == 1 {
+		issuerCert, errC := fetchIssuer(r.httpClient, issuedCert)
+		if errC != nil {
+			return nil, nil, errC
+		}
+
+		// Insert it into the slice on position 0
+		// We want it ordered right SRV CRT -> CA
+		certificates = append(certificates, issuerCert)
+	}
+
+	issuerCert := certificates[1]
+
+	return requestOCSP(r.httpClient, issuedCert, issuerCert)
+}
+
+func (r *RevocationChecker) checkOCSP(issuedCert, issuerCert *x509.Certificate) (*RevocationStatus, error) {
+	_, resp, err := requestOCSP(r.httpClient, issuedCert, issuerCert)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Status == ocsp.ServerFailed {
+		return nil, errors.New("the OCSP responder failed to process the request")
+	}
+
+	return &RevocationStatus{
+		Status:     resp.Status,
+		Source:     RevocationSourceOCSP,
+		RevokedAt:  resp.RevokedAt,
+		Reason:     resp.RevocationReason,
+		NextUpdate: resp.NextUpdate,
+	}, nil
+}
+
+func (r *RevocationChecker) checkCRL(issuedCert, issuerCert *x509.Certificate) (*RevocationStatus, error) {
+	var errs []string
+
+	for _, uri := range issuedCert.CRLDistributionPoints {
+		// only the HTTP distribution points are supported (no LDAP).
+		if !strings.HasPrefix(uri, "http://") && !strings.HasPrefix(uri, "https://") {
+			continue
+		}
+
+		crl, err := r.getCRL(uri, issuerCert)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", uri, err))
+			continue
+		}
+
+		return findInCRL(crl, issuedCert), nil
+	}
+
+	if len(errs) == 0 {
+		return nil, errors.New("no HTTP CRL distribution point specified in cert")
+	}
+
+	return nil, fmt.Errorf("unable to get the CRL: %s", strings.Join(errs, ", "))
+}
+
+// getCRL returns the CRL: from the memory, from the CRL directory, or downloaded.
+// A CRL is reused until its next update.
+func (r *RevocationChecker) getCRL(uri string, issuerCert *x509.Certificate) (*x509.RevocationList, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if crl, ok := r.crls[uri]; ok && verifyCRL(crl, issuerCert, time.Now()) == nil {
+		return crl, nil
+	}
+
+	delete(r.crls, uri)
+
+	if crl := r.readCRL(uri, issuerCert); crl != nil {
+		r.crls[uri] = crl
+		return crl, nil
+	}
+
+	raw, err := fetchCRL(r.httpClient, uri)
+	if err != nil {
+		return nil, err
+	}
+
+	crl, err := parseCRL(raw, issuerCert, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if !crl.NextUpdate.IsZero() {
+		r.crls[uri] = crl
+		r.writeCRL(uri, raw)
+	}
+
+	return crl, nil
+}
+
+// readCRL reads a CRL from the CRL directory.
+// Returns nil if there is no stored CRL, or if the stored CRL is not valid anymore.
+func (r *RevocationChecker) readCRL(uri string, issuerCert *x509.Certificate) *x509.RevocationList {
+	if r.crlDir == "" {
+		return nil
+	}
+
+	raw, err := ioutil.ReadFile(r.crlFilename(uri))
+	if err != nil {
+		return nil
+	}
+
+	crl, err := parseCRL(raw, issuerCert, time.Now())
+	if err != nil {
+		return nil
+	}
+
+	return crl
+}
+
+// writeCRL stores a CRL in the CRL directory, the errors are only logged.
+func (r *RevocationChecker) writeCRL(uri string, raw []byte) {
+	if r.crlDir == "" {
+		return
+	}
+
+	err := os.MkdirAll(r.crlDir, 0o700)
+	if err == nil {
+		err = ioutil.WriteFile(r.crlFilename(uri), raw, 0o600)
+	}
+
+	if err != nil {
+		log.Warnf("Unable to store the CRL %s: %v", uri, err)
+	}
+}
+
+// crlFilename returns the file of a CRL in the CRL directory: the SHA-256 digest of the URI.
+func (r *RevocationChecker) crlFilename(uri string) string {
+	digest := sha256.Sum256([]byte(uri))
+
+	return filepath.Join(r.crlDir, hex.EncodeToString(digest[:])+".crl")
+}
+
+// fetchCRL downloads a CRL.
+func fetchCRL(httpClient *http.Client, uri string) ([]byte, error) {
+	resp, err := httpClient.Get(uri)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return ioutil.ReadAll(http.MaxBytesReader(nil, resp.Body, maxCRLSize))
+}
+
+// parseCRL parses a DER encoded CRL and verifies it against the issuer certificate.
+func parseCRL(raw []byte, issuerCert *x509.Certificate, now time.Time) (*x509.RevocationList, error) {
+	crl, err := x509.ParseRevocationList(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	err = verifyCRL(crl, issuerCert, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return crl, nil
+}
+
+// verifyCRL checks that the CRL has been issued by the issuer certificate and is not expired.
+func verifyCRL(crl *x509.RevocationList, issuerCert *x509.Certificate, now time.Time) error {
+	if !bytes.Equal(crl.RawIssuer, issuerCert.RawSubject) {
+		return errors.New("the CRL issuer doesn't match the certificate issuer")
+	}
+
+	err := crl.CheckSignatureFrom(issuerCert)
+	if err != nil {
+		return fmt.Errorf("invalid CRL signature: %w", err)
+	}
+
+	if !crl.NextUpdate.IsZero() && now.After(crl.NextUpdate) {
+		return fmt.Errorf("the CRL is expired (next update: %s)", crl.NextUpdate.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// findInCRL returns the revocation status of the certificate according to the CRL.
+func findInCRL(crl *x509.RevocationList, issuedCert *x509.Certificate) *RevocationStatus {
+	status := &RevocationStatus{
+		Status:     certcrypto.OCSPGood,
+		Source:     RevocationSourceCRL,
+		NextUpdate: crl.NextUpdate,
+	}
+
+	for _, revoked := range crl.RevokedCertificates {
+		if revoked.SerialNumber.Cmp(issuedCert.SerialNumber) != 0 {
+			continue
+		}
+
+		status.Status = certcrypto.OCSPRevoked
+		status.RevokedAt = revoked.RevocationTime
+
+		for _, ext := range revoked.Extensions {
+			if !ext.Id.Equal(oidExtensionReasonCode) {
+				continue
+			}
+
+			var reason asn1.Enumerated
+			if _, err := asn1.Unmarshal(ext.Value, &reason); err == nil {
+				status.Reason = int(reason)
+			}
+		}
+
+		break
+	}
+
+	return status
+}
+
+// fetchIssuer fetches the issuer certificate from the IssuingCertificateURL of the certificate,
+// the URLs are tried in order.
+func fetchIssuer(httpClient *http.Client, issuedCert *x509.Certificate) (*x509.Certificate, error) {
+	if len(issuedCert.IssuingCertificateURL) == 0 {
+		return nil, errors.New("no issuing certificate URL")
+	}
+
+	var errs []string
+
+	for _, uri := range issuedCert.IssuingCertificateURL {
+		issuerCert, err := fetchCertificate(httpClient, uri)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", uri, err))
+			continue
+		}
+
+		return issuerCert, nil
+	}
+
+	return nil, fmt.Errorf("unable to get the issuer certificate: %s", strings.Join(errs, ", "))
+}
+
+// fetchCertificate downloads a DER encoded certificate.
+func fetchCertificate(httpClient *http.Client, uri string) (*x509.Certificate, error) {
+	resp, err := httpClient.Get(uri)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	issuerBytes, err := ioutil.ReadAll(http.MaxBytesReader(nil, resp.Body, maxBodySize))
+	if err != nil {
+		return nil, err
+	}
+
+	return x509.ParseCertificate(issuerBytes)
+}
+
+// requestOCSP requests the OCSP status of the certificate, the signature of the response is verified.
+func requestOCSP(httpClient *http.Client, issuedCert, issuerCert *x509.Certificate) ([]byte, *ocsp.Response, error) {
+	ocspReq, err := ocsp.CreateRequest(issuedCert, issuerCert, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := httpClient.Post(issuedCert.OCSPServer[0], "application/ocsp-request", bytes.NewReader(ocspReq))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("unexpected status code from the OCSP responder: %d", resp.StatusCode)
+	}
+
+	ocspResBytes, err := ioutil.ReadAll(http.MaxBytesReader(nil, resp.Body, maxBodySize))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ocspRes, err := ocsp.ParseResponse(ocspResBytes, issuerCert)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ocspResBytes, ocspRes, nil
+}
@@ -0,0 +1,269 @@
+package certificate
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ocsp"
+)
+
+type revocationCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+
+	revoked  []pkix.RevokedCertificate
+	crlCalls int
+	ocspDown bool
+}
+
+func (ca *revocationCA) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	switch req.URL.Path {
+	case "/crl":
+		ca.crlCalls++
+
+		template := &x509.RevocationList{
+			Number:              big.NewInt(int64(ca.crlCalls)),
+			ThisUpdate:          time.Now().Add(-time.Hour),
+			NextUpdate:          time.Now().Add(time.Hour),
+			RevokedCertificates: ca.revoked,
+		}
+
+		crl, err := x509.CreateRevocationList(rand.Reader, template, ca.cert, ca.key)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		_, _ = rw.Write(crl)
+
+	case "/ocsp":
+		if ca.ocspDown {
+			http.Error(rw, "down", http.StatusServiceUnavailable)
+			return
+		}
+
+		body, _ := ioutil.ReadAll(req.Body)
+
+		ocspReq, err := ocsp.ParseRequest(body)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		template := ocsp.Response{
+			Status:       ocsp.Good,
+			SerialNumber: ocspReq.SerialNumber,
+			ThisUpdate:   time.Now().Add(-time.Hour),
+			NextUpdate:   time.Now().Add(time.Hour),
+		}
+
+		resp, err := ocsp.CreateResponse(ca.cert, ca.cert, template, ca.key)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		_, _ = rw.Write(resp)
+
+	case "/issuer":
+		_, _ = rw.Write(ca.cert.Raw)
+
+	default:
+		http.NotFound(rw, req)
+	}
+}
+
+func TestRevocationChecker_Check(t *testing.T) {
+	ca := newRevocationCA(t)
+
+	server := httptest.NewServer(ca)
+	t.Cleanup(server.Close)
+
+	checker := NewRevocationChecker(server.Client())
+
+	// CRL only.
+	bundle := ca.issue(t, 2, nil, []string{"ldap://example.com/crl", server.URL + "/crl"})
+
+	status, err := checker.Check(bundle)
+	require.NoError(t, err)
+
+	assert.Equal(t, certcrypto.OCSPGood, status.Status)
+	assert.Equal(t, RevocationSourceCRL, status.Source)
+	assert.Equal(t, 1, ca.crlCalls)
+
+	// the CRL is kept in memory until its next update.
+	revokedAt := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+
+	reason, err := asn1.Marshal(asn1.Enumerated(ocsp.KeyCompromise))
+	require.NoError(t, err)
+
+	ca.revoked = []pkix.RevokedCertificate{{
+		SerialNumber:   big.NewInt(2),
+		RevocationTime: revokedAt,
+		Extensions:     []pkix.Extension{{Id: oidExtensionReasonCode, Value: reason}},
+	}}
+
+	status, err = checker.Check(bundle)
+	require.NoError(t, err)
+
+	assert.Equal(t, certcrypto.OCSPGood, status.Status)
+	assert.Equal(t, 1, ca.crlCalls)
+
+	status, err = NewRevocationChecker(server.Client()).Check(bundle)
+	require.NoError(t, err)
+
+	assert.Equal(t, certcrypto.OCSPRevoked, status.Status)
+	assert.Equal(t, ocsp.KeyCompromise, status.Reason)
+	assert.Equal(t, revokedAt, status.RevokedAt.UTC())
+	assert.Equal(t, 2, ca.crlCalls)
+
+	// OCSP first, then CRL.
+	bundle = ca.issue(t, 3, []string{server.URL + "/ocsp"}, []string{server.URL + "/crl"})
+
+	status, err = checker.Check(bundle)
+	require.NoError(t, err)
+
+	assert.Equal(t, certcrypto.OCSPGood, status.Status)
+	assert.Equal(t, RevocationSourceOCSP, status.Source)
+
+	ca.ocspDown = true
+
+	status, err = checker.Check(bundle)
+	require.NoError(t, err)
+
+	assert.Equal(t, certcrypto.OCSPGood, status.Status)
+	assert.Equal(t, RevocationSourceCRL, status.Source)
+
+	// neither OCSP nor CRL.
+	_, err = checker.Check(ca.issue(t, 4, nil, nil))
+	require.Error(t, err)
+}
+
+func TestRevocationChecker_Check_invalidCRL(t *testing.T) {
+	ca := newRevocationCA(t)
+	other := newRevocationCA(t)
+
+	server := httptest.NewServer(other)
+	t.Cleanup(server.Close)
+
+	// the CRL is signed by another CA with the same name.
+	bundle := ca.issue(t, 2, nil, []string{server.URL + "/crl"})
+
+	_, err := NewRevocationChecker(server.Client()).Check(bundle)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid CRL signature")
+}
+
+func TestRevocationChecker_Check_crlDir(t *testing.T) {
+	ca := newRevocationCA(t)
+
+	server := httptest.NewServer(ca)
+	t.Cleanup(server.Close)
+
+	dir := t.TempDir()
+
+	bundle := ca.issue(t, 2, nil, []string{server.URL + "/crl"})
+
+	status, err := NewRevocationChecker(server.Client(), WithCRLDir(dir)).Check(bundle)
+	require.NoError(t, err)
+
+	assert.Equal(t, certcrypto.OCSPGood, status.Status)
+	assert.Equal(t, 1, ca.crlCalls)
+
+	// the stored CRL is reused by another checker until its next update.
+	status, err = NewRevocationChecker(server.Client(), WithCRLDir(dir)).Check(bundle)
+	require.NoError(t, err)
+
+	assert.Equal(t, certcrypto.OCSPGood, status.Status)
+	assert.Equal(t, 1, ca.crlCalls)
+
+	// the stored CRL of another CA (same name) is ignored.
+	other := newRevocationCA(t)
+
+	status, err = NewRevocationChecker(server.Client(), WithCRLDir(dir)).Check(other.issue(t, 2, nil, []string{server.URL + "/crl"}))
+	require.Error(t, err)
+	assert.Nil(t, status)
+	assert.Equal(t, 2, ca.crlCalls)
+}
+
+func Test_fetchIssuer(t *testing.T) {
+	ca := newRevocationCA(t)
+
+	server := httptest.NewServer(ca)
+	t.Cleanup(server.Close)
+
+	// the URLs are tried in order, the errors are not parsed as certificates.
+	issuedCert := &x509.Certificate{IssuingCertificateURL: []string{server.URL + "/unknown", server.URL + "/issuer"}}
+
+	issuerCert, err := fetchIssuer(server.Client(), issuedCert)
+	require.NoError(t, err)
+
+	assert.Equal(t, ca.cert.Raw, issuerCert.Raw)
+
+	issuedCert = &x509.Certificate{IssuingCertificateURL: []string{server.URL + "/unknown"}}
+
+	_, err = fetchIssuer(server.Client(), issuedCert)
+	require.EqualError(t, err, "unable to get the issuer certificate: "+server.URL+"/unknown: unexpected status code: 404")
+}
+
+func newRevocationCA(t *testing.T) *revocationCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &revocationCA{cert: cert, key: key}
+}
+
+func (ca *revocationCA) issue(t *testing.T, serial int64, ocspServers, crlDistributionPoints []string) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: "example.com"},
+		DNSNames:              []string{"example.com"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		OCSPServer:            ocspServers,
+		CRLDistributionPoints: crlDistributionPoints,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, key.Public(), ca.key)
+	require.NoError(t, err)
+
+	bundle := certcrypto.PEMEncode(certcrypto.DERCertificateBytes(der))
+
+	return append(bundle, certcrypto.PEMEncode(certcrypto.DERCertificateBytes(ca.cert.Raw))...)
+}
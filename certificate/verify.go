@@ -0,0 +1,270 @@
+package certificate
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+)
+
+// The checks of the verification of an issued certificate.
+const (
+	VerifyCheckKey         = "key"
+	VerifyCheckIdentifiers = "identifiers"
+	VerifyCheckChain       = "chain"
+	VerifyCheckMustStaple  = "must-staple"
+	VerifyCheckSCT         = "sct"
+	VerifyCheckValidity    = "validity"
+)
+
+// The tolerated clock skew between the CA and the local clock.
+const verifyClockSkew = 5 * time.Minute
+
+// The Signed Certificate Timestamp List extension (RFC 6962, section 3.3).
+var oidExtensionSCTList = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2}
+
+// VerifyOptions the options of the verification of an issued certificate.
+type VerifyOptions struct {
+	// Roots verifies the chain against this pool (the chain is not verified if nil).
+	Roots *x509.CertPool
+
+	// MustStaple requires the OCSP must staple TLS feature extension.
+	MustStaple bool
+
+	// MinSCTs is the minimum number of embedded Signed Certificate Timestamps.
+	MinSCTs int
+}
+
+// VerificationError is returned when an issued certificate doesn't pass a check of the verification.
+type VerificationError struct {
+	Domain string
+	// Check is the failed check (VerifyCheckKey, VerifyCheckIdentifiers, ...).
+	Check string
+	Err   error
+}
+
+func (e *VerificationError) Error() string {
+	return fmt.Sprintf("[%s] the issued certificate doesn't pass the %s verification: %v", e.Domain, e.Check, e.Err)
+}
+
+func (e *VerificationError) Unwrap() error {
+	return e.Err
+}
+
+// Verify checks an issued certificate before using it:
+//   - the public key matches the private key (or the CSR) of the resource.
+//   - the identifiers (DNS names and IP addresses) are exactly the requested domains.
+//   - the chain verifies against the roots (optional).
+//   - the must staple extension is present (optional).
+//   - the number of embedded SCTs (optional).
+//   - the certificate is currently valid.
+//
+// Returns a *VerificationError if a check fails.
+func Verify(certRes *Resource, domains []string, options VerifyOptions) error {
+	newError := func(check string, err error) error {
+		return &VerificationError{Domain: certRes.Domain, Check: check, Err: err}
+	}
+
+	certificates, err := certcrypto.ParsePEMBundle(certRes.Certificate)
+	if err != nil {
+		return newError(VerifyCheckChain, err)
+	}
+
+	leaf := certificates[0]
+
+	if err = verifyPublicKey(leaf, certRes); err != nil {
+		return newError(VerifyCheckKey, err)
+	}
+
+	if err = verifyIdentifiers(leaf, domains); err != nil {
+		return newError(VerifyCheckIdentifiers, err)
+	}
+
+	if options.Roots != nil {
+		if err = verifyChain(certificates, certRes.IssuerCertificate, options.Roots); err != nil {
+			return newError(VerifyCheckChain, err)
+		}
+	}
+
+	if options.MustStaple && !certcrypto.HasMustStaple(leaf) {
+		return newError(VerifyCheckMustStaple, errors.New("the OCSP must staple extension is missing"))
+	}
+
+	if options.MinSCTs > 0 {
+		count, errS := countSCTs(leaf)
+		if errS != nil {
+			return newError(VerifyCheckSCT, errS)
+		}
+
+		if count < options.MinSCTs {
+			return newError(VerifyCheckSCT, fmt.Errorf("%d embedded SCTs, at least %d expected", count, options.MinSCTs))
+		}
+	}
+
+	now := time.Now()
+
+	if now.After(leaf.NotAfter) {
+		return newError(VerifyCheckValidity, fmt.Errorf("expired since %s", leaf.NotAfter.Format(time.RFC3339)))
+	}
+
+	if leaf.NotBefore.After(now.Add(verifyClockSkew)) {
+		return newError(VerifyCheckValidity, fmt.Errorf("not valid before %s", leaf.NotBefore.Format(time.RFC3339)))
+	}
+
+	return nil
+}
+
+func verifyPublicKey(leaf *x509.Certificate, certRes *Resource) error {
+	var expected crypto.PublicKey
+
+	switch {
+	case certRes.PrivateKey != nil:
+		privateKey, err := certcrypto.ParsePEMPrivateKey(certRes.PrivateKey)
+		if err != nil {
+			return err
+		}
+
+		signer, ok := privateKey.(crypto.Signer)
+		if !ok {
+			return fmt.Errorf("unsupported private key type: %T", privateKey)
+		}
+
+		expected = signer.Public()
+
+	case certRes.CSR != nil:
+		csr, err := certcrypto.PemDecodeTox509CSR(certRes.CSR)
+		if err != nil {
+			return err
+		}
+
+		expected = csr.PublicKey
+
+	default:
+		return errors.New("no private key or CSR to compare with")
+	}
+
+	key, ok := leaf.PublicKey.(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !key.Equal(expected) {
+		return errors.New("the public key of the certificate doesn't match")
+	}
+
+	return nil
+}
+
+func verifyIdentifiers(leaf *x509.Certificate, domains []string) error {
+	expected := map[string]bool{}
+	for _, domain := range domains {
+		expected[normalizeIdentifier(domain)] = true
+	}
+
+	actual := map[string]bool{}
+	for _, name := range leaf.DNSNames {
+		actual[normalizeIdentifier(name)] = true
+	}
+
+	for _, ip := range leaf.IPAddresses {
+		actual[ip.String()] = true
+	}
+
+	var missing, unexpected []string
+
+	for identifier := range expected {
+		if !actual[identifier] {
+			missing = append(missing, identifier)
+		}
+	}
+
+	for identifier := range actual {
+		if !expected[identifier] {
+			unexpected = append(unexpected, identifier)
+		}
+	}
+
+	if len(missing) == 0 && len(unexpected) == 0 {
+		return nil
+	}
+
+	sort.Strings(missing)
+	sort.Strings(unexpected)
+
+	return fmt.Errorf("missing: [%s], unexpected: [%s]", strings.Join(missing, ", "), strings.Join(unexpected, ", "))
+}
+
+func normalizeIdentifier(identifier string) string {
+	if ip := net.ParseIP(identifier); ip != nil {
+		return ip.String()
+	}
+
+	return strings.ToLower(strings.TrimSuffix(identifier, "."))
+}
+
+func verifyChain(certificates []*x509.Certificate, issuer []byte, roots *x509.CertPool) error {
+	intermediates := x509.NewCertPool()
+	for _, cert := range certificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	if len(issuer) > 0 {
+		issuers, err := certcrypto.ParsePEMBundle(issuer)
+		if err != nil {
+			return err
+		}
+
+		for _, cert := range issuers {
+			intermediates.AddCert(cert)
+		}
+	}
+
+	_, err := certificates[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+
+	return err
+}
+
+// countSCTs returns the number of Signed Certificate Timestamps embedded in the certificate.
+func countSCTs(cert *x509.Certificate) (int, error) {
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(oidExtensionSCTList) {
+			continue
+		}
+
+		var list []byte
+		if _, err := asn1.Unmarshal(ext.Value, &list); err != nil {
+			return 0, err
+		}
+
+		// opaque SerializedSCT<1..2^16-1>; SignedCertificateTimestampList<1..2^16-1>;
+		if len(list) < 2 || int(binary.BigEndian.Uint16(list)) != len(list)-2 {
+			return 0, errors.New("malformed SCT list")
+		}
+
+		var count int
+		for data := list[2:]; len(data) > 0; count++ {
+			if len(data) < 2 {
+				return 0, errors.New("malformed SCT list")
+			}
+
+			size := int(binary.BigEndian.Uint16(data))
+			if size == 0 || len(data) < 2+size {
+				return 0, errors.New("malformed SCT list")
+			}
+
+			data = data[2+size:]
+		}
+
+		return count, nil
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,188 @@
+package certificate
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify(t *testing.T) {
+	ca := newTestCA(t, "Root", nil)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	otherRoots := x509.NewCertPool()
+	otherRoots.AddCert(newTestCA(t, "Root", nil).cert)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	csr, err := certcrypto.GenerateCSR(key, "example.com", []string{"example.com"}, false)
+	require.NoError(t, err)
+
+	csrPEM := certcrypto.PEMEncode(&x509.CertificateRequest{Raw: csr})
+
+	mustStaple := pkix.Extension{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 24}, Value: []byte{0x30, 0x03, 0x02, 0x01, 0x05}}
+
+	testCases := []struct {
+		desc       string
+		template   *x509.Certificate
+		privateKey []byte
+		csr        []byte
+		domains    []string
+		options    VerifyOptions
+		check      string
+	}{
+		{
+			desc:       "valid",
+			template:   &x509.Certificate{DNSNames: []string{"example.com", "*.example.com"}},
+			privateKey: certcrypto.PEMEncode(key),
+			domains:    []string{"*.EXAMPLE.com", "example.com"},
+			options:    VerifyOptions{Roots: roots},
+		},
+		{
+			desc:     "valid with CSR",
+			template: &x509.Certificate{DNSNames: []string{"example.com"}, IPAddresses: []net.IP{net.ParseIP("10.0.0.1")}},
+			csr:      csrPEM,
+			domains:  []string{"example.com", "10.0.0.1"},
+		},
+		{
+			desc:       "valid with must staple and SCTs",
+			template:   &x509.Certificate{DNSNames: []string{"example.com"}, ExtraExtensions: []pkix.Extension{mustStaple, newTestSCTList(t, 2)}},
+			privateKey: certcrypto.PEMEncode(key),
+			domains:    []string{"example.com"},
+			options:    VerifyOptions{MustStaple: true, MinSCTs: 2},
+		},
+		{
+			desc:       "other private key",
+			template:   &x509.Certificate{DNSNames: []string{"example.com"}},
+			privateKey: certcrypto.PEMEncode(otherKey),
+			domains:    []string{"example.com"},
+			check:      VerifyCheckKey,
+		},
+		{
+			desc:     "no private key",
+			template: &x509.Certificate{DNSNames: []string{"example.com"}},
+			domains:  []string{"example.com"},
+			check:    VerifyCheckKey,
+		},
+		{
+			desc:       "missing identifier",
+			template:   &x509.Certificate{DNSNames: []string{"example.com"}},
+			privateKey: certcrypto.PEMEncode(key),
+			domains:    []string{"example.com", "example.org"},
+			check:      VerifyCheckIdentifiers,
+		},
+		{
+			desc:       "unexpected identifier",
+			template:   &x509.Certificate{DNSNames: []string{"example.com", "example.org"}},
+			privateKey: certcrypto.PEMEncode(key),
+			domains:    []string{"example.com"},
+			check:      VerifyCheckIdentifiers,
+		},
+		{
+			desc:       "other roots",
+			template:   &x509.Certificate{DNSNames: []string{"example.com"}},
+			privateKey: certcrypto.PEMEncode(key),
+			domains:    []string{"example.com"},
+			options:    VerifyOptions{Roots: otherRoots},
+			check:      VerifyCheckChain,
+		},
+		{
+			desc:       "missing must staple",
+			template:   &x509.Certificate{DNSNames: []string{"example.com"}},
+			privateKey: certcrypto.PEMEncode(key),
+			domains:    []string{"example.com"},
+			options:    VerifyOptions{MustStaple: true},
+			check:      VerifyCheckMustStaple,
+		},
+		{
+			desc:       "not enough SCTs",
+			template:   &x509.Certificate{DNSNames: []string{"example.com"}, ExtraExtensions: []pkix.Extension{newTestSCTList(t, 1)}},
+			privateKey: certcrypto.PEMEncode(key),
+			domains:    []string{"example.com"},
+			options:    VerifyOptions{MinSCTs: 2},
+			check:      VerifyCheckSCT,
+		},
+		{
+			desc: "expired",
+			template: &x509.Certificate{
+				DNSNames:  []string{"example.com"},
+				NotBefore: time.Now().Add(-48 * time.Hour),
+				NotAfter:  time.Now().Add(-24 * time.Hour),
+			},
+			privateKey: certcrypto.PEMEncode(key),
+			domains:    []string{"example.com"},
+			check:      VerifyCheckValidity,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			template := test.template
+			template.SerialNumber = big.NewInt(42)
+			if template.NotBefore.IsZero() {
+				template.NotBefore = time.Now().Add(-time.Hour)
+				template.NotAfter = time.Now().Add(24 * time.Hour)
+			}
+
+			der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, key.Public(), ca.key)
+			require.NoError(t, err)
+
+			certRes := &Resource{
+				Domain:            "example.com",
+				Certificate:       certcrypto.PEMEncode(certcrypto.DERCertificateBytes(der)),
+				IssuerCertificate: encodeTestCerts(ca),
+				PrivateKey:        test.privateKey,
+				CSR:               test.csr,
+			}
+
+			err = Verify(certRes, test.domains, test.options)
+
+			if test.check == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			var verifyErr *VerificationError
+			require.True(t, errors.As(err, &verifyErr), err)
+			assert.Equal(t, test.check, verifyErr.Check)
+		})
+	}
+}
+
+// newTestSCTList creates an SCT list extension with fake SCTs.
+func newTestSCTList(t *testing.T, count int) pkix.Extension {
+	t.Helper()
+
+	var scts []byte
+	for i := 0; i < count; i++ {
+		scts = append(scts, 0x00, 0x03, 0x00, 0x01, byte(i))
+	}
+
+	list := append([]byte{byte(len(scts) >> 8), byte(len(scts))}, scts...)
+
+	value, err := asn1.Marshal(list)
+	require.NoError(t, err)
+
+	return pkix.Extension{Id: oidExtensionSCTList, Value: value}
+}
@@ -20,6 +20,14 @@ const (
 
 	// TLSALPN01 is the "tls-alpn-01" ACME challenge https://tools.ietf.org/html/draft-ietf-acme-tls-alpn-07
 	TLSALPN01 = Type("tls-alpn-01")
+
+	// DNSAccount01 is the "dns-account-01" ACME challenge https://datatracker.ietf.org/doc/draft-ietf-acme-dns-account-label/
+	// Note: GetAccountRecord returns a DNS record which will fulfill this challenge.
+	DNSAccount01 = Type("dns-account-01")
+
+	// DNSPersist01 is the "dns-persist-01" ACME challenge https://datatracker.ietf.org/doc/draft-ietf-acme-dns-persist/
+	// Note: the record is created once (see dnspersist01.GetRecord), the solver only checks it.
+	DNSPersist01 = Type("dns-persist-01")
 )
 
 func (t Type) String() string {
@@ -42,3 +50,10 @@ func GetTargetedDomain(authz acme.Authorization) string {
 	}
 	return authz.Identifier.Value
 }
+
+// Record a DNS record presented to solve a challenge.
+type Record struct {
+	Type  Type   `json:"type"`
+	FQDN  string `json:"fqdn"`
+	Value string `json:"value"`
+}
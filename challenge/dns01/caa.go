@@ -0,0 +1,256 @@
+package dns01
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// CAA property tags.
+// - https://tools.ietf.org/html/rfc8659#section-4
+const (
+	caaTagIssue     = "issue"
+	caaTagIssueWild = "issuewild"
+	caaTagIodef     = "iodef"
+)
+
+// CAA parameters defined by RFC 8657.
+// - https://tools.ietf.org/html/rfc8657#section-3
+const (
+	caaParamAccountURI        = "accounturi"
+	caaParamValidationMethods = "validationmethods"
+)
+
+// caaCriticalFlag the "Issuer Critical" flag.
+// - https://tools.ietf.org/html/rfc8659#section-4.1
+const caaCriticalFlag = 128
+
+// CAAOptions the information about the CA and the account used to evaluate CAA records.
+type CAAOptions struct {
+	// The issuer domain names of the CA (`caaIdentities` of the ACME directory meta).
+	CAAIdentities []string
+
+	// The URL of the ACME account, checked against the `accounturi` parameter.
+	AccountURI string
+
+	// The challenge types that can be used, checked against the `validationmethods` parameter.
+	ValidationMethods []string
+}
+
+// CAAError is returned when the CAA records of a domain do not allow the CA to issue a certificate.
+type CAAError struct {
+	Domain string
+	Name   string
+	Reason string
+}
+
+func (e *CAAError) Error() string {
+	return fmt.Sprintf("[%s] CAA records at %s do not allow issuance: %s", e.Domain, e.Name, e.Reason)
+}
+
+// CheckCAA checks that the CAA records related to the domain allow the CA described by the options
+// to issue a certificate for the domain.
+//
+// The relevant record set is found by climbing the DNS tree from the domain to (but not including) the root,
+// CNAME are followed by the recursive nameservers.
+// - https://tools.ietf.org/html/rfc8659#section-3
+func CheckCAA(domain string, opts CAAOptions) error {
+	_, err := CheckCAAValidationMethods(domain, opts)
+	return err
+}
+
+// CheckCAAValidationMethods checks the CAA records like CheckCAA,
+// and returns the validation methods of the options allowed by the `validationmethods` parameter
+// (all of them if the records don't restrict the validation methods).
+// At least one validation method must be allowed.
+func CheckCAAValidationMethods(domain string, opts CAAOptions) ([]string, error) {
+	if len(opts.CAAIdentities) == 0 {
+		return nil, errors.New("no CAA identities")
+	}
+
+	wildcard := strings.HasPrefix(domain, "*.")
+	fqdn := ToFqdn(strings.TrimPrefix(domain, "*."))
+
+	for _, index := range dns.Split(fqdn) {
+		name := fqdn[index:]
+
+		records, err := lookupCAA(name)
+		if err != nil {
+			return nil, fmt.Errorf("[%s] CAA lookup: %w", domain, err)
+		}
+
+		if len(records) == 0 {
+			continue
+		}
+
+		methods, reason := checkCAARecords(records, wildcard, opts)
+		if reason != "" {
+			return nil, &CAAError{Domain: domain, Name: UnFqdn(name), Reason: reason}
+		}
+
+		return methods, nil
+	}
+
+	// No CAA records: any CA is allowed.
+	return opts.ValidationMethods, nil
+}
+
+func lookupCAA(fqdn string) ([]*dns.CAA, error) {
+	r, err := dnsQuery(fqdn, dns.TypeCAA, recursiveNameservers, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Rcode != dns.RcodeSuccess && r.Rcode != dns.RcodeNameError {
+		return nil, fmt.Errorf("unexpected response code '%s' for %s", dns.RcodeToString[r.Rcode], fqdn)
+	}
+
+	// The answer section can also contain the CNAME chain,
+	// only the CAA records (at the end of the chain) are relevant.
+	var records []*dns.CAA
+	for _, rr := range r.Answer {
+		if caa, ok := rr.(*dns.CAA); ok {
+			records = append(records, caa)
+		}
+	}
+
+	return records, nil
+}
+
+// checkCAARecords evaluates a CAA record set.
+// Returns the allowed validation methods,
+// or the reason why the issuance is not allowed (an empty string if it's allowed).
+func checkCAARecords(records []*dns.CAA, wildcard bool, opts CAAOptions) ([]string, string) {
+	var issue, issueWild []*dns.CAA
+
+	for _, rr := range records {
+		switch strings.ToLower(rr.Tag) {
+		case caaTagIssue:
+			issue = append(issue, rr)
+		case caaTagIssueWild:
+			issueWild = append(issueWild, rr)
+		case caaTagIodef:
+			// not related to the issuance.
+		default:
+			if rr.Flag&caaCriticalFlag != 0 {
+				return nil, fmt.Sprintf("unknown critical property %q", rr.Tag)
+			}
+		}
+	}
+
+	// https://tools.ietf.org/html/rfc8659#section-4.3
+	relevant := issue
+	if wildcard && len(issueWild) > 0 {
+		relevant = issueWild
+	}
+
+	if len(relevant) == 0 {
+		return opts.ValidationMethods, ""
+	}
+
+	var allowed bool
+	methods := make(map[string]bool)
+
+	var reasons []string
+	for _, rr := range relevant {
+		recordMethods, reason := checkCAAValue(rr.Value, opts)
+		if reason != "" {
+			reasons = append(reasons, fmt.Sprintf("%s %q: %s", rr.Tag, rr.Value, reason))
+			continue
+		}
+
+		allowed = true
+		for _, method := range recordMethods {
+			methods[method] = true
+		}
+	}
+
+	if !allowed {
+		return nil, strings.Join(reasons, ", ")
+	}
+
+	// keeps the order of the options (the order of preference).
+	var result []string
+	for _, method := range opts.ValidationMethods {
+		if methods[method] {
+			result = append(result, method)
+		}
+	}
+
+	return result, ""
+}
+
+// checkCAAValue evaluates the value of an "issue" or "issuewild" property.
+// Returns the allowed validation methods,
+// or the reason why the property doesn't allow the issuance (an empty string if it's allowed).
+func checkCAAValue(value string, opts CAAOptions) ([]string, string) {
+	issuer, params := parseCAAValue(value)
+
+	if issuer == "" {
+		return nil, "no CA is allowed"
+	}
+
+	if !containsFold(opts.CAAIdentities, issuer) {
+		return nil, fmt.Sprintf("the CA is not allowed (CA identities: %s)", strings.Join(opts.CAAIdentities, ", "))
+	}
+
+	if accountURI, ok := params[caaParamAccountURI]; ok && accountURI != opts.AccountURI {
+		return nil, fmt.Sprintf("the account %q is not allowed", opts.AccountURI)
+	}
+
+	methods, ok := params[caaParamValidationMethods]
+	if !ok {
+		return opts.ValidationMethods, ""
+	}
+
+	if len(opts.ValidationMethods) == 0 {
+		return nil, fmt.Sprintf("the validation methods are restricted (%s) but the validation method is unknown", methods)
+	}
+
+	allowed := strings.Split(methods, ",")
+
+	var result []string
+	for _, method := range opts.ValidationMethods {
+		if containsFold(allowed, method) {
+			result = append(result, method)
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Sprintf("the validation methods %s are not allowed (validation methods: %s)", strings.Join(opts.ValidationMethods, ", "), methods)
+	}
+
+	return result, ""
+}
+
+// parseCAAValue parses the value of an "issue" or "issuewild" property.
+// - https://tools.ietf.org/html/rfc8659#section-4.2
+func parseCAAValue(value string) (string, map[string]string) {
+	parts := strings.Split(value, ";")
+
+	issuer := strings.TrimSuffix(strings.TrimSpace(parts[0]), ".")
+
+	params := make(map[string]string)
+	for _, part := range parts[1:] {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+
+		params[strings.ToLower(strings.TrimSpace(kv[0]))] = strings.TrimSpace(kv[1])
+	}
+
+	return issuer, params
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSuffix(strings.TrimSpace(v), "."), value) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,257 @@
+package dns01
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckCAA(t *testing.T) {
+	records := map[string][]dns.RR{
+		"example.com.":         {newCAA("example.com.", "issue", "letsencrypt.org")},
+		"other.example.com.":   {newCAA("other.example.com.", "issue", "pki.goog")},
+		"alias.example.com.":   {newCNAME("alias.example.com.", "denied.example.net.")},
+		"denied.example.net.":  {newCAA("denied.example.net.", "issue", "pki.goog")},
+		"example.org.":         {newCAA("example.org.", "issue", "pki.goog")},
+		"alias.example.org.":   {newCNAME("alias.example.org.", "allowed.example.net.")},
+		"allowed.example.net.": {newCAA("allowed.example.net.", "issue", "letsencrypt.org")},
+	}
+
+	setupCAAServer(t, records)
+
+	opts := CAAOptions{CAAIdentities: []string{"letsencrypt.org"}}
+
+	testCases := []struct {
+		desc     string
+		domain   string
+		expected string
+	}{
+		{
+			desc:   "records on the domain",
+			domain: "example.com",
+		},
+		{
+			desc:   "climbs to the parent domain",
+			domain: "www.sub.example.com",
+		},
+		{
+			desc:   "wildcard climbs to the parent domain",
+			domain: "*.sub.example.com",
+		},
+		{
+			desc:     "the closest records are used",
+			domain:   "www.other.example.com",
+			expected: "other.example.com",
+		},
+		{
+			desc:     "follows the CNAME",
+			domain:   "alias.example.com",
+			expected: "alias.example.com",
+		},
+		{
+			desc:   "the CNAME target records are used instead of the parent domain",
+			domain: "alias.example.org",
+		},
+		{
+			desc:   "no records",
+			domain: "www.example.net",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			err := CheckCAA(test.domain, opts)
+			if test.expected == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			var caaErr *CAAError
+			require.True(t, errors.As(err, &caaErr), "unexpected error: %v", err)
+			assert.Equal(t, test.expected, caaErr.Name)
+		})
+	}
+}
+
+func Test_checkCAARecords(t *testing.T) {
+	opts := CAAOptions{
+		CAAIdentities:     []string{"letsencrypt.org"},
+		AccountURI:        "https://acme.example.com/acct/123",
+		ValidationMethods: []string{"dns-01", "http-01"},
+	}
+
+	testCases := []struct {
+		desc     string
+		records  []*dns.CAA
+		wildcard bool
+		expected []string
+	}{
+		{
+			desc:     "issue allowed",
+			records:  []*dns.CAA{{Tag: "issue", Value: "letsencrypt.org"}},
+			expected: []string{"dns-01", "http-01"},
+		},
+		{
+			desc:     "issue case insensitive",
+			records:  []*dns.CAA{{Tag: "ISSUE", Value: "LetsEncrypt.org."}},
+			expected: []string{"dns-01", "http-01"},
+		},
+		{
+			desc:    "another CA",
+			records: []*dns.CAA{{Tag: "issue", Value: "pki.goog"}},
+		},
+		{
+			desc: "multiple CA",
+			records: []*dns.CAA{
+				{Tag: "issue", Value: "pki.goog"},
+				{Tag: "issue", Value: "letsencrypt.org"},
+			},
+			expected: []string{"dns-01", "http-01"},
+		},
+		{
+			desc:    "no CA",
+			records: []*dns.CAA{{Tag: "issue", Value: ";"}},
+		},
+		{
+			desc:     "only iodef",
+			records:  []*dns.CAA{{Tag: "iodef", Value: "mailto:security@example.com"}},
+			expected: []string{"dns-01", "http-01"},
+		},
+		{
+			desc:    "unknown critical property",
+			records: []*dns.CAA{{Flag: 128, Tag: "tbs", Value: "foo"}, {Tag: "issue", Value: "letsencrypt.org"}},
+		},
+		{
+			desc:     "unknown non critical property",
+			records:  []*dns.CAA{{Tag: "tbs", Value: "foo"}, {Tag: "issue", Value: "letsencrypt.org"}},
+			expected: []string{"dns-01", "http-01"},
+		},
+		{
+			desc:     "wildcard with issuewild",
+			records:  []*dns.CAA{{Tag: "issue", Value: "letsencrypt.org"}, {Tag: "issuewild", Value: ";"}},
+			wildcard: true,
+		},
+		{
+			desc:     "wildcard without issuewild",
+			records:  []*dns.CAA{{Tag: "issue", Value: "letsencrypt.org"}},
+			wildcard: true,
+			expected: []string{"dns-01", "http-01"},
+		},
+		{
+			desc:     "non wildcard with issuewild only",
+			records:  []*dns.CAA{{Tag: "issuewild", Value: "pki.goog"}},
+			expected: []string{"dns-01", "http-01"},
+		},
+		{
+			desc:     "accounturi match",
+			records:  []*dns.CAA{{Tag: "issue", Value: "letsencrypt.org; accounturi=https://acme.example.com/acct/123"}},
+			expected: []string{"dns-01", "http-01"},
+		},
+		{
+			desc:    "accounturi mismatch",
+			records: []*dns.CAA{{Tag: "issue", Value: "letsencrypt.org; accounturi=https://acme.example.com/acct/456"}},
+		},
+		{
+			desc:     "validationmethods match",
+			records:  []*dns.CAA{{Tag: "issue", Value: "letsencrypt.org; validationmethods=http-01,dns-01"}},
+			expected: []string{"dns-01", "http-01"},
+		},
+		{
+			desc:    "validationmethods mismatch",
+			records: []*dns.CAA{{Tag: "issue", Value: "letsencrypt.org; validationmethods=tls-alpn-01"}},
+		},
+		{
+			desc:     "validationmethods without the preferred method",
+			records:  []*dns.CAA{{Tag: "issue", Value: "letsencrypt.org; validationmethods=http-01"}},
+			expected: []string{"http-01"},
+		},
+		{
+			desc: "validationmethods of multiple records",
+			records: []*dns.CAA{
+				{Tag: "issue", Value: "letsencrypt.org; validationmethods=http-01"},
+				{Tag: "issue", Value: "letsencrypt.org; validationmethods=tls-alpn-01,dns-01"},
+			},
+			expected: []string{"dns-01", "http-01"},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			methods, reason := checkCAARecords(test.records, test.wildcard, opts)
+			if test.expected != nil {
+				assert.Empty(t, reason)
+			} else {
+				assert.NotEmpty(t, reason)
+			}
+
+			assert.Equal(t, test.expected, methods)
+		})
+	}
+}
+
+func Test_parseCAAValue(t *testing.T) {
+	issuer, params := parseCAAValue(" ca.example.net; accounturi = https://example.net/account/1234 ;validationmethods=dns-01")
+
+	assert.Equal(t, "ca.example.net", issuer)
+	assert.Equal(t, map[string]string{
+		"accounturi":        "https://example.net/account/1234",
+		"validationmethods": "dns-01",
+	}, params)
+}
+
+// setupCAAServer starts a DNS server which answers like a recursive nameserver (following the CNAME)
+// and uses it as the recursive nameserver.
+func setupCAAServer(t *testing.T, records map[string][]dns.RR) {
+	t.Helper()
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	handler := func(w dns.ResponseWriter, req *dns.Msg) {
+		m := new(dns.Msg)
+		m.SetReply(req)
+
+		name := req.Question[0].Name
+		for name != "" {
+			next := ""
+			for _, rr := range records[name] {
+				if cname, ok := rr.(*dns.CNAME); ok {
+					next = cname.Target
+				}
+				m.Answer = append(m.Answer, rr)
+			}
+			name = next
+		}
+
+		_ = w.WriteMsg(m)
+	}
+
+	started := make(chan struct{})
+	server := &dns.Server{PacketConn: pc, Handler: dns.HandlerFunc(handler), NotifyStartedFunc: func() { close(started) }}
+
+	go func() { _ = server.ActivateAndServe() }()
+	<-started
+
+	original := recursiveNameservers
+	recursiveNameservers = []string{pc.LocalAddr().String()}
+
+	t.Cleanup(func() {
+		recursiveNameservers = original
+		_ = server.Shutdown()
+	})
+}
+
+func newCAA(name, tag, value string) dns.RR {
+	return &dns.CAA{Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeCAA, Class: dns.ClassINET, Ttl: 60}, Tag: tag, Value: value}
+}
+
+func newCNAME(name, target string) dns.RR {
+	return &dns.CNAME{Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeCNAME, Class: dns.ClassINET, Ttl: 60}, Target: target}
+}
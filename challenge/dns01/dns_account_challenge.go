@@ -0,0 +1,60 @@
+package dns01
+
+import (
+	"crypto/sha256"
+	"encoding/base32"
+	"fmt"
+	"strings"
+
+	"github.com/go-acme/lego/v4/acme/api"
+	"github.com/go-acme/lego/v4/challenge"
+)
+
+// NewAccountChallenge creates a solver of the dns-account-01 challenge.
+// The challenge uses the DNS providers of the dns-01 challenge which implement RecordProvider,
+// the TXT record is scoped to the account: several accounts can validate the same domain concurrently.
+func NewAccountChallenge(core *api.Core, validate ValidateFunc, provider challenge.Provider, opts ...ChallengeOption) *Challenge {
+	return newChallenge(challenge.DNSAccount01, core, validate, provider, opts...)
+}
+
+// GetAccountLabel returns the account label of the dns-account-01 challenge:
+// "_" followed by the lowercase base32 encoding of the first 10 bytes of the SHA-256 digest of the account URL.
+// - https://datatracker.ietf.org/doc/draft-ietf-acme-dns-account-label/
+func GetAccountLabel(accountURL string) string {
+	digest := sha256.Sum256([]byte(accountURL))
+
+	return "_" + strings.ToLower(base32.StdEncoding.EncodeToString(digest[:10]))
+}
+
+// GetAccountRecord returns a DNS record which will fulfill the `dns-account-01` challenge.
+func GetAccountRecord(domain, keyAuth, accountURL string) (fqdn, value string) {
+	return getRecord(fmt.Sprintf("%s._acme-challenge.%s.", GetAccountLabel(accountURL), domain), keyAuth)
+}
+
+func (c *Challenge) getRecord(domain, keyAuth string) (string, string) {
+	if c.chlgType == challenge.DNSAccount01 {
+		return GetAccountRecord(domain, keyAuth, c.core.GetAccountURL())
+	}
+
+	return GetRecord(domain, keyAuth)
+}
+
+// present creates the record of the challenge with the DNS provider.
+func (c *Challenge) present(domain, token, keyAuth string) error {
+	if c.chlgType == challenge.DNSAccount01 {
+		fqdn, value := c.getRecord(domain, keyAuth)
+		return PresentRecord(c.provider, domain, fqdn, value)
+	}
+
+	return c.provider.Present(domain, token, keyAuth)
+}
+
+// cleanUp removes the record of the challenge with the DNS provider.
+func (c *Challenge) cleanUp(domain, token, keyAuth string) error {
+	if c.chlgType == challenge.DNSAccount01 {
+		fqdn, value := c.getRecord(domain, keyAuth)
+		return CleanUpRecord(c.provider, domain, fqdn, value)
+	}
+
+	return c.provider.CleanUp(domain, token, keyAuth)
+}
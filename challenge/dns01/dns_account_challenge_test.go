@@ -0,0 +1,106 @@
+package dns01
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"testing"
+
+	"github.com/go-acme/lego/v4/acme"
+	"github.com/go-acme/lego/v4/acme/api"
+	"github.com/go-acme/lego/v4/challenge"
+	"github.com/go-acme/lego/v4/platform/tester"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordProvider records the FQDNs returned by GetRecord, like the DNS providers.
+// recordProvider a DNS provider which implements RecordProvider,
+// it records the FQDN of the records presented and cleaned.
+type recordProvider struct {
+	presented, cleaned []string
+}
+
+func (p *recordProvider) Present(domain, _, keyAuth string) error {
+	fqdn, _ := GetRecord(domain, keyAuth)
+	p.presented = append(p.presented, fqdn)
+
+	return nil
+}
+
+func (p *recordProvider) CleanUp(domain, _, keyAuth string) error {
+	fqdn, _ := GetRecord(domain, keyAuth)
+	p.cleaned = append(p.cleaned, fqdn)
+
+	return nil
+}
+
+func (p *recordProvider) PresentRecord(_, fqdn, _ string) error {
+	p.presented = append(p.presented, fqdn)
+
+	return nil
+}
+
+func (p *recordProvider) CleanUpRecord(_, fqdn, _ string) error {
+	p.cleaned = append(p.cleaned, fqdn)
+
+	return nil
+}
+
+func TestGetAccountLabel(t *testing.T) {
+	// example of the draft.
+	assert.Equal(t, "_ujmmovf2vn55tgye", GetAccountLabel("https://example.com/acme/acct/ExampleAccount"))
+}
+
+func TestGetAccountRecord(t *testing.T) {
+	fqdn, value := GetAccountRecord("example.org", "token.thumbprint", "https://example.com/acme/acct/ExampleAccount")
+
+	assert.Equal(t, "_ujmmovf2vn55tgye._acme-challenge.example.org.", fqdn)
+
+	_, expected := GetRecord("example.org", "token.thumbprint")
+	assert.Equal(t, expected, value)
+}
+
+func TestAccountChallenge(t *testing.T) {
+	_, apiURL, tearDown := tester.SetupFakeAPI()
+	defer tearDown()
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 512)
+	require.NoError(t, err)
+
+	accountURL := "https://example.com/acme/acct/ExampleAccount"
+
+	core, err := api.New(http.DefaultClient, "lego-test", apiURL+"/dir", accountURL, privateKey)
+	require.NoError(t, err)
+
+	var checked string
+
+	provider := &recordProvider{}
+
+	chlg := NewAccountChallenge(core,
+		func(_ *api.Core, _ string, _ acme.Challenge) error { return nil },
+		provider,
+		WrapPreCheck(func(_, fqdn, _ string, _ PreCheckFunc) (bool, error) {
+			checked = fqdn
+			return true, nil
+		}),
+	)
+
+	authz := acme.Authorization{
+		Identifier: acme.Identifier{Value: "example.org"},
+		Challenges: []acme.Challenge{
+			{Type: challenge.DNS01.String(), Token: "dns"},
+			{Type: challenge.DNSAccount01.String(), Token: "account"},
+		},
+	}
+
+	require.NoError(t, chlg.PreSolve(authz))
+	require.NoError(t, chlg.Solve(authz))
+	require.NoError(t, chlg.CleanUp(authz))
+
+	expected := "_ujmmovf2vn55tgye._acme-challenge.example.org."
+
+	assert.Equal(t, []string{expected}, provider.presented)
+	assert.Equal(t, expected, checked)
+	assert.Equal(t, []string{expected}, provider.cleaned)
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-acme/lego/v4/acme"
@@ -42,22 +43,28 @@ func CondOption(condition bool, opt ChallengeOption) ChallengeOption {
 	return opt
 }
 
-// Challenge implements the dns-01 challenge.
+// Challenge implements the dns-01 challenge (and the dns-account-01 challenge).
 type Challenge struct {
 	core       *api.Core
 	validate   ValidateFunc
 	provider   challenge.Provider
 	preCheck   preCheck
 	dnsTimeout time.Duration
+	chlgType   challenge.Type
 }
 
 func NewChallenge(core *api.Core, validate ValidateFunc, provider challenge.Provider, opts ...ChallengeOption) *Challenge {
+	return newChallenge(challenge.DNS01, core, validate, provider, opts...)
+}
+
+func newChallenge(chlgType challenge.Type, core *api.Core, validate ValidateFunc, provider challenge.Provider, opts ...ChallengeOption) *Challenge {
 	chlg := &Challenge{
 		core:       core,
 		validate:   validate,
 		provider:   provider,
 		preCheck:   newPreCheck(),
 		dnsTimeout: 10 * time.Second,
+		chlgType:   chlgType,
 	}
 
 	for _, opt := range opts {
@@ -74,9 +81,9 @@ func NewChallenge(core *api.Core, validate ValidateFunc, provider challenge.Prov
 // It does not validate record propagation, or do anything at all with the acme server.
 func (c *Challenge) PreSolve(authz acme.Authorization) error {
 	domain := challenge.GetTargetedDomain(authz)
-	log.Infof("[%s] acme: Preparing to solve DNS-01", domain)
+	log.Infof("[%s] acme: Preparing to solve %s", domain, c.name())
 
-	chlng, err := challenge.FindChallenge(challenge.DNS01, authz)
+	chlng, err := challenge.FindChallenge(c.chlgType, authz)
 	if err != nil {
 		return err
 	}
@@ -91,7 +98,7 @@ func (c *Challenge) PreSolve(authz acme.Authorization) error {
 		return err
 	}
 
-	err = c.provider.Present(authz.Identifier.Value, chlng.Token, keyAuth)
+	err = c.present(authz.Identifier.Value, chlng.Token, keyAuth)
 	if err != nil {
 		return fmt.Errorf("[%s] acme: error presenting token: %w", domain, err)
 	}
@@ -101,9 +108,9 @@ func (c *Challenge) PreSolve(authz acme.Authorization) error {
 
 func (c *Challenge) Solve(authz acme.Authorization) error {
 	domain := challenge.GetTargetedDomain(authz)
-	log.Infof("[%s] acme: Trying to solve DNS-01", domain)
+	log.Infof("[%s] acme: Trying to solve %s", domain, c.name())
 
-	chlng, err := challenge.FindChallenge(challenge.DNS01, authz)
+	chlng, err := challenge.FindChallenge(c.chlgType, authz)
 	if err != nil {
 		return err
 	}
@@ -114,7 +121,7 @@ func (c *Challenge) Solve(authz acme.Authorization) error {
 		return err
 	}
 
-	fqdn, value := GetRecord(authz.Identifier.Value, keyAuth)
+	fqdn, value := c.getRecord(authz.Identifier.Value, keyAuth)
 
 	var timeout, interval time.Duration
 	switch provider := c.provider.(type) {
@@ -145,9 +152,9 @@ func (c *Challenge) Solve(authz acme.Authorization) error {
 
 // CleanUp cleans the challenge.
 func (c *Challenge) CleanUp(authz acme.Authorization) error {
-	log.Infof("[%s] acme: Cleaning DNS-01 challenge", challenge.GetTargetedDomain(authz))
+	log.Infof("[%s] acme: Cleaning %s challenge", challenge.GetTargetedDomain(authz), c.name())
 
-	chlng, err := challenge.FindChallenge(challenge.DNS01, authz)
+	chlng, err := challenge.FindChallenge(c.chlgType, authz)
 	if err != nil {
 		return err
 	}
@@ -157,7 +164,46 @@ func (c *Challenge) CleanUp(authz acme.Authorization) error {
 		return err
 	}
 
-	return c.provider.CleanUp(authz.Identifier.Value, chlng.Token, keyAuth)
+	return c.cleanUp(authz.Identifier.Value, chlng.Token, keyAuth)
+}
+
+// Record returns the record presented for the authorization.
+func (c *Challenge) Record(authz acme.Authorization) (challenge.Record, error) {
+	chlng, err := challenge.FindChallenge(c.chlgType, authz)
+	if err != nil {
+		return challenge.Record{}, err
+	}
+
+	keyAuth, err := c.core.GetKeyAuthorization(chlng.Token)
+	if err != nil {
+		return challenge.Record{}, err
+	}
+
+	fqdn, value := c.getRecord(authz.Identifier.Value, keyAuth)
+
+	return challenge.Record{Type: c.chlgType, FQDN: fqdn, Value: value}, nil
+}
+
+// CleanUpRecord cleans a record presented for the authorization (ex: the record stored before an interruption).
+func (c *Challenge) CleanUpRecord(authz acme.Authorization, record challenge.Record) error {
+	log.Infof("[%s] acme: Cleaning %s record %s", challenge.GetTargetedDomain(authz), c.name(), record.FQDN)
+
+	chlng, err := challenge.FindChallenge(c.chlgType, authz)
+	if err != nil {
+		return err
+	}
+
+	// the dns-01 record is derived from the key authorization by the provider.
+	if c.chlgType == challenge.DNS01 {
+		keyAuth, errK := c.core.GetKeyAuthorization(chlng.Token)
+		if errK != nil {
+			return errK
+		}
+
+		return c.provider.CleanUp(authz.Identifier.Value, chlng.Token, keyAuth)
+	}
+
+	return CleanUpRecord(c.provider, authz.Identifier.Value, record.FQDN, record.Value)
 }
 
 func (c *Challenge) Sequential() (bool, time.Duration) {
@@ -171,12 +217,19 @@ type sequential interface {
 	Sequential() time.Duration
 }
 
+func (c *Challenge) name() string {
+	return strings.ToUpper(c.chlgType.String())
+}
+
 // GetRecord returns a DNS record which will fulfill the `dns-01` challenge.
 func GetRecord(domain, keyAuth string) (fqdn, value string) {
+	return getRecord(fmt.Sprintf("_acme-challenge.%s.", domain), keyAuth)
+}
+
+func getRecord(fqdn, keyAuth string) (string, string) {
 	keyAuthShaBytes := sha256.Sum256([]byte(keyAuth))
 	// base64URL encoding without padding
-	value = base64.RawURLEncoding.EncodeToString(keyAuthShaBytes[:sha256.Size])
-	fqdn = fmt.Sprintf("_acme-challenge.%s.", domain)
+	value := base64.RawURLEncoding.EncodeToString(keyAuthShaBytes[:sha256.Size])
 
 	if ok, _ := strconv.ParseBool(os.Getenv("LEGO_EXPERIMENTAL_CNAME_SUPPORT")); ok {
 		r, err := dnsQuery(fqdn, dns.TypeCNAME, recursiveNameservers, true)
@@ -186,5 +239,5 @@ func GetRecord(domain, keyAuth string) (fqdn, value string) {
 		}
 	}
 
-	return
+	return fqdn, value
 }
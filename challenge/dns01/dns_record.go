@@ -0,0 +1,40 @@
+package dns01
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-acme/lego/v4/challenge"
+)
+
+// ErrRecordNotSupported the DNS provider can only create the dns-01 record.
+var ErrRecordNotSupported = errors.New("the DNS provider can only create the dns-01 record")
+
+// RecordProvider is implemented by the DNS providers which create a TXT record from its FQDN and its value,
+// used to create the records which are not the dns-01 record (ex: the dns-account-01 and dns-persist-01 records).
+// The providers which can't create such records (ex: the record is not derived from the FQDN) return ErrRecordNotSupported.
+type RecordProvider interface {
+	PresentRecord(domain, fqdn, value string) error
+	CleanUpRecord(domain, fqdn, value string) error
+}
+
+// PresentRecord creates a TXT record which is not the dns-01 record with a DNS provider of the dns-01 challenge.
+// The provider must implement RecordProvider.
+func PresentRecord(provider challenge.Provider, domain, fqdn, value string) error {
+	p, ok := provider.(RecordProvider)
+	if !ok {
+		return fmt.Errorf("%T: %w", provider, ErrRecordNotSupported)
+	}
+
+	return p.PresentRecord(domain, fqdn, value)
+}
+
+// CleanUpRecord removes a TXT record created by PresentRecord.
+func CleanUpRecord(provider challenge.Provider, domain, fqdn, value string) error {
+	p, ok := provider.(RecordProvider)
+	if !ok {
+		return fmt.Errorf("%T: %w", provider, ErrRecordNotSupported)
+	}
+
+	return p.CleanUpRecord(domain, fqdn, value)
+}
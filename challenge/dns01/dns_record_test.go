@@ -0,0 +1,107 @@
+package dns01
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"testing"
+
+	"github.com/go-acme/lego/v4/acme"
+	"github.com/go-acme/lego/v4/acme/api"
+	"github.com/go-acme/lego/v4/challenge"
+	"github.com/go-acme/lego/v4/platform/tester"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dns01Provider a DNS provider which can only create the dns-01 record.
+type dns01Provider struct{}
+
+func (p *dns01Provider) Present(_, _, _ string) error { return nil }
+
+func (p *dns01Provider) CleanUp(_, _, _ string) error { return nil }
+
+func TestPresentRecord(t *testing.T) {
+	provider := &recordProvider{}
+
+	err := PresentRecord(provider, "example.com", "_validation-persist.example.com.", "authority.example")
+	require.NoError(t, err)
+
+	err = CleanUpRecord(provider, "example.com", "_validation-persist.example.com.", "authority.example")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"_validation-persist.example.com."}, provider.presented)
+	assert.Equal(t, []string{"_validation-persist.example.com."}, provider.cleaned)
+}
+
+func TestPresentRecord_notSupported(t *testing.T) {
+	provider := &dns01Provider{}
+
+	err := PresentRecord(provider, "example.com", "_validation-persist.example.com.", "authority.example")
+	require.ErrorIs(t, err, ErrRecordNotSupported)
+
+	err = CleanUpRecord(provider, "example.com", "_validation-persist.example.com.", "authority.example")
+	require.ErrorIs(t, err, ErrRecordNotSupported)
+}
+
+func TestChallenge_CleanUpRecord(t *testing.T) {
+	_, apiURL, tearDown := tester.SetupFakeAPI()
+	defer tearDown()
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 512)
+	require.NoError(t, err)
+
+	core, err := api.New(http.DefaultClient, "lego-test", apiURL+"/dir", "https://example.com/acme/acct/ExampleAccount", privateKey)
+	require.NoError(t, err)
+
+	provider := &recordProvider{}
+
+	chlg := NewAccountChallenge(core, nil, provider)
+
+	authz := acme.Authorization{
+		Identifier: acme.Identifier{Value: "example.org"},
+		Challenges: []acme.Challenge{{Type: challenge.DNSAccount01.String(), Token: "account"}},
+	}
+
+	record, err := chlg.Record(authz)
+	require.NoError(t, err)
+
+	assert.Equal(t, challenge.DNSAccount01, record.Type)
+	assert.Equal(t, "_ujmmovf2vn55tgye._acme-challenge.example.org.", record.FQDN)
+
+	// the stored record is cleaned, even if it differs from the current record.
+	err = chlg.CleanUpRecord(authz, challenge.Record{Type: challenge.DNSAccount01, FQDN: "_previous._acme-challenge.example.org.", Value: "previous"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"_previous._acme-challenge.example.org."}, provider.cleaned)
+}
+
+func TestChallenge_CleanUpRecord_dns01(t *testing.T) {
+	_, apiURL, tearDown := tester.SetupFakeAPI()
+	defer tearDown()
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 512)
+	require.NoError(t, err)
+
+	core, err := api.New(http.DefaultClient, "lego-test", apiURL+"/dir", "https://example.com/acme/acct/ExampleAccount", privateKey)
+	require.NoError(t, err)
+
+	provider := &recordProvider{}
+
+	chlg := NewChallenge(core, nil, provider)
+
+	authz := acme.Authorization{
+		Identifier: acme.Identifier{Value: "example.org"},
+		Challenges: []acme.Challenge{{Type: challenge.DNS01.String(), Token: "dns"}},
+	}
+
+	record, err := chlg.Record(authz)
+	require.NoError(t, err)
+
+	// the dns-01 record is cleaned by the provider from the key authorization.
+	err = chlg.CleanUpRecord(authz, record)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"_acme-challenge.example.org."}, provider.cleaned)
+}
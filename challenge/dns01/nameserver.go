@@ -151,6 +151,35 @@ func FindZoneByFqdnCustom(fqdn string, nameservers []string) (string, error) {
 	return soa.zone, nil
 }
 
+// LookupTXT returns the values of the TXT records of the given fqdn, using the recursive nameservers.
+func LookupTXT(fqdn string) ([]string, error) {
+	return LookupTXTCustom(fqdn, recursiveNameservers)
+}
+
+// LookupTXTCustom returns the values of the TXT records of the given fqdn, using the given nameservers.
+// The CNAME records are followed by the recursive nameservers.
+func LookupTXTCustom(fqdn string, nameservers []string) ([]string, error) {
+	in, err := dnsQuery(fqdn, dns.TypeTXT, nameservers, true)
+	if err != nil || in == nil {
+		return nil, fmt.Errorf("could not lookup the TXT records of %s%s", fqdn, formatDNSError(in, err))
+	}
+
+	switch in.Rcode {
+	case dns.RcodeSuccess, dns.RcodeNameError:
+	default:
+		return nil, fmt.Errorf("unexpected response code '%s' for %s", dns.RcodeToString[in.Rcode], fqdn)
+	}
+
+	var values []string
+	for _, ans := range in.Answer {
+		if txt, ok := ans.(*dns.TXT); ok {
+			values = append(values, strings.Join(txt.Txt, ""))
+		}
+	}
+
+	return values, nil
+}
+
 func lookupSoaByFqdn(fqdn string, nameservers []string) (*soaCacheEntry, error) {
 	muFqdnSoaCache.Lock()
 	defer muFqdnSoaCache.Unlock()
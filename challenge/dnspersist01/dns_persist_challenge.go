@@ -0,0 +1,137 @@
+// Package dnspersist01 implements the dns-persist-01 challenge.
+//
+// The dns-persist-01 record (`_validation-persist.<domain>`) is created once,
+// it names the CA (issuer domain name) and the account URI, and the next orders are validated without DNS changes.
+// - https://datatracker.ietf.org/doc/draft-ietf-acme-dns-persist/
+package dnspersist01
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-acme/lego/v4/acme"
+	"github.com/go-acme/lego/v4/acme/api"
+	"github.com/go-acme/lego/v4/challenge"
+	"github.com/go-acme/lego/v4/challenge/dns01"
+	"github.com/go-acme/lego/v4/log"
+)
+
+type ValidateFunc func(core *api.Core, domain string, chlng acme.Challenge) error
+
+// LookupFunc returns the values of the TXT records of a FQDN.
+type LookupFunc func(fqdn string) ([]string, error)
+
+type ChallengeOption func(*Challenge) error
+
+// CondOption Conditional challenge option.
+func CondOption(condition bool, opt ChallengeOption) ChallengeOption {
+	if !condition {
+		// NoOp options
+		return func(*Challenge) error {
+			return nil
+		}
+	}
+	return opt
+}
+
+// AddRecursiveNameservers the nameservers used to check the record.
+func AddRecursiveNameservers(nameservers []string) ChallengeOption {
+	return func(chlg *Challenge) error {
+		servers := dns01.ParseNameservers(nameservers)
+
+		chlg.lookup = func(fqdn string) ([]string, error) {
+			return dns01.LookupTXTCustom(fqdn, servers)
+		}
+
+		return nil
+	}
+}
+
+// SetLookup the function used to check the record.
+func SetLookup(lookup LookupFunc) ChallengeOption {
+	return func(chlg *Challenge) error {
+		chlg.lookup = lookup
+		return nil
+	}
+}
+
+// Challenge implements the dns-persist-01 challenge.
+// The record is not created by the solver: it only checks the record before the validation.
+type Challenge struct {
+	core     *api.Core
+	validate ValidateFunc
+	lookup   LookupFunc
+	now      func() time.Time
+}
+
+func NewChallenge(core *api.Core, validate ValidateFunc, opts ...ChallengeOption) *Challenge {
+	chlg := &Challenge{
+		core:     core,
+		validate: validate,
+		lookup:   dns01.LookupTXT,
+		now:      time.Now,
+	}
+
+	for _, opt := range opts {
+		err := opt(chlg)
+		if err != nil {
+			log.Infof("challenge option error: %v", err)
+		}
+	}
+
+	return chlg
+}
+
+func (c *Challenge) Solve(authz acme.Authorization) error {
+	domain := challenge.GetTargetedDomain(authz)
+	log.Infof("[%s] acme: Trying to solve DNS-PERSIST-01", domain)
+
+	chlng, err := challenge.FindChallenge(challenge.DNSPersist01, authz)
+	if err != nil {
+		return err
+	}
+
+	err = c.check(authz, chlng)
+	if err != nil {
+		return err
+	}
+
+	return c.validate(c.core, domain, chlng)
+}
+
+// Check checks the dns-persist-01 record of the domain:
+// without a valid record, the challenge can't be solved and another challenge must be used.
+func (c *Challenge) Check(authz acme.Authorization) error {
+	chlng, err := challenge.FindChallenge(challenge.DNSPersist01, authz)
+	if err != nil {
+		return err
+	}
+
+	return c.check(authz, chlng)
+}
+
+func (c *Challenge) check(authz acme.Authorization, chlng acme.Challenge) error {
+	domain := challenge.GetTargetedDomain(authz)
+
+	fqdn, _ := GetRecord(authz.Identifier.Value, Record{})
+
+	values, err := c.lookup(fqdn)
+	if err != nil {
+		return fmt.Errorf("[%s] acme: %w", domain, err)
+	}
+
+	_, err = FindRecord(values, chlng.IssuerDomainNames, c.core.GetAccountURL(), authz.Wildcard, c.now())
+	if err != nil {
+		return fmt.Errorf("[%s] acme: invalid dns-persist-01 record %s: %w", domain, fqdn, err)
+	}
+
+	return nil
+}
+
+// Present creates the dns-persist-01 record of a domain with a DNS provider
+// (the providers of the dns-01 challenge which implement dns01.RecordProvider).
+func Present(provider challenge.Provider, domain string, record Record) error {
+	fqdn, value := GetRecord(domain, record)
+
+	return dns01.PresentRecord(provider, domain, fqdn, value)
+}
@@ -0,0 +1,156 @@
+package dnspersist01
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/go-acme/lego/v4/acme"
+	"github.com/go-acme/lego/v4/acme/api"
+	"github.com/go-acme/lego/v4/challenge"
+	"github.com/go-acme/lego/v4/challenge/dns01"
+	"github.com/go-acme/lego/v4/platform/tester"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordProvider records the records created with dns01.RecordProvider.
+type recordProvider struct {
+	records map[string]string
+}
+
+func (p *recordProvider) Present(_, _, _ string) error {
+	return nil
+}
+
+func (p *recordProvider) CleanUp(_, _, _ string) error {
+	return nil
+}
+
+func (p *recordProvider) PresentRecord(_, fqdn, value string) error {
+	p.records[fqdn] = value
+
+	return nil
+}
+
+func (p *recordProvider) CleanUpRecord(_, fqdn, _ string) error {
+	delete(p.records, fqdn)
+
+	return nil
+}
+
+func TestChallenge_Solve(t *testing.T) {
+	_, apiURL, tearDown := tester.SetupFakeAPI()
+	defer tearDown()
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 512)
+	require.NoError(t, err)
+
+	core, err := api.New(http.DefaultClient, "lego-test", apiURL+"/dir", accountURI, privateKey)
+	require.NoError(t, err)
+
+	records := map[string][]string{
+		"_validation-persist.example.com.": {"authority.example; accounturi=https://ca.example/acct/123"},
+	}
+
+	lookup := func(fqdn string) ([]string, error) {
+		return records[fqdn], nil
+	}
+
+	testCases := []struct {
+		desc     string
+		authz    acme.Authorization
+		expected string
+	}{
+		{
+			desc:  "valid",
+			authz: acme.Authorization{Identifier: acme.Identifier{Value: "example.com"}},
+		},
+		{
+			desc:     "wildcard without policy",
+			authz:    acme.Authorization{Identifier: acme.Identifier{Value: "example.com"}, Wildcard: true},
+			expected: "[*.example.com] acme: invalid dns-persist-01 record _validation-persist.example.com.: no valid record found: the wildcard policy is required to validate a wildcard",
+		},
+		{
+			desc:     "missing record",
+			authz:    acme.Authorization{Identifier: acme.Identifier{Value: "example.org"}},
+			expected: "[example.org] acme: invalid dns-persist-01 record _validation-persist.example.org.: no record found",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			var validated bool
+
+			chlg := NewChallenge(core, func(_ *api.Core, _ string, _ acme.Challenge) error {
+				validated = true
+				return nil
+			}, SetLookup(lookup))
+
+			test.authz.Challenges = []acme.Challenge{
+				{Type: challenge.DNSPersist01.String(), IssuerDomainNames: []string{"authority.example"}},
+			}
+
+			err := chlg.Solve(test.authz)
+			if test.expected == "" {
+				require.NoError(t, err)
+				assert.True(t, validated)
+			} else {
+				require.EqualError(t, err, test.expected)
+				assert.False(t, validated)
+			}
+		})
+	}
+}
+
+func TestChallenge_Solve_lookupError(t *testing.T) {
+	_, apiURL, tearDown := tester.SetupFakeAPI()
+	defer tearDown()
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 512)
+	require.NoError(t, err)
+
+	core, err := api.New(http.DefaultClient, "lego-test", apiURL+"/dir", accountURI, privateKey)
+	require.NoError(t, err)
+
+	chlg := NewChallenge(core, nil, SetLookup(func(string) ([]string, error) {
+		return nil, errors.New("SERVFAIL")
+	}))
+
+	err = chlg.Solve(acme.Authorization{
+		Identifier: acme.Identifier{Value: "example.com"},
+		Challenges: []acme.Challenge{{Type: challenge.DNSPersist01.String()}},
+	})
+	require.EqualError(t, err, "[example.com] acme: SERVFAIL")
+}
+
+func TestPresent(t *testing.T) {
+	provider := &recordProvider{records: map[string]string{}}
+
+	record := Record{IssuerDomainName: "authority.example", AccountURI: accountURI, Policy: PolicyWildcard}
+
+	err := Present(provider, "example.com", record)
+	require.NoError(t, err)
+
+	expected := map[string]string{
+		"_validation-persist.example.com.": "authority.example; accounturi=https://ca.example/acct/123; policy=wildcard",
+	}
+	assert.Equal(t, expected, provider.records)
+}
+
+func TestPresent_notSupported(t *testing.T) {
+	provider := &dns01Provider{}
+
+	err := Present(provider, "example.com", Record{IssuerDomainName: "authority.example", AccountURI: accountURI})
+	require.ErrorIs(t, err, dns01.ErrRecordNotSupported)
+}
+
+// dns01Provider a DNS provider which can only create the dns-01 record.
+type dns01Provider struct{}
+
+func (p *dns01Provider) Present(_, _, _ string) error { return nil }
+
+func (p *dns01Provider) CleanUp(_, _, _ string) error { return nil }
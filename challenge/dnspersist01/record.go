@@ -0,0 +1,167 @@
+package dnspersist01
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Label the label of the dns-persist-01 record.
+const Label = "_validation-persist"
+
+// PolicyWildcard the policy allowing the validation of the wildcard of the domain (*.example.com with the record of example.com).
+// The record is only looked up for the domain itself: the other subdomains need their own record.
+const PolicyWildcard = "wildcard"
+
+// The parameters of the record.
+const (
+	paramAccountURI   = "accounturi"
+	paramPolicy       = "policy"
+	paramPersistUntil = "persistUntil"
+)
+
+// Record a dns-persist-01 record.
+// - https://datatracker.ietf.org/doc/draft-ietf-acme-dns-persist/
+type Record struct {
+	// IssuerDomainName the issuer domain name of the CA (ex: letsencrypt.org).
+	IssuerDomainName string
+
+	// AccountURI the URI of the ACME account.
+	AccountURI string
+
+	// Policy the validation policy: PolicyWildcard or empty.
+	Policy string
+
+	// PersistUntil the record is not used after this date (optional).
+	PersistUntil time.Time
+}
+
+// String returns the value of the TXT record.
+func (r Record) String() string {
+	parts := []string{r.IssuerDomainName, paramAccountURI + "=" + r.AccountURI}
+
+	if r.Policy != "" {
+		parts = append(parts, paramPolicy+"="+r.Policy)
+	}
+
+	if !r.PersistUntil.IsZero() {
+		parts = append(parts, paramPersistUntil+"="+strconv.FormatInt(r.PersistUntil.Unix(), 10))
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+// Check checks that the record allows the validation of a domain by an account.
+func (r Record) Check(issuerDomainNames []string, accountURI string, wildcard bool, now time.Time) error {
+	if !containsDomainName(issuerDomainNames, r.IssuerDomainName) {
+		return fmt.Errorf("the issuer domain name %q is not one of %v", r.IssuerDomainName, issuerDomainNames)
+	}
+
+	if r.AccountURI != accountURI {
+		return fmt.Errorf("the account URI %q is not %q", r.AccountURI, accountURI)
+	}
+
+	if wildcard && !strings.EqualFold(r.Policy, PolicyWildcard) {
+		return errors.New("the wildcard policy is required to validate a wildcard")
+	}
+
+	if !r.PersistUntil.IsZero() && now.After(r.PersistUntil) {
+		return fmt.Errorf("the record expired at %s", r.PersistUntil.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// GetRecord returns the dns-persist-01 record of a domain.
+func GetRecord(domain string, record Record) (fqdn, value string) {
+	return fmt.Sprintf("%s.%s.", Label, strings.TrimSuffix(domain, ".")), record.String()
+}
+
+// ParseRecord parses the value of a dns-persist-01 TXT record.
+// The unknown parameters are ignored.
+func ParseRecord(value string) (Record, error) {
+	parts := strings.Split(value, ";")
+
+	record := Record{IssuerDomainName: strings.TrimSpace(parts[0])}
+
+	if record.IssuerDomainName == "" || strings.Contains(record.IssuerDomainName, "=") {
+		return Record{}, fmt.Errorf("invalid issuer domain name: %q", record.IssuerDomainName)
+	}
+
+	for _, part := range parts[1:] {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return Record{}, fmt.Errorf("invalid parameter: %q", part)
+		}
+
+		key, val := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+
+		switch {
+		case strings.EqualFold(key, paramAccountURI):
+			record.AccountURI = val
+
+		case strings.EqualFold(key, paramPolicy):
+			record.Policy = strings.ToLower(val)
+
+		case strings.EqualFold(key, paramPersistUntil):
+			timestamp, err := strconv.ParseInt(val, 10, 64)
+			if err != nil {
+				return Record{}, fmt.Errorf("invalid %s: %q", paramPersistUntil, val)
+			}
+
+			record.PersistUntil = time.Unix(timestamp, 0).UTC()
+		}
+	}
+
+	if record.AccountURI == "" {
+		return Record{}, fmt.Errorf("missing %s", paramAccountURI)
+	}
+
+	return record, nil
+}
+
+// FindRecord returns the first record (of the values of the TXT records) allowing the validation of a domain by an account.
+func FindRecord(values, issuerDomainNames []string, accountURI string, wildcard bool, now time.Time) (Record, error) {
+	var errs []string
+
+	for _, value := range values {
+		record, err := ParseRecord(value)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+
+		err = record.Check(issuerDomainNames, accountURI, wildcard, now)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+
+		return record, nil
+	}
+
+	if len(errs) == 0 {
+		return Record{}, errors.New("no record found")
+	}
+
+	return Record{}, fmt.Errorf("no valid record found: %s", strings.Join(errs, ", "))
+}
+
+func containsDomainName(names []string, name string) bool {
+	name = strings.TrimSuffix(name, ".")
+
+	for _, n := range names {
+		if strings.EqualFold(strings.TrimSuffix(n, "."), name) {
+			return true
+		}
+	}
+
+	return false
+}
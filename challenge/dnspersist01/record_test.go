@@ -0,0 +1,195 @@
+package dnspersist01
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const accountURI = "https://ca.example/acct/123"
+
+func TestRecord_String(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		record   Record
+		expected string
+	}{
+		{
+			desc:     "account only",
+			record:   Record{IssuerDomainName: "authority.example", AccountURI: accountURI},
+			expected: "authority.example; accounturi=https://ca.example/acct/123",
+		},
+		{
+			desc: "wildcard and expiry",
+			record: Record{
+				IssuerDomainName: "authority.example",
+				AccountURI:       accountURI,
+				Policy:           PolicyWildcard,
+				PersistUntil:     time.Unix(1721952000, 0),
+			},
+			expected: "authority.example; accounturi=https://ca.example/acct/123; policy=wildcard; persistUntil=1721952000",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, test.record.String())
+
+			record, err := ParseRecord(test.expected)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, record.String())
+		})
+	}
+}
+
+func TestGetRecord(t *testing.T) {
+	fqdn, value := GetRecord("example.com", Record{IssuerDomainName: "authority.example", AccountURI: accountURI})
+
+	assert.Equal(t, "_validation-persist.example.com.", fqdn)
+	assert.Equal(t, "authority.example; accounturi=https://ca.example/acct/123", value)
+}
+
+func TestParseRecord(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		value    string
+		expected Record
+	}{
+		{
+			desc:     "spaces and case",
+			value:    " authority.example ;AccountURI=https://ca.example/acct/123;  Policy=Wildcard ",
+			expected: Record{IssuerDomainName: "authority.example", AccountURI: accountURI, Policy: PolicyWildcard},
+		},
+		{
+			desc:     "unknown parameter",
+			value:    "authority.example; accounturi=https://ca.example/acct/123; foo=bar",
+			expected: Record{IssuerDomainName: "authority.example", AccountURI: accountURI},
+		},
+		{
+			desc:     "persistUntil",
+			value:    "authority.example; accounturi=https://ca.example/acct/123; persistUntil=1721952000",
+			expected: Record{IssuerDomainName: "authority.example", AccountURI: accountURI, PersistUntil: time.Unix(1721952000, 0).UTC()},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			record, err := ParseRecord(test.value)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, record)
+		})
+	}
+}
+
+func TestParseRecord_error(t *testing.T) {
+	testCases := []struct {
+		desc  string
+		value string
+	}{
+		{desc: "empty", value: ""},
+		{desc: "no issuer", value: "accounturi=https://ca.example/acct/123"},
+		{desc: "no account", value: "authority.example; policy=wildcard"},
+		{desc: "invalid parameter", value: "authority.example; accounturi=https://ca.example/acct/123; wildcard"},
+		{desc: "invalid persistUntil", value: "authority.example; accounturi=https://ca.example/acct/123; persistUntil=tomorrow"},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := ParseRecord(test.value)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestRecord_Check(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	issuers := []string{"authority.example", "ca.example.net"}
+
+	testCases := []struct {
+		desc     string
+		record   Record
+		wildcard bool
+		expected string
+	}{
+		{
+			desc:   "valid",
+			record: Record{IssuerDomainName: "CA.example.net.", AccountURI: accountURI},
+		},
+		{
+			desc:     "wildcard",
+			record:   Record{IssuerDomainName: "authority.example", AccountURI: accountURI, Policy: PolicyWildcard},
+			wildcard: true,
+		},
+		{
+			desc:   "not expired",
+			record: Record{IssuerDomainName: "authority.example", AccountURI: accountURI, PersistUntil: now.Add(time.Hour)},
+		},
+		{
+			desc:     "other issuer",
+			record:   Record{IssuerDomainName: "other.example", AccountURI: accountURI},
+			expected: `the issuer domain name "other.example" is not one of [authority.example ca.example.net]`,
+		},
+		{
+			desc:     "other account",
+			record:   Record{IssuerDomainName: "authority.example", AccountURI: "https://ca.example/acct/456"},
+			expected: `the account URI "https://ca.example/acct/456" is not "https://ca.example/acct/123"`,
+		},
+		{
+			desc:     "wildcard without policy",
+			record:   Record{IssuerDomainName: "authority.example", AccountURI: accountURI},
+			wildcard: true,
+			expected: "the wildcard policy is required to validate a wildcard",
+		},
+		{
+			desc:     "expired",
+			record:   Record{IssuerDomainName: "authority.example", AccountURI: accountURI, PersistUntil: now.Add(-time.Hour).UTC()},
+			expected: "the record expired at 2023-11-14T21:13:20Z",
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			err := test.record.Check(issuers, accountURI, test.wildcard, now)
+			if test.expected == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, test.expected)
+			}
+		})
+	}
+}
+
+func TestFindRecord(t *testing.T) {
+	values := []string{
+		"invalid",
+		"other.example; accounturi=https://ca.example/acct/123",
+		"authority.example; accounturi=https://ca.example/acct/123; policy=wildcard",
+	}
+
+	record, err := FindRecord(values, []string{"authority.example"}, accountURI, true, time.Now())
+	require.NoError(t, err)
+
+	assert.Equal(t, Record{IssuerDomainName: "authority.example", AccountURI: accountURI, Policy: PolicyWildcard}, record)
+
+	_, err = FindRecord(values[:2], []string{"authority.example"}, accountURI, false, time.Now())
+	require.Error(t, err)
+
+	_, err = FindRecord(nil, []string{"authority.example"}, accountURI, false, time.Now())
+	require.EqualError(t, err, "no record found")
+}
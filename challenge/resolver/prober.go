@@ -25,6 +25,18 @@ type cleanup interface {
 	CleanUp(authorization acme.Authorization) error
 }
 
+// Interface for challenges like dns-persist, which can only be solved under a condition (ex: an existing record).
+// When the check fails, the next solver is used.
+type checker interface {
+	Check(authorization acme.Authorization) error
+}
+
+// Interface for challenges like dns, where the presented record can be stored to clean it after an interruption.
+type recorder interface {
+	Record(authorization acme.Authorization) (challenge.Record, error)
+	CleanUpRecord(authorization acme.Authorization, record challenge.Record) error
+}
+
 type sequential interface {
 	Sequential() (bool, time.Duration)
 }
@@ -45,6 +57,16 @@ func NewProber(solverManager *SolverManager) *Prober {
 	}
 }
 
+// GetChallengeTypes returns the challenge types that can be solved.
+func (p *Prober) GetChallengeTypes() []challenge.Type {
+	return p.solverManager.GetChallengeTypes()
+}
+
+// SetAllowedChallengeTypes restricts the challenge types which can be used to solve the authorizations of a domain.
+func (p *Prober) SetAllowedChallengeTypes(domain string, types []challenge.Type) {
+	p.solverManager.SetAllowedChallengeTypes(domain, types)
+}
+
 // Solve Looks through the challenge combinations to find a solvable match.
 // Then solves the challenges in series and returns.
 func (p *Prober) Solve(authorizations []acme.Authorization) error {
@@ -93,6 +115,61 @@ func (p *Prober) Solve(authorizations []acme.Authorization) error {
 	return nil
 }
 
+// Records returns the DNS records presented to solve the challenges of the authorizations, indexed by domain.
+func (p *Prober) Records(authorizations []acme.Authorization) map[string]challenge.Record {
+	records := make(map[string]challenge.Record)
+
+	for _, authz := range authorizations {
+		if authz.Status == acme.StatusValid {
+			continue
+		}
+
+		solvr, ok := p.solverManager.chooseSolver(authz).(recorder)
+		if !ok {
+			continue
+		}
+
+		record, err := solvr.Record(authz)
+		if err != nil {
+			log.Warnf("[%s] acme: unable to get the record of the challenge: %v", challenge.GetTargetedDomain(authz), err)
+			continue
+		}
+
+		records[challenge.GetTargetedDomain(authz)] = record
+	}
+
+	return records
+}
+
+// CleanUp cleans the challenges of the authorizations (ex: leftovers of an interrupted process).
+// The records presented for the authorizations (see Records) are cleaned as they were stored.
+// The errors are only logged.
+func (p *Prober) CleanUp(authorizations []acme.Authorization, records map[string]challenge.Record) {
+	for _, authz := range authorizations {
+		if authz.Status == acme.StatusValid {
+			// already valid authorizations are not solved, so there is nothing to clean.
+			continue
+		}
+
+		domain := challenge.GetTargetedDomain(authz)
+
+		if record, ok := records[domain]; ok {
+			if solvr, ok := p.solverManager.solvers[record.Type].(recorder); ok {
+				err := solvr.CleanUpRecord(authz, record)
+				if err != nil {
+					log.Warnf("[%s] acme: cleaning up failed: %v ", domain, err)
+				}
+
+				continue
+			}
+		}
+
+		if solvr := p.solverManager.chooseSolver(authz); solvr != nil {
+			cleanUp(solvr, authz)
+		}
+	}
+}
+
 func sequentialSolve(authSolvers []*selectedAuthSolver, failures obtainError) {
 	for i, authSolver := range authSolvers {
 		// Submit the challenge
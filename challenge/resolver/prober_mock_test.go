@@ -25,6 +25,37 @@ func (s *preSolverMock) CleanUp(authorization acme.Authorization) error {
 	return s.cleanUp[authorization.Identifier.Value]
 }
 
+type checkerMock struct {
+	preSolverMock
+	check map[string]error
+	calls int
+}
+
+func (s *checkerMock) Check(authorization acme.Authorization) error {
+	s.calls++
+	return s.check[authorization.Identifier.Value]
+}
+
+type recorderMock struct {
+	preSolverMock
+	cleaned        []string
+	cleanedRecords map[string]challenge.Record
+}
+
+func (s *recorderMock) Record(authorization acme.Authorization) (challenge.Record, error) {
+	return challenge.Record{Type: challenge.HTTP01, FQDN: "_acme-challenge." + authorization.Identifier.Value + ".", Value: "current"}, nil
+}
+
+func (s *recorderMock) CleanUp(authorization acme.Authorization) error {
+	s.cleaned = append(s.cleaned, authorization.Identifier.Value)
+	return nil
+}
+
+func (s *recorderMock) CleanUpRecord(authorization acme.Authorization, record challenge.Record) error {
+	s.cleanedRecords[authorization.Identifier.Value] = record
+	return nil
+}
+
 func createStubAuthorizationHTTP01(domain, status string) acme.Authorization {
 	return acme.Authorization{
 		Status:  status,
@@ -6,6 +6,7 @@ import (
 
 	"github.com/go-acme/lego/v4/acme"
 	"github.com/go-acme/lego/v4/challenge"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -116,3 +117,39 @@ func TestProber_Solve(t *testing.T) {
 		})
 	}
 }
+
+func TestProber_Records(t *testing.T) {
+	solvr := &recorderMock{}
+
+	prober := NewProber(&SolverManager{solvers: map[challenge.Type]solver{challenge.HTTP01: solvr}})
+
+	records := prober.Records([]acme.Authorization{
+		createStubAuthorizationHTTP01("acme.wtf", acme.StatusPending),
+		createStubAuthorizationHTTP01("lego.wtf", acme.StatusValid),
+	})
+
+	expected := map[string]challenge.Record{
+		"acme.wtf": {Type: challenge.HTTP01, FQDN: "_acme-challenge.acme.wtf.", Value: "current"},
+	}
+	assert.Equal(t, expected, records)
+}
+
+func TestProber_CleanUp(t *testing.T) {
+	solvr := &recorderMock{cleanedRecords: map[string]challenge.Record{}}
+
+	prober := NewProber(&SolverManager{solvers: map[challenge.Type]solver{challenge.HTTP01: solvr}})
+
+	stored := challenge.Record{Type: challenge.HTTP01, FQDN: "_acme-challenge.acme.wtf.", Value: "stored"}
+
+	prober.CleanUp([]acme.Authorization{
+		createStubAuthorizationHTTP01("acme.wtf", acme.StatusPending),
+		createStubAuthorizationHTTP01("lego.wtf", acme.StatusPending),
+		createStubAuthorizationHTTP01("mydomain.wtf", acme.StatusValid),
+	}, map[string]challenge.Record{"acme.wtf": stored})
+
+	// the stored record is cleaned as it was stored.
+	assert.Equal(t, map[string]challenge.Record{"acme.wtf": stored}, solvr.cleanedRecords)
+
+	// without a stored record, the challenge is cleaned.
+	assert.Equal(t, []string{"lego.wtf"}, solvr.cleaned)
+}
@@ -4,7 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"sort"
-	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
@@ -12,20 +13,71 @@ import (
 	"github.com/go-acme/lego/v4/acme/api"
 	"github.com/go-acme/lego/v4/challenge"
 	"github.com/go-acme/lego/v4/challenge/dns01"
+	"github.com/go-acme/lego/v4/challenge/dnspersist01"
 	"github.com/go-acme/lego/v4/challenge/http01"
 	"github.com/go-acme/lego/v4/challenge/tlsalpn01"
 	"github.com/go-acme/lego/v4/log"
+	"github.com/go-acme/lego/v4/platform/wait"
 )
 
+// preferences the order of preference of the challenge types.
+// The dns-persist-01 challenge is the first one: it doesn't require any change (the record is already there),
+// and it's skipped when the record is missing (see checker).
+// The dns-account-01 challenge is preferred to the dns-01 challenge: both use the DNS provider,
+// but the dns-account-01 record doesn't collide with the records of the other accounts.
+var preferences = []challenge.Type{challenge.DNSPersist01, challenge.TLSALPN01, challenge.HTTP01, challenge.DNSAccount01, challenge.DNS01}
+
 type byType []acme.Challenge
 
-func (a byType) Len() int           { return len(a) }
-func (a byType) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
-func (a byType) Less(i, j int) bool { return a[i].Type > a[j].Type }
+func (a byType) Len() int      { return len(a) }
+func (a byType) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byType) Less(i, j int) bool {
+	return lessType(challenge.Type(a[i].Type), challenge.Type(a[j].Type))
+}
+
+// lessType orders the challenge types by preference, the unknown types are the last ones.
+func lessType(a, b challenge.Type) bool {
+	ra, rb := preference(a), preference(b)
+	if ra != rb {
+		return ra < rb
+	}
+
+	return a > b
+}
+
+func preference(chlgType challenge.Type) int {
+	for i, t := range preferences {
+		if t == chlgType {
+			return i
+		}
+	}
+
+	return len(preferences)
+}
 
 type SolverManager struct {
 	core    *api.Core
 	solvers map[challenge.Type]solver
+
+	// checks the results of the checkers by challenge (see check):
+	// the solver chosen for an authorization is the same for Solve, Records and CleanUp.
+	checks   map[checkKey]checkResult
+	checksMu sync.Mutex
+
+	// allowed the challenge types allowed by domain (ex: by the CAA records), all the types are allowed if missing.
+	allowed   map[string][]challenge.Type
+	allowedMu sync.Mutex
+}
+
+type checkResult struct {
+	err     error
+	expires time.Time
+}
+
+// checkKey identifies the challenge of an authorization.
+type checkKey struct {
+	domain string
+	url    string
 }
 
 func NewSolversManager(core *api.Core) *SolverManager {
@@ -53,11 +105,81 @@ func (c *SolverManager) SetDNS01Provider(p challenge.Provider, opts ...dns01.Cha
 	return nil
 }
 
+// SetDNSAccount01Provider specifies a custom provider p that can solve the given DNS-ACCOUNT-01 challenge.
+// The DNS providers of the DNS-01 challenge which implement dns01.RecordProvider can be used.
+func (c *SolverManager) SetDNSAccount01Provider(p challenge.Provider, opts ...dns01.ChallengeOption) error {
+	if _, ok := p.(dns01.RecordProvider); !ok {
+		return fmt.Errorf("dns-account-01: %T: %w", p, dns01.ErrRecordNotSupported)
+	}
+
+	c.solvers[challenge.DNSAccount01] = dns01.NewAccountChallenge(c.core, validate, p, opts...)
+	return nil
+}
+
+// SetDNSPersist01 enables the DNS-PERSIST-01 challenge.
+// The record must be created beforehand (see dnspersist01.GetRecord), the solver only checks it.
+func (c *SolverManager) SetDNSPersist01(opts ...dnspersist01.ChallengeOption) error {
+	c.solvers[challenge.DNSPersist01] = dnspersist01.NewChallenge(c.core, validate, opts...)
+	return nil
+}
+
 // Remove Remove a challenge type from the available solvers.
 func (c *SolverManager) Remove(chlgType challenge.Type) {
 	delete(c.solvers, chlgType)
 }
 
+// GetChallengeTypes returns the challenge types of the available solvers.
+func (c *SolverManager) GetChallengeTypes() []challenge.Type {
+	var types []challenge.Type
+	for chlgType := range c.solvers {
+		types = append(types, chlgType)
+	}
+
+	// Same order as the one used to choose a solver.
+	sort.Slice(types, func(i, j int) bool { return lessType(types[i], types[j]) })
+
+	return types
+}
+
+// SetAllowedChallengeTypes restricts the challenge types which can be used to solve the authorizations of a domain
+// (ex: the types allowed by the `validationmethods` parameter of the CAA records).
+// A nil slice removes the restriction.
+func (c *SolverManager) SetAllowedChallengeTypes(domain string, types []challenge.Type) {
+	c.allowedMu.Lock()
+	defer c.allowedMu.Unlock()
+
+	domain = strings.ToLower(domain)
+
+	if types == nil {
+		delete(c.allowed, domain)
+		return
+	}
+
+	if c.allowed == nil {
+		c.allowed = make(map[string][]challenge.Type)
+	}
+
+	c.allowed[domain] = types
+}
+
+func (c *SolverManager) isAllowed(domain string, chlgType challenge.Type) bool {
+	c.allowedMu.Lock()
+	defer c.allowedMu.Unlock()
+
+	types, ok := c.allowed[strings.ToLower(domain)]
+	if !ok {
+		return true
+	}
+
+	for _, t := range types {
+		if t == chlgType {
+			return true
+		}
+	}
+
+	return false
+}
+
 // Checks all challenges from the server in order and returns the first matching solver.
 func (c *SolverManager) chooseSolver(authz acme.Authorization) solver {
 	// Allow to have a deterministic challenge order
@@ -66,6 +188,18 @@ func (c *SolverManager) chooseSolver(authz acme.Authorization) solver {
 	domain := challenge.GetTargetedDomain(authz)
 	for _, chlg := range authz.Challenges {
 		if solvr, ok := c.solvers[challenge.Type(chlg.Type)]; ok {
+			if !c.isAllowed(domain, challenge.Type(chlg.Type)) {
+				log.Infof("[%s] acme: Could not use the %s solver: not allowed by the CAA records", domain, chlg.Type)
+				continue
+			}
+
+			if chkr, ok := solvr.(checker); ok {
+				if err := c.check(chkr, authz, chlg); err != nil {
+					log.Infof("[%s] acme: Could not use the %s solver: %v", domain, chlg.Type, err)
+					continue
+				}
+			}
+
 			log.Infof("[%s] acme: use %s solver", domain, chlg.Type)
 			return solvr
 		}
@@ -75,6 +209,35 @@ func (c *SolverManager) chooseSolver(authz acme.Authorization) solver {
 	return nil
 }
 
+// check calls the checker once by challenge of an authorization, and returns the stored result for the next calls.
+// The results are kept until the expiration of the authorizations.
+func (c *SolverManager) check(chkr checker, authz acme.Authorization, chlg acme.Challenge) error {
+	key := checkKey{domain: challenge.GetTargetedDomain(authz), url: chlg.URL}
+
+	c.checksMu.Lock()
+	defer c.checksMu.Unlock()
+
+	if result, ok := c.checks[key]; ok {
+		return result.err
+	}
+
+	now := time.Now()
+	for k, result := range c.checks {
+		if !result.expires.IsZero() && now.After(result.expires) {
+			delete(c.checks, k)
+		}
+	}
+
+	err := chkr.Check(authz)
+
+	if c.checks == nil {
+		c.checks = make(map[checkKey]checkResult)
+	}
+	c.checks[key] = checkResult{err: err, expires: authz.Expires}
+
+	return err
+}
+
 func validate(core *api.Core, domain string, chlg acme.Challenge) error {
 	chlng, err := core.Challenges.New(chlg.URL)
 	if err != nil {
@@ -91,15 +254,14 @@ func validate(core *api.Core, domain string, chlg acme.Challenge) error {
 		return nil
 	}
 
-	ra, err := strconv.Atoi(chlng.RetryAfter)
-	if err != nil {
+	initialInterval := wait.ParseRetryAfter(chlng.RetryAfter)
+	if initialInterval <= 0 {
 		// The ACME server MUST return a Retry-After.
 		// If it doesn't, we'll just poll hard.
 		// Boulder does not implement the ability to retry challenges or the Retry-After header.
 		// https://github.com/letsencrypt/boulder/blob/master/docs/acme-divergences.md#section-82
-		ra = 5
+		initialInterval = 5 * time.Second
 	}
-	initialInterval := time.Duration(ra) * time.Second
 
 	bo := backoff.NewExponentialBackOff()
 	bo.InitialInterval = initialInterval
@@ -111,6 +273,12 @@ func validate(core *api.Core, domain string, chlg acme.Challenge) error {
 	operation := func() error {
 		authz, err := core.Authorizations.Get(chlng.AuthorizationURL)
 		if err != nil {
+			// Retry after the delay asked by the server if it's short enough,
+			// the other errors (ex: a long rate limit) are returned as is.
+			if delay, ok := api.RetryDelay(nil, err); ok {
+				return wait.RetryAfter(err, delay)
+			}
+
 			return backoff.Permanent(err)
 		}
 
@@ -127,7 +295,7 @@ func validate(core *api.Core, domain string, chlg acme.Challenge) error {
 		return errors.New("the server didn't respond to our request")
 	}
 
-	return backoff.Retry(operation, bo)
+	return wait.Retry(operation, bo, nil)
 }
 
 func checkChallengeStatus(chlng acme.ExtendedChallenge) (bool, error) {
@@ -3,14 +3,19 @@ package resolver
 import (
 	"crypto/rand"
 	"crypto/rsa"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/go-acme/lego/v4/acme"
 	"github.com/go-acme/lego/v4/acme/api"
+	"github.com/go-acme/lego/v4/challenge"
+	"github.com/go-acme/lego/v4/challenge/dns01"
 	"github.com/go-acme/lego/v4/platform/tester"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -19,24 +24,132 @@ import (
 
 func TestByType(t *testing.T) {
 	challenges := []acme.Challenge{
-		{Type: "dns-01"}, {Type: "tlsalpn-01"}, {Type: "http-01"},
+		{Type: "dns-01"}, {Type: "unknown-01"}, {Type: "tls-alpn-01"}, {Type: "dns-account-01"}, {Type: "http-01"}, {Type: "dns-persist-01"},
 	}
 
 	sort.Sort(byType(challenges))
 
 	expected := []acme.Challenge{
-		{Type: "tlsalpn-01"}, {Type: "http-01"}, {Type: "dns-01"},
+		{Type: "dns-persist-01"}, {Type: "tls-alpn-01"}, {Type: "http-01"}, {Type: "dns-account-01"}, {Type: "dns-01"}, {Type: "unknown-01"},
 	}
 
 	assert.Equal(t, expected, challenges)
 }
 
+func TestSolverManager_chooseSolver(t *testing.T) {
+	persist := &checkerMock{check: map[string]error{"example.org": errors.New("no record found")}}
+	http := &preSolverMock{}
+
+	manager := &SolverManager{solvers: map[challenge.Type]solver{
+		challenge.DNSPersist01: persist,
+		challenge.HTTP01:       http,
+	}}
+
+	challenges := []acme.Challenge{{Type: "http-01"}, {Type: "dns-persist-01"}}
+
+	// the record exists: dns-persist-01 is preferred.
+	solvr := manager.chooseSolver(acme.Authorization{Identifier: acme.Identifier{Value: "example.com"}, Challenges: challenges})
+	assert.Same(t, persist, solvr)
+
+	// the record is missing: fallback to http-01.
+	solvr = manager.chooseSolver(acme.Authorization{Identifier: acme.Identifier{Value: "example.org"}, Challenges: challenges})
+	assert.Same(t, http, solvr)
+
+	// no other solver.
+	solvr = manager.chooseSolver(acme.Authorization{Identifier: acme.Identifier{Value: "example.org"}, Challenges: []acme.Challenge{{Type: "dns-persist-01"}}})
+	assert.Nil(t, solvr)
+}
+
+func TestSolverManager_chooseSolver_checkOnce(t *testing.T) {
+	persist := &checkerMock{check: map[string]error{"example.org": errors.New("no record found")}}
+	http := &preSolverMock{}
+
+	manager := &SolverManager{solvers: map[challenge.Type]solver{
+		challenge.DNSPersist01: persist,
+		challenge.HTTP01:       http,
+	}}
+
+	authz := acme.Authorization{
+		Identifier: acme.Identifier{Value: "example.org"},
+		Expires:    time.Now().Add(time.Hour),
+		Challenges: []acme.Challenge{{Type: "http-01", URL: "https://ca.example/chlg/1"}, {Type: "dns-persist-01", URL: "https://ca.example/chlg/2"}},
+	}
+
+	solvr := manager.chooseSolver(authz)
+	assert.Same(t, http, solvr)
+
+	// the record is created in the meantime: the same solver is used for the authorization (ex: to clean up).
+	delete(persist.check, "example.org")
+
+	solvr = manager.chooseSolver(authz)
+	assert.Same(t, http, solvr)
+
+	assert.Equal(t, 1, persist.calls)
+}
+
+func TestSolverManager_chooseSolver_allowedChallengeTypes(t *testing.T) {
+	tlsALPN := &preSolverMock{}
+	http := &preSolverMock{}
+
+	manager := &SolverManager{solvers: map[challenge.Type]solver{
+		challenge.TLSALPN01: tlsALPN,
+		challenge.HTTP01:    http,
+	}}
+
+	authz := acme.Authorization{
+		Identifier: acme.Identifier{Value: "example.org"},
+		Challenges: []acme.Challenge{{Type: "http-01"}, {Type: "tls-alpn-01"}},
+	}
+
+	assert.Same(t, tlsALPN, manager.chooseSolver(authz))
+
+	manager.SetAllowedChallengeTypes("Example.org", []challenge.Type{challenge.HTTP01})
+	assert.Same(t, http, manager.chooseSolver(authz))
+
+	manager.SetAllowedChallengeTypes("example.org", []challenge.Type{challenge.DNS01})
+	assert.Nil(t, manager.chooseSolver(authz))
+
+	manager.SetAllowedChallengeTypes("example.org", nil)
+	assert.Same(t, tlsALPN, manager.chooseSolver(authz))
+}
+
+// dnsProviderMock a DNS provider which can only create the dns-01 record.
+type dnsProviderMock struct{}
+
+func (p *dnsProviderMock) Present(_, _, _ string) error { return nil }
+
+func (p *dnsProviderMock) CleanUp(_, _, _ string) error { return nil }
+
+// dnsRecordProviderMock a DNS provider which also creates the records from their FQDN and their value.
+type dnsRecordProviderMock struct {
+	dnsProviderMock
+}
+
+func (p *dnsRecordProviderMock) PresentRecord(_, _, _ string) error { return nil }
+
+func (p *dnsRecordProviderMock) CleanUpRecord(_, _, _ string) error { return nil }
+
+func TestSolverManager_SetDNSAccount01Provider(t *testing.T) {
+	manager := NewSolversManager(nil)
+
+	err := manager.SetDNSAccount01Provider(&dnsProviderMock{})
+	require.ErrorIs(t, err, dns01.ErrRecordNotSupported)
+	assert.Empty(t, manager.GetChallengeTypes())
+
+	err = manager.SetDNSAccount01Provider(&dnsRecordProviderMock{})
+	require.NoError(t, err)
+	assert.Equal(t, []challenge.Type{challenge.DNSAccount01}, manager.GetChallengeTypes())
+}
+
 func TestValidate(t *testing.T) {
 	mux, apiURL, tearDown := tester.SetupFakeAPI()
 	defer tearDown()
 
 	var statuses []string
 
+	// the pseudo statuses of the rate limited responses, and their Retry-After.
+	rateLimits := map[string]string{"rateLimited-short": "1", "rateLimited-long": "3600"}
+
 	privateKey, _ := rsa.GenerateKey(rand.Reader, 512)
 
 	mux.HandleFunc("/chlg", func(w http.ResponseWriter, r *http.Request) {
@@ -76,6 +189,20 @@ func TestValidate(t *testing.T) {
 		st := statuses[0]
 		statuses = statuses[1:]
 
+		if retryAfter, ok := rateLimits[st]; ok {
+			w.Header().Set("Retry-After", retryAfter)
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusTooManyRequests)
+
+			_ = json.NewEncoder(w).Encode(acme.ProblemDetails{
+				Type:       acme.RateLimitedErr,
+				Detail:     "too many requests",
+				HTTPStatus: http.StatusTooManyRequests,
+			})
+
+			return
+		}
+
 		authorization := acme.Authorization{
 			Status:     st,
 			Challenges: []acme.Challenge{},
@@ -132,6 +259,15 @@ func TestValidate(t *testing.T) {
 			statuses: []string{acme.StatusPending, acme.StatusInvalid},
 			want:     "error",
 		},
+		{
+			name:     "POST-pending-rate-limited-short-valid",
+			statuses: []string{acme.StatusPending, "rateLimited-short", acme.StatusValid},
+		},
+		{
+			name:     "POST-pending-rate-limited-long",
+			statuses: []string{acme.StatusPending, "rateLimited-long"},
+			want:     "too many requests",
+		},
 	}
 
 	for _, test := range testCases {
@@ -147,6 +283,15 @@ func TestValidate(t *testing.T) {
 			}
 		})
 	}
+
+	// the long rate limits are returned as is to allow the caller to reschedule.
+	statuses = []string{acme.StatusPending, "rateLimited-long"}
+
+	err = validate(core, "example.com", acme.Challenge{Type: "http-01", Token: "token", URL: apiURL + "/chlg"})
+
+	var rateLimitErr *acme.RateLimitError
+	require.True(t, errors.As(err, &rateLimitErr))
+	assert.False(t, rateLimitErr.RetryAfter.IsZero())
 }
 
 // validateNoBody reads the http.Request POST body, parses the JWS and validates it to read the body.
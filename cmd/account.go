@@ -10,7 +10,11 @@ import (
 type Account struct {
 	Email        string                 `json:"email"`
 	Registration *registration.Resource `json:"registration"`
-	key          crypto.PrivateKey
+	// KeyURI the reference of the account key when it is not stored by lego (ex: a PKCS#11 URI).
+	KeyURI string `json:"keyUri,omitempty"`
+	// TermsOfService the URL of the terms of service agreed by the account.
+	TermsOfService string `json:"termsOfService,omitempty"`
+	key            crypto.PrivateKey
 }
 
 /** Implementation of the registration.User interface **/
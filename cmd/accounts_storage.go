@@ -2,10 +2,7 @@ package cmd
 
 import (
 	"crypto"
-	"crypto/x509"
 	"encoding/json"
-	"encoding/pem"
-	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/url"
@@ -14,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/certificate"
 	"github.com/go-acme/lego/v4/lego"
 	"github.com/go-acme/lego/v4/log"
 	"github.com/go-acme/lego/v4/registration"
@@ -24,6 +22,7 @@ const (
 	baseAccountsRootFolderName = "accounts"
 	baseKeysFolderName         = "keys"
 	accountFileName            = "account.json"
+	authorizationsFileName     = "authorizations.json"
 )
 
 // AccountsStorage A storage for account data.
@@ -60,21 +59,39 @@ const (
 //          │      └── root accounts directory
 //          └── "path" option
 //
+// authorizationsFilePath:
+//
+//     ./.lego/accounts/localhost_14000/hubert@hubert.com/authorizations.json
+//          │      │             │             │             └── pre-authorizations file
+//          │      │             │             └── userID ("email" option)
+//          │      │             └── CA server ("server" option)
+//          │      └── root accounts directory
+//          └── "path" option
+//
 type AccountsStorage struct {
-	userID          string
-	rootPath        string
-	rootUserPath    string
-	keysPath        string
-	accountFilePath string
-	ctx             *cli.Context
+	userID                 string
+	rootPath               string
+	rootUserPath           string
+	keysPath               string
+	accountFilePath        string
+	authorizationsFilePath string
+	server                 string
+	keyURI                 string
+	keysEncryption         *keysEncryption
+	ctx                    *cli.Context
 }
 
 // NewAccountsStorage Creates a new AccountsStorage.
 func NewAccountsStorage(ctx *cli.Context) *AccountsStorage {
+	return newAccountsStorage(ctx, ctx.GlobalString("server"))
+}
+
+// newAccountsStorage Creates a new AccountsStorage for a specific CA server.
+func newAccountsStorage(ctx *cli.Context, server string) *AccountsStorage {
 	// TODO: move to account struct? Currently MUST pass email.
 	email := getEmail(ctx)
 
-	serverURL, err := url.Parse(ctx.GlobalString("server"))
+	serverURL, err := url.Parse(server)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -85,12 +102,16 @@ func NewAccountsStorage(ctx *cli.Context) *AccountsStorage {
 	rootUserPath := filepath.Join(accountsPath, email)
 
 	return &AccountsStorage{
-		userID:          email,
-		rootPath:        rootPath,
-		rootUserPath:    rootUserPath,
-		keysPath:        filepath.Join(rootUserPath, baseKeysFolderName),
-		accountFilePath: filepath.Join(rootUserPath, accountFileName),
-		ctx:             ctx,
+		userID:                 email,
+		rootPath:               rootPath,
+		rootUserPath:           rootUserPath,
+		keysPath:               filepath.Join(rootUserPath, baseKeysFolderName),
+		accountFilePath:        filepath.Join(rootUserPath, accountFileName),
+		authorizationsFilePath: filepath.Join(rootUserPath, authorizationsFileName),
+		server:                 server,
+		keyURI:                 ctx.GlobalString("account-key"),
+		keysEncryption:         newKeysEncryption(ctx),
+		ctx:                    ctx,
 	}
 }
 
@@ -116,7 +137,15 @@ func (s *AccountsStorage) GetUserID() string {
 	return s.userID
 }
 
+func (s *AccountsStorage) GetServer() string {
+	return s.server
+}
+
 func (s *AccountsStorage) Save(account *Account) error {
+	if s.keyURI != "" {
+		account.KeyURI = storableKeyURI(s.keyURI)
+	}
+
 	jsonBytes, err := json.MarshalIndent(account, "", "\t")
 	if err != nil {
 		return err
@@ -125,6 +154,36 @@ func (s *AccountsStorage) Save(account *Account) error {
 	return ioutil.WriteFile(s.accountFilePath, jsonBytes, filePerm)
 }
 
+// SaveAuthorizations saves the pre-authorizations of the account, indexed by domain.
+func (s *AccountsStorage) SaveAuthorizations(authorizations map[string]certificate.AuthorizationResource) error {
+	jsonBytes, err := json.MarshalIndent(authorizations, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(s.authorizationsFilePath, jsonBytes, filePerm)
+}
+
+// LoadAuthorizations loads the pre-authorizations of the account, indexed by domain.
+func (s *AccountsStorage) LoadAuthorizations() (map[string]certificate.AuthorizationResource, error) {
+	authorizations := make(map[string]certificate.AuthorizationResource)
+
+	fileBytes, err := ioutil.ReadFile(s.authorizationsFilePath)
+	if os.IsNotExist(err) {
+		return authorizations, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(fileBytes, &authorizations)
+	if err != nil {
+		return nil, err
+	}
+
+	return authorizations, nil
+}
+
 func (s *AccountsStorage) LoadAccount(privateKey crypto.PrivateKey) *Account {
 	fileBytes, err := ioutil.ReadFile(s.accountFilePath)
 	if err != nil {
@@ -140,7 +199,7 @@ func (s *AccountsStorage) LoadAccount(privateKey crypto.PrivateKey) *Account {
 	account.key = privateKey
 
 	if account.Registration == nil || account.Registration.Body.Status == "" {
-		reg, err := tryRecoverRegistration(s.ctx, privateKey)
+		reg, err := tryRecoverRegistration(s.ctx, s.server, privateKey)
 		if err != nil {
 			log.Fatalf("Could not load account for %s. Registration is nil: %#v", s.userID, err)
 		}
@@ -155,14 +214,42 @@ func (s *AccountsStorage) LoadAccount(privateKey crypto.PrivateKey) *Account {
 	return &account
 }
 
+// GetPrivateKeyPath returns the path of the private key of the account.
+func (s *AccountsStorage) GetPrivateKeyPath() string {
+	return filepath.Join(s.keysPath, s.userID+".key")
+}
+
+// SavePrivateKey saves the private key of the account (encrypted if the keys encryption is enabled).
+func (s *AccountsStorage) SavePrivateKey(privateKey crypto.PrivateKey) error {
+	if err := createNonExistingFolder(s.keysPath); err != nil {
+		return err
+	}
+
+	keyBytes, err := s.keysEncryption.encrypt(privateKey)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(s.GetPrivateKeyPath(), keyBytes, filePerm)
+}
+
 func (s *AccountsStorage) GetPrivateKey(keyType certcrypto.KeyType) crypto.PrivateKey {
-	accKeyPath := filepath.Join(s.keysPath, s.userID+".key")
+	if keyURI := s.getKeyURI(); keyURI != "" {
+		privateKey, err := loadKeyReference(keyURI)
+		if err != nil {
+			log.Fatalf("Could not load the private key %s for account %s: %v", keyURI, s.userID, err)
+		}
+
+		return privateKey
+	}
+
+	accKeyPath := s.GetPrivateKeyPath()
 
 	if _, err := os.Stat(accKeyPath); os.IsNotExist(err) {
 		log.Printf("No key found for account %s. Generating a %s key.", s.userID, keyType)
 		s.createKeysFolder()
 
-		privateKey, err := generatePrivateKey(accKeyPath, keyType)
+		privateKey, err := generatePrivateKey(accKeyPath, keyType, s.keysEncryption)
 		if err != nil {
 			log.Fatalf("Could not generate RSA private account key for account %s: %v", s.userID, err)
 		}
@@ -171,7 +258,7 @@ func (s *AccountsStorage) GetPrivateKey(keyType certcrypto.KeyType) crypto.Priva
 		return privateKey
 	}
 
-	privateKey, err := loadPrivateKey(accKeyPath)
+	privateKey, err := loadPrivateKey(accKeyPath, s.keysEncryption)
 	if err != nil {
 		log.Fatalf("Could not load RSA private key from file %s: %v", accKeyPath, err)
 	}
@@ -179,26 +266,52 @@ func (s *AccountsStorage) GetPrivateKey(keyType certcrypto.KeyType) crypto.Priva
 	return privateKey
 }
 
+// readPrivateKey reads the stored private key of the account, or loads the private key referenced by the account.
+func (s *AccountsStorage) readPrivateKey() (crypto.PrivateKey, error) {
+	if keyURI := s.getKeyURI(); keyURI != "" {
+		return loadKeyReference(keyURI)
+	}
+
+	return loadPrivateKey(s.GetPrivateKeyPath(), s.keysEncryption)
+}
+
+// getKeyURI returns the reference of the account key: the "account-key" option, or the reference stored in the account file.
+func (s *AccountsStorage) getKeyURI() string {
+	if s.keyURI != "" {
+		return s.keyURI
+	}
+
+	fileBytes, err := ioutil.ReadFile(s.accountFilePath)
+	if err != nil {
+		return ""
+	}
+
+	var account Account
+	if err = json.Unmarshal(fileBytes, &account); err != nil {
+		return ""
+	}
+
+	return account.KeyURI
+}
+
 func (s *AccountsStorage) createKeysFolder() {
 	if err := createNonExistingFolder(s.keysPath); err != nil {
 		log.Fatalf("Could not check/create directory for account %s: %v", s.userID, err)
 	}
 }
 
-func generatePrivateKey(file string, keyType certcrypto.KeyType) (crypto.PrivateKey, error) {
+func generatePrivateKey(file string, keyType certcrypto.KeyType, encryption *keysEncryption) (crypto.PrivateKey, error) {
 	privateKey, err := certcrypto.GeneratePrivateKey(keyType)
 	if err != nil {
 		return nil, err
 	}
 
-	certOut, err := os.Create(file)
+	keyBytes, err := encryption.encrypt(privateKey)
 	if err != nil {
 		return nil, err
 	}
-	defer certOut.Close()
 
-	pemKey := certcrypto.PEMBlock(privateKey)
-	err = pem.Encode(certOut, pemKey)
+	err = ioutil.WriteFile(file, keyBytes, filePerm)
 	if err != nil {
 		return nil, err
 	}
@@ -206,28 +319,19 @@ func generatePrivateKey(file string, keyType certcrypto.KeyType) (crypto.Private
 	return privateKey, nil
 }
 
-func loadPrivateKey(file string) (crypto.PrivateKey, error) {
+func loadPrivateKey(file string, encryption *keysEncryption) (crypto.PrivateKey, error) {
 	keyBytes, err := ioutil.ReadFile(file)
 	if err != nil {
 		return nil, err
 	}
 
-	keyBlock, _ := pem.Decode(keyBytes)
-
-	switch keyBlock.Type {
-	case "RSA PRIVATE KEY":
-		return x509.ParsePKCS1PrivateKey(keyBlock.Bytes)
-	case "EC PRIVATE KEY":
-		return x509.ParseECPrivateKey(keyBlock.Bytes)
-	}
-
-	return nil, errors.New("unknown private key type")
+	return encryption.decrypt(keyBytes)
 }
 
-func tryRecoverRegistration(ctx *cli.Context, privateKey crypto.PrivateKey) (*registration.Resource, error) {
+func tryRecoverRegistration(ctx *cli.Context, server string, privateKey crypto.PrivateKey) (*registration.Resource, error) {
 	// couldn't load account but got a key. Try to look the account up.
 	config := lego.NewConfig(&Account{key: privateKey})
-	config.CADirURL = ctx.GlobalString("server")
+	config.CADirURL = server
 	config.UserAgent = fmt.Sprintf("lego-cli/%s", ctx.App.Version)
 
 	client, err := lego.NewClient(config)
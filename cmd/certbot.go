@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"crypto"
+	"crypto/md5" //nolint:gosec // certbot identifies the accounts by the MD5 of the public key.
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-acme/lego/v4/registration"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// The files of a certbot account: <config-dir>/accounts/<server host and path>/<account ID>/.
+const (
+	certbotAccountsFolderName   = "accounts"
+	certbotMetaFileName         = "meta.json"
+	certbotPrivateKeyFileName   = "private_key.json"
+	certbotRegistrationFileName = "regr.json"
+)
+
+// certbotMeta the content of the meta.json file of a certbot account.
+type certbotMeta struct {
+	CreationDate  string `json:"creation_dt"`
+	CreationHost  string `json:"creation_host"`
+	RegisterToEFF *bool  `json:"register_to_eff"`
+}
+
+// certbotAccountsPath returns the directory of the certbot accounts of a CA server.
+func certbotAccountsPath(configDir, server string) (string, error) {
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(configDir, certbotAccountsFolderName, filepath.FromSlash(serverURL.Host+serverURL.Path)), nil
+}
+
+// certbotAccountID returns the ID of a certbot account: the MD5 hash of the PEM encoded public key (SubjectPublicKeyInfo).
+// certbot refuses to load an account when the name of its directory is not this ID.
+func certbotAccountID(privateKey crypto.PrivateKey) (string, error) {
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return "", fmt.Errorf("unsupported private key type: %T", privateKey)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return "", err
+	}
+
+	sum := md5.Sum(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})) //nolint:gosec // certbot identifies the accounts by the MD5 of the public key.
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// findCertbotAccount returns the directory of the certbot account of a CA server.
+// The path can be the certbot configuration directory (ex: /etc/letsencrypt) or the account directory.
+func findCertbotAccount(path, server string) (string, error) {
+	if _, err := os.Stat(filepath.Join(path, certbotRegistrationFileName)); err == nil {
+		return path, nil
+	}
+
+	accountsPath, err := certbotAccountsPath(path, server)
+	if err != nil {
+		return "", err
+	}
+
+	matches, err := filepath.Glob(filepath.Join(accountsPath, "*", certbotRegistrationFileName))
+	if err != nil {
+		return "", err
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no certbot account found in %s", accountsPath)
+	case 1:
+		return filepath.Dir(matches[0]), nil
+	default:
+		return "", fmt.Errorf("several certbot accounts found in %s, use the path of the account directory", accountsPath)
+	}
+}
+
+// readCertbotAccount reads the registration and the private key of a certbot account directory.
+func readCertbotAccount(accountPath string) (*registration.Resource, crypto.PrivateKey, error) {
+	regBytes, err := ioutil.ReadFile(filepath.Join(accountPath, certbotRegistrationFileName))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var reg registration.Resource
+	err = json.Unmarshal(regBytes, &reg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", certbotRegistrationFileName, err)
+	}
+
+	if reg.URI == "" {
+		return nil, nil, fmt.Errorf("%s: the account URI is missing", certbotRegistrationFileName)
+	}
+
+	keyBytes, err := ioutil.ReadFile(filepath.Join(accountPath, certbotPrivateKeyFileName))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var jwk jose.JSONWebKey
+	err = jwk.UnmarshalJSON(keyBytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", certbotPrivateKeyFileName, err)
+	}
+
+	if jwk.IsPublic() {
+		return nil, nil, fmt.Errorf("%s: not a private key", certbotPrivateKeyFileName)
+	}
+
+	return &reg, jwk.Key, nil
+}
+
+// writeCertbotAccount writes a certbot account directory in the accounts directory of a CA server,
+// and returns the path of the account directory.
+func writeCertbotAccount(accountsPath string, reg *registration.Resource, privateKey crypto.PrivateKey) (string, error) {
+	if reg == nil || reg.URI == "" {
+		return "", errors.New("the account is not registered")
+	}
+
+	id, err := certbotAccountID(privateKey)
+	if err != nil {
+		return "", err
+	}
+
+	keyBytes, err := jose.JSONWebKey{Key: privateKey}.MarshalJSON()
+	if err != nil {
+		return "", err
+	}
+
+	regBytes, err := json.Marshal(reg)
+	if err != nil {
+		return "", err
+	}
+
+	hostname, _ := os.Hostname()
+
+	metaBytes, err := json.Marshal(certbotMeta{
+		CreationDate: time.Now().UTC().Format(time.RFC3339),
+		CreationHost: hostname,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	accountPath := filepath.Join(accountsPath, id)
+
+	err = createNonExistingFolder(accountPath)
+	if err != nil {
+		return "", err
+	}
+
+	files := map[string][]byte{
+		certbotMetaFileName:         metaBytes,
+		certbotRegistrationFileName: regBytes,
+		certbotPrivateKeyFileName:   keyBytes,
+	}
+
+	for name, content := range files {
+		err = ioutil.WriteFile(filepath.Join(accountPath, name), content, filePerm)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return accountPath, nil
+}
+
+// emailFromContacts returns the first email address of the contacts of an account.
+func emailFromContacts(contacts []string) string {
+	for _, contact := range contacts {
+		if strings.HasPrefix(contact, "mailto:") {
+			return strings.TrimPrefix(contact, "mailto:")
+		}
+	}
+
+	return ""
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bytes"
+	"crypto"
 	"crypto/x509"
 	"encoding/json"
 	"io/ioutil"
@@ -21,6 +22,8 @@ import (
 const (
 	baseCertificatesFolderName = "certificates"
 	baseArchivesFolderName     = "archives"
+	baseLiveFolderName         = "live"
+	baseVersionsFolderName     = "versions"
 )
 
 // CertificatesStorage a certificates storage.
@@ -37,20 +40,50 @@ const (
 //          │      └── archived certificates directory
 //          └── "path" option
 //
+// livePath ("versioned" option):
+//
+//     ./.lego/certificates/live/
+//          │      │        └── links to the current version of each certificate (live/<domain> -> ../versions/<domain>/<version>)
+//          │      └── root certificates directory
+//          └── "path" option
+//
+// versionsPath ("versioned" option):
+//
+//     ./.lego/certificates/versions/
+//          │      │        └── one directory per issuance (versions/<domain>/<version>/)
+//          │      └── root certificates directory
+//          └── "path" option
+//
 type CertificatesStorage struct {
-	rootPath    string
-	archivePath string
-	pem         bool
-	filename    string // Deprecated
+	rootPath       string
+	archivePath    string
+	livePath       string
+	versionsPath   string
+	pem            bool
+	pfx            bool
+	pfxPassword    string
+	pfxProfile     certcrypto.PFXProfile
+	versioned      bool
+	keysEncryption *keysEncryption
+	filename       string // Deprecated
 }
 
 // NewCertificatesStorage create a new certificates storage.
 func NewCertificatesStorage(ctx *cli.Context) *CertificatesStorage {
+	rootPath := filepath.Join(ctx.GlobalString("path"), baseCertificatesFolderName)
+
 	return &CertificatesStorage{
-		rootPath:    filepath.Join(ctx.GlobalString("path"), baseCertificatesFolderName),
-		archivePath: filepath.Join(ctx.GlobalString("path"), baseArchivesFolderName),
-		pem:         ctx.GlobalBool("pem"),
-		filename:    ctx.GlobalString("filename"),
+		rootPath:       rootPath,
+		archivePath:    filepath.Join(ctx.GlobalString("path"), baseArchivesFolderName),
+		livePath:       filepath.Join(rootPath, baseLiveFolderName),
+		versionsPath:   filepath.Join(rootPath, baseVersionsFolderName),
+		pem:            ctx.GlobalBool("pem"),
+		pfx:            ctx.GlobalBool("pfx"),
+		pfxPassword:    ctx.GlobalString("pfx.password"),
+		pfxProfile:     certcrypto.PFXProfile(ctx.GlobalString("pfx.profile")),
+		versioned:      ctx.GlobalBool("versioned"),
+		keysEncryption: newKeysEncryption(ctx),
+		filename:       ctx.GlobalString("filename"),
 	}
 }
 
@@ -75,36 +108,77 @@ func (s *CertificatesStorage) GetRootPath() string {
 func (s *CertificatesStorage) SaveResource(certRes *certificate.Resource) {
 	domain := certRes.Domain
 
+	writeFile := s.WriteFile
+
+	var version *certificateVersion
+	if s.versioned {
+		var err error
+		version, err = s.newVersion(domain)
+		if err != nil {
+			log.Fatalf("Unable to create a new version for domain %s\n\t%v", domain, err)
+		}
+
+		writeFile = version.WriteFile
+	}
+
 	// We store the certificate, private key and metadata in different files
 	// as web servers would not be able to work with a combined file.
-	err := s.WriteFile(domain, ".crt", certRes.Certificate)
+	err := writeFile(domain, ".crt", certRes.Certificate)
 	if err != nil {
 		log.Fatalf("Unable to save Certificate for domain %s\n\t%v", domain, err)
 	}
 
 	if certRes.IssuerCertificate != nil {
-		err = s.WriteFile(domain, ".issuer.crt", certRes.IssuerCertificate)
+		err = writeFile(domain, ".issuer.crt", certRes.IssuerCertificate)
 		if err != nil {
 			log.Fatalf("Unable to save IssuerCertificate for domain %s\n\t%v", domain, err)
 		}
 	}
 
+	if !s.versioned {
+		err = s.removeAlternateChains(domain)
+		if err != nil {
+			log.Fatalf("Unable to remove the previous alternate chains for domain %s\n\t%v", domain, err)
+		}
+	}
+
+	// the alternate chains offered by the CA (ex: for a deploy hook).
+	for i, chain := range certRes.AlternateChains {
+		err = writeFile(domain, alternateChainFileExtension(i+1), encodeAlternateChain(chain))
+		if err != nil {
+			log.Fatalf("Unable to save the alternate chain %s for domain %s\n\t%v", chain.URL, domain, err)
+		}
+	}
+
 	if certRes.PrivateKey != nil {
+		// the stored key can be encrypted.
+		keyBytes, errE := s.keysEncryption.encryptPEM(certRes.PrivateKey)
+		if errE != nil {
+			log.Fatalf("Unable to encrypt PrivateKey for domain %s\n\t%v", domain, errE)
+		}
+
 		// if we were given a CSR, we don't know the private key
-		err = s.WriteFile(domain, ".key", certRes.PrivateKey)
+		err = writeFile(domain, ".key", keyBytes)
 		if err != nil {
 			log.Fatalf("Unable to save PrivateKey for domain %s\n\t%v", domain, err)
 		}
 
 		if s.pem {
-			err = s.WriteFile(domain, ".pem", bytes.Join([][]byte{certRes.Certificate, certRes.PrivateKey}, nil))
+			err = writeFile(domain, ".pem", bytes.Join([][]byte{certRes.Certificate, keyBytes}, nil))
 			if err != nil {
 				log.Fatalf("Unable to save Certificate and PrivateKey in .pem for domain %s\n\t%v", domain, err)
 			}
 		}
-	} else if s.pem {
-		// we don't have the private key; can't write the .pem file
-		log.Fatalf("Unable to save pem without private key for domain %s\n\t%v; are you using a CSR?", domain, err)
+
+		if s.pfx {
+			err = writeFile(domain, ".pfx", s.encodePFX(certRes))
+			if err != nil {
+				log.Fatalf("Unable to save Certificate and PrivateKey in .pfx for domain %s\n\t%v", domain, err)
+			}
+		}
+	} else if s.pem || s.pfx {
+		// we don't have the private key; can't write the .pem or .pfx file
+		log.Fatalf("Unable to save pem or pfx without private key for domain %s\n\t%v; are you using a CSR?", domain, err)
 	}
 
 	jsonBytes, err := json.MarshalIndent(certRes, "", "\t")
@@ -112,10 +186,17 @@ func (s *CertificatesStorage) SaveResource(certRes *certificate.Resource) {
 		log.Fatalf("Unable to marshal CertResource for domain %s\n\t%v", domain, err)
 	}
 
-	err = s.WriteFile(domain, ".json", jsonBytes)
+	err = writeFile(domain, ".json", jsonBytes)
 	if err != nil {
 		log.Fatalf("Unable to save CertResource for domain %s\n\t%v", domain, err)
 	}
+
+	if version != nil {
+		err = version.commit()
+		if err != nil {
+			log.Fatalf("Unable to activate the new version for domain %s\n\t%v", domain, err)
+		}
+	}
 }
 
 func (s *CertificatesStorage) ReadResource(domain string) certificate.Resource {
@@ -147,11 +228,57 @@ func (s *CertificatesStorage) ReadFile(domain, extension string) ([]byte, error)
 	return ioutil.ReadFile(s.GetFileName(domain, extension))
 }
 
+func (s *CertificatesStorage) encodePFX(certRes *certificate.Resource) []byte {
+	domain := certRes.Domain
+
+	privateKey, err := certcrypto.ParsePEMPrivateKey(certRes.PrivateKey)
+	if err != nil {
+		log.Fatalf("Unable to parse PrivateKey for domain %s\n\t%v", domain, err)
+	}
+
+	certificates, err := certcrypto.ParsePEMBundle(certRes.Certificate)
+	if err != nil {
+		log.Fatalf("Unable to parse Certificate for domain %s\n\t%v", domain, err)
+	}
+
+	// the certificate is not bundled: the issuer is stored separately.
+	if len(certificates) == 1 && certRes.IssuerCertificate != nil {
+		issuers, errP := certcrypto.ParsePEMBundle(certRes.IssuerCertificate)
+		if errP != nil {
+			log.Fatalf("Unable to parse IssuerCertificate for domain %s\n\t%v", domain, errP)
+		}
+
+		certificates = append(certificates, issuers...)
+	}
+
+	pfxBytes, err := certcrypto.EncodePFX(privateKey, certificates, s.pfxPassword, s.pfxProfile)
+	if err != nil {
+		log.Fatalf("Unable to encode Certificate and PrivateKey in .pfx for domain %s\n\t%v", domain, err)
+	}
+
+	return pfxBytes
+}
+
 func (s *CertificatesStorage) GetFileName(domain, extension string) string {
 	filename := sanitizedDomain(domain) + extension
+
+	if s.versioned {
+		return filepath.Join(s.livePath, sanitizedDomain(domain), filename)
+	}
+
 	return filepath.Join(s.rootPath, filename)
 }
 
+// ReadPrivateKey reads the private key of a certificate, the key is decrypted if needed.
+func (s *CertificatesStorage) ReadPrivateKey(domain string) (crypto.PrivateKey, error) {
+	keyBytes, err := s.ReadFile(domain, ".key")
+	if err != nil {
+		return nil, err
+	}
+
+	return s.keysEncryption.decrypt(keyBytes)
+}
+
 func (s *CertificatesStorage) ReadCertificate(domain, extension string) ([]*x509.Certificate, error) {
 	content, err := s.ReadFile(domain, extension)
 	if err != nil {
@@ -176,6 +303,11 @@ func (s *CertificatesStorage) WriteFile(domain, extension string, data []byte) e
 }
 
 func (s *CertificatesStorage) MoveToArchive(domain string) error {
+	if s.versioned {
+		// the versions are kept, only the live link is removed.
+		return os.Remove(filepath.Join(s.livePath, sanitizedDomain(domain)))
+	}
+
 	matches, err := filepath.Glob(filepath.Join(s.rootPath, sanitizedDomain(domain)+".*"))
 	if err != nil {
 		return err
@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const versionLayout = "20060102150405"
+
+// certificateVersion a version of a certificate being written.
+// The files are written in a temporary directory,
+// the directory is renamed and the live link updated only when all the files are written.
+type certificateVersion struct {
+	storage *CertificatesStorage
+	domain  string
+	name    string
+	tmpPath string
+}
+
+func (s *CertificatesStorage) newVersion(domain string) (*certificateVersion, error) {
+	domainPath := filepath.Join(s.versionsPath, sanitizedDomain(domain))
+
+	err := createNonExistingFolder(domainPath)
+	if err != nil {
+		return nil, err
+	}
+
+	base := time.Now().UTC().Format(versionLayout)
+
+	name := base
+	for i := 1; existsPath(filepath.Join(domainPath, name)); i++ {
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+
+	tmpPath, err := ioutil.TempDir(domainPath, "."+name+"-")
+	if err != nil {
+		return nil, err
+	}
+
+	return &certificateVersion{storage: s, domain: domain, name: name, tmpPath: tmpPath}, nil
+}
+
+// WriteFile writes and syncs a file of the version.
+func (v *certificateVersion) WriteFile(domain, extension string, data []byte) error {
+	return writeFileSync(filepath.Join(v.tmpPath, sanitizedDomain(domain)+extension), data)
+}
+
+// commit moves the version to its final directory, then points the live link to it.
+func (v *certificateVersion) commit() error {
+	err := syncDir(v.tmpPath)
+	if err != nil {
+		return err
+	}
+
+	domainPath := filepath.Join(v.storage.versionsPath, sanitizedDomain(v.domain))
+
+	err = os.Rename(v.tmpPath, filepath.Join(domainPath, v.name))
+	if err != nil {
+		return err
+	}
+
+	err = syncDir(domainPath)
+	if err != nil {
+		return err
+	}
+
+	return v.storage.SetLiveVersion(v.domain, v.name)
+}
+
+// ListVersionedDomains returns the domains with at least one version.
+func (s *CertificatesStorage) ListVersionedDomains() ([]string, error) {
+	return listDirectories(s.versionsPath)
+}
+
+// ListVersions returns the versions of the certificate of a domain, the newest first.
+func (s *CertificatesStorage) ListVersions(domain string) ([]string, error) {
+	versions, err := listDirectories(filepath.Join(s.versionsPath, sanitizedDomain(domain)))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Sort(sort.Reverse(sort.StringSlice(versions)))
+
+	return versions, nil
+}
+
+// GetLiveVersion returns the version used by the live link of a domain.
+func (s *CertificatesStorage) GetLiveVersion(domain string) (string, error) {
+	target, err := os.Readlink(filepath.Join(s.livePath, sanitizedDomain(domain)))
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Base(target), nil
+}
+
+// SetLiveVersion atomically points the live link of a domain to a version.
+func (s *CertificatesStorage) SetLiveVersion(domain, version string) error {
+	safeDomain := sanitizedDomain(domain)
+
+	if _, err := os.Stat(s.GetVersionFileName(domain, version, ".json")); err != nil {
+		return fmt.Errorf("unknown version %q: %w", version, err)
+	}
+
+	err := createNonExistingFolder(s.livePath)
+	if err != nil {
+		return err
+	}
+
+	// the link is relative: the storage can be moved.
+	target := filepath.Join("..", baseVersionsFolderName, safeDomain, version)
+
+	tmpLink := filepath.Join(s.livePath, "."+safeDomain+".tmp")
+
+	err = os.Remove(tmpLink)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	err = os.Symlink(target, tmpLink)
+	if err != nil {
+		return err
+	}
+
+	// the rename of a link over another link is atomic.
+	err = os.Rename(tmpLink, filepath.Join(s.livePath, safeDomain))
+	if err != nil {
+		return err
+	}
+
+	return syncDir(s.livePath)
+}
+
+// GetVersionFileName returns the path of a file of a version.
+func (s *CertificatesStorage) GetVersionFileName(domain, version, extension string) string {
+	safeDomain := sanitizedDomain(domain)
+	return filepath.Join(s.versionsPath, safeDomain, version, safeDomain+extension)
+}
+
+// GetCertificateFiles returns the paths of the current certificates (.crt files).
+func (s *CertificatesStorage) GetCertificateFiles() ([]string, error) {
+	if s.versioned {
+		return filepath.Glob(filepath.Join(s.livePath, "*", "*.crt"))
+	}
+
+	return filepath.Glob(filepath.Join(s.rootPath, "*.crt"))
+}
+
+func listDirectories(path string) ([]string, error) {
+	entries, err := ioutil.ReadDir(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		// ignores the temporary directories.
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			names = append(names, entry.Name())
+		}
+	}
+
+	return names, nil
+}
+
+func existsPath(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func writeFileSync(filename string, data []byte) error {
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, filePerm)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(data)
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	err = file.Sync()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+func syncDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = dir.Close() }()
+
+	return dir.Sync()
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCertificatesStorage_versioned(t *testing.T) {
+	rootPath := t.TempDir()
+
+	storage := &CertificatesStorage{
+		rootPath:       rootPath,
+		livePath:       filepath.Join(rootPath, baseLiveFolderName),
+		versionsPath:   filepath.Join(rootPath, baseVersionsFolderName),
+		versioned:      true,
+		keysEncryption: &keysEncryption{},
+	}
+
+	storage.SaveResource(&certificate.Resource{Domain: "*.example.com", Certificate: []byte("cert1"), PrivateKey: []byte("key1")})
+	storage.SaveResource(&certificate.Resource{Domain: "*.example.com", Certificate: []byte("cert2"), PrivateKey: []byte("key2")})
+
+	versions, err := storage.ListVersions("*.example.com")
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+
+	live, err := storage.GetLiveVersion("*.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, versions[0], live)
+
+	assertFileContent(t, storage.GetFileName("*.example.com", ".crt"), "cert2")
+	assertFileContent(t, storage.GetFileName("*.example.com", ".key"), "key2")
+
+	err = storage.SetLiveVersion("*.example.com", versions[1])
+	require.NoError(t, err)
+
+	assertFileContent(t, storage.GetFileName("*.example.com", ".crt"), "cert1")
+	assertFileContent(t, storage.GetFileName("*.example.com", ".key"), "key1")
+
+	err = storage.SetLiveVersion("*.example.com", "unknown")
+	require.Error(t, err)
+
+	domains, err := storage.ListVersionedDomains()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"_.example.com"}, domains)
+}
+
+func assertFileContent(t *testing.T, filename, expected string) {
+	t.Helper()
+
+	content, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+
+	assert.Equal(t, expected, string(content))
+}
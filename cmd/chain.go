@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/go-acme/lego/v4/log"
+	"github.com/urfave/cli"
+)
+
+// The extension of the alternate chains files: <domain>.alternate-<n>.chain.
+const alternateChainExtension = ".chain"
+
+// createPreferredChainFlags the flags related to the selection of the certificate chain.
+func createPreferredChainFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringSliceFlag{
+			Name:  "preferred-chain.fingerprint",
+			Usage: "If the CA offers multiple certificate chains, prefer the chain containing a certificate with this SHA-256 fingerprint (hex). Can be specified multiple times.",
+		},
+		cli.StringSliceFlag{
+			Name:  "preferred-chain.ski",
+			Usage: "If the CA offers multiple certificate chains, prefer the chain containing a certificate with this subject key ID (hex). Can be specified multiple times.",
+		},
+		cli.BoolFlag{
+			Name:  "preferred-chain.shortest",
+			Usage: "If the CA offers multiple certificate chains, prefer the shortest chain.",
+		},
+		cli.StringFlag{
+			Name:  "preferred-chain.roots",
+			Usage: "If the CA offers multiple certificate chains, prefer the chains verifying against the root certificates of this PEM file.",
+		},
+	}
+}
+
+// getChainPolicy the policy used to select the certificate chain.
+func getChainPolicy(ctx *cli.Context) certificate.ChainPolicy {
+	policy := certificate.ChainPolicy{
+		Fingerprints:  ctx.StringSlice("preferred-chain.fingerprint"),
+		SubjectKeyIDs: ctx.StringSlice("preferred-chain.ski"),
+		Shortest:      ctx.Bool("preferred-chain.shortest"),
+	}
+
+	if filename := ctx.String("preferred-chain.roots"); filename != "" {
+		roots, err := readRootsFile(filename)
+		if err != nil {
+			log.Fatalf("Unable to read the preferred roots: %v", err)
+		}
+
+		policy.Roots = roots
+	}
+
+	err := policy.Validate()
+	if err != nil {
+		log.Fatalf("Invalid preferred chain: %v", err)
+	}
+
+	return policy
+}
+
+func readRootsFile(filename string) (*x509.CertPool, error) {
+	raw, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	certificates, err := certcrypto.ParsePEMBundle(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	roots := x509.NewCertPool()
+	for _, cert := range certificates {
+		roots.AddCert(cert)
+	}
+
+	return roots, nil
+}
+
+// alternateChainFileExtension the extension of the file of an alternate chain (index starts at 1).
+func alternateChainFileExtension(index int) string {
+	return fmt.Sprintf(".alternate-%d%s", index, alternateChainExtension)
+}
+
+// encodeAlternateChain returns the full chain (certificate and issuers).
+func encodeAlternateChain(chain certificate.Chain) []byte {
+	certificates, err := certcrypto.ParsePEMBundle(chain.Certificate)
+	if err == nil && len(certificates) > 1 {
+		return chain.Certificate
+	}
+
+	return append(append([]byte{}, chain.Certificate...), chain.IssuerCertificate...)
+}
+
+// removeAlternateChains removes the alternate chains of a previous certificate (flat layout only).
+func (s *CertificatesStorage) removeAlternateChains(domain string) error {
+	baseFileName := sanitizedDomain(domain)
+	if s.filename != "" {
+		baseFileName = s.filename
+	}
+
+	matches, err := filepath.Glob(filepath.Join(s.rootPath, baseFileName+".alternate-*"+alternateChainExtension))
+	if err != nil {
+		return err
+	}
+
+	for _, match := range matches {
+		err = os.Remove(match)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
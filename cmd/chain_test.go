@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCertificatesStorage_SaveResource_alternateChains(t *testing.T) {
+	storage := &CertificatesStorage{rootPath: t.TempDir(), keysEncryption: &keysEncryption{}}
+
+	storage.SaveResource(&certificate.Resource{
+		Domain:      "*.example.com",
+		Certificate: []byte("cert"),
+		AlternateChains: []certificate.Chain{
+			{URL: "https://example.com/cert/1", Certificate: []byte("cert1"), IssuerCertificate: []byte("issuer1")},
+			{URL: "https://example.com/cert/2", Certificate: []byte("cert2"), IssuerCertificate: []byte("issuer2")},
+		},
+	})
+
+	assertFileContent(t, storage.GetFileName("*.example.com", ".alternate-1.chain"), "cert1issuer1")
+	assertFileContent(t, storage.GetFileName("*.example.com", ".alternate-2.chain"), "cert2issuer2")
+
+	// the alternate chains of the previous certificate are removed.
+	storage.SaveResource(&certificate.Resource{
+		Domain:      "*.example.com",
+		Certificate: []byte("cert"),
+		AlternateChains: []certificate.Chain{
+			{URL: "https://example.com/cert/3", Certificate: []byte("cert3"), IssuerCertificate: []byte("issuer3")},
+		},
+	})
+
+	assertFileContent(t, storage.GetFileName("*.example.com", ".alternate-1.chain"), "cert3issuer3")
+	assert.NoFileExists(t, storage.GetFileName("*.example.com", ".alternate-2.chain"))
+}
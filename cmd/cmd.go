@@ -4,11 +4,26 @@ import "github.com/urfave/cli"
 
 // CreateCommands Creates all CLI commands.
 func CreateCommands() []cli.Command {
-	return []cli.Command{
+	commands := []cli.Command{
 		createRun(),
 		createRevoke(),
 		createRenew(),
 		createDNSHelp(),
 		createList(),
+		createAccounts(),
+		createArchive(),
+		createKeys(),
+		createConfig(),
+		createOCSP(),
+		createAuthorize(),
+		createDNSPersist(),
+		createDirectory(),
+		createOrders(),
 	}
+
+	for i, command := range commands {
+		commands[i] = withConfiguration(command)
+	}
+
+	return commands
 }
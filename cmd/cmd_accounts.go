@@ -0,0 +1,490 @@
+package cmd
+
+import (
+	"bufio"
+	"crypto"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-acme/lego/v4/acme"
+	"github.com/go-acme/lego/v4/log"
+	"github.com/go-acme/lego/v4/registration"
+	"github.com/urfave/cli"
+)
+
+// The formats of the imported/exported accounts.
+const (
+	accountFormatLego    = "lego"
+	accountFormatCertbot = "certbot"
+)
+
+func createAccounts() cli.Command {
+	formatFlag := cli.StringFlag{
+		Name:  "format",
+		Usage: "The format of the account: 'lego' (the --path directory of another lego) or 'certbot' (the certbot configuration directory, ex: /etc/letsencrypt).",
+		Value: accountFormatLego,
+	}
+
+	forceFlag := cli.BoolFlag{
+		Name:  "force",
+		Usage: "Overwrite the existing account.",
+	}
+
+	return cli.Command{
+		Name:  "accounts",
+		Usage: "Manage the accounts (the account is defined by --email and --server).",
+		Subcommands: []cli.Command{
+			{
+				Name:   "list",
+				Usage:  "Display the accounts of all the servers.",
+				Action: listAccount,
+			},
+			{
+				Name:   "show",
+				Usage:  "Display the account.",
+				Action: accountsShow,
+				Flags: []cli.Flag{
+					cli.BoolFlag{
+						Name:  "remote",
+						Usage: "Fetch the current state of the account from the server.",
+					},
+				},
+			},
+			{
+				Name:   "update-contact",
+				Usage:  "Update the contact email of the account (the local account is renamed).",
+				Action: accountsUpdateContact,
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "new-email",
+						Usage: "The new email of the account.",
+					},
+				},
+			},
+			{
+				Name:   "deactivate",
+				Usage:  "Deactivate the account on the server. This cannot be undone.",
+				Action: accountsDeactivate,
+				Flags: []cli.Flag{
+					cli.BoolFlag{
+						Name:  "yes, y",
+						Usage: "Do not ask for a confirmation.",
+					},
+				},
+			},
+			{
+				Name:   "recover",
+				Usage:  "Rebuild the account file from the private key of the account (the account must exist on the server).",
+				Action: accountsRecover,
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "key",
+						Usage: "The PEM file of the private key of the account. By default, the stored key of the account is used.",
+					},
+					forceFlag,
+				},
+			},
+			{
+				Name:   "import",
+				Usage:  "Import an account from another lego directory or from certbot.",
+				Action: accountsImport,
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "from",
+						Usage: "The path of the account to import: a lego directory (--path), a certbot configuration directory, or a certbot account directory.",
+					},
+					formatFlag,
+					forceFlag,
+				},
+			},
+			{
+				Name:   "export",
+				Usage:  "Export the account to another lego directory or to certbot.",
+				Action: accountsExport,
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "to",
+						Usage: "The destination: a lego directory (--path) or a certbot configuration directory.",
+					},
+					formatFlag,
+					forceFlag,
+				},
+			},
+		},
+	}
+}
+
+func accountsShow(ctx *cli.Context) error {
+	accountsStorage, account := loadExistingAccount(ctx)
+
+	if ctx.Bool("remote") {
+		client := newClient(ctx, account, getKeyType(ctx), accountsStorage.GetServer())
+
+		reg, err := client.Registration.QueryRegistration()
+		if err != nil {
+			return fmt.Errorf("could not query the account: %w", err)
+		}
+
+		account.Registration = reg
+
+		err = accountsStorage.Save(account)
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Println("Email:", account.Email)
+	fmt.Println("Server:", accountsStorage.GetServer())
+	fmt.Println("URI:", account.Registration.URI)
+	fmt.Println("Status:", account.Registration.Body.Status)
+	fmt.Println("Contacts:", strings.Join(account.Registration.Body.Contact, ", "))
+	if account.KeyURI != "" {
+		fmt.Println("Key:", account.KeyURI)
+	} else {
+		fmt.Println("Key:", accountsStorage.GetPrivateKeyPath())
+	}
+	fmt.Println("Path:", accountsStorage.GetRootUserPath())
+
+	return nil
+}
+
+func accountsUpdateContact(ctx *cli.Context) error {
+	newEmail := ctx.String("new-email")
+	if newEmail == "" {
+		log.Fatal("The new email is required: use --new-email.")
+	}
+
+	accountsStorage, account := loadExistingAccount(ctx)
+
+	if newEmail == account.Email {
+		log.Printf("The email of the account is already %s.", newEmail)
+		return nil
+	}
+
+	newStorage := accountsStorageFor(ctx, newEmail)
+	if newStorage.ExistsAccountFilePath() {
+		log.Fatalf("An account already exists for %s.", newEmail)
+	}
+
+	updated := &Account{Email: newEmail, Registration: account.Registration, KeyURI: account.KeyURI, key: account.key}
+
+	client := newClient(ctx, updated, getKeyType(ctx), accountsStorage.GetServer())
+
+	reg, err := client.Registration.UpdateRegistration(registration.RegisterOptions{})
+	if err != nil {
+		return fmt.Errorf("could not update the account: %w", err)
+	}
+
+	updated.Registration = reg
+
+	err = os.Rename(accountsStorage.GetRootUserPath(), newStorage.GetRootUserPath())
+	if err != nil {
+		return err
+	}
+
+	if account.KeyURI == "" {
+		err = os.Rename(filepath.Join(newStorage.keysPath, filepath.Base(accountsStorage.GetPrivateKeyPath())), newStorage.GetPrivateKeyPath())
+		if err != nil {
+			return err
+		}
+	}
+
+	err = newStorage.Save(updated)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("The contact of the account %s has been updated to %s.", reg.URI, newEmail)
+
+	return nil
+}
+
+func accountsDeactivate(ctx *cli.Context) error {
+	accountsStorage, account := loadExistingAccount(ctx)
+
+	if account.Registration.Body.Status == acme.StatusDeactivated {
+		log.Printf("The account %s is already deactivated.", account.Registration.URI)
+		return nil
+	}
+
+	if !ctx.Bool("yes") && !confirm(fmt.Sprintf("Deactivate the account %s (%s)? This cannot be undone. y/N", account.Email, account.Registration.URI)) {
+		log.Fatal("The account has not been deactivated.")
+	}
+
+	client := newClient(ctx, account, getKeyType(ctx), accountsStorage.GetServer())
+
+	err := client.Registration.DeleteRegistration()
+	if err != nil {
+		return fmt.Errorf("could not deactivate the account: %w", err)
+	}
+
+	account.Registration.Body.Status = acme.StatusDeactivated
+
+	err = accountsStorage.Save(account)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("The account %s has been deactivated.", account.Registration.URI)
+
+	return nil
+}
+
+func accountsRecover(ctx *cli.Context) error {
+	accountsStorage := NewAccountsStorage(ctx)
+
+	if accountsStorage.ExistsAccountFilePath() && !ctx.Bool("force") {
+		log.Fatalf("The account %s already exists, use --force to overwrite it.", accountsStorage.GetUserID())
+	}
+
+	var privateKey crypto.PrivateKey
+	var err error
+
+	if ctx.IsSet("key") {
+		privateKey, err = loadPrivateKey(ctx.String("key"), accountsStorage.keysEncryption)
+	} else {
+		privateKey, err = accountsStorage.readPrivateKey()
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not load the private key: %w", err)
+	}
+
+	reg, err := tryRecoverRegistration(ctx, accountsStorage.GetServer(), privateKey)
+	if err != nil {
+		return fmt.Errorf("could not recover the account: %w", err)
+	}
+
+	err = saveImportedAccount(accountsStorage, &Account{Email: accountsStorage.GetUserID(), Registration: reg, key: privateKey})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("The account %s has been recovered.", reg.URI)
+
+	return nil
+}
+
+func accountsImport(ctx *cli.Context) error {
+	from := ctx.String("from")
+	if from == "" {
+		log.Fatal("The path of the account to import is required: use --from.")
+	}
+
+	switch ctx.String("format") {
+	case accountFormatLego:
+		accountsStorage := NewAccountsStorage(ctx)
+
+		if accountsStorage.ExistsAccountFilePath() && !ctx.Bool("force") {
+			log.Fatalf("The account %s already exists, use --force to overwrite it.", accountsStorage.GetUserID())
+		}
+
+		return copyAccount(accountsStorage.withPath(from), accountsStorage)
+
+	case accountFormatCertbot:
+		accountPath, err := findCertbotAccount(from, ctx.GlobalString("server"))
+		if err != nil {
+			return err
+		}
+
+		reg, privateKey, err := readCertbotAccount(accountPath)
+		if err != nil {
+			return err
+		}
+
+		email := ctx.GlobalString("email")
+		if email == "" {
+			email = emailFromContacts(reg.Body.Contact)
+		}
+
+		if email == "" {
+			log.Fatal("The certbot account has no email, use --email.")
+		}
+
+		accountsStorage := accountsStorageFor(ctx, email)
+
+		if accountsStorage.ExistsAccountFilePath() && !ctx.Bool("force") {
+			log.Fatalf("The account %s already exists, use --force to overwrite it.", email)
+		}
+
+		err = saveImportedAccount(accountsStorage, &Account{Email: email, Registration: reg, key: privateKey})
+		if err != nil {
+			return err
+		}
+
+		log.Printf("The certbot account %s has been imported to %s.", reg.URI, accountsStorage.GetRootUserPath())
+
+		return nil
+
+	default:
+		return fmt.Errorf("unsupported account format: %s", ctx.String("format"))
+	}
+}
+
+func accountsExport(ctx *cli.Context) error {
+	to := ctx.String("to")
+	if to == "" {
+		log.Fatal("The destination is required: use --to.")
+	}
+
+	switch ctx.String("format") {
+	case accountFormatLego:
+		accountsStorage := NewAccountsStorage(ctx)
+		if !accountsStorage.ExistsAccountFilePath() {
+			log.Fatalf("Account %s does not exist for the server %s.", accountsStorage.GetUserID(), accountsStorage.GetServer())
+		}
+
+		destination := accountsStorage.withPath(to)
+
+		if destination.ExistsAccountFilePath() && !ctx.Bool("force") {
+			log.Fatalf("The account %s already exists in %s, use --force to overwrite it.", destination.GetUserID(), to)
+		}
+
+		return copyAccount(accountsStorage, destination)
+
+	case accountFormatCertbot:
+		accountsStorage, account := loadExistingAccount(ctx)
+
+		accountsPath, err := certbotAccountsPath(to, accountsStorage.GetServer())
+		if err != nil {
+			return err
+		}
+
+		id, err := certbotAccountID(account.key)
+		if err != nil {
+			return err
+		}
+
+		if _, err = os.Stat(filepath.Join(accountsPath, id)); err == nil && !ctx.Bool("force") {
+			log.Fatalf("The certbot account %s already exists, use --force to overwrite it.", id)
+		}
+
+		accountPath, err := writeCertbotAccount(accountsPath, account.Registration, account.key)
+		if err != nil {
+			return err
+		}
+
+		log.Printf("The account %s has been exported to %s.", account.Registration.URI, accountPath)
+
+		return nil
+
+	default:
+		return fmt.Errorf("unsupported account format: %s", ctx.String("format"))
+	}
+}
+
+// loadExistingAccount loads the account defined by --email and --server, the account must exist.
+func loadExistingAccount(ctx *cli.Context) (*AccountsStorage, *Account) {
+	accountsStorage := NewAccountsStorage(ctx)
+
+	if !accountsStorage.ExistsAccountFilePath() {
+		log.Fatalf("Account %s does not exist for the server %s.", accountsStorage.GetUserID(), accountsStorage.GetServer())
+	}
+
+	privateKey, err := accountsStorage.readPrivateKey()
+	if err != nil {
+		log.Fatalf("Could not load the private key of the account %s: %v", accountsStorage.GetUserID(), err)
+	}
+
+	return accountsStorage, accountsStorage.LoadAccount(privateKey)
+}
+
+// accountsStorageFor creates the AccountsStorage of another email.
+func accountsStorageFor(ctx *cli.Context, email string) *AccountsStorage {
+	if err := ctx.GlobalSet("email", email); err != nil {
+		log.Fatal(err)
+	}
+
+	return NewAccountsStorage(ctx)
+}
+
+// withPath returns the storage of the same account in another lego directory (--path).
+func (s *AccountsStorage) withPath(path string) *AccountsStorage {
+	rel, err := filepath.Rel(s.rootPath, s.rootUserPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	rootPath := filepath.Join(path, baseAccountsRootFolderName)
+	rootUserPath := filepath.Join(rootPath, rel)
+
+	storage := *s
+	storage.rootPath = rootPath
+	storage.rootUserPath = rootUserPath
+	storage.keysPath = filepath.Join(rootUserPath, baseKeysFolderName)
+	storage.accountFilePath = filepath.Join(rootUserPath, accountFileName)
+	storage.authorizationsFilePath = filepath.Join(rootUserPath, authorizationsFileName)
+
+	return &storage
+}
+
+// copyAccount copies the files of an account (account, private key, and pre-authorizations) between two lego directories.
+func copyAccount(source, destination *AccountsStorage) error {
+	if !source.ExistsAccountFilePath() {
+		return fmt.Errorf("account %s does not exist in %s", source.GetUserID(), source.GetRootPath())
+	}
+
+	err := createNonExistingFolder(destination.keysPath)
+	if err != nil {
+		return err
+	}
+
+	files := map[string]string{
+		source.accountFilePath:        destination.accountFilePath,
+		source.GetPrivateKeyPath():    destination.GetPrivateKeyPath(),
+		source.authorizationsFilePath: destination.authorizationsFilePath,
+	}
+
+	for src, dst := range files {
+		content, err := ioutil.ReadFile(src)
+		if errors.Is(err, os.ErrNotExist) && (src == source.authorizationsFilePath || source.getKeyURI() != "") {
+			// the pre-authorizations are optional, and the key can be stored outside of lego (ex: HSM).
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		err = ioutil.WriteFile(dst, content, filePerm)
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Printf("The account %s has been copied to %s.", source.GetUserID(), destination.GetRootUserPath())
+
+	return nil
+}
+
+// saveImportedAccount saves the private key (unless the key is referenced by the account) and the account file.
+func saveImportedAccount(accountsStorage *AccountsStorage, account *Account) error {
+	if accountsStorage.keyURI == "" {
+		if _, ok := account.key.(crypto.Signer); !ok {
+			return fmt.Errorf("unsupported private key type: %T", account.key)
+		}
+
+		err := accountsStorage.SavePrivateKey(account.key)
+		if err != nil {
+			return err
+		}
+	}
+
+	return accountsStorage.Save(account)
+}
+
+func confirm(message string) bool {
+	fmt.Println(message)
+
+	text, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		log.Fatalf("Could not read from console: %v", err)
+	}
+
+	text = strings.TrimSpace(text)
+
+	return text == "y" || text == "Y"
+}
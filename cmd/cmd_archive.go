@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/log"
+	"github.com/urfave/cli"
+)
+
+func createArchive() cli.Command {
+	return cli.Command{
+		Name:  "archive",
+		Usage: "Manage the versions of the certificates (versioned layout).",
+		Subcommands: []cli.Command{
+			{
+				Name:   "list",
+				Usage:  "Display the versions of the certificates. Use --domains/-d to filter the certificates.",
+				Action: archiveList,
+			},
+			{
+				Name:   "rollback",
+				Usage:  "Point the live certificates of the domains (--domains/-d) to a previous version.",
+				Action: archiveRollback,
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "version",
+						Usage: "The version to use. By default, the version before the current live version.",
+					},
+				},
+			},
+		},
+	}
+}
+
+func archiveList(ctx *cli.Context) error {
+	certsStorage := NewCertificatesStorage(ctx)
+
+	domains := ctx.GlobalStringSlice("domains")
+	if len(domains) == 0 {
+		var err error
+		domains, err = certsStorage.ListVersionedDomains()
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(domains) == 0 {
+		fmt.Println("No versions found.")
+		return nil
+	}
+
+	for _, domain := range domains {
+		versions, err := certsStorage.ListVersions(domain)
+		if err != nil {
+			return err
+		}
+
+		// an error means that there is no live version.
+		live, _ := certsStorage.GetLiveVersion(domain)
+
+		fmt.Println(domain)
+
+		for _, version := range versions {
+			line := "  " + version
+
+			data, err := ioutil.ReadFile(certsStorage.GetVersionFileName(domain, version, ".crt"))
+			if err == nil {
+				certificates, errP := certcrypto.ParsePEMBundle(data)
+				if errP == nil {
+					line += " expires " + certificates[0].NotAfter.String()
+				}
+			}
+
+			if version == live {
+				line += " (live)"
+			}
+
+			fmt.Println(line)
+		}
+
+		fmt.Println()
+	}
+
+	return nil
+}
+
+func archiveRollback(ctx *cli.Context) error {
+	certsStorage := NewCertificatesStorage(ctx)
+
+	domains := ctx.GlobalStringSlice("domains")
+	if len(domains) == 0 {
+		log.Fatal("Please specify --domains/-d")
+	}
+
+	for _, domain := range domains {
+		version := ctx.String("version")
+		if version == "" {
+			version = previousVersion(certsStorage, domain)
+		}
+
+		err := certsStorage.SetLiveVersion(domain, version)
+		if err != nil {
+			log.Fatalf("[%s] Unable to rollback to the version %s: %v", domain, version, err)
+		}
+
+		log.Printf("[%s] The live certificate is now the version %s", domain, version)
+	}
+
+	return nil
+}
+
+// previousVersion returns the version before the current live version.
+func previousVersion(certsStorage *CertificatesStorage, domain string) string {
+	versions, err := certsStorage.ListVersions(domain)
+	if err != nil {
+		log.Fatalf("[%s] Unable to list the versions: %v", domain, err)
+	}
+
+	live, err := certsStorage.GetLiveVersion(domain)
+	if err != nil {
+		log.Fatalf("[%s] Unable to find the live version: %v", domain, err)
+	}
+
+	// the versions are sorted from the newest to the oldest.
+	for i, version := range versions {
+		if version == live && i+1 < len(versions) {
+			return versions[i+1]
+		}
+	}
+
+	log.Fatalf("[%s] No version before the live version %s", domain, live)
+
+	return ""
+}
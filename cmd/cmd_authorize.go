@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"github.com/go-acme/lego/v4/log"
+	"github.com/urfave/cli"
+)
+
+func createAuthorize() cli.Command {
+	return cli.Command{
+		Name:  "authorize",
+		Usage: "Pre-authorize domains (without creating an order), or deactivate pre-authorizations",
+		Before: func(ctx *cli.Context) error {
+			if len(ctx.GlobalStringSlice("domains")) == 0 {
+				log.Fatal("Please specify --domains/-d")
+			}
+			return nil
+		},
+		Action: authorize,
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:  "deactivate",
+				Usage: "Deactivate the stored pre-authorizations of the domains instead of creating new ones.",
+			},
+		},
+	}
+}
+
+func authorize(ctx *cli.Context) error {
+	accountsStorage := NewAccountsStorage(ctx)
+
+	account, client := setup(ctx, accountsStorage)
+
+	if account.Registration == nil {
+		log.Fatalf("Account %s is not registered. Use 'run' to register a new account.\n", account.Email)
+	}
+
+	domains := ctx.GlobalStringSlice("domains")
+
+	if ctx.Bool("deactivate") {
+		err := client.Certificate.DeactivatePreAuthorizations(domains)
+		if err != nil {
+			log.Fatalf("Could not deactivate the authorizations:\n\t%v", err)
+		}
+
+		return nil
+	}
+
+	setupChallenges(ctx, client)
+
+	// the pre-authorizations are stored in the account directory.
+	_, err := client.Certificate.Authorize(domains)
+	if err != nil {
+		log.Fatalf("Could not authorize the domains:\n\t%v", err)
+	}
+
+	return nil
+}
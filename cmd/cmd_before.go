@@ -1,11 +1,24 @@
 package cmd
 
 import (
+	"github.com/go-acme/lego/v4/certcrypto"
 	"github.com/go-acme/lego/v4/log"
 	"github.com/urfave/cli"
 )
 
 func Before(ctx *cli.Context) error {
+	err := setupConfiguration(ctx)
+	if err != nil {
+		log.Fatalf("Could not load the configuration file: %v", err)
+	}
+
+	checkGlobalOptions(ctx)
+
+	return nil
+}
+
+// checkGlobalOptions validates the global options (also done for each certificate section of the configuration file).
+func checkGlobalOptions(ctx *cli.Context) {
 	if ctx.GlobalString("path") == "" {
 		log.Fatal("Could not determine current working directory. Please pass --path.")
 	}
@@ -19,5 +32,28 @@ func Before(ctx *cli.Context) error {
 		log.Fatal("Could not determine current working server. Please pass --server.")
 	}
 
-	return nil
+	switch certcrypto.PFXProfile(ctx.GlobalString("pfx.profile")) {
+	case certcrypto.PFXLegacy, certcrypto.PFXModern:
+	default:
+		log.Fatalf("Invalid PFX profile: %q. Please pass 'legacy' or 'modern' to --pfx.profile.", ctx.GlobalString("pfx.profile"))
+	}
+
+	if ctx.GlobalBool("pfx") && ctx.GlobalString("pfx.password") == "" {
+		log.Fatal("The .pfx file must be protected by a password: please pass --pfx.password (or LEGO_PFX_PASSWORD).")
+	}
+
+	hasPassphrase := ctx.GlobalString("keys.passphrase") != "" || ctx.GlobalString("keys.passphrase-file") != ""
+	hasRecipients := len(ctx.GlobalStringSlice("keys.age-recipient")) > 0
+
+	if hasPassphrase && hasRecipients {
+		log.Fatal("Please specify either a passphrase (--keys.passphrase, --keys.passphrase-file) or age recipients (--keys.age-recipient) to encrypt the private keys, but not both.")
+	}
+
+	if hasRecipients && ctx.GlobalBool("pem") {
+		log.Fatal("The .pem file cannot contain a private key encrypted with age: --pem and --keys.age-recipient cannot be used together.")
+	}
+
+	if hasPassphrase && ctx.GlobalBool("pem") {
+		log.Fatal("The .pem file is used by servers which cannot load an encrypted private key: --pem and --keys.passphrase (or --keys.passphrase-file) cannot be used together.")
+	}
 }
@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v2"
+)
+
+const secretMask = "********"
+
+// The options containing a secret.
+var secretOptions = map[string]bool{
+	"hmac":            true,
+	"keys.passphrase": true,
+	"pfx.password":    true,
+}
+
+func createConfig() cli.Command {
+	return cli.Command{
+		Name:  "config",
+		Usage: "Manage the configuration file (--config).",
+		Subcommands: []cli.Command{
+			{
+				Name:   "dump",
+				Usage:  "Display the effective configuration (command line flags, configuration file, environment variables).",
+				Action: configDump,
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "format",
+						Usage: "The output format: toml or yaml. By default, the format of the configuration file.",
+					},
+					cli.BoolFlag{
+						Name:  "show-secrets",
+						Usage: "Display the secrets (passwords, DNS provider credentials).",
+					},
+				},
+			},
+		},
+	}
+}
+
+func configDump(ctx *cli.Context) error {
+	showSecrets := ctx.Bool("show-secrets")
+
+	section := newConfigSection()
+	if conf := getConfiguration(ctx); conf != nil {
+		var err error
+		section, err = conf.section(ctx.GlobalString("config.certificate"))
+		if err != nil {
+			return err
+		}
+	}
+
+	app := rootApp(ctx)
+
+	dump := map[string]interface{}{}
+
+	for _, f := range app.Flags {
+		name := flagName(f)
+		if configForbiddenOptions[name] {
+			continue
+		}
+
+		value, _ := ctx.GlobalGeneric(name).(flag.Value)
+
+		dump[name] = dumpValue(f, value, showSecrets || !secretOptions[name])
+	}
+
+	for _, command := range app.Commands {
+		if len(command.Flags) == 0 {
+			continue
+		}
+
+		set, err := newFlagSet(command.Flags)
+		if err != nil {
+			return err
+		}
+
+		err = applyConfigOptions(flagSetValues{set}, command.Flags, section.Commands[command.Name], nil)
+		if err != nil {
+			return err
+		}
+
+		options := map[string]interface{}{}
+		for _, f := range command.Flags {
+			name := flagName(f)
+			options[name] = dumpValue(f, set.Lookup(name).Value, true)
+		}
+
+		dump[command.Name] = options
+	}
+
+	if len(section.Providers) > 0 {
+		providers := map[string]interface{}{}
+
+		for code, envs := range section.Providers {
+			values := map[string]string{}
+			for k, v := range envs {
+				values[k] = v
+				if !showSecrets {
+					values[k] = secretMask
+				}
+			}
+
+			providers[code] = values
+		}
+
+		dump[configKeyProviders] = providers
+	}
+
+	format := ctx.String("format")
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(ctx.GlobalString("config"))), ".")
+	}
+
+	switch format {
+	case "yaml", "yml":
+		data, err := yaml.Marshal(dump)
+		if err != nil {
+			return err
+		}
+
+		fmt.Print(string(data))
+
+		return nil
+
+	case "", "toml":
+		return toml.NewEncoder(os.Stdout).Encode(dump)
+
+	default:
+		return fmt.Errorf("unsupported format: %s (toml, yaml)", format)
+	}
+}
+
+// rootApp returns the application (the subcommands are executed by a dedicated application).
+func rootApp(ctx *cli.Context) *cli.App {
+	for ctx.Parent() != nil {
+		ctx = ctx.Parent()
+	}
+
+	return ctx.App
+}
+
+// dumpValue converts the value of a flag to a value of the configuration file.
+func dumpValue(f cli.Flag, value flag.Value, visible bool) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case *cli.StringSlice:
+		return []string(*v)
+	case *cli.IntSlice:
+		return []int(*v)
+	case *cli.Int64Slice:
+		return []int64(*v)
+	}
+
+	raw := value.String()
+
+	switch f.(type) {
+	case cli.BoolFlag, cli.BoolTFlag:
+		b, err := strconv.ParseBool(raw)
+		if err == nil {
+			return b
+		}
+	case cli.IntFlag, cli.Int64Flag:
+		i, err := strconv.ParseInt(raw, 10, 64)
+		if err == nil {
+			return i
+		}
+	}
+
+	if !visible && raw != "" {
+		return secretMask
+	}
+
+	return raw
+}
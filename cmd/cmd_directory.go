@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/go-acme/lego/v4/acme"
+	"github.com/go-acme/lego/v4/log"
+	"github.com/urfave/cli"
+)
+
+func createDirectory() cli.Command {
+	return cli.Command{
+		Name:   "directory",
+		Usage:  "Display the directory of the server (--server): the resources and the meta (terms of service, website, CAA identities, EAB requirement).",
+		Action: directory,
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:  "json",
+				Usage: "Display the directory as JSON (as returned by the server).",
+			},
+		},
+	}
+}
+
+func directory(ctx *cli.Context) error {
+	server := ctx.GlobalString("server")
+
+	raw, err := fetchDirectory(ctx, server)
+	if err != nil {
+		log.Fatalf("Could not get the directory of %s: %v", server, err)
+	}
+
+	if ctx.Bool("json") {
+		var buf bytes.Buffer
+		if err = json.Indent(&buf, raw, "", "  "); err != nil {
+			return err
+		}
+
+		buf.WriteString("\n")
+
+		_, err = buf.WriteTo(os.Stdout)
+
+		return err
+	}
+
+	var dir acme.Directory
+	if err = json.Unmarshal(raw, &dir); err != nil {
+		return fmt.Errorf("invalid directory: %w", err)
+	}
+
+	fmt.Println("Directory:", server)
+	fmt.Println("  New nonce:", dir.NewNonceURL)
+	fmt.Println("  New account:", dir.NewAccountURL)
+	fmt.Println("  New order:", dir.NewOrderURL)
+	if dir.NewAuthzURL != "" {
+		fmt.Println("  New authorization:", dir.NewAuthzURL)
+	}
+	fmt.Println("  Revoke certificate:", dir.RevokeCertURL)
+	fmt.Println("  Key change:", dir.KeyChangeURL)
+
+	fmt.Println("Meta:")
+	fmt.Println("  Terms of service:", dir.Meta.TermsOfService)
+	fmt.Println("  Website:", dir.Meta.Website)
+	fmt.Println("  CAA identities:", strings.Join(dir.Meta.CaaIdentities, ", "))
+	fmt.Println("  External account required:", dir.Meta.ExternalAccountRequired)
+
+	return nil
+}
+
+// fetchDirectory returns the raw directory of the server (no account is required).
+func fetchDirectory(ctx *cli.Context, server string) ([]byte, error) {
+	config := newConfig(ctx, nil, getKeyType(ctx), server)
+
+	req, err := http.NewRequest(http.MethodGet, server, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", config.UserAgent)
+
+	resp, err := config.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%d: %s", resp.StatusCode, string(body))
+	}
+
+	return body, nil
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-acme/lego/v4/challenge"
+	"github.com/go-acme/lego/v4/challenge/dnspersist01"
+	"github.com/go-acme/lego/v4/log"
+	"github.com/urfave/cli"
+)
+
+func createDNSPersist() cli.Command {
+	return cli.Command{
+		Name:  "dns-persist",
+		Usage: "Manage the dns-persist-01 records (persistent validation records).",
+		Subcommands: []cli.Command{
+			{
+				Name:  "setup",
+				Usage: "Print (or create with the DNS provider) the dns-persist-01 records of the domains for the account.",
+				Before: func(ctx *cli.Context) error {
+					if len(ctx.GlobalStringSlice("domains")) == 0 {
+						log.Fatal("Please specify --domains/-d")
+					}
+					if ctx.String("issuer") == "" {
+						log.Fatal("Please specify --issuer")
+					}
+					return nil
+				},
+				Action: dnsPersistSetup,
+				Flags: []cli.Flag{
+					cli.StringFlag{
+						Name:  "issuer",
+						Usage: "The issuer domain name of the CA (ex: letsencrypt.org).",
+					},
+					cli.BoolFlag{
+						Name:  "wildcard",
+						Usage: "Allow the validation of the wildcard of the domains (policy=wildcard). Implied by the wildcard domains.",
+					},
+					cli.StringFlag{
+						Name:  "persist-until",
+						Usage: "The expiration date of the records (RFC3339, ex: 2030-01-01T00:00:00Z). By default, the records don't expire.",
+					},
+					cli.BoolFlag{
+						Name:  "create",
+						Usage: "Create the records with the DNS provider (--dns, the provider must support explicit records: exec, httpreq) instead of printing them.",
+					},
+				},
+			},
+		},
+	}
+}
+
+func dnsPersistSetup(ctx *cli.Context) error {
+	_, account := loadExistingAccount(ctx)
+
+	if account.Registration == nil || account.Registration.URI == "" {
+		log.Fatalf("Account %s is not registered. Use 'run' to register a new account.\n", account.Email)
+	}
+
+	record := dnspersist01.Record{
+		IssuerDomainName: ctx.String("issuer"),
+		AccountURI:       account.Registration.URI,
+	}
+
+	if ctx.IsSet("persist-until") {
+		persistUntil, err := time.Parse(time.RFC3339, ctx.String("persist-until"))
+		if err != nil {
+			log.Fatalf("Invalid --persist-until: %v", err)
+		}
+
+		record.PersistUntil = persistUntil
+	}
+
+	var provider challenge.Provider
+	if ctx.Bool("create") {
+		if !ctx.GlobalIsSet("dns") {
+			log.Fatal("Please specify --dns to create the records.")
+		}
+
+		var err error
+		provider, err = newDNSProvider(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	for _, domain := range ctx.GlobalStringSlice("domains") {
+		r := record
+		if ctx.Bool("wildcard") || strings.HasPrefix(domain, "*.") {
+			r.Policy = dnspersist01.PolicyWildcard
+		}
+
+		domain = strings.TrimPrefix(domain, "*.")
+
+		if provider == nil {
+			fqdn, value := dnspersist01.GetRecord(domain, r)
+			fmt.Printf("%s IN TXT %q\n", fqdn, value)
+
+			continue
+		}
+
+		err := dnspersist01.Present(provider, domain, r)
+		if err != nil {
+			log.Fatalf("Could not create the dns-persist-01 record of %s: %v", domain, err)
+		}
+
+		log.Infof("[%s] dns-persist-01 record created", domain)
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"crypto"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/log"
+	"github.com/urfave/cli"
+)
+
+func createKeys() cli.Command {
+	return cli.Command{
+		Name:  "keys",
+		Usage: "Migrate the stored private keys (accounts and certificates).",
+		Subcommands: []cli.Command{
+			{
+				Name:   "encrypt",
+				Usage:  "Encrypt the stored private keys (--keys.passphrase, --keys.passphrase-file or --keys.age-recipient).",
+				Action: keysEncrypt,
+			},
+			{
+				Name:   "decrypt",
+				Usage:  "Decrypt the stored private keys (--keys.passphrase, --keys.passphrase-file or --keys.age-identity).",
+				Action: keysDecrypt,
+			},
+		},
+	}
+}
+
+func keysEncrypt(ctx *cli.Context) error {
+	encryption := newKeysEncryption(ctx)
+	if !encryption.enabled() {
+		log.Fatal("Please specify --keys.passphrase, --keys.passphrase-file or --keys.age-recipient.")
+	}
+
+	return migrateKeys(ctx, encryption, encryption.isEncrypted, encryption.encrypt)
+}
+
+func keysDecrypt(ctx *cli.Context) error {
+	encryption := newKeysEncryption(ctx)
+
+	isPlaintext := func(data []byte) bool {
+		return !isAgeEncrypted(data) && !certcrypto.IsEncryptedPEMPrivateKey(data)
+	}
+
+	return migrateKeys(ctx, encryption, isPlaintext, (&keysEncryption{}).encrypt)
+}
+
+// migrateKeys rewrites all the stored private keys which are not already in the expected format.
+// The .pem files are not migrated.
+func migrateKeys(ctx *cli.Context, encryption *keysEncryption, skip func([]byte) bool, encode func(crypto.PrivateKey) ([]byte, error)) error {
+	files, err := findKeyFiles(ctx.GlobalString("path"))
+	if err != nil {
+		return err
+	}
+
+	var count int
+	for _, filename := range files {
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+
+		if skip(data) {
+			continue
+		}
+
+		privateKey, err := encryption.decrypt(data)
+		if err != nil {
+			log.Fatalf("Could not load the private key %s: %v", filename, err)
+		}
+
+		newData, err := encode(privateKey)
+		if err != nil {
+			log.Fatalf("Could not encode the private key %s: %v", filename, err)
+		}
+
+		err = writeFileAtomic(filename, newData)
+		if err != nil {
+			log.Fatalf("Could not save the private key %s: %v", filename, err)
+		}
+
+		log.Printf("Migrated %s", filename)
+		count++
+	}
+
+	log.Printf("%d private key(s) migrated.", count)
+
+	return nil
+}
+
+// findKeyFiles returns the account keys and the certificate keys (including the versions).
+func findKeyFiles(rootPath string) ([]string, error) {
+	patterns := []string{
+		filepath.Join(rootPath, baseAccountsRootFolderName, "*", "*", baseKeysFolderName, "*.key"),
+		filepath.Join(rootPath, baseCertificatesFolderName, "*.key"),
+		filepath.Join(rootPath, baseCertificatesFolderName, baseVersionsFolderName, "*", "*", "*.key"),
+	}
+
+	var files []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, matches...)
+	}
+
+	return files, nil
+}
+
+// writeFileAtomic replaces the content of a file: the data is written in a temporary file which is then renamed.
+func writeFileAtomic(filename string, data []byte) error {
+	tmpFile := filename + ".tmp"
+
+	err := writeFileSync(tmpFile, data)
+	if err != nil {
+		_ = os.Remove(tmpFile)
+		return err
+	}
+
+	return os.Rename(tmpFile, filename)
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/certificate"
 	"github.com/urfave/cli"
 )
 
@@ -26,6 +27,10 @@ func createList() cli.Command {
 				Name:  "names, n",
 				Usage: "Display certificate common names only.",
 			},
+			cli.BoolFlag{
+				Name:  "check-revocation",
+				Usage: "Display the revocation status of the certificates (OCSP, or CRL if OCSP is not available).",
+			},
 		},
 	}
 }
@@ -43,13 +48,18 @@ func list(ctx *cli.Context) error {
 func listCertificates(ctx *cli.Context) error {
 	certsStorage := NewCertificatesStorage(ctx)
 
-	matches, err := filepath.Glob(filepath.Join(certsStorage.GetRootPath(), "*.crt"))
+	matches, err := certsStorage.GetCertificateFiles()
 	if err != nil {
 		return err
 	}
 
 	names := ctx.Bool("names")
 
+	var checker *certificate.RevocationChecker
+	if ctx.Bool("check-revocation") {
+		checker = newRevocationChecker(ctx)
+	}
+
 	if len(matches) == 0 {
 		if !names {
 			fmt.Println("No certificates found.")
@@ -83,6 +93,15 @@ func listCertificates(ctx *cli.Context) error {
 			fmt.Println("    Domains:", strings.Join(pCert.DNSNames, ", "))
 			fmt.Println("    Expiry Date:", pCert.NotAfter)
 			fmt.Println("    Certificate Path:", filename)
+
+			if checker != nil {
+				status, errC := checkRevocation(checker, certsStorage, strings.TrimSuffix(filepath.Base(filename), ".crt"))
+				if errC != nil {
+					fmt.Println("    Revocation Status: unavailable:", errC)
+				} else {
+					fmt.Println("    Revocation Status: the certificate", formatRevocationStatus(status))
+				}
+			}
 			fmt.Println()
 		}
 	}
@@ -98,7 +117,7 @@ func listAccount(ctx *cli.Context) error {
 
 	accountsStorage := NewAccountsStorage(ctx)
 
-	matches, err := filepath.Glob(filepath.Join(accountsStorage.GetRootPath(), "*", "*", "*.json"))
+	matches, err := filepath.Glob(filepath.Join(accountsStorage.GetRootPath(), "*", "*", accountFileName))
 	if err != nil {
 		return err
 	}
@@ -121,6 +140,10 @@ func listAccount(ctx *cli.Context) error {
 			return err
 		}
 
+		if account.Registration == nil {
+			continue
+		}
+
 		uri, err := url.Parse(account.Registration.URI)
 		if err != nil {
 			return err
@@ -128,6 +151,7 @@ func listAccount(ctx *cli.Context) error {
 
 		fmt.Println("  Email:", account.Email)
 		fmt.Println("  Server:", uri.Host)
+		fmt.Println("  Status:", account.Registration.Body.Status)
 		fmt.Println("  Path:", filepath.Dir(filename))
 		fmt.Println()
 	}
@@ -0,0 +1,262 @@
+package cmd
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/log"
+	"github.com/urfave/cli"
+	"golang.org/x/crypto/ocsp"
+)
+
+// The tolerated clock skew between the OCSP responder and the local clock.
+const ocspClockSkew = 5 * time.Minute
+
+func createOCSP() cli.Command {
+	return cli.Command{
+		Name:  "ocsp",
+		Usage: "Manage the OCSP responses of the certificates.",
+		Subcommands: []cli.Command{
+			{
+				Name: "refresh",
+				Usage: "Fetch the OCSP responses and store them next to the certificates (<domain>.ocsp, DER encoded)." +
+					" A response is only refreshed after the half of its validity.",
+				Action: ocspRefresh,
+				Flags: append([]cli.Flag{
+					cli.BoolFlag{
+						Name:  "force",
+						Usage: "Refresh the OCSP responses even if the stored responses are still fresh.",
+					},
+					cli.StringFlag{
+						Name:  "revoked-hook",
+						Usage: "Define a hook executed for each certificate reported as revoked by the OCSP responder.",
+					},
+				}, createHookOptionFlags()...),
+			},
+		},
+	}
+}
+
+func ocspRefresh(ctx *cli.Context) error {
+	certsStorage := NewCertificatesStorage(ctx)
+
+	names, err := ocspCertificateNames(ctx, certsStorage)
+	if err != nil {
+		return err
+	}
+
+	if len(names) == 0 {
+		log.Println("No certificates found.")
+		return nil
+	}
+
+	// the OCSP requests don't require an ACME account.
+	checker := newRevocationChecker(ctx)
+
+	hooks := newHooks(ctx)
+
+	var failed []string
+
+	for _, name := range names {
+		revoked, err := refreshOCSPResponse(certsStorage, name, ctx.Bool("force"), checker.GetOCSP)
+		if err != nil {
+			log.Warnf("[%s] Unable to refresh the OCSP response: %v", name, err)
+			failed = append(failed, name)
+
+			continue
+		}
+
+		if !revoked {
+			continue
+		}
+
+		certRes := certsStorage.ReadResource(name)
+
+		certRes.Certificate, err = certsStorage.ReadFile(name, ".crt")
+		if err != nil {
+			return err
+		}
+
+		err = hooks.Revoked(certsStorage, &certRes)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("unable to refresh the OCSP responses of: %s", strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+// ocspCertificateNames returns the names of the certificates (--domains or all the certificates).
+func ocspCertificateNames(ctx *cli.Context, certsStorage *CertificatesStorage) ([]string, error) {
+	if domains := ctx.GlobalStringSlice("domains"); len(domains) > 0 {
+		var names []string
+		for _, domain := range domains {
+			names = append(names, sanitizedDomain(domain))
+		}
+
+		return names, nil
+	}
+
+	matches, err := certsStorage.GetCertificateFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, filename := range matches {
+		if strings.HasSuffix(filename, ".issuer.crt") {
+			continue
+		}
+
+		names = append(names, strings.TrimSuffix(filepath.Base(filename), ".crt"))
+	}
+
+	return names, nil
+}
+
+// refreshOCSPResponse fetches and stores the OCSP response of a certificate,
+// the stored response is kept until the half of its validity, except if force is true.
+// Returns true if the certificate is reported as revoked.
+func refreshOCSPResponse(certsStorage *CertificatesStorage, name string, force bool,
+	getOCSP func(bundle []byte) ([]byte, *ocsp.Response, error)) (bool, error) {
+	leaf, issuer, err := readCertificateAndIssuer(certsStorage, name)
+	if err != nil {
+		return false, err
+	}
+
+	filename := certsStorage.GetFileName(name, ".ocsp")
+
+	if !force {
+		stored, errR := readOCSPResponse(filename, leaf, issuer)
+		if errR != nil {
+			log.Warnf("[%s] The stored OCSP response is ignored: %v", name, errR)
+		}
+
+		if stored != nil && stored.Status == ocsp.Good && time.Now().Before(ocspRefreshDate(stored)) {
+			log.Infof("[%s] The OCSP response is fresh until %s, no refresh needed.", name, ocspRefreshDate(stored).Format(time.RFC3339))
+			return false, nil
+		}
+	}
+
+	bundle := certcrypto.PEMEncode(certcrypto.DERCertificateBytes(leaf.Raw))
+	bundle = append(bundle, certcrypto.PEMEncode(certcrypto.DERCertificateBytes(issuer.Raw))...)
+
+	raw, resp, err := getOCSP(bundle)
+	if err != nil {
+		return false, err
+	}
+
+	if resp == nil {
+		return false, errors.New("no OCSP response")
+	}
+
+	err = validateOCSPResponse(resp, leaf, time.Now())
+	if err != nil {
+		return false, err
+	}
+
+	switch resp.Status {
+	case ocsp.Good:
+		err = writeFileAtomic(filename, raw)
+		if err != nil {
+			return false, err
+		}
+
+		log.Infof("[%s] The OCSP response has been stored, the next refresh is scheduled after %s.", name, ocspRefreshDate(resp).Format(time.RFC3339))
+
+		return false, nil
+
+	case ocsp.Revoked:
+		// a revoked response must not be stapled.
+		_ = os.Remove(filename)
+
+		log.Warnf("[%s] The certificate has been revoked at %s.", name, resp.RevokedAt.Format(time.RFC3339))
+
+		return true, nil
+
+	default:
+		return false, errors.New("the OCSP responder doesn't know the certificate")
+	}
+}
+
+// readCertificateAndIssuer reads the certificate and its issuer (from the bundle, or from the .issuer.crt file).
+func readCertificateAndIssuer(certsStorage *CertificatesStorage, name string) (*x509.Certificate, *x509.Certificate, error) {
+	certificates, err := certsStorage.ReadCertificate(name, ".crt")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(certificates) > 1 {
+		return certificates[0], certificates[1], nil
+	}
+
+	issuers, err := certsStorage.ReadCertificate(name, ".issuer.crt")
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to read the issuer certificate: %w", err)
+	}
+
+	return certificates[0], issuers[0], nil
+}
+
+// readOCSPResponse reads and verifies a stored OCSP response.
+// Returns nil if there is no stored response.
+func readOCSPResponse(filename string, leaf, issuer *x509.Certificate) (*ocsp.Response, error) {
+	raw, err := ioutil.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := ocsp.ParseResponseForCert(raw, leaf, issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	err = validateOCSPResponse(resp, leaf, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// validateOCSPResponse checks that the response is related to the certificate and is fresh.
+// The signature is verified during the parsing of the response.
+func validateOCSPResponse(resp *ocsp.Response, leaf *x509.Certificate, now time.Time) error {
+	if resp.SerialNumber == nil || resp.SerialNumber.Cmp(leaf.SerialNumber) != 0 {
+		return errors.New("the OCSP response is not related to the certificate")
+	}
+
+	if resp.ThisUpdate.After(now.Add(ocspClockSkew)) {
+		return fmt.Errorf("the OCSP response is not yet valid (this update: %s)", resp.ThisUpdate.Format(time.RFC3339))
+	}
+
+	if !resp.NextUpdate.IsZero() && !resp.NextUpdate.After(now) {
+		return fmt.Errorf("the OCSP response is expired (next update: %s)", resp.NextUpdate.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// ocspRefreshDate returns the date of the next refresh: the half of the response validity.
+// A response without NextUpdate is refreshed on each call.
+func ocspRefreshDate(resp *ocsp.Response) time.Time {
+	if resp.NextUpdate.IsZero() {
+		return resp.ThisUpdate
+	}
+
+	return resp.ThisUpdate.Add(resp.NextUpdate.Sub(resp.ThisUpdate) / 2)
+}
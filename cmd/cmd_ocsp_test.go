@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ocsp"
+)
+
+func Test_refreshOCSPResponse(t *testing.T) {
+	issuer, issuerKey, leaf := generateTestChain(t)
+
+	storage := &CertificatesStorage{rootPath: t.TempDir(), keysEncryption: &keysEncryption{}}
+
+	bundle := certcrypto.PEMEncode(certcrypto.DERCertificateBytes(leaf.Raw))
+	bundle = append(bundle, certcrypto.PEMEncode(certcrypto.DERCertificateBytes(issuer.Raw))...)
+
+	err := storage.WriteFile("example.com", ".crt", bundle)
+	require.NoError(t, err)
+
+	var calls int
+	status := ocsp.Good
+	thisUpdate := time.Now().Add(-time.Hour)
+
+	getOCSP := func(_ []byte) ([]byte, *ocsp.Response, error) {
+		calls++
+
+		template := ocsp.Response{
+			Status:       status,
+			SerialNumber: leaf.SerialNumber,
+			ThisUpdate:   thisUpdate,
+			NextUpdate:   thisUpdate.Add(4 * 24 * time.Hour),
+			RevokedAt:    thisUpdate,
+		}
+
+		raw, errR := ocsp.CreateResponse(issuer, issuer, template, issuerKey)
+		if errR != nil {
+			return nil, nil, errR
+		}
+
+		resp, errR := ocsp.ParseResponse(raw, issuer)
+
+		return raw, resp, errR
+	}
+
+	revoked, err := refreshOCSPResponse(storage, "example.com", false, getOCSP)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+	assert.Equal(t, 1, calls)
+	assert.FileExists(t, storage.GetFileName("example.com", ".ocsp"))
+
+	// the stored response is fresh.
+	revoked, err = refreshOCSPResponse(storage, "example.com", false, getOCSP)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+	assert.Equal(t, 1, calls)
+
+	revoked, err = refreshOCSPResponse(storage, "example.com", true, getOCSP)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+	assert.Equal(t, 2, calls)
+
+	// the stored response is after the half of its validity.
+	thisUpdate = time.Now().Add(-3 * 24 * time.Hour)
+
+	_, err = refreshOCSPResponse(storage, "example.com", true, getOCSP)
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	revoked, err = refreshOCSPResponse(storage, "example.com", false, getOCSP)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+	assert.Equal(t, 4, calls)
+
+	status = ocsp.Revoked
+
+	revoked, err = refreshOCSPResponse(storage, "example.com", true, getOCSP)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+	assert.Equal(t, 5, calls)
+	assert.NoFileExists(t, storage.GetFileName("example.com", ".ocsp"))
+
+	status = ocsp.Unknown
+
+	_, err = refreshOCSPResponse(storage, "example.com", true, getOCSP)
+	require.Error(t, err)
+}
+
+func Test_validateOCSPResponse(t *testing.T) {
+	_, _, leaf := generateTestChain(t)
+
+	now := time.Now()
+
+	testCases := []struct {
+		desc   string
+		resp   *ocsp.Response
+		assert require.ErrorAssertionFunc
+	}{
+		{
+			desc:   "valid",
+			resp:   &ocsp.Response{SerialNumber: leaf.SerialNumber, ThisUpdate: now.Add(-time.Hour), NextUpdate: now.Add(time.Hour)},
+			assert: require.NoError,
+		},
+		{
+			desc:   "without next update",
+			resp:   &ocsp.Response{SerialNumber: leaf.SerialNumber, ThisUpdate: now.Add(-time.Hour)},
+			assert: require.NoError,
+		},
+		{
+			desc:   "clock skew",
+			resp:   &ocsp.Response{SerialNumber: leaf.SerialNumber, ThisUpdate: now.Add(time.Minute), NextUpdate: now.Add(time.Hour)},
+			assert: require.NoError,
+		},
+		{
+			desc:   "other certificate",
+			resp:   &ocsp.Response{SerialNumber: big.NewInt(42), ThisUpdate: now.Add(-time.Hour), NextUpdate: now.Add(time.Hour)},
+			assert: require.Error,
+		},
+		{
+			desc:   "not yet valid",
+			resp:   &ocsp.Response{SerialNumber: leaf.SerialNumber, ThisUpdate: now.Add(time.Hour), NextUpdate: now.Add(2 * time.Hour)},
+			assert: require.Error,
+		},
+		{
+			desc:   "expired",
+			resp:   &ocsp.Response{SerialNumber: leaf.SerialNumber, ThisUpdate: now.Add(-2 * time.Hour), NextUpdate: now.Add(-time.Hour)},
+			assert: require.Error,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			test.assert(t, validateOCSPResponse(test.resp, leaf, now))
+		})
+	}
+}
+
+func generateTestChain(t *testing.T) (*x509.Certificate, *ecdsa.PrivateKey, *x509.Certificate) {
+	t.Helper()
+
+	issuerKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	issuerTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+
+	issuerDER, err := x509.CreateCertificate(rand.Reader, issuerTemplate, issuerTemplate, issuerKey.Public(), issuerKey)
+	require.NoError(t, err)
+
+	issuer, err := x509.ParseCertificate(issuerDER)
+	require.NoError(t, err)
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	leafTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		DNSNames:     []string{"example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, issuer, leafKey.Public(), issuerKey)
+	require.NoError(t, err)
+
+	leaf, err := x509.ParseCertificate(leafDER)
+	require.NoError(t, err)
+
+	return issuer, issuerKey, leaf
+}
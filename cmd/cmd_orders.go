@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-acme/lego/v4/acme"
+	"github.com/go-acme/lego/v4/log"
+	"github.com/urfave/cli"
+)
+
+func createOrders() cli.Command {
+	return cli.Command{
+		Name:  "orders",
+		Usage: "Inspect the orders of the account (the account is defined by --email and --server).",
+		Subcommands: []cli.Command{
+			{
+				Name:   "list",
+				Usage:  "Display the orders of the account (the server may omit the invalid orders).",
+				Action: ordersList,
+			},
+			{
+				Name:      "show",
+				Usage:     "Display an order, its authorizations and the status and errors of their challenges.",
+				ArgsUsage: "<order URL>",
+				Action:    ordersShow,
+				Flags: []cli.Flag{
+					cli.BoolFlag{
+						Name:  "json",
+						Usage: "Display the order and its authorizations as JSON.",
+					},
+				},
+			},
+		},
+	}
+}
+
+func ordersList(ctx *cli.Context) error {
+	accountsStorage, account := loadExistingAccount(ctx)
+
+	client := newClient(ctx, account, getKeyType(ctx), accountsStorage.GetServer())
+
+	orders, err := client.Certificate.ListOrders()
+	if err != nil {
+		log.Fatalf("Could not list the orders of the account %s: %v", account.Email, err)
+	}
+
+	if len(orders) == 0 {
+		fmt.Println("No orders found.")
+		return nil
+	}
+
+	fmt.Println("Found the following orders:")
+
+	for _, orderURL := range orders {
+		order, err := client.Certificate.GetOrder(orderURL)
+		if err != nil {
+			fmt.Println(orderURL)
+			fmt.Println("  Error:", err)
+
+			continue
+		}
+
+		fmt.Println(orderURL)
+		fmt.Println("  Status:", order.Status)
+		fmt.Println("  Identifiers:", formatIdentifiers(order.Identifiers))
+		if order.Expires != "" {
+			fmt.Println("  Expires:", order.Expires)
+		}
+	}
+
+	return nil
+}
+
+func ordersShow(ctx *cli.Context) error {
+	orderURL := ctx.Args().First()
+	if orderURL == "" {
+		log.Fatal("The URL of the order is required: lego orders show <order URL>.")
+	}
+
+	accountsStorage, account := loadExistingAccount(ctx)
+
+	client := newClient(ctx, account, getKeyType(ctx), accountsStorage.GetServer())
+
+	details, err := client.Certificate.GetOrderDetails(orderURL)
+	if err != nil {
+		log.Fatalf("Could not get the order %s: %v", orderURL, err)
+	}
+
+	if ctx.Bool("json") {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(details)
+	}
+
+	fmt.Println("Order:", details.Location)
+	fmt.Println("  Status:", details.Status)
+	fmt.Println("  Identifiers:", formatIdentifiers(details.Identifiers))
+	if details.Expires != "" {
+		fmt.Println("  Expires:", details.Expires)
+	}
+	if details.Certificate != "" {
+		fmt.Println("  Certificate:", details.Certificate)
+	}
+	printProblem("  ", details.Error)
+
+	for i, authz := range details.Authorizations {
+		fmt.Println("Authorization:", details.Order.Authorizations[i])
+		if authz.Wildcard {
+			fmt.Println("  Identifier: *." + authz.Identifier.Value)
+		} else {
+			fmt.Println("  Identifier:", authz.Identifier.Value)
+		}
+		fmt.Println("  Status:", authz.Status)
+		if !authz.Expires.IsZero() {
+			fmt.Println("  Expires:", authz.Expires.Format(time.RFC3339))
+		}
+
+		for _, chlg := range authz.Challenges {
+			fmt.Println("  Challenge:", chlg.Type)
+			fmt.Println("    URL:", chlg.URL)
+			fmt.Println("    Status:", chlg.Status)
+			if !chlg.Validated.IsZero() {
+				fmt.Println("    Validated:", chlg.Validated.Format(time.RFC3339))
+			}
+			printProblem("    ", chlg.Error)
+		}
+	}
+
+	return nil
+}
+
+func formatIdentifiers(identifiers []acme.Identifier) string {
+	var values []string
+	for _, ident := range identifiers {
+		values = append(values, ident.Type+":"+ident.Value)
+	}
+
+	return strings.Join(values, ", ")
+}
+
+// printProblem displays a problem document and its subproblems.
+func printProblem(indent string, problem *acme.ProblemDetails) {
+	if problem == nil {
+		return
+	}
+
+	fmt.Printf("%sError: %s :: %s\n", indent, problem.Type, problem.Detail)
+
+	for _, sub := range problem.SubProblems {
+		fmt.Printf("%s  %s: %s :: %s\n", indent, sub.Identifier.Value, sub.Type, sub.Detail)
+	}
+}
@@ -1,13 +1,11 @@
 package cmd
 
 import (
-	"crypto"
 	"crypto/x509"
 	"time"
 
 	"github.com/go-acme/lego/v4/certcrypto"
 	"github.com/go-acme/lego/v4/certificate"
-	"github.com/go-acme/lego/v4/lego"
 	"github.com/go-acme/lego/v4/log"
 	"github.com/urfave/cli"
 )
@@ -17,6 +15,7 @@ const (
 	renewEnvCertDomain   = "LEGO_CERT_DOMAIN"
 	renewEnvCertPath     = "LEGO_CERT_PATH"
 	renewEnvCertKeyPath  = "LEGO_CERT_KEY_PATH"
+	renewEnvCertKeyURI   = "LEGO_CERT_KEY_URI"
 )
 
 func createRenew() cli.Command {
@@ -31,12 +30,12 @@ func createRenew() cli.Command {
 			if hasDomains && hasCsr {
 				log.Fatal("Please specify either --domains/-d or --csr/-c, but not both")
 			}
-			if !hasDomains && !hasCsr {
+			if !hasDomains && !hasCsr && !hasCertificateSections(ctx) {
 				log.Fatal("Please specify --domains/-d (or --csr/-c if you already have a CSR)")
 			}
 			return nil
 		},
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			cli.IntFlag{
 				Name:  "days",
 				Value: 30,
@@ -56,40 +55,62 @@ func createRenew() cli.Command {
 			},
 			cli.StringFlag{
 				Name:  "renew-hook",
-				Usage: "Define a hook. The hook is executed only when the certificates are effectively renewed.",
+				Usage: "Define a hook. The hook is executed only when the certificates are effectively renewed (same as --deploy-hook).",
+			},
+			cli.BoolFlag{
+				Name:  "check-revocation",
+				Usage: "Check the revocation status of the certificate (OCSP, or CRL if OCSP is not available) and renew it if it has been revoked.",
 			},
 			cli.StringFlag{
 				Name:  "preferred-chain",
 				Usage: "If the CA offers multiple certificate chains, prefer the chain with an issuer matching this Subject Common Name. If no match, the default offered chain will be used.",
 			},
-		},
+		}, append(append(append(createPreferredChainFlags(), createVerifyFlags()...), createCSRFlags()...), createHookFlags()...)...),
 	}
 }
 
 func renew(ctx *cli.Context) error {
-	account, client := setup(ctx, NewAccountsStorage(ctx))
-	setupChallenges(ctx, client)
-
-	if account.Registration == nil {
-		log.Fatalf("Account %s is not registered. Use 'run' to register a new account.\n", account.Email)
+	// each certificate section of the configuration file is processed with its own options.
+	if hasCertificateSections(ctx) {
+		return runForEachCertificate(ctx, renew)
 	}
 
 	certsStorage := NewCertificatesStorage(ctx)
 
+	var obtainer certificateObtainer
+	if hasFailoverServers(ctx) {
+		obtainer = setupFailover(ctx, certsStorage)
+	} else {
+		accountsStorage := NewAccountsStorage(ctx)
+
+		account, client := setup(ctx, accountsStorage)
+		setupChallenges(ctx, client)
+
+		if account.Registration == nil {
+			log.Fatalf("Account %s is not registered. Use 'run' to register a new account.\n", account.Email)
+		}
+
+		if err := checkTermsOfService(ctx, client, account, accountsStorage); err != nil {
+			log.Fatal(err)
+		}
+
+		obtainer = client.Certificate
+	}
+
 	bundle := !ctx.Bool("no-bundle")
 
-	meta := map[string]string{renewEnvAccountEmail: account.Email}
+	hooks := newHooks(ctx, ctx.String("renew-hook"))
 
 	// CSR
 	if ctx.GlobalIsSet("csr") {
-		return renewForCSR(ctx, client, certsStorage, bundle, meta)
+		return renewForCSR(ctx, obtainer, certsStorage, bundle, hooks)
 	}
 
 	// Domains
-	return renewForDomains(ctx, client, certsStorage, bundle, meta)
+	return renewForDomains(ctx, obtainer, certsStorage, bundle, hooks)
 }
 
-func renewForDomains(ctx *cli.Context, client *lego.Client, certsStorage *CertificatesStorage, bundle bool, meta map[string]string) error {
+func renewForDomains(ctx *cli.Context, obtainer certificateObtainer, certsStorage *CertificatesStorage, bundle bool, hooks *hooks) error {
 	domains := ctx.GlobalStringSlice("domains")
 	domain := domains[0]
 
@@ -103,7 +124,7 @@ func renewForDomains(ctx *cli.Context, client *lego.Client, certsStorage *Certif
 
 	cert := certificates[0]
 
-	if !needRenewal(cert, domain, ctx.Int("days")) {
+	if !needRenewal(cert, domain, ctx.Int("days")) && !isRevoked(ctx, certsStorage, domain) {
 		return nil
 	}
 
@@ -111,18 +132,26 @@ func renewForDomains(ctx *cli.Context, client *lego.Client, certsStorage *Certif
 	timeLeft := cert.NotAfter.Sub(time.Now().UTC())
 	log.Infof("[%s] acme: Trying renewal with %d hours remaining", domain, int(timeLeft.Hours()))
 
+	err = hooks.Pre()
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	certDomains := certcrypto.ExtractDomains(cert)
 
-	var privateKey crypto.PrivateKey
-	if ctx.Bool("reuse-key") {
-		keyBytes, errR := certsStorage.ReadFile(domain, ".key")
-		if errR != nil {
-			log.Fatalf("Error while loading the private key for domain %s\n\t%v", domain, errR)
-		}
+	// the key not stored by lego (ex: HSM) is always reused.
+	var previousKeyURI string
+	if certsStorage.ExistsFile(domain, ".json") {
+		previousKeyURI = certsStorage.ReadResource(domain).PrivateKeyURI
+	}
+
+	privateKey, keyURI := getCertificateKey(ctx, previousKeyURI)
 
-		privateKey, errR = certcrypto.ParsePEMPrivateKey(keyBytes)
+	if privateKey == nil && ctx.Bool("reuse-key") {
+		var errR error
+		privateKey, errR = certsStorage.ReadPrivateKey(domain)
 		if errR != nil {
-			return errR
+			log.Fatalf("Error while loading the private key for domain %s\n\t%v", domain, errR)
 		}
 	}
 
@@ -132,22 +161,31 @@ func renewForDomains(ctx *cli.Context, client *lego.Client, certsStorage *Certif
 		PrivateKey:     privateKey,
 		MustStaple:     ctx.Bool("must-staple"),
 		PreferredChain: ctx.String("preferred-chain"),
+		ChainPolicy:    getChainPolicy(ctx),
+		Verify:         getVerifyOptions(ctx),
+		CSRTemplate:    getCSRTemplate(ctx),
+		OmitCommonName: ctx.Bool("csr.no-cn"),
 	}
-	certRes, err := client.Certificate.Obtain(request)
+	certRes, err := obtainer.Obtain(request)
 	if err != nil {
-		log.Fatal(err)
+		if errH := hooks.Post(certsStorage, nil, err); errH != nil {
+			log.Println(errH)
+		}
+
+		checkRateLimit(err)
+		checkSignatureAlgorithm(err)
+
+		return err
 	}
 
-	certsStorage.SaveResource(certRes)
+	certRes.PrivateKeyURI = storableKeyURI(keyURI)
 
-	meta[renewEnvCertDomain] = domain
-	meta[renewEnvCertPath] = certsStorage.GetFileName(domain, ".crt")
-	meta[renewEnvCertKeyPath] = certsStorage.GetFileName(domain, ".key")
+	certsStorage.SaveResource(certRes)
 
-	return launchHook(ctx.String("renew-hook"), meta)
+	return runDeployAndPostHooks(hooks, certsStorage, certRes)
 }
 
-func renewForCSR(ctx *cli.Context, client *lego.Client, certsStorage *CertificatesStorage, bundle bool, meta map[string]string) error {
+func renewForCSR(ctx *cli.Context, obtainer certificateObtainer, certsStorage *CertificatesStorage, bundle bool, hooks *hooks) error {
 	csr, err := readCSRFile(ctx.GlobalString("csr"))
 	if err != nil {
 		log.Fatal(err)
@@ -165,7 +203,7 @@ func renewForCSR(ctx *cli.Context, client *lego.Client, certsStorage *Certificat
 
 	cert := certificates[0]
 
-	if !needRenewal(cert, domain, ctx.Int("days")) {
+	if !needRenewal(cert, domain, ctx.Int("days")) && !isRevoked(ctx, certsStorage, domain) {
 		return nil
 	}
 
@@ -173,22 +211,32 @@ func renewForCSR(ctx *cli.Context, client *lego.Client, certsStorage *Certificat
 	timeLeft := cert.NotAfter.Sub(time.Now().UTC())
 	log.Infof("[%s] acme: Trying renewal with %d hours remaining", domain, int(timeLeft.Hours()))
 
-	certRes, err := client.Certificate.ObtainForCSR(certificate.ObtainForCSRRequest{
+	err = hooks.Pre()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	certRes, err := obtainer.ObtainForCSR(certificate.ObtainForCSRRequest{
 		CSR:            csr,
 		Bundle:         bundle,
 		PreferredChain: ctx.String("preferred-chain"),
+		ChainPolicy:    getChainPolicy(ctx),
+		Verify:         getVerifyOptions(ctx),
 	})
 	if err != nil {
-		log.Fatal(err)
+		if errH := hooks.Post(certsStorage, nil, err); errH != nil {
+			log.Println(errH)
+		}
+
+		checkRateLimit(err)
+		checkSignatureAlgorithm(err)
+
+		return err
 	}
 
 	certsStorage.SaveResource(certRes)
 
-	meta[renewEnvCertDomain] = domain
-	meta[renewEnvCertPath] = certsStorage.GetFileName(domain, ".crt")
-	meta[renewEnvCertKeyPath] = certsStorage.GetFileName(domain, ".key")
-
-	return launchHook(ctx.String("renew-hook"), meta)
+	return runDeployAndPostHooks(hooks, certsStorage, certRes)
 }
 
 func needRenewal(x509Cert *x509.Certificate, domain string, days int) bool {
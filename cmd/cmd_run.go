@@ -24,13 +24,13 @@ func createRun() cli.Command {
 			if hasDomains && hasCsr {
 				log.Fatal("Please specify either --domains/-d or --csr/-c, but not both")
 			}
-			if !hasDomains && !hasCsr {
+			if !hasDomains && !hasCsr && !hasCertificateSections(ctx) {
 				log.Fatal("Please specify --domains/-d (or --csr/-c if you already have a CSR)")
 			}
 			return nil
 		},
 		Action: run,
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			cli.BoolFlag{
 				Name:  "no-bundle",
 				Usage: "Do not create a certificate bundle by adding the issuers certificate to the new certificate.",
@@ -41,13 +41,13 @@ func createRun() cli.Command {
 			},
 			cli.StringFlag{
 				Name:  "run-hook",
-				Usage: "Define a hook. The hook is executed when the certificates are effectively created.",
+				Usage: "Define a hook. The hook is executed when the certificates are effectively created (same as --deploy-hook).",
 			},
 			cli.StringFlag{
 				Name:  "preferred-chain",
 				Usage: "If the CA offers multiple certificate chains, prefer the chain with an issuer matching this Subject Common Name. If no match, the default offered chain will be used.",
 			},
-		},
+		}, append(append(append(createPreferredChainFlags(), createVerifyFlags()...), createCSRFlags()...), createHookFlags()...)...),
 	}
 }
 
@@ -63,6 +63,62 @@ backups of this folder is ideal.
 `
 
 func run(ctx *cli.Context) error {
+	// each certificate section of the configuration file is processed with its own options.
+	if hasCertificateSections(ctx) {
+		return runForEachCertificate(ctx, run)
+	}
+
+	certsStorage := NewCertificatesStorage(ctx)
+	certsStorage.CreateRootFolder()
+
+	var obtainer certificateObtainer
+	if hasFailoverServers(ctx) {
+		obtainer = setupFailover(ctx, certsStorage)
+	} else {
+		obtainer = setupRun(ctx).Certificate
+	}
+
+	hooks := newHooks(ctx, ctx.String("run-hook"))
+
+	err := hooks.Pre()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	cert, err := obtainCertificate(ctx, obtainer)
+	if err != nil {
+		if errH := hooks.Post(certsStorage, nil, err); errH != nil {
+			log.Println(errH)
+		}
+
+		checkRateLimit(err)
+		checkSignatureAlgorithm(err)
+
+		// The error is returned (non-zero exit code) instead of exiting:
+		// the next certificate sections of the configuration file are still processed.
+		return fmt.Errorf("could not obtain certificates:\n\t%w", err)
+	}
+
+	certsStorage.SaveResource(cert)
+
+	return runDeployAndPostHooks(hooks, certsStorage, cert)
+}
+
+// runDeployAndPostHooks launches the deploy hooks, then the post-hook (even if a deploy hook failed).
+func runDeployAndPostHooks(hooks *hooks, certsStorage *CertificatesStorage, cert *certificate.Resource) error {
+	errDeploy := hooks.Deploy(certsStorage, cert)
+
+	errPost := hooks.Post(certsStorage, cert, nil)
+
+	if errDeploy != nil {
+		return errDeploy
+	}
+
+	return errPost
+}
+
+// setupRun creates the client and registers the account if needed.
+func setupRun(ctx *cli.Context) *lego.Client {
 	accountsStorage := NewAccountsStorage(ctx)
 
 	account, client := setup(ctx, accountsStorage)
@@ -71,37 +127,22 @@ func run(ctx *cli.Context) error {
 	if account.Registration == nil {
 		reg, err := register(ctx, client)
 		if err != nil {
+			checkSignatureAlgorithm(err)
 			log.Fatalf("Could not complete registration\n\t%v", err)
 		}
 
 		account.Registration = reg
+		account.TermsOfService = client.GetToSURL()
 		if err = accountsStorage.Save(account); err != nil {
 			log.Fatal(err)
 		}
 
 		fmt.Printf(rootPathWarningMessage, accountsStorage.GetRootPath())
+	} else if err := checkTermsOfService(ctx, client, account, accountsStorage); err != nil {
+		log.Fatal(err)
 	}
 
-	certsStorage := NewCertificatesStorage(ctx)
-	certsStorage.CreateRootFolder()
-
-	cert, err := obtainCertificate(ctx, client)
-	if err != nil {
-		// Make sure to return a non-zero exit code if ObtainSANCertificate returned at least one error.
-		// Due to us not returning partial certificate we can just exit here instead of at the end.
-		log.Fatalf("Could not obtain certificates:\n\t%v", err)
-	}
-
-	certsStorage.SaveResource(cert)
-
-	meta := map[string]string{
-		renewEnvAccountEmail: account.Email,
-		renewEnvCertDomain:   cert.Domain,
-		renewEnvCertPath:     certsStorage.GetFileName(cert.Domain, ".crt"),
-		renewEnvCertKeyPath:  certsStorage.GetFileName(cert.Domain, ".key"),
-	}
-
-	return launchHook(ctx.String("run-hook"), meta)
+	return client
 }
 
 func handleTOS(ctx *cli.Context, client *lego.Client) bool {
@@ -156,19 +197,34 @@ func register(ctx *cli.Context, client *lego.Client) (*registration.Resource, er
 	return client.Registration.Register(registration.RegisterOptions{TermsOfServiceAgreed: true})
 }
 
-func obtainCertificate(ctx *cli.Context, client *lego.Client) (*certificate.Resource, error) {
+func obtainCertificate(ctx *cli.Context, obtainer certificateObtainer) (*certificate.Resource, error) {
 	bundle := !ctx.Bool("no-bundle")
 
 	domains := ctx.GlobalStringSlice("domains")
 	if len(domains) > 0 {
-		// obtain a certificate, generating a new private key
+		// obtain a certificate, generating a new private key (unless the key is defined by --cert-key)
+		privateKey, keyURI := getCertificateKey(ctx, "")
+
 		request := certificate.ObtainRequest{
 			Domains:        domains,
 			Bundle:         bundle,
+			PrivateKey:     privateKey,
 			MustStaple:     ctx.Bool("must-staple"),
 			PreferredChain: ctx.String("preferred-chain"),
+			ChainPolicy:    getChainPolicy(ctx),
+			Verify:         getVerifyOptions(ctx),
+			CSRTemplate:    getCSRTemplate(ctx),
+			OmitCommonName: ctx.Bool("csr.no-cn"),
 		}
-		return client.Certificate.Obtain(request)
+
+		certRes, err := obtainer.Obtain(request)
+		if err != nil {
+			return nil, err
+		}
+
+		certRes.PrivateKeyURI = storableKeyURI(keyURI)
+
+		return certRes, nil
 	}
 
 	// read the CSR
@@ -178,9 +234,11 @@ func obtainCertificate(ctx *cli.Context, client *lego.Client) (*certificate.Reso
 	}
 
 	// obtain a certificate for this CSR
-	return client.Certificate.ObtainForCSR(certificate.ObtainForCSRRequest{
+	return obtainer.ObtainForCSR(certificate.ObtainForCSRRequest{
 		CSR:            csr,
 		Bundle:         bundle,
 		PreferredChain: ctx.String("preferred-chain"),
+		ChainPolicy:    getChainPolicy(ctx),
+		Verify:         getVerifyOptions(ctx),
 	})
 }
@@ -0,0 +1,728 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/go-acme/lego/v4/log"
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v2"
+)
+
+// Sections of the configuration file.
+const (
+	configKeyProviders    = "providers"
+	configKeyCertificates = "certificates"
+)
+
+const configMetadataKey = "configuration"
+
+// The options that cannot be defined in the configuration file.
+var configForbiddenOptions = map[string]bool{
+	"config":             true,
+	"config.certificate": true,
+	"help":               true,
+	"version":            true,
+}
+
+// configSection a section of the configuration file:
+// the global options, the options of the commands (ex: [renew]), and the DNS providers configuration (ex: [providers.cloudflare]).
+type configSection struct {
+	Options   map[string]interface{}
+	Commands  map[string]map[string]interface{}
+	Providers map[string]map[string]string
+}
+
+// configuration the content of the configuration file (--config).
+//
+// The options are resolved in this order: command line flags and environment variables, certificate section, configuration file.
+//
+//	email = "foo@example.com"
+//	dns = "cloudflare"
+//
+//	[renew]
+//	days = 45
+//
+//	[providers.cloudflare]
+//	CF_DNS_API_TOKEN = "xxx"
+//
+//	[certificates.example]
+//	domains = ["example.com", "*.example.com"]
+//
+//	[certificates.example.renew]
+//	reuse-key = true
+type configuration struct {
+	configSection
+
+	Certificates map[string]*configSection
+
+	// the flags set on the command line or by an environment variable (they take precedence over the configuration file).
+	explicitGlobal  map[string]bool
+	explicitCommand map[string]bool
+}
+
+func newConfigSection() *configSection {
+	return &configSection{
+		Options:   map[string]interface{}{},
+		Commands:  map[string]map[string]interface{}{},
+		Providers: map[string]map[string]string{},
+	}
+}
+
+// loadConfiguration reads a TOML or YAML configuration file, and validates the options against the flags of the application.
+func loadConfiguration(filename string, app *cli.App) (*configuration, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := map[string]interface{}{}
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".toml":
+		err = toml.Unmarshal(content, &raw)
+	case ".yaml", ".yml":
+		var data map[interface{}]interface{}
+		err = yaml.Unmarshal(content, &data)
+		if err == nil {
+			raw, err = normalizeYAMLMap(data)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported configuration file format: %s (toml, yaml)", filename)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration file %s: %w", filename, err)
+	}
+
+	conf := &configuration{Certificates: map[string]*configSection{}}
+
+	if certs, ok := raw[configKeyCertificates]; ok {
+		delete(raw, configKeyCertificates)
+
+		certsMap, ok := certs.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s: a section is expected", configKeyCertificates)
+		}
+
+		for name, value := range certsMap {
+			certMap, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s.%s: a section is expected", configKeyCertificates, name)
+			}
+
+			conf.Certificates[name], err = parseConfigSection(certMap, app)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", configKeyCertificates, name, err)
+			}
+		}
+	}
+
+	section, err := parseConfigSection(raw, app)
+	if err != nil {
+		return nil, err
+	}
+
+	conf.configSection = *section
+
+	return conf, nil
+}
+
+func parseConfigSection(raw map[string]interface{}, app *cli.App) (*configSection, error) {
+	section := newConfigSection()
+
+	for key, value := range raw {
+		switch {
+		case key == configKeyProviders:
+			providers, err := parseConfigProviders(value)
+			if err != nil {
+				return nil, err
+			}
+
+			section.Providers = providers
+
+		case app.Command(key) != nil:
+			options, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%s: a section is expected", key)
+			}
+
+			// the name of the command, not an alias.
+			section.Commands[app.Command(key).Name] = options
+
+		default:
+			section.Options[key] = value
+		}
+	}
+
+	err := validateConfigOptions(app.Flags, section.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, options := range section.Commands {
+		err = validateConfigOptions(app.Command(name).Flags, options)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	return section, nil
+}
+
+// parseConfigProviders validates the DNS providers configuration against the environment variables of each provider.
+func parseConfigProviders(value interface{}) (map[string]map[string]string, error) {
+	rawProviders, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: a section is expected", configKeyProviders)
+	}
+
+	providers := map[string]map[string]string{}
+
+	for code, rawEnvs := range rawProviders {
+		envVars, ok := dnsProvidersEnvVars[code]
+		if !ok {
+			return nil, fmt.Errorf("%s.%s: unknown DNS provider", configKeyProviders, code)
+		}
+
+		envs, ok := rawEnvs.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s.%s: a section is expected", configKeyProviders, code)
+		}
+
+		providers[code] = map[string]string{}
+
+		for key, rawValue := range envs {
+			// the values can also be read from a file (<key>_FILE).
+			if !containsString(envVars, key) && !containsString(envVars, strings.TrimSuffix(key, "_FILE")) {
+				return nil, fmt.Errorf("%s.%s: unknown environment variable %s (supported: %s)",
+					configKeyProviders, code, key, strings.Join(envVars, ", "))
+			}
+
+			values, err := configValueToStrings(rawValue)
+			if err != nil || len(values) != 1 {
+				return nil, fmt.Errorf("%s.%s.%s: a single value is expected", configKeyProviders, code, key)
+			}
+
+			providers[code][key] = values[0]
+		}
+	}
+
+	return providers, nil
+}
+
+// validateConfigOptions checks the names and the values of the options by applying them to a new flag set.
+func validateConfigOptions(flags []cli.Flag, options map[string]interface{}) error {
+	known := map[string]bool{}
+	for _, f := range flags {
+		known[flagName(f)] = true
+	}
+
+	for key := range options {
+		if !known[key] || configForbiddenOptions[key] {
+			return fmt.Errorf("unknown option %q", key)
+		}
+	}
+
+	set, err := newFlagSet(flags)
+	if err != nil {
+		return err
+	}
+
+	return applyConfigOptions(flagSetValues{set}, flags, options, nil)
+}
+
+// section returns the section of a certificate (merged with the root section),
+// or the root section if the name is empty.
+func (c *configuration) section(name string) (*configSection, error) {
+	if name == "" {
+		return &c.configSection, nil
+	}
+
+	cert, ok := c.Certificates[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown certificate section: %s", name)
+	}
+
+	section := newConfigSection()
+
+	for _, src := range []*configSection{&c.configSection, cert} {
+		for k, v := range src.Options {
+			section.Options[k] = v
+		}
+
+		for cmdName, options := range src.Commands {
+			if section.Commands[cmdName] == nil {
+				section.Commands[cmdName] = map[string]interface{}{}
+			}
+
+			for k, v := range options {
+				section.Commands[cmdName][k] = v
+			}
+		}
+
+		for code, envs := range src.Providers {
+			if section.Providers[code] == nil {
+				section.Providers[code] = map[string]string{}
+			}
+
+			for k, v := range envs {
+				section.Providers[code][k] = v
+			}
+		}
+	}
+
+	return section, nil
+}
+
+// certificateNames returns the sorted names of the certificate sections.
+func (c *configuration) certificateNames() []string {
+	var names []string
+	for name := range c.Certificates {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// setupConfiguration loads the configuration file, and applies the global options and the DNS provider configuration.
+func setupConfiguration(ctx *cli.Context) error {
+	filename := ctx.GlobalString("config")
+	if filename == "" {
+		if ctx.GlobalString("config.certificate") != "" {
+			return errors.New("--config.certificate requires --config")
+		}
+
+		return nil
+	}
+
+	conf, err := loadConfiguration(filename, ctx.App)
+	if err != nil {
+		return err
+	}
+
+	ctx.App.Metadata[configMetadataKey] = conf
+
+	section, err := conf.section(ctx.GlobalString("config.certificate"))
+	if err != nil {
+		return err
+	}
+
+	conf.explicitGlobal = setFlags(ctx.App.Flags, ctx.GlobalIsSet)
+
+	err = applyConfigOptions(globalContextValues{ctx}, ctx.App.Flags, section.Options, conf.explicitGlobal)
+	if err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+
+	return nil
+}
+
+// getProviderValues returns the DNS provider configuration of the configuration file
+// (the values take precedence over the environment variables).
+func getProviderValues(ctx *cli.Context) (map[string]string, error) {
+	conf := getConfiguration(ctx)
+	if conf == nil {
+		return nil, nil
+	}
+
+	section, err := conf.section(ctx.GlobalString("config.certificate"))
+	if err != nil {
+		return nil, err
+	}
+
+	return section.Providers[ctx.GlobalString("dns")], nil
+}
+
+// getConfiguration returns the configuration file, or nil if no configuration file is used.
+func getConfiguration(ctx *cli.Context) *configuration {
+	conf, ok := ctx.App.Metadata[configMetadataKey].(*configuration)
+	if !ok {
+		return nil
+	}
+
+	return conf
+}
+
+// withConfiguration applies the section of the configuration file related to a command to the options of the command.
+func withConfiguration(command cli.Command) cli.Command {
+	before := command.Before
+
+	command.Before = func(ctx *cli.Context) error {
+		conf := getConfiguration(ctx)
+		if conf != nil {
+			section, err := conf.section(ctx.GlobalString("config.certificate"))
+			if err != nil {
+				return err
+			}
+
+			conf.explicitCommand = setFlags(command.Flags, ctx.IsSet)
+
+			err = applyConfigOptions(contextValues{ctx}, command.Flags, section.Commands[command.Name], conf.explicitCommand)
+			if err != nil {
+				return fmt.Errorf("%s: %w", command.Name, err)
+			}
+		}
+
+		if before != nil {
+			return before(ctx)
+		}
+
+		return nil
+	}
+
+	return command
+}
+
+// hasCertificateSections returns true if the command must be executed for each certificate section of the configuration file:
+// no domains, no CSR, and no certificate section selected.
+func hasCertificateSections(ctx *cli.Context) bool {
+	conf := getConfiguration(ctx)
+
+	return conf != nil && len(conf.Certificates) > 0 &&
+		ctx.GlobalString("config.certificate") == "" &&
+		len(ctx.GlobalStringSlice("domains")) == 0 && ctx.GlobalString("csr") == ""
+}
+
+// runForEachCertificate executes the action of the command for each certificate section of the configuration file.
+// Each section has its own flag sets (see newSectionContext).
+// A fatal error (ex: a rate limit) stops the execution of the remaining sections.
+func runForEachCertificate(ctx *cli.Context, action func(*cli.Context) error) error {
+	conf := getConfiguration(ctx)
+
+	var failed []string
+
+	for _, name := range conf.certificateNames() {
+		log.Printf("Certificate section: %s", name)
+
+		sectionCtx, err := newSectionContext(ctx, conf, name)
+		if err == nil {
+			err = action(sectionCtx)
+		}
+
+		if err != nil {
+			log.Printf("Certificate section %s: %v", name, err)
+			failed = append(failed, name)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed certificate sections: %s", strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+// newSectionContext creates the context of the command for a certificate section:
+// the flags set on the command line or by an environment variable are copied from the context of the command,
+// then the options of the section are applied.
+func newSectionContext(ctx *cli.Context, conf *configuration, name string) (*cli.Context, error) {
+	section, err := conf.section(name)
+	if err != nil {
+		return nil, err
+	}
+
+	globalSet, err := newFlagSet(ctx.App.Flags)
+	if err != nil {
+		return nil, err
+	}
+
+	err = copyFlagValues(flagSetValues{globalSet}, globalContextValues{ctx}, conf.explicitGlobal)
+	if err != nil {
+		return nil, err
+	}
+
+	err = globalSet.Set("config.certificate", name)
+	if err != nil {
+		return nil, err
+	}
+
+	err = applyConfigOptions(flagSetValues{globalSet}, ctx.App.Flags, section.Options, conf.explicitGlobal)
+	if err != nil {
+		return nil, err
+	}
+
+	commandSet, err := newFlagSet(ctx.Command.Flags)
+	if err != nil {
+		return nil, err
+	}
+
+	err = copyFlagValues(flagSetValues{commandSet}, contextValues{ctx}, conf.explicitCommand)
+	if err != nil {
+		return nil, err
+	}
+
+	err = applyConfigOptions(flagSetValues{commandSet}, ctx.Command.Flags, section.Commands[ctx.Command.Name], conf.explicitCommand)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ctx.Command.Name, err)
+	}
+
+	globalCtx := cli.NewContext(ctx.App, globalSet, nil)
+
+	sectionCtx := cli.NewContext(ctx.App, commandSet, globalCtx)
+	sectionCtx.Command = ctx.Command
+
+	// we require either domains or csr, but not both
+	hasDomains := len(sectionCtx.GlobalStringSlice("domains")) > 0
+	hasCsr := len(sectionCtx.GlobalString("csr")) > 0
+	if hasDomains == hasCsr {
+		return nil, errors.New("the section must define either domains or csr, but not both")
+	}
+
+	checkGlobalOptions(sectionCtx)
+
+	return sectionCtx, nil
+}
+
+// flagValues the values of a set of flags.
+type flagValues interface {
+	Set(name, value string) error
+	Value(name string) flag.Value
+}
+
+type flagSetValues struct {
+	set *flag.FlagSet
+}
+
+func (f flagSetValues) Set(name, value string) error {
+	return f.set.Set(name, value)
+}
+
+func (f flagSetValues) Value(name string) flag.Value {
+	return f.set.Lookup(name).Value
+}
+
+type contextValues struct {
+	ctx *cli.Context
+}
+
+func (c contextValues) Set(name, value string) error {
+	return c.ctx.Set(name, value)
+}
+
+func (c contextValues) Value(name string) flag.Value {
+	value, _ := c.ctx.Generic(name).(flag.Value)
+	return value
+}
+
+type globalContextValues struct {
+	ctx *cli.Context
+}
+
+func (g globalContextValues) Set(name, value string) error {
+	return g.ctx.GlobalSet(name, value)
+}
+
+func (g globalContextValues) Value(name string) flag.Value {
+	value, _ := g.ctx.GlobalGeneric(name).(flag.Value)
+	return value
+}
+
+// applyConfigOptions sets the values of the flags which are not explicitly defined (command line, environment variables).
+func applyConfigOptions(values flagValues, flags []cli.Flag, options map[string]interface{}, explicit map[string]bool) error {
+	for _, f := range flags {
+		name := flagName(f)
+
+		value, ok := options[name]
+		if !ok || explicit[name] {
+			continue
+		}
+
+		items, err := configValueToStrings(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+
+		err = setFlagValue(values, name, items)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// copyFlagValues copies the values of the flags from src to dst.
+func copyFlagValues(dst, src flagValues, names map[string]bool) error {
+	for name := range names {
+		var items []string
+
+		switch v := src.Value(name).(type) {
+		case nil:
+			continue
+		case *cli.StringSlice:
+			items = v.Value()
+		case *cli.IntSlice:
+			for _, i := range v.Value() {
+				items = append(items, strconv.Itoa(i))
+			}
+		case *cli.Int64Slice:
+			for _, i := range v.Value() {
+				items = append(items, strconv.FormatInt(i, 10))
+			}
+		default:
+			items = []string{v.String()}
+		}
+
+		err := setFlagValue(dst, name, items)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// setFlagValue sets the value of a flag, the values of a slice are replaced.
+func setFlagValue(values flagValues, name string, items []string) error {
+	switch v := values.Value(name).(type) {
+	case *cli.StringSlice:
+		*v = cli.StringSlice{}
+	case *cli.IntSlice:
+		*v = cli.IntSlice{}
+	case *cli.Int64Slice:
+		*v = cli.Int64Slice{}
+	default:
+		if len(items) != 1 {
+			return errors.New("a single value is expected")
+		}
+	}
+
+	for _, item := range items {
+		err := values.Set(name, item)
+		if err != nil {
+			return fmt.Errorf("invalid value %q: %w", item, err)
+		}
+	}
+
+	return nil
+}
+
+// setFlags returns the flags set on the command line or by an environment variable.
+func setFlags(flags []cli.Flag, isSet func(name string) bool) map[string]bool {
+	explicit := map[string]bool{}
+	for _, f := range flags {
+		if isSet(flagName(f)) {
+			explicit[flagName(f)] = true
+		}
+	}
+
+	return explicit
+}
+
+type errorableFlag interface {
+	ApplyWithError(set *flag.FlagSet) error
+}
+
+func newFlagSet(flags []cli.Flag) (*flag.FlagSet, error) {
+	set := flag.NewFlagSet("lego", flag.ContinueOnError)
+	set.SetOutput(ioutil.Discard)
+
+	for _, f := range flags {
+		if ef, ok := f.(errorableFlag); ok {
+			if err := ef.ApplyWithError(set); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		f.Apply(set)
+	}
+
+	return set, nil
+}
+
+// flagName returns the name of a flag (without the aliases).
+func flagName(f cli.Flag) string {
+	return strings.TrimSpace(strings.Split(f.GetName(), ",")[0])
+}
+
+func configValueToStrings(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case string:
+		return []string{v}, nil
+	case bool:
+		return []string{strconv.FormatBool(v)}, nil
+	case int, int64, uint64, float64:
+		return []string{fmt.Sprint(v)}, nil
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			switch item.(type) {
+			case []interface{}, map[string]interface{}:
+				return nil, errors.New("nested values are not supported")
+			}
+
+			itemValues, err := configValueToStrings(item)
+			if err != nil {
+				return nil, err
+			}
+
+			values = append(values, itemValues...)
+		}
+
+		return values, nil
+	default:
+		return nil, fmt.Errorf("unsupported value type: %T", value)
+	}
+}
+
+func normalizeYAMLMap(data map[interface{}]interface{}) (map[string]interface{}, error) {
+	result := map[string]interface{}{}
+
+	for k, v := range data {
+		key, ok := k.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid key: %v", k)
+		}
+
+		value, err := normalizeYAMLValue(v)
+		if err != nil {
+			return nil, err
+		}
+
+		result[key] = value
+	}
+
+	return result, nil
+}
+
+func normalizeYAMLValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		return normalizeYAMLMap(v)
+	case []interface{}:
+		var items []interface{}
+		for _, item := range v {
+			normalized, err := normalizeYAMLValue(item)
+			if err != nil {
+				return nil, err
+			}
+
+			items = append(items, normalized)
+		}
+
+		return items, nil
+	default:
+		return value, nil
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,339 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli"
+)
+
+const testConfigTOML = `
+email = "foo@example.com"
+dns = "cloudflare"
+"dns.resolvers" = ["1.1.1.1:53", "8.8.8.8:53"]
+
+[renew]
+days = 45
+
+[providers.cloudflare]
+CF_DNS_API_TOKEN = "secret"
+
+[certificates.example]
+domains = ["example.com", "*.example.com"]
+key-type = "ec384"
+
+[certificates.example.renew]
+reuse-key = true
+
+[certificates.example.providers.cloudflare]
+CF_ZONE_API_TOKEN_FILE = "/run/secrets/zone"
+`
+
+const testConfigYAML = `
+email: foo@example.com
+dns: cloudflare
+dns.resolvers:
+  - 1.1.1.1:53
+  - 8.8.8.8:53
+renew:
+  days: 45
+providers:
+  cloudflare:
+    CF_DNS_API_TOKEN: secret
+certificates:
+  example:
+    domains:
+      - example.com
+      - "*.example.com"
+    key-type: ec384
+    renew:
+      reuse-key: true
+    providers:
+      cloudflare:
+        CF_ZONE_API_TOKEN_FILE: /run/secrets/zone
+`
+
+func newTestApp() *cli.App {
+	app := cli.NewApp()
+	app.Flags = CreateFlags("")
+	app.Commands = CreateCommands()
+
+	return app
+}
+
+func writeTestConfig(t *testing.T, filename, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), filename)
+
+	err := ioutil.WriteFile(path, []byte(content), 0o600)
+	require.NoError(t, err)
+
+	return path
+}
+
+func Test_loadConfiguration(t *testing.T) {
+	testCases := []struct {
+		filename string
+		content  string
+	}{
+		{filename: "lego.toml", content: testConfigTOML},
+		{filename: "lego.yaml", content: testConfigYAML},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.filename, func(t *testing.T) {
+			t.Parallel()
+
+			conf, err := loadConfiguration(writeTestConfig(t, test.filename, test.content), newTestApp())
+			require.NoError(t, err)
+
+			assert.Equal(t, []string{"example"}, conf.certificateNames())
+
+			section, err := conf.section("")
+			require.NoError(t, err)
+
+			assert.Equal(t, "foo@example.com", section.Options["email"])
+			assert.Equal(t, "cloudflare", section.Options["dns"])
+			assert.EqualValues(t, 45, section.Commands["renew"]["days"])
+			assert.Equal(t, map[string]string{"CF_DNS_API_TOKEN": "secret"}, section.Providers["cloudflare"])
+
+			section, err = conf.section("example")
+			require.NoError(t, err)
+
+			assert.Equal(t, "foo@example.com", section.Options["email"])
+			assert.Equal(t, "ec384", section.Options["key-type"])
+			assert.EqualValues(t, 45, section.Commands["renew"]["days"])
+			assert.Equal(t, true, section.Commands["renew"]["reuse-key"])
+
+			expectedProviders := map[string]string{
+				"CF_DNS_API_TOKEN":       "secret",
+				"CF_ZONE_API_TOKEN_FILE": "/run/secrets/zone",
+			}
+			assert.Equal(t, expectedProviders, section.Providers["cloudflare"])
+
+			_, err = conf.section("unknown")
+			require.Error(t, err)
+		})
+	}
+}
+
+func Test_loadConfiguration_errors(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		filename string
+		content  string
+		expected string
+	}{
+		{
+			desc:     "unsupported format",
+			filename: "lego.json",
+			content:  `{}`,
+			expected: "unsupported configuration file format",
+		},
+		{
+			desc:     "unknown option",
+			filename: "lego.toml",
+			content:  `foo = "bar"`,
+			expected: `unknown option "foo"`,
+		},
+		{
+			desc:     "forbidden option",
+			filename: "lego.toml",
+			content:  `"config.certificate" = "example"`,
+			expected: `unknown option "config.certificate"`,
+		},
+		{
+			desc:     "unknown command option",
+			filename: "lego.toml",
+			content:  "[renew]\nfoo = 1",
+			expected: `renew: unknown option "foo"`,
+		},
+		{
+			desc:     "invalid value",
+			filename: "lego.toml",
+			content:  "[renew]\ndays = \"abc\"",
+			expected: `renew: days: invalid value "abc"`,
+		},
+		{
+			desc:     "multiple values",
+			filename: "lego.toml",
+			content:  `email = ["a@example.com", "b@example.com"]`,
+			expected: "email: a single value is expected",
+		},
+		{
+			desc:     "unknown provider",
+			filename: "lego.toml",
+			content:  "[providers.foo]\nFOO = \"bar\"",
+			expected: "providers.foo: unknown DNS provider",
+		},
+		{
+			desc:     "unknown provider environment variable",
+			filename: "lego.toml",
+			content:  "[providers.cloudflare]\nFOO = \"bar\"",
+			expected: "providers.cloudflare: unknown environment variable FOO",
+		},
+		{
+			desc:     "invalid certificate section",
+			filename: "lego.yaml",
+			content:  "certificates:\n  example:\n    foo: bar",
+			expected: `certificates.example: unknown option "foo"`,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := loadConfiguration(writeTestConfig(t, test.filename, test.content), newTestApp())
+			require.Error(t, err)
+
+			assert.Contains(t, err.Error(), test.expected)
+		})
+	}
+}
+
+func Test_applyConfigOptions(t *testing.T) {
+	flags := []cli.Flag{
+		cli.StringFlag{Name: "email, m"},
+		cli.StringFlag{Name: "server", Value: "default"},
+		cli.StringSliceFlag{Name: "domains, d"},
+		cli.IntFlag{Name: "days", Value: 30},
+		cli.BoolFlag{Name: "pem"},
+	}
+
+	set, err := newFlagSet(flags)
+	require.NoError(t, err)
+
+	err = set.Parse([]string{"--email", "explicit@example.com"})
+	require.NoError(t, err)
+
+	app := cli.NewApp()
+	app.Flags = flags
+
+	explicit := setFlags(flags, cli.NewContext(app, set, nil).GlobalIsSet)
+
+	assert.Equal(t, map[string]bool{"email": true}, explicit)
+
+	options := map[string]interface{}{
+		"email":   "config@example.com",
+		"domains": []interface{}{"example.com", "example.org"},
+		"days":    int64(45),
+		"pem":     true,
+	}
+
+	err = applyConfigOptions(flagSetValues{set}, flags, options, explicit)
+	require.NoError(t, err)
+
+	assert.Equal(t, "explicit@example.com", set.Lookup("email").Value.String())
+	assert.Equal(t, "default", set.Lookup("server").Value.String())
+	assert.Equal(t, []string{"example.com", "example.org"}, []string(*set.Lookup("domains").Value.(*cli.StringSlice)))
+	assert.Equal(t, "45", set.Lookup("days").Value.String())
+	assert.Equal(t, "true", set.Lookup("pem").Value.String())
+}
+
+func Test_runForEachCertificate(t *testing.T) {
+	dir := t.TempDir()
+
+	// the environment variables take precedence over the configuration file.
+	t.Setenv("LEGO_PATH", filepath.Join(dir, "env"))
+
+	filename := writeTestConfig(t, "lego.toml", `
+email = "foo@example.com"
+dns = "cloudflare"
+path = "`+filepath.Join(dir, "config")+`"
+
+[renew]
+days = 45
+
+[providers.cloudflare]
+CF_DNS_API_TOKEN = "secret"
+
+[certificates.a]
+domains = ["a.example.com"]
+key-type = "ec384"
+
+[certificates.a.renew]
+days = 20
+
+[certificates.a.providers.cloudflare]
+CF_DNS_API_TOKEN = "secret-a"
+
+[certificates.b]
+domains = ["b.example.com"]
+email = "b@example.com"
+
+[certificates.c]
+key-type = "rsa2048"
+`)
+
+	type result struct {
+		path      string
+		domains   []string
+		email     string
+		keyType   string
+		days      int
+		reuseKey  bool
+		providers map[string]string
+	}
+
+	results := map[string]result{}
+
+	renew := createRenew()
+	renew.Action = func(ctx *cli.Context) error {
+		return runForEachCertificate(ctx, func(ctx *cli.Context) error {
+			providers, err := getProviderValues(ctx)
+			if err != nil {
+				return err
+			}
+
+			results[ctx.GlobalString("config.certificate")] = result{
+				path:      ctx.GlobalString("path"),
+				domains:   ctx.GlobalStringSlice("domains"),
+				email:     ctx.GlobalString("email"),
+				keyType:   ctx.GlobalString("key-type"),
+				days:      ctx.Int("days"),
+				reuseKey:  ctx.Bool("reuse-key"),
+				providers: providers,
+			}
+
+			return nil
+		})
+	}
+
+	app := cli.NewApp()
+	app.Flags = CreateFlags("")
+	app.Before = Before
+	app.Commands = []cli.Command{withConfiguration(renew)}
+
+	err := app.Run([]string{"lego", "--config", filename, "--key-type", "rsa4096", "renew", "--reuse-key"})
+	require.EqualError(t, err, "failed certificate sections: c")
+
+	expected := map[string]result{
+		"a": {
+			path:      filepath.Join(dir, "env"),
+			domains:   []string{"a.example.com"},
+			email:     "foo@example.com",
+			keyType:   "rsa4096",
+			days:      20,
+			reuseKey:  true,
+			providers: map[string]string{"CF_DNS_API_TOKEN": "secret-a"},
+		},
+		"b": {
+			path:      filepath.Join(dir, "env"),
+			domains:   []string{"b.example.com"},
+			email:     "b@example.com",
+			keyType:   "rsa4096",
+			days:      45,
+			reuseKey:  true,
+			providers: map[string]string{"CF_DNS_API_TOKEN": "secret"},
+		},
+	}
+
+	assert.Equal(t, expected, results)
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/log"
+	"github.com/urfave/cli"
+)
+
+// createCSRFlags the flags related to the customization of the CSR generated by lego.
+func createCSRFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringSliceFlag{
+			Name:  "csr.organization",
+			Usage: "The organization (O) of the subject of the CSR. Can be specified multiple times.",
+		},
+		cli.StringSliceFlag{
+			Name:  "csr.organizational-unit",
+			Usage: "The organizational unit (OU) of the subject of the CSR. Can be specified multiple times.",
+		},
+		cli.StringSliceFlag{
+			Name:  "csr.country",
+			Usage: "The country (C) of the subject of the CSR. Can be specified multiple times.",
+		},
+		cli.StringSliceFlag{
+			Name:  "csr.province",
+			Usage: "The province (ST) of the subject of the CSR. Can be specified multiple times.",
+		},
+		cli.StringSliceFlag{
+			Name:  "csr.locality",
+			Usage: "The locality (L) of the subject of the CSR. Can be specified multiple times.",
+		},
+		cli.StringSliceFlag{
+			Name:  "csr.email",
+			Usage: "An email SAN of the CSR. Can be specified multiple times.",
+		},
+		cli.StringSliceFlag{
+			Name:  "csr.uri",
+			Usage: "An URI SAN of the CSR (ex: spiffe://example.com/service). Can be specified multiple times.",
+		},
+		cli.BoolFlag{
+			Name:  "csr.no-cn",
+			Usage: "Create a CSR without common name (the domains are only in the SANs).",
+		},
+		cli.StringSliceFlag{
+			Name:  "csr.key-usage",
+			Usage: "A key usage of the CSR (ex: digitalSignature, keyEncipherment). Can be specified multiple times.",
+		},
+		cli.StringSliceFlag{
+			Name:  "csr.ext-key-usage",
+			Usage: "An extended key usage of the CSR (ex: serverAuth, clientAuth, or an OID). Can be specified multiple times.",
+		},
+		cli.StringSliceFlag{
+			Name:  "csr.extension",
+			Usage: "An extra extension of the CSR: '<OID>=<DER value in hex>', 'critical,<OID>=<DER value in hex>' for a critical extension. Can be specified multiple times.",
+		},
+	}
+}
+
+// getCSRTemplate the template of the CSR generated by lego.
+// These options are ignored when the CSR is defined by --csr.
+func getCSRTemplate(ctx *cli.Context) *x509.CertificateRequest {
+	template := &x509.CertificateRequest{
+		Subject: pkix.Name{
+			Organization:       ctx.StringSlice("csr.organization"),
+			OrganizationalUnit: ctx.StringSlice("csr.organizational-unit"),
+			Country:            ctx.StringSlice("csr.country"),
+			Province:           ctx.StringSlice("csr.province"),
+			Locality:           ctx.StringSlice("csr.locality"),
+		},
+		EmailAddresses: ctx.StringSlice("csr.email"),
+	}
+
+	for _, value := range ctx.StringSlice("csr.uri") {
+		uri, err := url.Parse(value)
+		if err != nil || uri.Scheme == "" {
+			log.Fatalf("Invalid --csr.uri %q", value)
+		}
+
+		template.URIs = append(template.URIs, uri)
+	}
+
+	if usages := ctx.StringSlice("csr.key-usage"); len(usages) > 0 {
+		ext, err := certcrypto.KeyUsageExtension(usages)
+		if err != nil {
+			log.Fatalf("Invalid --csr.key-usage: %v", err)
+		}
+
+		template.ExtraExtensions = append(template.ExtraExtensions, ext)
+	}
+
+	if usages := ctx.StringSlice("csr.ext-key-usage"); len(usages) > 0 {
+		ext, err := certcrypto.ExtKeyUsageExtension(usages)
+		if err != nil {
+			log.Fatalf("Invalid --csr.ext-key-usage: %v", err)
+		}
+
+		template.ExtraExtensions = append(template.ExtraExtensions, ext)
+	}
+
+	for _, value := range ctx.StringSlice("csr.extension") {
+		ext, err := parseCSRExtension(value)
+		if err != nil {
+			log.Fatalf("Invalid --csr.extension: %v", err)
+		}
+
+		template.ExtraExtensions = append(template.ExtraExtensions, ext)
+	}
+
+	return template
+}
+
+// parseCSRExtension parses an extension: "[critical,]<OID>=<DER value in hex>".
+func parseCSRExtension(value string) (pkix.Extension, error) {
+	var ext pkix.Extension
+
+	raw := value
+	if strings.HasPrefix(raw, "critical,") {
+		ext.Critical = true
+		raw = strings.TrimPrefix(raw, "critical,")
+	}
+
+	parts := strings.SplitN(raw, "=", 2)
+	if len(parts) != 2 {
+		return pkix.Extension{}, fmt.Errorf("%q: the format is '[critical,]<OID>=<DER value in hex>'", value)
+	}
+
+	oid, err := certcrypto.ParseOID(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return pkix.Extension{}, fmt.Errorf("%q: %w", value, err)
+	}
+
+	ext.Id = oid
+
+	ext.Value, err = hex.DecodeString(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return pkix.Extension{}, fmt.Errorf("%q: invalid DER value: %w", value, err)
+	}
+
+	return ext, nil
+}
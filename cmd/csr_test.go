@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseCSRExtension(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		value    string
+		expected pkix.Extension
+	}{
+		{
+			desc:     "extension",
+			value:    "1.2.3.4=0500",
+			expected: pkix.Extension{Id: asn1.ObjectIdentifier{1, 2, 3, 4}, Value: []byte{0x05, 0x00}},
+		},
+		{
+			desc:     "critical extension",
+			value:    "critical,1.2.3.4 = 0101ff",
+			expected: pkix.Extension{Id: asn1.ObjectIdentifier{1, 2, 3, 4}, Critical: true, Value: []byte{0x01, 0x01, 0xff}},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			ext, err := parseCSRExtension(test.value)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, ext)
+		})
+	}
+}
+
+func Test_parseCSRExtension_error(t *testing.T) {
+	for _, value := range []string{"1.2.3.4", "foo=0500", "1.2.3.4=zz", "critical=0500"} {
+		_, err := parseCSRExtension(value)
+		assert.Error(t, err, value)
+	}
+}
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/go-acme/lego/v4/lego"
+	"github.com/go-acme/lego/v4/log"
+	"github.com/go-acme/lego/v4/registration"
+	"github.com/urfave/cli"
+)
+
+// certificateObtainer obtains certificates (certificate.Certifier or failoverObtainer).
+type certificateObtainer interface {
+	Obtain(request certificate.ObtainRequest) (*certificate.Resource, error)
+	ObtainForCSR(request certificate.ObtainForCSRRequest) (*certificate.Resource, error)
+}
+
+// failoverObtainer obtains certificates through a failover client,
+// the CA which has issued the previous certificate is tried first.
+type failoverObtainer struct {
+	client       *lego.FailoverClient
+	certsStorage *CertificatesStorage
+}
+
+func (o failoverObtainer) Obtain(request certificate.ObtainRequest) (*certificate.Resource, error) {
+	return o.client.Obtain(request, o.previousCA(request.Domains[0]))
+}
+
+func (o failoverObtainer) ObtainForCSR(request certificate.ObtainForCSRRequest) (*certificate.Resource, error) {
+	return o.client.ObtainForCSR(request, o.previousCA(request.CSR.Subject.CommonName))
+}
+
+// previousCA returns the directory URL of the CA which has issued the previous certificate of the domain, if any.
+func (o failoverObtainer) previousCA(domain string) string {
+	if !o.certsStorage.ExistsFile(domain, ".json") {
+		return ""
+	}
+
+	return o.certsStorage.ReadResource(domain).CADirURL
+}
+
+// failoverServer a failover server and its options.
+type failoverServer struct {
+	URL            string
+	Kid            string
+	HMAC           string
+	PreferredChain string
+}
+
+// parseFailoverServer parses a failover server: "<URL>[,kid=<kid>,hmac=<hmac>,preferred-chain=<common name>]".
+func parseFailoverServer(value string) (failoverServer, error) {
+	parts := strings.Split(value, ",")
+
+	server := failoverServer{URL: strings.TrimSpace(parts[0])}
+	if server.URL == "" {
+		return failoverServer{}, fmt.Errorf("%q: the URL is required", value)
+	}
+
+	for _, part := range parts[1:] {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return failoverServer{}, fmt.Errorf("%q: invalid option %q (key=value expected)", value, part)
+		}
+
+		switch strings.TrimSpace(kv[0]) {
+		case "kid":
+			server.Kid = strings.TrimSpace(kv[1])
+		case "hmac":
+			server.HMAC = strings.TrimSpace(kv[1])
+		case "preferred-chain":
+			server.PreferredChain = strings.TrimSpace(kv[1])
+		default:
+			return failoverServer{}, fmt.Errorf("%q: unknown option %q", value, kv[0])
+		}
+	}
+
+	if (server.Kid == "") != (server.HMAC == "") {
+		return failoverServer{}, fmt.Errorf("%q: the EAB requires both kid and hmac", value)
+	}
+
+	return server, nil
+}
+
+func hasFailoverServers(ctx *cli.Context) bool {
+	return len(ctx.GlobalStringSlice("failover-server")) > 0
+}
+
+// setupFailover creates a failover client with the main server, then the failover servers.
+// The client of a CA is only created (and the account registered) when the CA is used.
+func setupFailover(ctx *cli.Context, certsStorage *CertificatesStorage) failoverObtainer {
+	keyType := getKeyType(ctx)
+
+	// the main server uses the global options (EAB, preferred chain).
+	servers := []failoverServer{{URL: ctx.GlobalString("server")}}
+
+	for _, value := range ctx.GlobalStringSlice("failover-server") {
+		server, err := parseFailoverServer(value)
+		if err != nil {
+			log.Fatalf("Invalid --failover-server: %v", err)
+		}
+
+		servers = append(servers, server)
+	}
+
+	var cas []lego.FailoverCA
+	for i, server := range servers {
+		server := server
+		accountsStorage := newAccountsStorage(ctx, server.URL)
+		account := getAccount(accountsStorage, getAccountKeyType(ctx))
+		mainServer := i == 0
+
+		cas = append(cas, lego.FailoverCA{
+			Config:         newConfig(ctx, account, keyType, server.URL),
+			PreferredChain: server.PreferredChain,
+			Setup: func(client *lego.Client) error {
+				setupChallenges(ctx, client)
+
+				if account.Registration != nil {
+					return checkTermsOfService(ctx, client, account, accountsStorage)
+				}
+
+				reg, err := registerFailover(ctx, client, mainServer, server)
+				if err != nil {
+					return fmt.Errorf("could not complete registration: %w", err)
+				}
+
+				account.Registration = reg
+				account.TermsOfService = client.GetToSURL()
+				if err = accountsStorage.Save(account); err != nil {
+					return err
+				}
+
+				fmt.Printf(rootPathWarningMessage, accountsStorage.GetRootPath())
+
+				return nil
+			},
+		})
+	}
+
+	client, err := lego.NewFailoverClient(cas...)
+	if err != nil {
+		log.Fatalf("Could not create client: %v", err)
+	}
+
+	return failoverObtainer{client: client, certsStorage: certsStorage}
+}
+
+// registerFailover registers an account.
+// The global EAB options are only related to the main server,
+// the EAB of a failover server is defined by its options (kid and hmac).
+func registerFailover(ctx *cli.Context, client *lego.Client, mainServer bool, server failoverServer) (*registration.Resource, error) {
+	if mainServer {
+		return register(ctx, client)
+	}
+
+	if server.Kid == "" && client.GetExternalAccountRequired() {
+		return nil, errors.New("the server requires External Account Binding: define kid and hmac in --failover-server")
+	}
+
+	if !handleTOS(ctx, client) {
+		return nil, errors.New("the TOS are not accepted")
+	}
+
+	if server.Kid != "" {
+		return client.Registration.RegisterWithExternalAccountBinding(registration.RegisterEABOptions{
+			TermsOfServiceAgreed: true,
+			Kid:                  server.Kid,
+			HmacEncoded:          server.HMAC,
+		})
+	}
+
+	return client.Registration.Register(registration.RegisterOptions{TermsOfServiceAgreed: true})
+}
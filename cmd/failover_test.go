@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseFailoverServer(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		value    string
+		expected failoverServer
+	}{
+		{
+			desc:     "URL only",
+			value:    "https://acme.example.com/directory",
+			expected: failoverServer{URL: "https://acme.example.com/directory"},
+		},
+		{
+			desc:  "EAB and preferred chain",
+			value: "https://acme.example.com/directory,kid=kid-1,hmac=c2VjcmV0,preferred-chain=ISRG Root X1",
+			expected: failoverServer{
+				URL:            "https://acme.example.com/directory",
+				Kid:            "kid-1",
+				HMAC:           "c2VjcmV0",
+				PreferredChain: "ISRG Root X1",
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			server, err := parseFailoverServer(test.value)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, server)
+		})
+	}
+}
+
+func Test_parseFailoverServer_errors(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		value    string
+		expected string
+	}{
+		{
+			desc:     "no URL",
+			value:    ",kid=kid-1,hmac=c2VjcmV0",
+			expected: `",kid=kid-1,hmac=c2VjcmV0": the URL is required`,
+		},
+		{
+			desc:     "unknown option",
+			value:    "https://acme.example.com/directory,foo=bar",
+			expected: `"https://acme.example.com/directory,foo=bar": unknown option "foo"`,
+		},
+		{
+			desc:     "kid without hmac",
+			value:    "https://acme.example.com/directory,kid=kid-1",
+			expected: `"https://acme.example.com/directory,kid=kid-1": the EAB requires both kid and hmac`,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := parseFailoverServer(test.value)
+			require.EqualError(t, err, test.expected)
+		})
+	}
+}
@@ -1,12 +1,22 @@
 package cmd
 
 import (
+	"github.com/go-acme/lego/v4/certcrypto"
 	"github.com/go-acme/lego/v4/lego"
 	"github.com/urfave/cli"
 )
 
 func CreateFlags(defaultPath string) []cli.Flag {
 	return []cli.Flag{
+		cli.StringFlag{
+			Name:   "config",
+			Usage:  "Load the options from a configuration file (toml or yaml). The command line flags and the environment variables take precedence over the configuration file.",
+			EnvVar: "LEGO_CONFIG",
+		},
+		cli.StringFlag{
+			Name:  "config.certificate",
+			Usage: "Use the options of a certificate section of the configuration file. By default, 'run' and 'renew' are executed for each certificate section.",
+		},
 		cli.StringSliceFlag{
 			Name:  "domains, d",
 			Usage: "Add a domain to the process. Can be specified multiple times.",
@@ -16,6 +26,10 @@ func CreateFlags(defaultPath string) []cli.Flag {
 			Usage: "CA hostname (and optionally :port). The server certificate must be trusted in order to avoid further modifications to the client.",
 			Value: lego.LEDirectoryProduction,
 		},
+		cli.StringSliceFlag{
+			Name:  "failover-server",
+			Usage: "CA used when the main server is unreachable, has an internal error, or rate limits the client. Can be specified multiple times (tried in order). Format: '<URL>[,kid=<kid>,hmac=<hmac>,preferred-chain=<common name>]' (EAB and preferred chain of this server).",
+		},
 		cli.BoolFlag{
 			Name:  "accept-tos, a",
 			Usage: "By setting this flag to true you indicate that you accept the current Let's Encrypt terms of service.",
@@ -28,6 +42,14 @@ func CreateFlags(defaultPath string) []cli.Flag {
 			Name:  "csr, c",
 			Usage: "Certificate signing request filename, if an external CSR is to be used.",
 		},
+		cli.StringFlag{
+			Name:  "account-key",
+			Usage: "Use the account key identified by this PKCS#11 URI (RFC 7512) instead of a key stored by lego (ex: 'pkcs11:token=lego;object=account?module-path=/usr/lib/softhsm/libsofthsm2.so&pin-source=/etc/lego/pin'). The URI is stored in the account.",
+		},
+		cli.StringFlag{
+			Name:  "cert-key",
+			Usage: "Use the certificate key identified by this PKCS#11 URI (RFC 7512) instead of generating a key. The URI is stored with the certificate, and used by the renewals.",
+		},
 		cli.BoolFlag{
 			Name:  "eab",
 			Usage: "Use External Account Binding for account registration. Requires --kid and --hmac.",
@@ -43,7 +65,11 @@ func CreateFlags(defaultPath string) []cli.Flag {
 		cli.StringFlag{
 			Name:  "key-type, k",
 			Value: "ec256",
-			Usage: "Key type to use for private keys. Supported: rsa2048, rsa4096, rsa8192, ec256, ec384.",
+			Usage: "Key type to use for private keys. Supported: rsa2048, rsa3072, rsa4096, rsa8192, ec256, ec384, ec521, ed25519 (if accepted by the CA).",
+		},
+		cli.StringFlag{
+			Name:  "account-key-type",
+			Usage: "Key type of the account key, used when the account key is generated. Supported: rsa2048, rsa3072, rsa4096, rsa8192, ec256, ec384, ec521, ed25519 (if accepted by the CA). Default: the key type of the private keys (--key-type).",
 		},
 		cli.StringFlag{
 			Name:  "filename",
@@ -94,6 +120,14 @@ func CreateFlags(defaultPath string) []cli.Flag {
 			Name:  "dns.disable-cp",
 			Usage: "By setting this flag to true, disables the need to wait the propagation of the TXT record to all authoritative name servers.",
 		},
+		cli.BoolFlag{
+			Name:  "dns.account",
+			Usage: "Solve the dns-account-01 challenge (account-scoped TXT record) with the DNS provider when the server offers it, the dns-01 challenge is used otherwise. The DNS provider must support explicit records (exec, httpreq).",
+		},
+		cli.BoolFlag{
+			Name:  "dns-persist",
+			Usage: "Solve the dns-persist-01 challenge when the server offers it: the records must be created beforehand (see 'lego dns-persist setup'). Can be mixed with other types of challenges.",
+		},
 		cli.StringSliceFlag{
 			Name:  "dns.resolvers",
 			Usage: "Set the resolvers to use for performing recursive DNS queries. Supported: host:port. The default is to use the system resolvers, or Google's DNS resolvers if the system's cannot be determined.",
@@ -109,12 +143,53 @@ func CreateFlags(defaultPath string) []cli.Flag {
 		},
 		cli.BoolFlag{
 			Name:  "pem",
-			Usage: "Generate a .pem file by concatenating the .key and .crt files together.",
+			Usage: "Generate a .pem file by concatenating the .key and .crt files together. Cannot be used with the encryption of the private keys (--keys.*).",
+		},
+		cli.BoolFlag{
+			Name:  "pfx",
+			Usage: "Generate a .pfx (PKCS#12) file with the private key, the certificate and the issuer certificates.",
+		},
+		cli.StringFlag{
+			Name:   "pfx.password",
+			Usage:  "The password used to encrypt the .pfx (PKCS#12) file. Required with --pfx.",
+			EnvVar: "LEGO_PFX_PASSWORD",
+		},
+		cli.StringFlag{
+			Name:  "pfx.profile",
+			Usage: "The encryption profile of the .pfx (PKCS#12) file: 'modern' (AES-256, SHA-256 MAC: Windows Server 2019+, OpenSSL 3, Java 8u301+) or 'legacy' (3DES/RC2, SHA-1 MAC: only for older consumers like Windows Server 2016 and Java 8).",
+			Value: string(certcrypto.PFXModern),
+		},
+		cli.StringFlag{
+			Name:   "keys.passphrase",
+			Usage:  "Encrypt the stored private keys (encrypted PKCS#8) with this passphrase. Prefer the environment variable or --keys.passphrase-file.",
+			EnvVar: "LEGO_KEYS_PASSPHRASE",
+		},
+		cli.StringFlag{
+			Name:   "keys.passphrase-file",
+			Usage:  "Encrypt the stored private keys (encrypted PKCS#8) with the passphrase contained in this file.",
+			EnvVar: "LEGO_KEYS_PASSPHRASE_FILE",
+		},
+		cli.StringSliceFlag{
+			Name:  "keys.age-recipient",
+			Usage: "Encrypt the stored private keys with age for this recipient (age1...). Can be specified multiple times.",
+		},
+		cli.StringFlag{
+			Name:   "keys.age-identity",
+			Usage:  "The age identity file used to decrypt the stored private keys.",
+			EnvVar: "LEGO_KEYS_AGE_IDENTITY",
+		},
+		cli.BoolFlag{
+			Name:  "versioned",
+			Usage: "Store each certificate in its own version directory (certificates/versions/), the current version is linked by certificates/live/<domain>/.",
 		},
 		cli.IntFlag{
 			Name:  "cert.timeout",
 			Usage: "Set the certificate timeout value to a specific value in seconds. Only used when obtaining certificates.",
 			Value: 30,
 		},
+		cli.BoolFlag{
+			Name:  "cert.check-caa",
+			Usage: "Check that the CAA records of the domains allow the CA to issue the certificate before creating an order (the challenge is chosen among the ones allowed by the validationmethods parameter).",
+		},
 	}
 }
@@ -1,27 +1,312 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"runtime"
 	"strings"
 	"time"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/go-acme/lego/v4/log"
+	"github.com/urfave/cli"
+)
+
+// Hook events.
+const (
+	hookEventPre     = "pre"
+	hookEventPost    = "post"
+	hookEventDeploy  = "deploy"
+	hookEventRevoked = "revoked"
+)
+
+// Hook failure policies.
+const (
+	hookPolicyFail   = "fail"
+	hookPolicyWarn   = "warn"
+	hookPolicyIgnore = "ignore"
+)
+
+const (
+	hookEnvEvent = "LEGO_HOOK_EVENT"
+	hookEnvError = "LEGO_HOOK_ERROR"
 )
 
-func launchHook(hook string, meta map[string]string) error {
+func createHookFlags() []cli.Flag {
+	return append([]cli.Flag{
+		cli.StringFlag{
+			Name:  "pre-hook",
+			Usage: "Define a hook executed before the certificate is requested. With the 'fail' policy, a failure aborts the request.",
+		},
+		cli.StringFlag{
+			Name:  "post-hook",
+			Usage: "Define a hook executed after the certificate is requested, even if the request failed (the error is passed to the hook).",
+		},
+		cli.StringFlag{
+			Name:  "deploy-hook",
+			Usage: "Define a hook executed for each certificate effectively issued, after the certificate is saved.",
+		},
+	}, createHookOptionFlags()...)
+}
+
+// createHookOptionFlags the flags related to the execution of the hooks.
+func createHookOptionFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.IntFlag{
+			Name:  "hook.timeout",
+			Usage: "The timeout of a hook in seconds.",
+			Value: 120,
+		},
+		cli.BoolFlag{
+			Name:  "hook.shell",
+			Usage: "Execute the hooks with the shell ('sh -c' or 'cmd /C' on Windows) instead of splitting the hook into arguments.",
+		},
+		cli.StringFlag{
+			Name:  "hook.failure-policy",
+			Usage: "The behavior when a hook fails: 'fail' (exit with an error), 'warn' (log the error and continue), or 'ignore'.",
+			Value: hookPolicyFail,
+		},
+	}
+}
+
+// hookPayload the JSON document sent to the hooks on stdin.
+type hookPayload struct {
+	Event       string           `json:"event"`
+	Account     string           `json:"account,omitempty"`
+	CAURL       string           `json:"caUrl,omitempty"`
+	Domains     []string         `json:"domains,omitempty"`
+	Certificate *hookCertificate `json:"certificate,omitempty"`
+	Error       string           `json:"error,omitempty"`
+}
+
+type hookCertificate struct {
+	Domain    string            `json:"domain"`
+	Domains   []string          `json:"domains"`
+	Serial    string            `json:"serial"`
+	NotBefore time.Time         `json:"notBefore"`
+	NotAfter  time.Time         `json:"notAfter"`
+	Issuer    string            `json:"issuer"`
+	CertURL   string            `json:"certUrl,omitempty"`
+	Files     map[string]string `json:"files"`
+}
+
+// hooks launches the pre, post, deploy, and revoked hooks.
+type hooks struct {
+	pre     string
+	post    string
+	deploy  []string
+	revoked string
+	timeout time.Duration
+	shell   bool
+	policy  string
+
+	account string
+	caURL   string
+	domains []string
+}
+
+func newHooks(ctx *cli.Context, deploy ...string) *hooks {
+	policy := ctx.String("hook.failure-policy")
+	switch policy {
+	case hookPolicyFail, hookPolicyWarn, hookPolicyIgnore:
+	default:
+		log.Fatalf("Unsupported hook failure policy: %s", policy)
+	}
+
+	h := &hooks{
+		pre:     ctx.String("pre-hook"),
+		post:    ctx.String("post-hook"),
+		revoked: ctx.String("revoked-hook"),
+		timeout: time.Duration(ctx.Int("hook.timeout")) * time.Second,
+		shell:   ctx.Bool("hook.shell"),
+		policy:  policy,
+		account: ctx.GlobalString("email"),
+		caURL:   ctx.GlobalString("server"),
+		domains: ctx.GlobalStringSlice("domains"),
+	}
+
+	for _, hook := range append([]string{ctx.String("deploy-hook")}, deploy...) {
+		if hook != "" {
+			h.deploy = append(h.deploy, hook)
+		}
+	}
+
+	return h
+}
+
+// Pre launches the pre-hook.
+func (h *hooks) Pre() error {
+	payload := h.newPayload(hookEventPre)
+
+	meta := map[string]string{renewEnvAccountEmail: h.account}
+
+	return h.launch(h.pre, meta, payload)
+}
+
+// Post launches the post-hook with the result of the request.
+func (h *hooks) Post(certsStorage *CertificatesStorage, certRes *certificate.Resource, errObtain error) error {
+	payload := h.newPayload(hookEventPost)
+
+	meta := map[string]string{renewEnvAccountEmail: h.account}
+
+	if errObtain != nil {
+		payload.Error = errObtain.Error()
+		meta[hookEnvError] = errObtain.Error()
+	}
+
+	if certRes != nil {
+		h.addCertificate(payload, meta, certsStorage, certRes)
+	}
+
+	return h.launch(h.post, meta, payload)
+}
+
+// Deploy launches the deploy hooks for a saved certificate.
+func (h *hooks) Deploy(certsStorage *CertificatesStorage, certRes *certificate.Resource) error {
+	payload := h.newPayload(hookEventDeploy)
+
+	meta := map[string]string{renewEnvAccountEmail: h.account}
+
+	h.addCertificate(payload, meta, certsStorage, certRes)
+
+	for _, hook := range h.deploy {
+		err := h.launch(hook, meta, payload)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Revoked launches the revoked hook for a certificate reported as revoked by the OCSP responder.
+func (h *hooks) Revoked(certsStorage *CertificatesStorage, certRes *certificate.Resource) error {
+	payload := h.newPayload(hookEventRevoked)
+
+	meta := map[string]string{renewEnvAccountEmail: h.account}
+
+	h.addCertificate(payload, meta, certsStorage, certRes)
+
+	return h.launch(h.revoked, meta, payload)
+}
+
+func (h *hooks) newPayload(event string) *hookPayload {
+	return &hookPayload{
+		Event:   event,
+		Account: h.account,
+		CAURL:   h.caURL,
+		Domains: h.domains,
+	}
+}
+
+func (h *hooks) addCertificate(payload *hookPayload, meta map[string]string, certsStorage *CertificatesStorage, certRes *certificate.Resource) {
+	domain := certRes.Domain
+
+	meta[renewEnvCertDomain] = domain
+	meta[renewEnvCertPath] = certsStorage.GetFileName(domain, ".crt")
+	if certRes.PrivateKeyURI != "" {
+		// the private key is not stored by lego (ex: HSM).
+		meta[renewEnvCertKeyURI] = certRes.PrivateKeyURI
+	} else {
+		meta[renewEnvCertKeyPath] = certsStorage.GetFileName(domain, ".key")
+	}
+
+	if certRes.CADirURL != "" {
+		payload.CAURL = certRes.CADirURL
+	}
+
+	hookCert := &hookCertificate{
+		Domain:  domain,
+		CertURL: certRes.CertURL,
+		Files:   map[string]string{},
+	}
+
+	for _, ext := range []string{".crt", ".issuer.crt", ".key", ".pem", ".pfx", ".json", ".ocsp"} {
+		if certsStorage.ExistsFile(domain, ext) {
+			hookCert.Files[strings.TrimPrefix(ext, ".")] = certsStorage.GetFileName(domain, ext)
+		}
+	}
+
+	for i := range certRes.AlternateChains {
+		ext := alternateChainFileExtension(i + 1)
+		if certsStorage.ExistsFile(domain, ext) {
+			hookCert.Files[strings.TrimPrefix(ext, ".")] = certsStorage.GetFileName(domain, ext)
+		}
+	}
+
+	cert, err := certcrypto.ParsePEMCertificate(certRes.Certificate)
+	if err != nil {
+		log.Warnf("[%s] Unable to parse the certificate for the hooks: %v", domain, err)
+	} else {
+		hookCert.Domains = certcrypto.ExtractDomains(cert)
+		hookCert.Serial = fmt.Sprintf("%x", cert.SerialNumber)
+		hookCert.NotBefore = cert.NotBefore
+		hookCert.NotAfter = cert.NotAfter
+		hookCert.Issuer = cert.Issuer.String()
+	}
+
+	payload.Certificate = hookCert
+	if len(payload.Domains) == 0 {
+		payload.Domains = hookCert.Domains
+	}
+}
+
+// launch executes a hook and applies the failure policy.
+func (h *hooks) launch(hook string, meta map[string]string, payload *hookPayload) error {
 	if hook == "" {
 		return nil
 	}
 
-	ctxCmd, cancel := context.WithTimeout(context.Background(), 120*time.Second)
-	defer cancel()
+	err := h.exec(hook, meta, payload)
+	if err == nil {
+		return nil
+	}
+
+	switch h.policy {
+	case hookPolicyWarn:
+		log.Warnf("The %s hook failed: %v", payload.Event, err)
+		return nil
+	case hookPolicyIgnore:
+		return nil
+	default:
+		return fmt.Errorf("%s hook: %w", payload.Event, err)
+	}
+}
+
+func (h *hooks) exec(hook string, meta map[string]string, payload *hookPayload) error {
+	var parts []string
+	if h.shell {
+		parts = shellCommand(hook)
+	} else {
+		var err error
+		parts, err = splitCommand(hook)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(parts) == 0 {
+		return errors.New("empty hook")
+	}
+
+	stdin, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
 
-	parts := strings.Fields(hook)
+	ctxCmd, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
 
 	cmdCtx := exec.CommandContext(ctxCmd, parts[0], parts[1:]...)
 	cmdCtx.Env = append(os.Environ(), metaToEnv(meta)...)
+	cmdCtx.Env = append(cmdCtx.Env, hookEnvEvent+"="+payload.Event)
+	cmdCtx.Stdin = bytes.NewReader(stdin)
 
 	output, err := cmdCtx.CombinedOutput()
 
@@ -36,6 +321,81 @@ func launchHook(hook string, meta map[string]string) error {
 	return err
 }
 
+func shellCommand(hook string) []string {
+	if runtime.GOOS == "windows" {
+		return []string{"cmd", "/C", hook}
+	}
+
+	return []string{"sh", "-c", hook}
+}
+
+// splitCommand splits a command into arguments.
+// Supports single quotes (literal) and double quotes.
+// Outside single quotes, a backslash escapes the next character only if it's a double quote, a backslash, or a whitespace:
+// the other backslashes are literal (ex: Windows paths like C:\scripts\deploy.bat).
+func splitCommand(command string) ([]string, error) {
+	var (
+		args    []string
+		current strings.Builder
+		inArg   bool
+		quote   rune
+	)
+
+	runes := []rune(command)
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		switch {
+		case r == '\\' && quote != '\'' && i+1 < len(runes) && isEscapable(runes[i+1]):
+			i++
+			current.WriteRune(runes[i])
+			inArg = true
+
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+
+		case isSpace(r):
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote (%c) in the hook", quote)
+	}
+
+	if inArg {
+		args = append(args, current.String())
+	}
+
+	return args, nil
+}
+
+// isEscapable reports whether a backslash followed by r is an escape sequence.
+func isEscapable(r rune) bool {
+	return r == '"' || r == '\\' || isSpace(r)
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r'
+}
+
 func metaToEnv(meta map[string]string) []string {
 	var envs []string
 
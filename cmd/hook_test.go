@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_splitCommand(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		command  string
+		expected []string
+	}{
+		{
+			desc:     "empty",
+			command:  "",
+			expected: nil,
+		},
+		{
+			desc:     "simple",
+			command:  "./myscript.sh a b",
+			expected: []string{"./myscript.sh", "a", "b"},
+		},
+		{
+			desc:     "multiple spaces",
+			command:  "  ./myscript.sh   a\tb ",
+			expected: []string{"./myscript.sh", "a", "b"},
+		},
+		{
+			desc:     "double quotes",
+			command:  `./myscript.sh "a b" c`,
+			expected: []string{"./myscript.sh", "a b", "c"},
+		},
+		{
+			desc:     "single quotes",
+			command:  `./myscript.sh 'a "b" \c'`,
+			expected: []string{"./myscript.sh", `a "b" \c`},
+		},
+		{
+			desc:     "escape",
+			command:  `./my\ script.sh "a \"b\""`,
+			expected: []string{"./my script.sh", `a "b"`},
+		},
+		{
+			desc:     "Windows path",
+			command:  `C:\scripts\deploy.bat C:\certs\`,
+			expected: []string{`C:\scripts\deploy.bat`, `C:\certs\`},
+		},
+		{
+			desc:     "Windows path with spaces",
+			command:  `"C:\Program Files\lego\deploy.bat" a`,
+			expected: []string{`C:\Program Files\lego\deploy.bat`, "a"},
+		},
+		{
+			desc:     "escaped backslash",
+			command:  `./myscript.sh a\\b`,
+			expected: []string{"./myscript.sh", `a\b`},
+		},
+		{
+			desc:     "empty argument",
+			command:  `./myscript.sh "" a`,
+			expected: []string{"./myscript.sh", "", "a"},
+		},
+		{
+			desc:     "concatenation",
+			command:  `./myscript.sh --name="a b"c`,
+			expected: []string{"./myscript.sh", "--name=a bc"},
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			args, err := splitCommand(test.command)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, args)
+		})
+	}
+}
+
+func Test_splitCommand_errors(t *testing.T) {
+	testCases := []string{`./myscript.sh "a`, `./myscript.sh 'a`, `./myscript.sh "a\"`}
+
+	for _, command := range testCases {
+		_, err := splitCommand(command)
+		require.Error(t, err, command)
+	}
+}
+
+func Test_hooks_launch(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix only")
+	}
+
+	dir := t.TempDir()
+	output := filepath.Join(dir, "payload.json")
+
+	h := &hooks{timeout: 10 * time.Second, shell: true, policy: hookPolicyFail}
+
+	payload := &hookPayload{Event: hookEventPost, Domains: []string{"example.com"}, Error: "boom"}
+
+	err := h.launch(`cat > '`+output+`' && test "$LEGO_HOOK_EVENT" = post`, map[string]string{}, payload)
+	require.NoError(t, err)
+
+	content, err := ioutil.ReadFile(output)
+	require.NoError(t, err)
+
+	var actual hookPayload
+	err = json.Unmarshal(content, &actual)
+	require.NoError(t, err)
+
+	assert.Equal(t, payload, &actual)
+}
+
+func Test_hooks_launch_policy(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix only")
+	}
+
+	testCases := []struct {
+		policy  string
+		timeout time.Duration
+		hook    string
+		failed  bool
+	}{
+		{policy: hookPolicyFail, timeout: 10 * time.Second, hook: "exit 1", failed: true},
+		{policy: hookPolicyFail, timeout: 100 * time.Millisecond, hook: "sleep 1", failed: true},
+		{policy: hookPolicyWarn, timeout: 10 * time.Second, hook: "exit 1"},
+		{policy: hookPolicyIgnore, timeout: 10 * time.Second, hook: "exit 1"},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.policy+" "+test.hook, func(t *testing.T) {
+			t.Parallel()
+
+			h := &hooks{timeout: test.timeout, shell: true, policy: test.policy}
+
+			err := h.launch(test.hook, map[string]string{}, &hookPayload{Event: hookEventDeploy})
+			if test.failed {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"crypto"
+	"fmt"
+
+	"github.com/go-acme/lego/v4/certcrypto/pkcs11"
+	"github.com/go-acme/lego/v4/log"
+	"github.com/urfave/cli"
+)
+
+// loadKeyReference loads a private key not stored by lego (ex: a key stored in an HSM) from its URI.
+func loadKeyReference(uri string) (crypto.Signer, error) {
+	if pkcs11.IsURI(uri) {
+		return pkcs11.LoadSigner(uri)
+	}
+
+	return nil, fmt.Errorf("unsupported key URI: %s", uri)
+}
+
+// getCertificateKey returns the private key of the certificate, and its URI:
+// the key defined by the "cert-key" option, or the key referenced by the previous certificate.
+// Returns a nil key if the private key is managed by lego.
+func getCertificateKey(ctx *cli.Context, previousURI string) (crypto.PrivateKey, string) {
+	uri := ctx.GlobalString("cert-key")
+	if uri == "" {
+		uri = previousURI
+	}
+
+	if uri == "" {
+		return nil, ""
+	}
+
+	privateKey, err := loadKeyReference(uri)
+	if err != nil {
+		log.Fatalf("Could not load the certificate private key %s: %v", uri, err)
+	}
+
+	return privateKey, uri
+}
+
+// storableKeyURI returns the URI of a key reference without its secrets (the PIN), before storing it.
+func storableKeyURI(uri string) string {
+	if !pkcs11.IsURI(uri) {
+		return uri
+	}
+
+	stripped := pkcs11.StripPINValue(uri)
+	if stripped != uri {
+		log.Warnf("The pin-value of the key URI is not stored, use pin-source to use the stored key URI without the option: %s", stripped)
+	}
+
+	return stripped
+}
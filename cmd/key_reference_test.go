@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountsStorage_keyURI(t *testing.T) {
+	rootUserPath := t.TempDir()
+
+	storage := &AccountsStorage{
+		userID:          "foo@example.com",
+		rootUserPath:    rootUserPath,
+		accountFilePath: filepath.Join(rootUserPath, accountFileName),
+		keyURI:          "pkcs11:token=lego;object=account?module-path=/usr/lib/softhsm/libsofthsm2.so",
+		keysEncryption:  &keysEncryption{},
+	}
+
+	account := &Account{Email: "foo@example.com"}
+
+	err := storage.Save(account)
+	require.NoError(t, err)
+
+	assert.Equal(t, storage.keyURI, account.KeyURI)
+
+	// the reference stored in the account is used without the "account-key" option.
+	storage.keyURI = ""
+
+	assert.Equal(t, "pkcs11:token=lego;object=account?module-path=/usr/lib/softhsm/libsofthsm2.so", storage.getKeyURI())
+}
+
+func TestAccountsStorage_keyURI_pinValue(t *testing.T) {
+	rootUserPath := t.TempDir()
+
+	storage := &AccountsStorage{
+		userID:          "foo@example.com",
+		rootUserPath:    rootUserPath,
+		accountFilePath: filepath.Join(rootUserPath, accountFileName),
+		keyURI:          "pkcs11:token=lego;object=account?module-path=/usr/lib/softhsm/libsofthsm2.so&pin-value=1234",
+		keysEncryption:  &keysEncryption{},
+	}
+
+	err := storage.Save(&Account{Email: "foo@example.com"})
+	require.NoError(t, err)
+
+	fileBytes, err := ioutil.ReadFile(storage.accountFilePath)
+	require.NoError(t, err)
+
+	assert.NotContains(t, string(fileBytes), "pin-value")
+
+	storage.keyURI = ""
+
+	assert.Equal(t, "pkcs11:token=lego;object=account?module-path=/usr/lib/softhsm/libsofthsm2.so", storage.getKeyURI())
+}
+
+func Test_storableKeyURI(t *testing.T) {
+	assert.Equal(t, "pkcs11:token=lego;object=example.com?module-path=/usr/lib/softhsm/libsofthsm2.so&pin-source=/etc/lego/pin",
+		storableKeyURI("pkcs11:token=lego;object=example.com?module-path=/usr/lib/softhsm/libsofthsm2.so&pin-value=1234&pin-source=/etc/lego/pin"))
+}
+
+func Test_loadKeyReference_unsupported(t *testing.T) {
+	_, err := loadKeyReference("file:/etc/lego/account.key")
+	require.Error(t, err)
+}
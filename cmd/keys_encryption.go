@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/log"
+	"github.com/urfave/cli"
+)
+
+const ageArmorHeader = "-----BEGIN AGE ENCRYPTED FILE-----"
+
+// keysEncryption encrypts the private keys before storing them, and decrypts them when they are loaded.
+//
+// The private keys are encrypted with age if at least one age recipient is defined,
+// otherwise with a passphrase (encrypted PKCS#8) if a passphrase is defined.
+// The format of a stored key is detected when the key is loaded: plaintext keys are always supported.
+type keysEncryption struct {
+	passphrase string
+	recipients []age.Recipient
+	identities []age.Identity
+}
+
+func newKeysEncryption(ctx *cli.Context) *keysEncryption {
+	enc := &keysEncryption{passphrase: ctx.GlobalString("keys.passphrase")}
+
+	if filename := ctx.GlobalString("keys.passphrase-file"); filename != "" {
+		content, err := ioutil.ReadFile(filename)
+		if err != nil {
+			log.Fatalf("Could not read the passphrase file: %v", err)
+		}
+
+		enc.passphrase = strings.TrimRight(string(content), "\r\n")
+	}
+
+	for _, value := range ctx.GlobalStringSlice("keys.age-recipient") {
+		recipient, err := age.ParseX25519Recipient(value)
+		if err != nil {
+			log.Fatalf("Invalid age recipient %q: %v", value, err)
+		}
+
+		enc.recipients = append(enc.recipients, recipient)
+	}
+
+	if filename := ctx.GlobalString("keys.age-identity"); filename != "" {
+		file, err := os.Open(filename)
+		if err != nil {
+			log.Fatalf("Could not read the age identity file: %v", err)
+		}
+
+		defer func() { _ = file.Close() }()
+
+		enc.identities, err = age.ParseIdentities(file)
+		if err != nil {
+			log.Fatalf("Invalid age identity file %s: %v", filename, err)
+		}
+	}
+
+	return enc
+}
+
+// enabled returns true if the new keys are encrypted.
+func (e *keysEncryption) enabled() bool {
+	return len(e.recipients) > 0 || e.passphrase != ""
+}
+
+// isEncrypted returns true if the stored key is already in the format used to encrypt the new keys.
+func (e *keysEncryption) isEncrypted(data []byte) bool {
+	if len(e.recipients) > 0 {
+		return isAgeEncrypted(data)
+	}
+
+	return certcrypto.IsEncryptedPEMPrivateKey(data)
+}
+
+// encrypt encodes a private key in the stored format.
+func (e *keysEncryption) encrypt(privateKey crypto.PrivateKey) ([]byte, error) {
+	switch {
+	case len(e.recipients) > 0:
+		buf := &bytes.Buffer{}
+
+		armorWriter := armor.NewWriter(buf)
+
+		w, err := age.Encrypt(armorWriter, e.recipients...)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err = w.Write(certcrypto.PEMEncode(privateKey)); err != nil {
+			return nil, err
+		}
+
+		if err = w.Close(); err != nil {
+			return nil, err
+		}
+
+		if err = armorWriter.Close(); err != nil {
+			return nil, err
+		}
+
+		return buf.Bytes(), nil
+
+	case e.passphrase != "":
+		return certcrypto.EncryptPEMPrivateKey(privateKey, e.passphrase)
+
+	default:
+		return certcrypto.PEMEncode(privateKey), nil
+	}
+}
+
+// encryptPEM encodes a PEM private key in the stored format.
+func (e *keysEncryption) encryptPEM(pemKey []byte) ([]byte, error) {
+	if !e.enabled() {
+		return pemKey, nil
+	}
+
+	privateKey, err := certcrypto.ParsePEMPrivateKey(pemKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.encrypt(privateKey)
+}
+
+// decrypt decodes a stored private key (age, encrypted PKCS#8, or plaintext PEM).
+func (e *keysEncryption) decrypt(data []byte) (crypto.PrivateKey, error) {
+	switch {
+	case isAgeEncrypted(data):
+		if len(e.identities) == 0 {
+			return nil, errors.New("the key is encrypted with age, use --keys.age-identity")
+		}
+
+		r, err := age.Decrypt(armor.NewReader(bytes.NewReader(data)), e.identities...)
+		if err != nil {
+			return nil, err
+		}
+
+		pemKey, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+
+		return certcrypto.ParsePEMPrivateKey(pemKey)
+
+	case certcrypto.IsEncryptedPEMPrivateKey(data):
+		if e.passphrase == "" {
+			return nil, errors.New("the key is encrypted with a passphrase, use --keys.passphrase or --keys.passphrase-file")
+		}
+
+		return certcrypto.DecryptPEMPrivateKey(data, e.passphrase)
+
+	default:
+		return certcrypto.ParsePEMPrivateKey(data)
+	}
+}
+
+func isAgeEncrypted(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte(ageArmorHeader))
+}
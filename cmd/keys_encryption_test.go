@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"testing"
+
+	"filippo.io/age"
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_keysEncryption(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc       string
+		encryption *keysEncryption
+		encrypted  bool
+	}{
+		{
+			desc:       "plaintext",
+			encryption: &keysEncryption{},
+		},
+		{
+			desc:       "passphrase",
+			encryption: &keysEncryption{passphrase: "secret"},
+			encrypted:  true,
+		},
+		{
+			desc: "age",
+			encryption: &keysEncryption{
+				recipients: []age.Recipient{identity.Recipient()},
+				identities: []age.Identity{identity},
+			},
+			encrypted: true,
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			privateKey, err := certcrypto.GeneratePrivateKey(certcrypto.EC256)
+			require.NoError(t, err)
+
+			data, err := test.encryption.encrypt(privateKey)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.encrypted, test.encryption.isEncrypted(data))
+
+			decrypted, err := test.encryption.decrypt(data)
+			require.NoError(t, err)
+
+			assert.Equal(t, privateKey, decrypted)
+
+			if test.encrypted {
+				_, err = (&keysEncryption{}).decrypt(data)
+				require.Error(t, err)
+			}
+		})
+	}
+}
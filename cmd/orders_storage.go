@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/go-acme/lego/v4/log"
+	"github.com/urfave/cli"
+)
+
+const baseOrdersFolderName = "orders"
+
+// OrdersStorage a storage for the state of the in-flight orders, scoped by CA server and account.
+//
+// rootPath:
+//
+//	./.lego/orders/localhost_14000/hubert@hubert.com/
+//	     │      │             │             └── userID ("email" option)
+//	     │      │             └── CA server ("server" option)
+//	     │      └── root in-flight orders directory
+//	     └── "path" option
+type OrdersStorage struct {
+	rootPath string
+	provider string
+}
+
+// inFlightOrder the stored state of an in-flight order.
+type inFlightOrder struct {
+	certificate.OrderState
+
+	// The DNS provider used to present the challenges.
+	Provider string `json:"provider,omitempty"`
+}
+
+// NewOrdersStorage create a new in-flight orders storage for the CA server and the account of the accounts storage.
+func NewOrdersStorage(ctx *cli.Context, accountsStorage *AccountsStorage) *OrdersStorage {
+	// <server>/<userID>, the same layout as the accounts.
+	accountPath, err := filepath.Rel(accountsStorage.GetRootPath(), accountsStorage.GetRootUserPath())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return &OrdersStorage{
+		rootPath: filepath.Join(ctx.GlobalString("path"), baseOrdersFolderName, accountPath),
+		provider: ctx.GlobalString("dns"),
+	}
+}
+
+// LoadOrderState implements certificate.OrderStorage.
+func (s *OrdersStorage) LoadOrderState(key string) (*certificate.OrderState, error) {
+	raw, err := ioutil.ReadFile(s.getFileName(key))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var order inFlightOrder
+	err = json.Unmarshal(raw, &order)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.Provider != s.provider {
+		log.Warnf("[%s] The previous order used the DNS provider %q, the cleaning of its challenges is done with the current provider %q.",
+			key, order.Provider, s.provider)
+	}
+
+	return &order.OrderState, nil
+}
+
+// SaveOrderState implements certificate.OrderStorage.
+func (s *OrdersStorage) SaveOrderState(key string, state *certificate.OrderState) error {
+	err := createNonExistingFolder(s.rootPath)
+	if err != nil {
+		return err
+	}
+
+	jsonBytes, err := json.MarshalIndent(inFlightOrder{OrderState: *state, Provider: s.provider}, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(s.getFileName(key), jsonBytes, filePerm)
+}
+
+// DeleteOrderState implements certificate.OrderStorage.
+func (s *OrdersStorage) DeleteOrderState(key string) error {
+	err := os.Remove(s.getFileName(key))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+func (s *OrdersStorage) getFileName(key string) string {
+	return filepath.Join(s.rootPath, sanitizedDomain(key)+".json")
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/go-acme/lego/v4/lego"
+	"github.com/go-acme/lego/v4/log"
+	"github.com/urfave/cli"
+	"golang.org/x/crypto/ocsp"
+)
+
+const baseCRLsFolderName = "crls"
+
+// The revocation reason codes (RFC 5280, section 5.3.1).
+var revocationReasons = map[int]string{
+	ocsp.Unspecified:          "unspecified",
+	ocsp.KeyCompromise:        "keyCompromise",
+	ocsp.CACompromise:         "cACompromise",
+	ocsp.AffiliationChanged:   "affiliationChanged",
+	ocsp.Superseded:           "superseded",
+	ocsp.CessationOfOperation: "cessationOfOperation",
+	ocsp.CertificateHold:      "certificateHold",
+	ocsp.RemoveFromCRL:        "removeFromCRL",
+	ocsp.PrivilegeWithdrawn:   "privilegeWithdrawn",
+	ocsp.AACompromise:         "aACompromise",
+}
+
+func newRevocationChecker(ctx *cli.Context) *certificate.RevocationChecker {
+	httpClient := lego.NewConfig(nil).HTTPClient
+
+	if ctx.GlobalIsSet("http-timeout") {
+		httpClient.Timeout = time.Duration(ctx.GlobalInt("http-timeout")) * time.Second
+	}
+
+	return certificate.NewRevocationChecker(httpClient, certificate.WithCRLDir(filepath.Join(ctx.GlobalString("path"), baseCRLsFolderName)))
+}
+
+// checkRevocation returns the revocation status of a stored certificate.
+// The issuer certificate is read from the bundle or from the .issuer.crt file.
+func checkRevocation(checker *certificate.RevocationChecker, certsStorage *CertificatesStorage, domain string) (*certificate.RevocationStatus, error) {
+	bundle, err := certsStorage.ReadFile(domain, ".crt")
+	if err != nil {
+		return nil, err
+	}
+
+	certificates, err := certcrypto.ParsePEMBundle(bundle)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(certificates) == 1 && certsStorage.ExistsFile(domain, ".issuer.crt") {
+		issuer, errR := certsStorage.ReadFile(domain, ".issuer.crt")
+		if errR != nil {
+			return nil, errR
+		}
+
+		bundle = append(bundle, issuer...)
+	}
+
+	return checker.Check(bundle)
+}
+
+// isRevoked reports whether a stored certificate has been revoked (only if --check-revocation is used).
+// The certificate is considered as not revoked if the revocation status is not available.
+func isRevoked(ctx *cli.Context, certsStorage *CertificatesStorage, domain string) bool {
+	if !ctx.Bool("check-revocation") {
+		return false
+	}
+
+	status, err := checkRevocation(newRevocationChecker(ctx), certsStorage, domain)
+	if err != nil {
+		log.Warnf("[%s] Unable to check the revocation status of the certificate: %v", domain, err)
+		return false
+	}
+
+	if status.Status != certcrypto.OCSPRevoked {
+		return false
+	}
+
+	log.Warnf("[%s] The certificate %s: renewal.", domain, formatRevocationStatus(status))
+
+	return true
+}
+
+func formatRevocationStatus(status *certificate.RevocationStatus) string {
+	switch status.Status {
+	case certcrypto.OCSPGood:
+		return fmt.Sprintf("is not revoked (%s)", status.Source)
+	case certcrypto.OCSPRevoked:
+		return fmt.Sprintf("has been revoked at %s, reason: %s (%s)",
+			status.RevokedAt.Format(time.RFC3339), revocationReasons[status.Reason], status.Source)
+	default:
+		return fmt.Sprintf("is unknown by the CA (%s)", status.Source)
+	}
+}
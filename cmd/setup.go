@@ -3,12 +3,14 @@ package cmd
 import (
 	"crypto/x509"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"strings"
 	"time"
 
+	"github.com/go-acme/lego/v4/acme"
 	"github.com/go-acme/lego/v4/certcrypto"
 	"github.com/go-acme/lego/v4/lego"
 	"github.com/go-acme/lego/v4/log"
@@ -20,35 +22,27 @@ const filePerm os.FileMode = 0o600
 
 func setup(ctx *cli.Context, accountsStorage *AccountsStorage) (*Account, *lego.Client) {
 	keyType := getKeyType(ctx)
-	privateKey := accountsStorage.GetPrivateKey(keyType)
 
-	var account *Account
-	if accountsStorage.ExistsAccountFilePath() {
-		account = accountsStorage.LoadAccount(privateKey)
-	} else {
-		account = &Account{Email: accountsStorage.GetUserID(), key: privateKey}
-	}
+	account := getAccount(accountsStorage, getAccountKeyType(ctx))
 
-	client := newClient(ctx, account, keyType)
+	client := newClient(ctx, account, keyType, accountsStorage.GetServer())
 
 	return account, client
 }
 
-func newClient(ctx *cli.Context, acc registration.User, keyType certcrypto.KeyType) *lego.Client {
-	config := lego.NewConfig(acc)
-	config.CADirURL = ctx.GlobalString("server")
+// getAccount loads the account, or creates a new (unregistered) account if it doesn't exist.
+func getAccount(accountsStorage *AccountsStorage, keyType certcrypto.KeyType) *Account {
+	privateKey := accountsStorage.GetPrivateKey(keyType)
 
-	config.Certificate = lego.CertificateConfig{
-		KeyType: keyType,
-		Timeout: time.Duration(ctx.GlobalInt("cert.timeout")) * time.Second,
+	if accountsStorage.ExistsAccountFilePath() {
+		return accountsStorage.LoadAccount(privateKey)
 	}
-	config.UserAgent = fmt.Sprintf("lego-cli/%s", ctx.App.Version)
 
-	if ctx.GlobalIsSet("http-timeout") {
-		config.HTTPClient.Timeout = time.Duration(ctx.GlobalInt("http-timeout")) * time.Second
-	}
+	return &Account{Email: accountsStorage.GetUserID(), key: privateKey}
+}
 
-	client, err := lego.NewClient(config)
+func newClient(ctx *cli.Context, acc registration.User, keyType certcrypto.KeyType, server string) *lego.Client {
+	client, err := lego.NewClient(newConfig(ctx, acc, keyType, server))
 	if err != nil {
 		log.Fatalf("Could not create client: %v", err)
 	}
@@ -60,12 +54,51 @@ func newClient(ctx *cli.Context, acc registration.User, keyType certcrypto.KeyTy
 	return client
 }
 
+func newConfig(ctx *cli.Context, acc registration.User, keyType certcrypto.KeyType, server string) *lego.Config {
+	config := lego.NewConfig(acc)
+	config.CADirURL = server
+
+	config.Certificate = lego.CertificateConfig{
+		KeyType:  keyType,
+		Timeout:  time.Duration(ctx.GlobalInt("cert.timeout")) * time.Second,
+		CheckCAA: ctx.GlobalBool("cert.check-caa"),
+	}
+	if acc != nil {
+		accountsStorage := newAccountsStorage(ctx, server)
+
+		config.Certificate.OrderStorage = NewOrdersStorage(ctx, accountsStorage)
+		config.Certificate.AuthorizationStorage = accountsStorage
+	}
+
+	config.UserAgent = fmt.Sprintf("lego-cli/%s", ctx.App.Version)
+
+	if ctx.GlobalIsSet("http-timeout") {
+		config.HTTPClient.Timeout = time.Duration(ctx.GlobalInt("http-timeout")) * time.Second
+	}
+
+	return config
+}
+
 // getKeyType the type from which private keys should be generated.
 func getKeyType(ctx *cli.Context) certcrypto.KeyType {
-	keyType := ctx.GlobalString("key-type")
+	return parseKeyType(ctx.GlobalString("key-type"))
+}
+
+// getAccountKeyType returns the type of the account key, by default the type of the certificate keys.
+func getAccountKeyType(ctx *cli.Context) certcrypto.KeyType {
+	if ctx.GlobalIsSet("account-key-type") {
+		return parseKeyType(ctx.GlobalString("account-key-type"))
+	}
+
+	return getKeyType(ctx)
+}
+
+func parseKeyType(keyType string) certcrypto.KeyType {
 	switch strings.ToUpper(keyType) {
 	case "RSA2048":
 		return certcrypto.RSA2048
+	case "RSA3072":
+		return certcrypto.RSA3072
 	case "RSA4096":
 		return certcrypto.RSA4096
 	case "RSA8192":
@@ -74,12 +107,40 @@ func getKeyType(ctx *cli.Context) certcrypto.KeyType {
 		return certcrypto.EC256
 	case "EC384":
 		return certcrypto.EC384
+	case "EC521":
+		return certcrypto.EC521
+	case "ED25519":
+		return certcrypto.Ed25519
 	}
 
 	log.Fatalf("Unsupported KeyType: %s", keyType)
 	return ""
 }
 
+// checkRateLimit exits with the time after which the request can be retried if the error is a rate limit error.
+func checkRateLimit(err error) {
+	var rateLimitErr *acme.RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		return
+	}
+
+	if rateLimitErr.RetryAfter.IsZero() {
+		log.Fatalf("The rate limit of the CA has been exceeded:\n\t%v", err)
+	}
+
+	log.Fatalf("The rate limit of the CA has been exceeded, retry after %s:\n\t%v", rateLimitErr.RetryAfter.Format(time.RFC3339), err)
+}
+
+// checkSignatureAlgorithm exits with the accepted algorithms if the CA doesn't accept the signature algorithm of the account key.
+func checkSignatureAlgorithm(err error) {
+	var algErr *acme.BadSignatureAlgorithmError
+	if !errors.As(err, &algErr) {
+		return
+	}
+
+	log.Fatalf("The CA doesn't accept the account key, use an account with another key type (--account-key-type):\n\t%v", err)
+}
+
 func getEmail(ctx *cli.Context) string {
 	email := ctx.GlobalString("email")
 	if email == "" {
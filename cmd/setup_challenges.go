@@ -7,10 +7,12 @@ import (
 
 	"github.com/go-acme/lego/v4/challenge"
 	"github.com/go-acme/lego/v4/challenge/dns01"
+	"github.com/go-acme/lego/v4/challenge/dnspersist01"
 	"github.com/go-acme/lego/v4/challenge/http01"
 	"github.com/go-acme/lego/v4/challenge/tlsalpn01"
 	"github.com/go-acme/lego/v4/lego"
 	"github.com/go-acme/lego/v4/log"
+	"github.com/go-acme/lego/v4/platform/config/env"
 	"github.com/go-acme/lego/v4/providers/dns"
 	"github.com/go-acme/lego/v4/providers/http/memcached"
 	"github.com/go-acme/lego/v4/providers/http/webroot"
@@ -18,8 +20,8 @@ import (
 )
 
 func setupChallenges(ctx *cli.Context, client *lego.Client) {
-	if !ctx.GlobalBool("http") && !ctx.GlobalBool("tls") && !ctx.GlobalIsSet("dns") {
-		log.Fatal("No challenge selected. You must specify at least one challenge: `--http`, `--tls`, `--dns`.")
+	if !ctx.GlobalBool("http") && !ctx.GlobalBool("tls") && !ctx.GlobalIsSet("dns") && !ctx.GlobalBool("dns-persist") {
+		log.Fatal("No challenge selected. You must specify at least one challenge: `--http`, `--tls`, `--dns`, `--dns-persist`.")
 	}
 
 	if ctx.GlobalBool("http") {
@@ -39,6 +41,16 @@ func setupChallenges(ctx *cli.Context, client *lego.Client) {
 	if ctx.GlobalIsSet("dns") {
 		setupDNS(ctx, client)
 	}
+
+	if ctx.GlobalBool("dns-persist") {
+		servers := ctx.GlobalStringSlice("dns.resolvers")
+		err := client.Challenge.SetDNSPersist01(
+			dnspersist01.CondOption(len(servers) > 0, dnspersist01.AddRecursiveNameservers(servers)),
+		)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
 }
 
 func setupHTTPProvider(ctx *cli.Context) challenge.Provider {
@@ -106,21 +118,52 @@ func setupTLSProvider(ctx *cli.Context) challenge.Provider {
 }
 
 func setupDNS(ctx *cli.Context, client *lego.Client) {
-	provider, err := dns.NewDNSChallengeProviderByName(ctx.GlobalString("dns"))
+	provider, err := newDNSProvider(ctx)
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	servers := ctx.GlobalStringSlice("dns.resolvers")
-	err = client.Challenge.SetDNS01Provider(provider,
+	opts := []dns01.ChallengeOption{
 		dns01.CondOption(len(servers) > 0,
 			dns01.AddRecursiveNameservers(dns01.ParseNameservers(ctx.GlobalStringSlice("dns.resolvers")))),
 		dns01.CondOption(ctx.GlobalBool("dns.disable-cp"),
 			dns01.DisableCompletePropagationRequirement()),
 		dns01.CondOption(ctx.GlobalIsSet("dns-timeout"),
 			dns01.AddDNSTimeout(time.Duration(ctx.GlobalInt("dns-timeout"))*time.Second)),
-	)
+	}
+
+	err = client.Challenge.SetDNS01Provider(provider, opts...)
 	if err != nil {
 		log.Fatal(err)
 	}
+
+	if ctx.GlobalBool("dns.account") {
+		err = client.Challenge.SetDNSAccount01Provider(provider, opts...)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// newDNSProvider creates the DNS provider,
+// the values of the configuration file take precedence over the environment variables (without modifying the environment).
+func newDNSProvider(ctx *cli.Context) (challenge.Provider, error) {
+	values, err := getProviderValues(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var provider challenge.Provider
+
+	err = env.WithValues(values, func() error {
+		var errP error
+		provider, errP = dns.NewDNSChallengeProviderByName(ctx.GlobalString("dns"))
+		return errP
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return provider, nil
 }
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/go-acme/lego/v4/lego"
+	"github.com/go-acme/lego/v4/log"
+	"github.com/urfave/cli"
+)
+
+// checkTermsOfService detects a change of the terms of service of the server since the account agreed to them.
+// With --accept-tos, the account agrees to the new terms of service, otherwise an error is returned.
+func checkTermsOfService(ctx *cli.Context, client *lego.Client, account *Account, accountsStorage *AccountsStorage) error {
+	current := client.GetToSURL()
+	if current == "" || account.Registration == nil || account.TermsOfService == current {
+		return nil
+	}
+
+	// the accounts created before the tracking of the terms of service.
+	if account.TermsOfService == "" {
+		account.TermsOfService = current
+		return accountsStorage.Save(account)
+	}
+
+	log.Warnf("The terms of service of the server have changed: %s (agreed: %s)", current, account.TermsOfService)
+
+	if !ctx.GlobalBool("accept-tos") {
+		return fmt.Errorf("the terms of service have changed, review them at %s and use --accept-tos to agree to them", current)
+	}
+
+	reg, err := client.Registration.AgreeToTermsOfService()
+	if err != nil {
+		return fmt.Errorf("could not agree to the terms of service: %w", err)
+	}
+
+	account.Registration = reg
+	account.TermsOfService = current
+
+	return accountsStorage.Save(account)
+}
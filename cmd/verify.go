@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/go-acme/lego/v4/log"
+	"github.com/urfave/cli"
+)
+
+// createVerifyFlags the flags related to the verification of the issued certificates.
+func createVerifyFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.BoolFlag{
+			Name: "verify",
+			Usage: "Verify the issued certificate before saving it: the public key, the identifiers, the validity, and the must staple extension (if requested)." +
+				" The certificate is not saved and the deploy hooks are not executed if the verification fails.",
+		},
+		cli.StringFlag{
+			Name:  "verify.roots",
+			Usage: "Verify that the certificate chain verifies against the root certificates of this PEM file (requires --verify).",
+		},
+		cli.IntFlag{
+			Name:  "verify.min-scts",
+			Usage: "The minimum number of Signed Certificate Timestamps embedded in the certificate (requires --verify).",
+		},
+	}
+}
+
+// getVerifyOptions the options of the verification of the issued certificate (nil if the verification is disabled).
+func getVerifyOptions(ctx *cli.Context) *certificate.VerifyOptions {
+	if !ctx.Bool("verify") {
+		return nil
+	}
+
+	options := &certificate.VerifyOptions{
+		MinSCTs: ctx.Int("verify.min-scts"),
+	}
+
+	if filename := ctx.String("verify.roots"); filename != "" {
+		roots, err := readRootsFile(filename)
+		if err != nil {
+			log.Fatalf("Unable to read the roots of the verification: %v", err)
+		}
+
+		options.Roots = roots
+	}
+
+	return options
+}
@@ -112,6 +112,104 @@ func allDNSCodes() string {
 	return strings.Join(providers, ", ")
 }
 
+// dnsProvidersEnvVars the environment variables supported by each DNS provider.
+var dnsProvidersEnvVars = map[string][]string{
+	"manual":       nil,
+	"acme-dns":     {"ACME_DNS_API_BASE", "ACME_DNS_STORAGE_PATH"},
+	"alidns":       {"ALICLOUD_ACCESS_KEY", "ALICLOUD_HTTP_TIMEOUT", "ALICLOUD_POLLING_INTERVAL", "ALICLOUD_PROPAGATION_TIMEOUT", "ALICLOUD_REGION_ID", "ALICLOUD_SECRET_KEY", "ALICLOUD_TTL"},
+	"allinkl":      {"ALL_INKL_API_KEY", "ALL_INKL_HTTP_TIMEOUT", "ALL_INKL_LOGIN", "ALL_INKL_PASSWORD", "ALL_INKL_POLLING_INTERVAL", "ALL_INKL_PROPAGATION_TIMEOUT"},
+	"arvancloud":   {"ARVANCLOUD_API_KEY", "ARVANCLOUD_HTTP_TIMEOUT", "ARVANCLOUD_POLLING_INTERVAL", "ARVANCLOUD_PROPAGATION_TIMEOUT", "ARVANCLOUD_TTL"},
+	"auroradns":    {"AURORA_ENDPOINT", "AURORA_KEY", "AURORA_POLLING_INTERVAL", "AURORA_PROPAGATION_TIMEOUT", "AURORA_TTL", "AURORA_USER_ID"},
+	"autodns":      {"AUTODNS_API_PASSWORD", "AUTODNS_API_USER", "AUTODNS_CONTEXT", "AUTODNS_ENDPOINT", "AUTODNS_HTTP_TIMEOUT", "AUTODNS_POLLING_INTERVAL", "AUTODNS_PROPAGATION_TIMEOUT", "AUTODNS_TTL"},
+	"azure":        {"AZURE_CLIENT_ID", "AZURE_CLIENT_SECRET", "AZURE_ENVIRONMENT", "AZURE_METADATA_ENDPOINT", "AZURE_POLLING_INTERVAL", "AZURE_PROPAGATION_TIMEOUT", "AZURE_RESOURCE_GROUP", "AZURE_SUBSCRIPTION_ID", "AZURE_TENANT_ID", "AZURE_TTL"},
+	"bindman":      {"BINDMAN_HTTP_TIMEOUT", "BINDMAN_MANAGER_ADDRESS", "BINDMAN_POLLING_INTERVAL", "BINDMAN_PROPAGATION_TIMEOUT"},
+	"bluecat":      {"BLUECAT_CONFIG_NAME", "BLUECAT_DNS_VIEW", "BLUECAT_HTTP_TIMEOUT", "BLUECAT_PASSWORD", "BLUECAT_POLLING_INTERVAL", "BLUECAT_PROPAGATION_TIMEOUT", "BLUECAT_SERVER_URL", "BLUECAT_TTL", "BLUECAT_USER_NAME"},
+	"checkdomain":  {"CHECKDOMAIN_ENDPOINT", "CHECKDOMAIN_HTTP_TIMEOUT", "CHECKDOMAIN_POLLING_INTERVAL", "CHECKDOMAIN_PROPAGATION_TIMEOUT", "CHECKDOMAIN_TOKEN", "CHECKDOMAIN_TTL"},
+	"clouddns":     {"CLOUDDNS_CLIENT_ID", "CLOUDDNS_EMAIL", "CLOUDDNS_HTTP_TIMEOUT", "CLOUDDNS_PASSWORD", "CLOUDDNS_POLLING_INTERVAL", "CLOUDDNS_PROPAGATION_TIMEOUT", "CLOUDDNS_TTL"},
+	"cloudflare":   {"CF_API_EMAIL", "CF_API_KEY", "CF_DNS_API_TOKEN", "CF_ZONE_API_TOKEN", "CLOUDFLARE_API_KEY", "CLOUDFLARE_DNS_API_TOKEN", "CLOUDFLARE_EMAIL", "CLOUDFLARE_HTTP_TIMEOUT", "CLOUDFLARE_POLLING_INTERVAL", "CLOUDFLARE_PROPAGATION_TIMEOUT", "CLOUDFLARE_TTL", "CLOUDFLARE_ZONE_API_TOKEN"},
+	"cloudns":      {"CLOUDNS_AUTH_ID", "CLOUDNS_AUTH_PASSWORD", "CLOUDNS_HTTP_TIMEOUT", "CLOUDNS_POLLING_INTERVAL", "CLOUDNS_PROPAGATION_TIMEOUT", "CLOUDNS_SUB_AUTH_ID", "CLOUDNS_TTL"},
+	"cloudxns":     {"CLOUDXNS_API_KEY", "CLOUDXNS_HTTP_TIMEOUT", "CLOUDXNS_POLLING_INTERVAL", "CLOUDXNS_PROPAGATION_TIMEOUT", "CLOUDXNS_SECRET_KEY", "CLOUDXNS_TTL"},
+	"conoha":       {"CONOHA_API_PASSWORD", "CONOHA_API_USERNAME", "CONOHA_HTTP_TIMEOUT", "CONOHA_POLLING_INTERVAL", "CONOHA_PROPAGATION_TIMEOUT", "CONOHA_REGION", "CONOHA_TENANT_ID", "CONOHA_TTL"},
+	"constellix":   {"CONSTELLIX_API_KEY", "CONSTELLIX_HTTP_TIMEOUT", "CONSTELLIX_POLLING_INTERVAL", "CONSTELLIX_PROPAGATION_TIMEOUT", "CONSTELLIX_SECRET_KEY", "CONSTELLIX_TTL"},
+	"desec":        {"DESEC_HTTP_TIMEOUT", "DESEC_POLLING_INTERVAL", "DESEC_PROPAGATION_TIMEOUT", "DESEC_TOKEN", "DESEC_TTL"},
+	"designate":    {"DESIGNATE_POLLING_INTERVAL", "DESIGNATE_PROPAGATION_TIMEOUT", "DESIGNATE_TTL", "OS_APPLICATION_CREDENTIAL_ID", "OS_APPLICATION_CREDENTIAL_NAME", "OS_APPLICATION_CREDENTIAL_SECRET", "OS_AUTH_URL", "OS_CLOUD", "OS_PASSWORD", "OS_PROJECT_ID", "OS_PROJECT_NAME", "OS_REGION_NAME", "OS_TENANT_NAME", "OS_USERNAME", "OS_USER_ID"},
+	"digitalocean": {"DO_AUTH_TOKEN", "DO_HTTP_TIMEOUT", "DO_POLLING_INTERVAL", "DO_PROPAGATION_TIMEOUT", "DO_TTL"},
+	"dnsimple":     {"DNSIMPLE_BASE_URL", "DNSIMPLE_OAUTH_TOKEN", "DNSIMPLE_POLLING_INTERVAL", "DNSIMPLE_PROPAGATION_TIMEOUT", "DNSIMPLE_TTL"},
+	"dnsmadeeasy":  {"DNSMADEEASY_API_KEY", "DNSMADEEASY_API_SECRET", "DNSMADEEASY_HTTP_TIMEOUT", "DNSMADEEASY_POLLING_INTERVAL", "DNSMADEEASY_PROPAGATION_TIMEOUT", "DNSMADEEASY_SANDBOX", "DNSMADEEASY_TTL"},
+	"dnspod":       {"DNSPOD_API_KEY", "DNSPOD_HTTP_TIMEOUT", "DNSPOD_POLLING_INTERVAL", "DNSPOD_PROPAGATION_TIMEOUT", "DNSPOD_TTL"},
+	"dode":         {"DODE_HTTP_TIMEOUT", "DODE_POLLING_INTERVAL", "DODE_PROPAGATION_TIMEOUT", "DODE_SEQUENCE_INTERVAL", "DODE_TOKEN", "DODE_TTL"},
+	"domeneshop":   {"DOMENESHOP_API_SECRET", "DOMENESHOP_API_TOKEN", "DOMENESHOP_HTTP_TIMEOUT", "DOMENESHOP_POLLING_INTERVAL", "DOMENESHOP_PROPAGATION_TIMEOUT"},
+	"dreamhost":    {"DREAMHOST_API_KEY", "DREAMHOST_HTTP_TIMEOUT", "DREAMHOST_POLLING_INTERVAL", "DREAMHOST_PROPAGATION_TIMEOUT", "DREAMHOST_TTL"},
+	"duckdns":      {"DUCKDNS_HTTP_TIMEOUT", "DUCKDNS_POLLING_INTERVAL", "DUCKDNS_PROPAGATION_TIMEOUT", "DUCKDNS_SEQUENCE_INTERVAL", "DUCKDNS_TOKEN", "DUCKDNS_TTL"},
+	"dyn":          {"DYN_CUSTOMER_NAME", "DYN_HTTP_TIMEOUT", "DYN_PASSWORD", "DYN_POLLING_INTERVAL", "DYN_PROPAGATION_TIMEOUT", "DYN_TTL", "DYN_USER_NAME"},
+	"dynu":         {"DYNU_API_KEY", "DYNU_HTTP_TIMEOUT", "DYNU_POLLING_INTERVAL", "DYNU_PROPAGATION_TIMEOUT", "DYNU_TTL"},
+	"easydns":      {"EASYDNS_ENDPOINT", "EASYDNS_HTTP_TIMEOUT", "EASYDNS_KEY", "EASYDNS_POLLING_INTERVAL", "EASYDNS_PROPAGATION_TIMEOUT", "EASYDNS_SEQUENCE_INTERVAL", "EASYDNS_TOKEN", "EASYDNS_TTL"},
+	"edgedns":      {"AKAMAI_ACCESS_TOKEN", "AKAMAI_CLIENT_SECRET", "AKAMAI_CLIENT_TOKEN", "AKAMAI_EDGERC", "AKAMAI_EDGERC_SECTION", "AKAMAI_HOST", "AKAMAI_POLLING_INTERVAL", "AKAMAI_PROPAGATION_TIMEOUT", "AKAMAI_TTL"},
+	"exec":         {"EXEC_MODE", "EXEC_PATH", "EXEC_POLLING_INTERVAL", "EXEC_PROPAGATION_TIMEOUT"},
+	"exoscale":     {"EXOSCALE_API_KEY", "EXOSCALE_API_SECRET", "EXOSCALE_ENDPOINT", "EXOSCALE_HTTP_TIMEOUT", "EXOSCALE_POLLING_INTERVAL", "EXOSCALE_PROPAGATION_TIMEOUT", "EXOSCALE_TTL"},
+	"freemyip":     {"FREEMYIP_HTTP_TIMEOUT", "FREEMYIP_POLLING_INTERVAL", "FREEMYIP_PROPAGATION_TIMEOUT", "FREEMYIP_SEQUENCE_INTERVAL", "FREEMYIP_TOKEN", "FREEMYIP_TTL"},
+	"gandi":        {"GANDI_API_KEY", "GANDI_HTTP_TIMEOUT", "GANDI_POLLING_INTERVAL", "GANDI_PROPAGATION_TIMEOUT", "GANDI_TTL"},
+	"gandiv5":      {"GANDIV5_API_KEY", "GANDIV5_HTTP_TIMEOUT", "GANDIV5_POLLING_INTERVAL", "GANDIV5_PROPAGATION_TIMEOUT", "GANDIV5_TTL"},
+	"gcloud":       {"GCE_ALLOW_PRIVATE_ZONE", "GCE_DEBUG", "GCE_POLLING_INTERVAL", "GCE_PROJECT", "GCE_PROPAGATION_TIMEOUT", "GCE_SERVICE_ACCOUNT", "GCE_SERVICE_ACCOUNT_FILE", "GCE_TTL"},
+	"glesys":       {"GLESYS_API_KEY", "GLESYS_API_USER", "GLESYS_HTTP_TIMEOUT", "GLESYS_POLLING_INTERVAL", "GLESYS_PROPAGATION_TIMEOUT", "GLESYS_TTL"},
+	"godaddy":      {"GODADDY_API_KEY", "GODADDY_API_SECRET", "GODADDY_HTTP_TIMEOUT", "GODADDY_POLLING_INTERVAL", "GODADDY_PROPAGATION_TIMEOUT", "GODADDY_TTL"},
+	"hetzner":      {"HETZNER_API_KEY", "HETZNER_HTTP_TIMEOUT", "HETZNER_POLLING_INTERVAL", "HETZNER_PROPAGATION_TIMEOUT", "HETZNER_TTL"},
+	"hostingde":    {"HOSTINGDE_API_KEY", "HOSTINGDE_HTTP_TIMEOUT", "HOSTINGDE_POLLING_INTERVAL", "HOSTINGDE_PROPAGATION_TIMEOUT", "HOSTINGDE_TTL", "HOSTINGDE_ZONE_NAME"},
+	"hosttech":     {"HOSTTECH_API_KEY", "HOSTTECH_HTTP_TIMEOUT", "HOSTTECH_PASSWORD", "HOSTTECH_POLLING_INTERVAL", "HOSTTECH_PROPAGATION_TIMEOUT", "HOSTTECH_TTL"},
+	"httpreq":      {"HTTPREQ_ENDPOINT", "HTTPREQ_HTTP_TIMEOUT", "HTTPREQ_MODE", "HTTPREQ_PASSWORD", "HTTPREQ_POLLING_INTERVAL", "HTTPREQ_PROPAGATION_TIMEOUT", "HTTPREQ_USERNAME"},
+	"hurricane":    {"HURRICANE_HTTP_TIMEOUT", "HURRICANE_POLLING_INTERVAL", "HURRICANE_PROPAGATION_TIMEOUT", "HURRICANE_SEQUENCE_INTERVAL", "HURRICANE_TOKENS"},
+	"hyperone":     {"HYPERONE_API_URL", "HYPERONE_HTTP_TIMEOUT", "HYPERONE_LOCATION_ID", "HYPERONE_PASSPORT_LOCATION", "HYPERONE_POLLING_INTERVAL", "HYPERONE_PROPAGATION_TIMEOUT", "HYPERONE_TTL"},
+	"iij":          {"IIJ_API_ACCESS_KEY", "IIJ_API_SECRET_KEY", "IIJ_DO_SERVICE_CODE", "IIJ_POLLING_INTERVAL", "IIJ_PROPAGATION_TIMEOUT", "IIJ_TTL"},
+	"infoblox":     {"INFOBLOX_DNS_VIEW", "INFOBLOX_HOST", "INFOBLOX_HTTP_TIMEOUT", "INFOBLOX_PASSWORD", "INFOBLOX_POLLING_INTERVAL", "INFOBLOX_PORT", "INFOBLOX_PROPAGATION_TIMEOUT", "INFOBLOX_SSL_VERIFY", "INFOBLOX_TTL", "INFOBLOX_USER", "INFOBLOX_USERNAME", "INFOBLOX_VIEW", "INFOBLOX_WAPI_VERSION"},
+	"infomaniak":   {"INFOMANIAK_ACCESS_TOKEN", "INFOMANIAK_ENDPOINT", "INFOMANIAK_HTTP_TIMEOUT", "INFOMANIAK_POLLING_INTERVAL", "INFOMANIAK_PROPAGATION_TIMEOUT", "INFOMANIAK_TTL"},
+	"internetbs":   {"INTERNET_BS_API_KEY", "INTERNET_BS_HTTP_TIMEOUT", "INTERNET_BS_PASSWORD", "INTERNET_BS_POLLING_INTERVAL", "INTERNET_BS_PROPAGATION_TIMEOUT", "INTERNET_BS_TTL"},
+	"inwx":         {"INWX_PASSWORD", "INWX_POLLING_INTERVAL", "INWX_PROPAGATION_TIMEOUT", "INWX_SANDBOX", "INWX_SHARED_SECRET", "INWX_TTL", "INWX_USERNAME"},
+	"ionos":        {"IONOS_API_KEY", "IONOS_HTTP_TIMEOUT", "IONOS_POLLING_INTERVAL", "IONOS_PROPAGATION_TIMEOUT", "IONOS_TTL"},
+	"joker":        {"JOKER_API_KEY", "JOKER_API_MODE", "JOKER_DEBUG", "JOKER_HTTP_TIMEOUT", "JOKER_PASSWORD", "JOKER_POLLING_INTERVAL", "JOKER_PROPAGATION_TIMEOUT", "JOKER_SEQUENCE_INTERVAL", "JOKER_TTL", "JOKER_USERNAME"},
+	"lightsail":    {"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SHARED_CREDENTIALS_FILE", "DNS_ZONE", "LIGHTSAIL_POLLING_INTERVAL", "LIGHTSAIL_PROPAGATION_TIMEOUT", "LIGHTSAIL_REGION"},
+	"linode":       {"LINODE_HTTP_TIMEOUT", "LINODE_POLLING_INTERVAL", "LINODE_PROPAGATION_TIMEOUT", "LINODE_TOKEN", "LINODE_TTL"},
+	"liquidweb":    {"LIQUID_WEB_HTTP_TIMEOUT", "LIQUID_WEB_PASSWORD", "LIQUID_WEB_POLLING_INTERVAL", "LIQUID_WEB_PROPAGATION_TIMEOUT", "LIQUID_WEB_TTL", "LIQUID_WEB_URL", "LIQUID_WEB_USERNAME", "LIQUID_WEB_ZONE"},
+	"loopia":       {"LOOPIA_API_PASSWORD", "LOOPIA_API_USER", "LOOPIA_HTTP_TIMEOUT", "LOOPIA_POLLING_INTERVAL", "LOOPIA_PROPAGATION_TIMEOUT", "LOOPIA_TTL"},
+	"luadns":       {"LUADNS_API_TOKEN", "LUADNS_API_USERNAME", "LUADNS_HTTP_TIMEOUT", "LUADNS_POLLING_INTERVAL", "LUADNS_PROPAGATION_TIMEOUT", "LUADNS_TTL"},
+	"mydnsjp":      {"MYDNSJP_HTTP_TIMEOUT", "MYDNSJP_MASTER_ID", "MYDNSJP_PASSWORD", "MYDNSJP_POLLING_INTERVAL", "MYDNSJP_PROPAGATION_TIMEOUT", "MYDNSJP_TTL"},
+	"mythicbeasts": {"MYTHICBEASTS_API_ENDPOINT", "MYTHICBEASTS_AUTH_API_ENDPOINT", "MYTHICBEASTS_HTTP_TIMEOUT", "MYTHICBEASTS_PASSWORD", "MYTHICBEASTS_POLLING_INTERVAL", "MYTHICBEASTS_PROPAGATION_TIMEOUT", "MYTHICBEASTS_TTL", "MYTHICBEASTS_USERNAME"},
+	"namecheap":    {"NAMECHEAP_API_KEY", "NAMECHEAP_API_USER", "NAMECHEAP_DEBUG", "NAMECHEAP_HTTP_TIMEOUT", "NAMECHEAP_POLLING_INTERVAL", "NAMECHEAP_PROPAGATION_TIMEOUT", "NAMECHEAP_SANDBOX", "NAMECHEAP_TTL"},
+	"namedotcom":   {"NAMECOM_API_TOKEN", "NAMECOM_HTTP_TIMEOUT", "NAMECOM_POLLING_INTERVAL", "NAMECOM_PROPAGATION_TIMEOUT", "NAMECOM_SERVER", "NAMECOM_TTL", "NAMECOM_USERNAME"},
+	"namesilo":     {"NAMESILO_API_KEY", "NAMESILO_POLLING_INTERVAL", "NAMESILO_PROPAGATION_TIMEOUT", "NAMESILO_TTL"},
+	"netcup":       {"NETCUP_API_KEY", "NETCUP_API_PASSWORD", "NETCUP_CUSTOMER_NUMBER", "NETCUP_HTTP_TIMEOUT", "NETCUP_POLLING_INTERVAL", "NETCUP_PROPAGATION_TIMEOUT", "NETCUP_TTL"},
+	"netlify":      {"NETLIFY_HTTP_TIMEOUT", "NETLIFY_POLLING_INTERVAL", "NETLIFY_PROPAGATION_TIMEOUT", "NETLIFY_TOKEN", "NETLIFY_TTL"},
+	"nifcloud":     {"NIFCLOUD_ACCESS_KEY_ID", "NIFCLOUD_DNS_ENDPOINT", "NIFCLOUD_HTTP_TIMEOUT", "NIFCLOUD_POLLING_INTERVAL", "NIFCLOUD_PROPAGATION_TIMEOUT", "NIFCLOUD_SECRET_ACCESS_KEY", "NIFCLOUD_TTL"},
+	"njalla":       {"NJALLA_HTTP_TIMEOUT", "NJALLA_POLLING_INTERVAL", "NJALLA_PROPAGATION_TIMEOUT", "NJALLA_TOKEN", "NJALLA_TTL"},
+	"ns1":          {"NS1_API_KEY", "NS1_HTTP_TIMEOUT", "NS1_POLLING_INTERVAL", "NS1_PROPAGATION_TIMEOUT", "NS1_TTL"},
+	"oraclecloud":  {"OCI_COMPARTMENT_OCID", "OCI_HTTP_TIMEOUT", "OCI_POLLING_INTERVAL", "OCI_PRIVKEY_FILE", "OCI_PRIVKEY_PASS", "OCI_PROPAGATION_TIMEOUT", "OCI_PUBKEY_FINGERPRINT", "OCI_REGION", "OCI_TENANCY_OCID", "OCI_TTL", "OCI_USER_OCID"},
+	"otc":          {"OTC_DOMAIN_NAME", "OTC_HTTP_TIMEOUT", "OTC_IDENTITY_ENDPOINT", "OTC_PASSWORD", "OTC_POLLING_INTERVAL", "OTC_PROJECT_NAME", "OTC_PROPAGATION_TIMEOUT", "OTC_TTL", "OTC_USER_NAME"},
+	"ovh":          {"OVH_APPLICATION_KEY", "OVH_APPLICATION_SECRET", "OVH_CONSUMER_KEY", "OVH_ENDPOINT", "OVH_HTTP_TIMEOUT", "OVH_POLLING_INTERVAL", "OVH_PROPAGATION_TIMEOUT", "OVH_TTL"},
+	"pdns":         {"PDNS_API_KEY", "PDNS_API_URL", "PDNS_HTTP_TIMEOUT", "PDNS_POLLING_INTERVAL", "PDNS_PROPAGATION_TIMEOUT", "PDNS_SERVER_NAME", "PDNS_TTL"},
+	"porkbun":      {"PORKBUN_API_KEY", "PORKBUN_HTTP_TIMEOUT", "PORKBUN_POLLING_INTERVAL", "PORKBUN_PROPAGATION_TIMEOUT", "PORKBUN_SECRET_API_KEY", "PORKBUN_TTL"},
+	"rackspace":    {"RACKSPACE_API_KEY", "RACKSPACE_HTTP_TIMEOUT", "RACKSPACE_POLLING_INTERVAL", "RACKSPACE_PROPAGATION_TIMEOUT", "RACKSPACE_TTL", "RACKSPACE_USER"},
+	"regru":        {"REGRU_HTTP_TIMEOUT", "REGRU_PASSWORD", "REGRU_POLLING_INTERVAL", "REGRU_PROPAGATION_TIMEOUT", "REGRU_TTL", "REGRU_USERNAME"},
+	"rfc2136":      {"RFC2136_DNS_TIMEOUT", "RFC2136_NAMESERVER", "RFC2136_POLLING_INTERVAL", "RFC2136_PROPAGATION_TIMEOUT", "RFC2136_SEQUENCE_INTERVAL", "RFC2136_TSIG_ALGORITHM", "RFC2136_TSIG_KEY", "RFC2136_TSIG_SECRET", "RFC2136_TTL"},
+	"rimuhosting":  {"RIMUHOSTING_API_KEY", "RIMUHOSTING_HTTP_TIMEOUT", "RIMUHOSTING_POLLING_INTERVAL", "RIMUHOSTING_PROPAGATION_TIMEOUT", "RIMUHOSTING_TTL"},
+	"route53":      {"AWS_ACCESS_KEY_ID", "AWS_HOSTED_ZONE_ID", "AWS_MAX_RETRIES", "AWS_POLLING_INTERVAL", "AWS_PROFILE", "AWS_PROPAGATION_TIMEOUT", "AWS_REGION", "AWS_SDK_LOAD_CONFIG", "AWS_SECRET_ACCESS_KEY", "AWS_SHARED_CREDENTIALS_FILE", "AWS_TTL"},
+	"sakuracloud":  {"SAKURACLOUD_ACCESS_TOKEN", "SAKURACLOUD_ACCESS_TOKEN_SECRET", "SAKURACLOUD_HTTP_TIMEOUT", "SAKURACLOUD_POLLING_INTERVAL", "SAKURACLOUD_PROPAGATION_TIMEOUT", "SAKURACLOUD_TTL"},
+	"scaleway":     {"SCALEWAY_API_TOKEN", "SCALEWAY_POLLING_INTERVAL", "SCALEWAY_PROJECT_ID", "SCALEWAY_PROPAGATION_TIMEOUT", "SCALEWAY_TTL"},
+	"selectel":     {"SELECTEL_API_TOKEN", "SELECTEL_BASE_URL", "SELECTEL_HTTP_TIMEOUT", "SELECTEL_POLLING_INTERVAL", "SELECTEL_PROPAGATION_TIMEOUT", "SELECTEL_TTL"},
+	"servercow":    {"SERVERCOW_HTTP_TIMEOUT", "SERVERCOW_PASSWORD", "SERVERCOW_POLLING_INTERVAL", "SERVERCOW_PROPAGATION_TIMEOUT", "SERVERCOW_TTL", "SERVERCOW_USERNAME"},
+	"simply":       {"SIMPLY_ACCOUNT_NAME", "SIMPLY_API_KEY", "SIMPLY_HTTP_TIMEOUT", "SIMPLY_POLLING_INTERVAL", "SIMPLY_PROPAGATION_TIMEOUT", "SIMPLY_TTL"},
+	"sonic":        {"SONIC_API_KEY", "SONIC_HTTP_TIMEOUT", "SONIC_POLLING_INTERVAL", "SONIC_PROPAGATION_TIMEOUT", "SONIC_SEQUENCE_INTERVAL", "SONIC_TTL", "SONIC_USER_ID"},
+	"stackpath":    {"STACKPATH_CLIENT_ID", "STACKPATH_CLIENT_SECRET", "STACKPATH_POLLING_INTERVAL", "STACKPATH_PROPAGATION_TIMEOUT", "STACKPATH_STACK_ID", "STACKPATH_TTL"},
+	"transip":      {"TRANSIP_ACCOUNT_NAME", "TRANSIP_POLLING_INTERVAL", "TRANSIP_PRIVATE_KEY_PATH", "TRANSIP_PROPAGATION_TIMEOUT", "TRANSIP_TTL"},
+	"vegadns":      {"SECRET_VEGADNS_KEY", "SECRET_VEGADNS_SECRET", "VEGADNS_POLLING_INTERVAL", "VEGADNS_PROPAGATION_TIMEOUT", "VEGADNS_TTL", "VEGADNS_URL"},
+	"versio":       {"VERSIO_ENDPOINT", "VERSIO_HTTP_TIMEOUT", "VERSIO_PASSWORD", "VERSIO_POLLING_INTERVAL", "VERSIO_PROPAGATION_TIMEOUT", "VERSIO_SEQUENCE_INTERVAL", "VERSIO_TTL", "VERSIO_USERNAME"},
+	"vinyldns":     {"VINYLDNS_ACCESS_KEY", "VINYLDNS_HOST", "VINYLDNS_POLLING_INTERVAL", "VINYLDNS_PROPAGATION_TIMEOUT", "VINYLDNS_SECRET_KEY", "VINYLDNS_TTL"},
+	"vscale":       {"VSCALE_API_TOKEN", "VSCALE_BASE_URL", "VSCALE_HTTP_TIMEOUT", "VSCALE_POLLING_INTERVAL", "VSCALE_PROPAGATION_TIMEOUT", "VSCALE_TTL"},
+	"vultr":        {"VULTR_API_KEY", "VULTR_HTTP_TIMEOUT", "VULTR_POLLING_INTERVAL", "VULTR_PROPAGATION_TIMEOUT", "VULTR_TTL"},
+	"wedos":        {"WEDOS_HTTP_TIMEOUT", "WEDOS_POLLING_INTERVAL", "WEDOS_PROPAGATION_TIMEOUT", "WEDOS_TTL", "WEDOS_USERNAME", "WEDOS_WAPI_PASSWORD"},
+	"yandex":       {"YANDEX_HTTP_TIMEOUT", "YANDEX_PDD_TOKEN", "YANDEX_POLLING_INTERVAL", "YANDEX_PROPAGATION_TIMEOUT", "YANDEX_TTL"},
+	"zoneee":       {"ZONEEE_API_KEY", "ZONEEE_API_USER", "ZONEEE_ENDPOINT", "ZONEEE_HTTP_TIMEOUT", "ZONEEE_POLLING_INTERVAL", "ZONEEE_PROPAGATION_TIMEOUT", "ZONEEE_TTL"},
+	"zonomi":       {"ZONOMI_API_KEY", "ZONOMI_HTTP_TIMEOUT", "ZONOMI_POLLING_INTERVAL", "ZONOMI_PROPAGATION_TIMEOUT", "ZONOMI_TTL"},
+}
+
 func displayDNSHelp(name string) error {
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 	ew := &errWriter{w: w}
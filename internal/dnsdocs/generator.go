@@ -7,12 +7,16 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"go/ast"
 	"go/format"
+	"go/parser"
+	"go/token"
 	"io"
 	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 	"text/template"
@@ -35,6 +39,8 @@ const (
 	endLine   = "<!-- END DNS PROVIDERS LIST -->"
 )
 
+var envVarPattern = regexp.MustCompile(`^[A-Z0-9_]+$`)
+
 type Model struct {
 	Name          string         // Real name of the DNS provider
 	Code          string         // DNS code
@@ -46,6 +52,7 @@ type Model struct {
 	Links         *Links         // Links
 	Additional    string         // Extra documentation
 	GeneratedFrom string         // Source file
+	EnvVars       []string       `toml:"-"` // Environment variables (Env* constants and documented variables)
 }
 
 type Configuration struct {
@@ -104,6 +111,11 @@ func walker(prs *Providers) func(string, os.FileInfo, error) error {
 				return err
 			}
 
+			m.EnvVars, err = extractEnvVars(filepath.Dir(path), m.Configuration)
+			if err != nil {
+				return err
+			}
+
 			prs.Providers = append(prs.Providers, m)
 
 			// generate documentation
@@ -114,6 +126,116 @@ func walker(prs *Providers) func(string, os.FileInfo, error) error {
 	}
 }
 
+// extractEnvVars returns the environment variables of a provider:
+// the values of the Env* constants of the package, and the variables defined in the documentation.
+func extractEnvVars(dir string, conf *Configuration) ([]string, error) {
+	uniq := map[string]struct{}{}
+
+	if conf != nil {
+		for _, values := range []map[string]string{conf.Credentials, conf.Additional} {
+			for k := range values {
+				// some documented entries are not environment variables (ex: "instance metadata service").
+				if envVarPattern.MatchString(k) {
+					uniq[k] = struct{}{}
+				}
+			}
+		}
+	}
+
+	pkgs, err := parser.ParseDir(token.NewFileSet(), dir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pkg := range pkgs {
+		// all the string constants of the package (i.e. envNamespace and Env*).
+		consts := map[string]ast.Expr{}
+
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok || gen.Tok != token.CONST {
+					continue
+				}
+
+				for _, spec := range gen.Specs {
+					vs := spec.(*ast.ValueSpec)
+					for i, name := range vs.Names {
+						if i < len(vs.Values) {
+							consts[name.Name] = vs.Values[i]
+						}
+					}
+				}
+			}
+		}
+
+		for name, expr := range consts {
+			if !strings.HasPrefix(name, "Env") {
+				continue
+			}
+
+			value, ok := evalString(consts, expr)
+			if ok && value != "" {
+				uniq[value] = struct{}{}
+			}
+		}
+	}
+
+	var envVars []string
+	for k := range uniq {
+		envVars = append(envVars, k)
+	}
+
+	sort.Strings(envVars)
+
+	return envVars, nil
+}
+
+// evalString evaluates a constant string expression (string literals, constants, and concatenations).
+func evalString(consts map[string]ast.Expr, expr ast.Expr) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind != token.STRING {
+			return "", false
+		}
+
+		return strings.Trim(e.Value, "`\""), true
+
+	case *ast.Ident:
+		value, ok := consts[e.Name]
+		if !ok {
+			return "", false
+		}
+
+		return evalString(consts, value)
+
+	case *ast.BinaryExpr:
+		if e.Op != token.ADD {
+			return "", false
+		}
+
+		x, ok := evalString(consts, e.X)
+		if !ok {
+			return "", false
+		}
+
+		y, ok := evalString(consts, e.Y)
+		if !ok {
+			return "", false
+		}
+
+		return x + y, true
+
+	case *ast.ParenExpr:
+		return evalString(consts, e.X)
+
+	default:
+		return "", false
+	}
+}
+
 func generateDocumentation(m Model) error {
 	filename := filepath.Join(docOutput, "zz_gen_"+m.Code+".md")
 
@@ -0,0 +1,53 @@
+// Package errutil helpers for the errors of several domains.
+package errutil
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// DomainErrors the errors by domain.
+type DomainErrors map[string]error
+
+func (e DomainErrors) Error() string {
+	buffer := bytes.NewBufferString("error: one or more domains had a problem:\n")
+
+	for _, domain := range e.domains() {
+		buffer.WriteString(fmt.Sprintf("[%s] %s\n", domain, e[domain]))
+	}
+	return buffer.String()
+}
+
+// As finds the first error of the domains (in the alphabetical order) that matches target (see errors.As).
+func (e DomainErrors) As(target interface{}) bool {
+	for _, domain := range e.domains() {
+		if errors.As(e[domain], target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Is reports whether an error of the domains matches target (see errors.Is).
+func (e DomainErrors) Is(target error) bool {
+	for _, domain := range e.domains() {
+		if errors.Is(e[domain], target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (e DomainErrors) domains() []string {
+	var domains []string
+	for domain := range e {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+
+	return domains
+}
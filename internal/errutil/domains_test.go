@@ -0,0 +1,46 @@
+package errutil
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-acme/lego/v4/acme"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDomainErrors_Error(t *testing.T) {
+	err := DomainErrors{
+		"b.example.com": errors.New("oops"),
+		"a.example.com": errors.New("boom"),
+	}
+
+	assert.EqualError(t, err, "error: one or more domains had a problem:\n[a.example.com] boom\n[b.example.com] oops\n")
+}
+
+func TestDomainErrors_As(t *testing.T) {
+	rateLimitErr := &acme.RateLimitError{ProblemDetails: &acme.ProblemDetails{Type: acme.RateLimitedErr}}
+
+	var err error = DomainErrors{
+		"a.example.com": errors.New("oops"),
+		"b.example.com": rateLimitErr,
+	}
+
+	var target *acme.RateLimitError
+	assert.True(t, errors.As(err, &target))
+	assert.Same(t, rateLimitErr, target)
+
+	var transportErr *acme.TransportError
+	assert.False(t, errors.As(err, &transportErr))
+}
+
+func TestDomainErrors_Is(t *testing.T) {
+	errNotFound := errors.New("not found")
+
+	var err error = DomainErrors{
+		"a.example.com": errors.New("oops"),
+		"b.example.com": errNotFound,
+	}
+
+	assert.True(t, errors.Is(err, errNotFound))
+	assert.False(t, errors.Is(err, errors.New("not found")))
+}
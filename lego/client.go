@@ -53,7 +53,13 @@ func NewClient(config *Config) (*Client, error) {
 	solversManager := resolver.NewSolversManager(core)
 
 	prober := resolver.NewProber(solversManager)
-	certifier := certificate.NewCertifier(core, prober, certificate.CertifierOptions{KeyType: config.Certificate.KeyType, Timeout: config.Certificate.Timeout})
+	certifier := certificate.NewCertifier(core, prober, certificate.CertifierOptions{
+		KeyType:              config.Certificate.KeyType,
+		Timeout:              config.Certificate.Timeout,
+		CheckCAA:             config.Certificate.CheckCAA,
+		OrderStorage:         config.Certificate.OrderStorage,
+		AuthorizationStorage: config.Certificate.AuthorizationStorage,
+	})
 
 	return &Client{
 		Certificate:  certifier,
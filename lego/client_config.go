@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/certificate"
 	"github.com/go-acme/lego/v4/registration"
 )
 
@@ -55,8 +56,11 @@ func NewConfig(user registration.User) *Config {
 }
 
 type CertificateConfig struct {
-	KeyType certcrypto.KeyType
-	Timeout time.Duration
+	KeyType              certcrypto.KeyType
+	Timeout              time.Duration
+	CheckCAA             bool
+	OrderStorage         certificate.OrderStorage
+	AuthorizationStorage certificate.AuthorizationStorage
 }
 
 // createDefaultHTTPClient Creates an HTTP client with a reasonable timeout value
@@ -0,0 +1,166 @@
+package lego
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-acme/lego/v4/acme"
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/go-acme/lego/v4/log"
+)
+
+// FailoverCA a CA used by the FailoverClient.
+type FailoverCA struct {
+	// Config the configuration of the client for this CA (directory URL, account, ...).
+	Config *Config
+
+	// PreferredChain the preferred chain for this CA (optional).
+	// Overrides the preferred chain of the requests.
+	PreferredChain string
+
+	// Setup is called once, after the creation of the client (optional).
+	// Can be used to configure the challenge providers, or to register the account (with or without EAB).
+	Setup func(client *Client) error
+}
+
+// FailoverClient obtains certificates from an ordered list of CAs.
+// The next CA is used when a CA is unreachable, returns an internal error, or rate limits the client.
+//
+// The clients are created lazily: an unreachable CA doesn't prevent the use of the others.
+type FailoverClient struct {
+	cas     []FailoverCA
+	clients map[int]*Client
+}
+
+// NewFailoverClient creates a new FailoverClient.
+func NewFailoverClient(cas ...FailoverCA) (*FailoverClient, error) {
+	if len(cas) == 0 {
+		return nil, errors.New("at least one CA must be provided")
+	}
+
+	for i, ca := range cas {
+		if ca.Config == nil {
+			return nil, fmt.Errorf("a configuration must be provided for the CA %d", i)
+		}
+	}
+
+	return &FailoverClient{cas: cas, clients: make(map[int]*Client)}, nil
+}
+
+// Obtain tries to obtain a single certificate using all domains passed into it,
+// from the first CA able to issue it.
+//
+// preferredCA is the directory URL of the CA to try first (ex: the CA of the certificate to renew), it's optional.
+// The directory URL of the CA which has issued the certificate is stored in the resource (Resource.CADirURL).
+func (f *FailoverClient) Obtain(request certificate.ObtainRequest, preferredCA string) (*certificate.Resource, error) {
+	return f.try(preferredCA, func(client *Client, ca FailoverCA) (*certificate.Resource, error) {
+		if ca.PreferredChain != "" {
+			request.PreferredChain = ca.PreferredChain
+		}
+
+		return client.Certificate.Obtain(request)
+	})
+}
+
+// ObtainForCSR tries to obtain a certificate matching the CSR passed into it,
+// from the first CA able to issue it.
+//
+// preferredCA is the directory URL of the CA to try first (ex: the CA of the certificate to renew), it's optional.
+// The directory URL of the CA which has issued the certificate is stored in the resource (Resource.CADirURL).
+func (f *FailoverClient) ObtainForCSR(request certificate.ObtainForCSRRequest, preferredCA string) (*certificate.Resource, error) {
+	return f.try(preferredCA, func(client *Client, ca FailoverCA) (*certificate.Resource, error) {
+		if ca.PreferredChain != "" {
+			request.PreferredChain = ca.PreferredChain
+		}
+
+		return client.Certificate.ObtainForCSR(request)
+	})
+}
+
+func (f *FailoverClient) try(preferredCA string, obtain func(client *Client, ca FailoverCA) (*certificate.Resource, error)) (*certificate.Resource, error) {
+	var lastErr error
+
+	for _, index := range f.order(preferredCA) {
+		ca := f.cas[index]
+
+		client, err := f.getClient(index)
+		if err != nil {
+			log.Warnf("acme: Unable to use the CA %s: %v", ca.Config.CADirURL, err)
+			lastErr = err
+			continue
+		}
+
+		certRes, err := obtain(client, ca)
+		if err == nil {
+			certRes.CADirURL = ca.Config.CADirURL
+			return certRes, nil
+		}
+
+		if !isFailoverError(err) {
+			return nil, err
+		}
+
+		log.Warnf("acme: The CA %s is not able to issue the certificate: %v", ca.Config.CADirURL, err)
+		lastErr = err
+	}
+
+	return nil, fmt.Errorf("all the CAs have failed, last error: %w", lastErr)
+}
+
+// order returns the indexes of the CAs, the preferred CA first.
+func (f *FailoverClient) order(preferredCA string) []int {
+	var indexes []int
+	for i, ca := range f.cas {
+		if preferredCA != "" && ca.Config.CADirURL == preferredCA {
+			indexes = append([]int{i}, indexes...)
+			continue
+		}
+
+		indexes = append(indexes, i)
+	}
+
+	return indexes
+}
+
+func (f *FailoverClient) getClient(index int) (*Client, error) {
+	if client, ok := f.clients[index]; ok {
+		return client, nil
+	}
+
+	ca := f.cas[index]
+
+	client, err := NewClient(ca.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	if ca.Setup != nil {
+		err = ca.Setup(client)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	f.clients[index] = client
+
+	return client, nil
+}
+
+// isFailoverError returns true if the error is related to the CA (and not to the domains),
+// i.e. another CA may be able to issue the certificate.
+// Only the errors of the ACME transport (the directory, the nonces and the requests to the CA) are concerned:
+// the other network errors (ex: the DNS propagation check against the nameservers of the user) don't trigger a failover.
+func isFailoverError(err error) bool {
+	var rateLimitErr *acme.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return true
+	}
+
+	var problem *acme.ProblemDetails
+	if errors.As(err, &problem) {
+		return problem.Type == acme.ServerInternalErr || problem.HTTPStatus >= 500
+	}
+
+	var transportErr *acme.TransportError
+	return errors.As(err, &transportErr)
+}
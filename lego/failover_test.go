@@ -0,0 +1,173 @@
+package lego
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/go-acme/lego/v4/acme"
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/go-acme/lego/v4/challenge/dns01"
+	"github.com/go-acme/lego/v4/platform/tester"
+	"github.com/go-acme/lego/v4/platform/tester/acmeserver"
+	"github.com/go-acme/lego/v4/registration"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFailoverClient_Obtain(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err, "Could not generate test key")
+
+	user := mockUser{email: "test@test.com", regres: &registration.Resource{URI: "/account/1"}, privatekey: key}
+
+	// unreachable CA.
+	_, unreachableURL, tearDownUnreachable := tester.SetupFakeAPI()
+	tearDownUnreachable()
+
+	var calls []string
+
+	muxInternal, internalURL, tearDownInternal := tester.SetupFakeAPI()
+	defer tearDownInternal()
+
+	muxInternal.HandleFunc("/newOrder", func(w http.ResponseWriter, _ *http.Request) {
+		calls = append(calls, "internal")
+		writeProblem(w, acme.ProblemDetails{Type: acme.ServerInternalErr, HTTPStatus: http.StatusInternalServerError})
+	})
+
+	muxMalformed, malformedURL, tearDownMalformed := tester.SetupFakeAPI()
+	defer tearDownMalformed()
+
+	muxMalformed.HandleFunc("/newOrder", func(w http.ResponseWriter, _ *http.Request) {
+		calls = append(calls, "malformed")
+		writeProblem(w, acme.ProblemDetails{Type: "urn:ietf:params:acme:error:malformed", HTTPStatus: http.StatusBadRequest})
+	})
+
+	newCA := func(apiURL string) FailoverCA {
+		config := NewConfig(user)
+		config.CADirURL = apiURL + "/dir"
+		return FailoverCA{Config: config}
+	}
+
+	client, err := NewFailoverClient(newCA(unreachableURL), newCA(internalURL), newCA(malformedURL))
+	require.NoError(t, err)
+
+	_, err = client.Obtain(certificate.ObtainRequest{Domains: []string{"example.com"}}, "")
+	require.Error(t, err)
+
+	// the malformed error is not related to the CA: no failover.
+	var problem *acme.ProblemDetails
+	require.True(t, errors.As(err, &problem))
+	assert.Equal(t, "urn:ietf:params:acme:error:malformed", problem.Type)
+	assert.Equal(t, []string{"internal", "malformed"}, calls)
+
+	// the preferred CA is used first.
+	calls = nil
+
+	_, err = client.Obtain(certificate.ObtainRequest{Domains: []string{"example.com"}}, malformedURL+"/dir")
+	require.Error(t, err)
+	assert.Equal(t, []string{"malformed"}, calls)
+}
+
+func TestFailoverClient_Obtain_finalize(t *testing.T) {
+	primary := acmeserver.NewTestServer(t, acmeserver.Options{SkipValidation: true})
+	secondary := acmeserver.NewTestServer(t, acmeserver.Options{SkipValidation: true})
+
+	// the order is valid, but the CA is not able to issue the certificate.
+	primary.InjectFault(acmeserver.FaultFinalizeServerInternal, 1)
+
+	newCA := func(server *acmeserver.Server) FailoverCA {
+		privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+
+		user := &mockUser{email: "test@test.com", privatekey: privateKey}
+
+		config := NewConfig(user)
+		config.CADirURL = server.URL()
+		config.HTTPClient = server.HTTPClient()
+
+		return FailoverCA{
+			Config: config,
+			Setup: func(client *Client) error {
+				reg, err := client.Registration.Register(registration.RegisterOptions{TermsOfServiceAgreed: true})
+				if err != nil {
+					return err
+				}
+
+				user.regres = reg
+
+				return client.Challenge.SetDNS01Provider(acmeserver.NewLocalResolver(),
+					dns01.WrapPreCheck(func(_, _, _ string, _ dns01.PreCheckFunc) (bool, error) { return true, nil }))
+			},
+		}
+	}
+
+	client, err := NewFailoverClient(newCA(primary), newCA(secondary))
+	require.NoError(t, err)
+
+	certRes, err := client.Obtain(certificate.ObtainRequest{Domains: []string{"example.com"}, Bundle: true}, "")
+	require.NoError(t, err)
+
+	assert.Equal(t, secondary.URL(), certRes.CADirURL)
+}
+
+func Test_isFailoverError(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		err      error
+		expected bool
+	}{
+		{
+			desc:     "rate limited",
+			err:      &acme.RateLimitError{ProblemDetails: &acme.ProblemDetails{Type: acme.RateLimitedErr, HTTPStatus: http.StatusTooManyRequests}},
+			expected: true,
+		},
+		{
+			desc:     "server internal",
+			err:      &acme.ProblemDetails{Type: acme.ServerInternalErr, HTTPStatus: http.StatusInternalServerError},
+			expected: true,
+		},
+		{
+			desc:     "service unavailable",
+			err:      &acme.ProblemDetails{HTTPStatus: http.StatusServiceUnavailable},
+			expected: true,
+		},
+		{
+			desc: "unauthorized",
+			err:  &acme.ProblemDetails{Type: "urn:ietf:params:acme:error:unauthorized", HTTPStatus: http.StatusForbidden},
+		},
+		{
+			desc:     "ACME transport error",
+			err:      fmt.Errorf("get directory: %w", &acme.TransportError{Err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}}),
+			expected: true,
+		},
+		{
+			desc: "network error of the DNS propagation check",
+			err:  fmt.Errorf("propagation: %w", &net.DNSError{Err: "i/o timeout", Name: "_acme-challenge.example.com", IsTimeout: true}),
+		},
+		{
+			desc: "other error",
+			err:  errors.New("oops"),
+		},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, test.expected, isFailoverError(test.err))
+		})
+	}
+}
+
+func writeProblem(w http.ResponseWriter, problem acme.ProblemDetails) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(problem.HTTPStatus)
+
+	_ = tester.WriteJSONResponse(w, problem)
+}
@@ -0,0 +1,421 @@
+// Package manager manages a set of certificates: obtains, stores, and renews them, and provides them to a tls.Config.
+package manager
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/go-acme/lego/v4/log"
+	"golang.org/x/crypto/ocsp"
+)
+
+const (
+	defaultRenewBefore   = 30 * 24 * time.Hour
+	defaultCheckInterval = 12 * time.Hour
+)
+
+// Certifier obtains the certificates (ex: lego.Client.Certificate).
+type Certifier interface {
+	Obtain(request certificate.ObtainRequest) (*certificate.Resource, error)
+	GetOCSP(bundle []byte) ([]byte, *ocsp.Response, error)
+}
+
+// Storage persists the certificates.
+//
+// The key is the name of the certificate (see Spec.Name).
+type Storage interface {
+	// Load returns nil if there is no certificate for the name.
+	Load(name string) (*certificate.Resource, error)
+	Save(name string, certRes *certificate.Resource) error
+}
+
+// Spec a certificate managed by the Manager.
+type Spec struct {
+	// Name is the key of the certificate in the storage.
+	// The first domain is used by default.
+	Name string
+
+	// Domains are the domains of the certificate, the first domain is the common name.
+	// Wildcard domains (*.example.com) are supported.
+	Domains []string
+
+	MustStaple     bool
+	PreferredChain string
+}
+
+// Options the options of the Manager.
+type Options struct {
+	// RenewBefore is the remaining validity under which a certificate is renewed (30 days by default).
+	RenewBefore time.Duration
+
+	// CheckInterval is the interval between the checks of the background renewal (12 hours by default).
+	CheckInterval time.Duration
+
+	// OCSPStapling enables the stapling of the OCSP responses (fetched with Certifier.GetOCSP).
+	// A certificate reported as revoked by the OCSP responder is renewed immediately.
+	OCSPStapling bool
+
+	// OnError is called when a certificate cannot be obtained, renewed, or stapled by the background renewal.
+	// The errors are logged by default.
+	OnError func(name string, err error)
+}
+
+// Manager obtains, stores, and renews a set of certificates.
+//
+// The certificates are provided to a tls.Config through GetCertificate,
+// a renewed certificate replaces the previous one without restarting the server.
+type Manager struct {
+	certifier Certifier
+	storage   Storage
+	options   Options
+
+	muSync sync.Mutex
+
+	mu      sync.RWMutex
+	specs   map[string]Spec
+	names   []string
+	domains map[string]string
+	certs   map[string]*managedCertificate
+}
+
+type managedCertificate struct {
+	resource *certificate.Resource
+	leaf     *x509.Certificate
+	tlsCert  *tls.Certificate
+
+	// the date of the next refresh of the OCSP staple.
+	ocspRefresh time.Time
+	revoked     bool
+}
+
+// New creates a new Manager.
+func New(certifier Certifier, storage Storage, options Options) *Manager {
+	if options.RenewBefore <= 0 {
+		options.RenewBefore = defaultRenewBefore
+	}
+
+	if options.CheckInterval <= 0 {
+		options.CheckInterval = defaultCheckInterval
+	}
+
+	return &Manager{
+		certifier: certifier,
+		storage:   storage,
+		options:   options,
+		specs:     map[string]Spec{},
+		domains:   map[string]string{},
+		certs:     map[string]*managedCertificate{},
+	}
+}
+
+// Add adds a certificate to the Manager.
+// The certificate is loaded from the storage if it exists, it's obtained (or renewed) by Sync or Start.
+func (m *Manager) Add(spec Spec) error {
+	if len(spec.Domains) == 0 {
+		return errors.New("manager: no domains")
+	}
+
+	if spec.Name == "" {
+		spec.Name = spec.Domains[0]
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.specs[spec.Name]; exists {
+		return fmt.Errorf("manager: the certificate %s already exists", spec.Name)
+	}
+
+	for _, domain := range spec.Domains {
+		if name, exists := m.domains[normalizeDomain(domain)]; exists {
+			return fmt.Errorf("manager: the domain %s is already managed by the certificate %s", domain, name)
+		}
+	}
+
+	certRes, err := m.storage.Load(spec.Name)
+	if err != nil {
+		return fmt.Errorf("manager: unable to load the certificate %s: %w", spec.Name, err)
+	}
+
+	if certRes != nil {
+		managed, errM := newManagedCertificate(certRes)
+		if errM != nil {
+			log.Warnf("[%s] Unable to use the stored certificate: %v", spec.Name, errM)
+		} else {
+			m.certs[spec.Name] = managed
+		}
+	}
+
+	m.specs[spec.Name] = spec
+	m.names = append(m.names, spec.Name)
+
+	for _, domain := range spec.Domains {
+		m.domains[normalizeDomain(domain)] = spec.Name
+	}
+
+	return nil
+}
+
+// Start obtains the missing certificates, renews the expiring certificates,
+// then checks the certificates in the background until the context is canceled.
+// The errors are reported through Options.OnError.
+func (m *Manager) Start(ctx context.Context) {
+	m.syncAll()
+
+	go func() {
+		ticker := time.NewTicker(m.options.CheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.syncAll()
+			}
+		}
+	}()
+}
+
+// Sync obtains the missing certificates, renews the expiring certificates, and refreshes the OCSP staples.
+func (m *Manager) Sync() error {
+	var msgs []string
+
+	for name, err := range m.sync() {
+		msgs = append(msgs, fmt.Sprintf("%s: %v", name, err))
+	}
+
+	if len(msgs) > 0 {
+		return fmt.Errorf("manager: %s", strings.Join(msgs, ", "))
+	}
+
+	return nil
+}
+
+func (m *Manager) syncAll() {
+	for name, err := range m.sync() {
+		if m.options.OnError != nil {
+			m.options.OnError(name, err)
+			continue
+		}
+
+		log.Warnf("[%s] %v", name, err)
+	}
+}
+
+func (m *Manager) sync() map[string]error {
+	m.muSync.Lock()
+	defer m.muSync.Unlock()
+
+	m.mu.RLock()
+	names := append([]string{}, m.names...)
+	m.mu.RUnlock()
+
+	errs := map[string]error{}
+
+	for _, name := range names {
+		err := m.syncCertificate(name)
+		if err != nil {
+			errs[name] = err
+		}
+	}
+
+	return errs
+}
+
+func (m *Manager) syncCertificate(name string) error {
+	m.mu.RLock()
+	spec := m.specs[name]
+	managed := m.certs[name]
+	m.mu.RUnlock()
+
+	if managed == nil || managed.revoked || m.needRenewal(managed) {
+		var err error
+		managed, err = m.obtain(spec)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !m.options.OCSPStapling || time.Now().Before(managed.ocspRefresh) {
+		return nil
+	}
+
+	if !m.refreshStaple(name, managed).revoked {
+		return nil
+	}
+
+	// the revoked certificate is renewed immediately.
+	log.Warnf("[%s] The certificate has been revoked, renewing it", name)
+
+	managed, err := m.obtain(spec)
+	if err != nil {
+		return fmt.Errorf("the certificate has been revoked, unable to renew it: %w", err)
+	}
+
+	if m.refreshStaple(name, managed).revoked {
+		return errors.New("the renewed certificate has been revoked")
+	}
+
+	return nil
+}
+
+// refreshStaple refreshes the OCSP staple of the certificate, and stores the result.
+func (m *Manager) refreshStaple(name string, managed *managedCertificate) *managedCertificate {
+	managed = m.staple(managed)
+
+	m.mu.Lock()
+	m.certs[name] = managed
+	m.mu.Unlock()
+
+	return managed
+}
+
+func (m *Manager) needRenewal(managed *managedCertificate) bool {
+	return time.Until(managed.leaf.NotAfter) < m.options.RenewBefore
+}
+
+func (m *Manager) obtain(spec Spec) (*managedCertificate, error) {
+	log.Infof("[%s] Obtaining the certificate", spec.Name)
+
+	certRes, err := m.certifier.Obtain(certificate.ObtainRequest{
+		Domains:        spec.Domains,
+		Bundle:         true,
+		MustStaple:     spec.MustStaple,
+		PreferredChain: spec.PreferredChain,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	managed, err := newManagedCertificate(certRes)
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.storage.Save(spec.Name, certRes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to save the certificate: %w", err)
+	}
+
+	m.mu.Lock()
+	m.certs[spec.Name] = managed
+	m.mu.Unlock()
+
+	return managed, nil
+}
+
+// staple returns a copy of the certificate with a fresh OCSP staple.
+// The certificate is returned unchanged (without the staple) if the OCSP response is not available.
+func (m *Manager) staple(managed *managedCertificate) *managedCertificate {
+	next := *managed
+	next.ocspRefresh = time.Now().Add(m.options.CheckInterval)
+
+	raw, resp, err := m.certifier.GetOCSP(managed.resource.Certificate)
+	if err != nil {
+		log.Warnf("[%s] Unable to get the OCSP response: %v", managed.resource.Domain, err)
+		return &next
+	}
+
+	if resp == nil {
+		log.Warnf("[%s] The OCSP response is not available", managed.resource.Domain)
+		return &next
+	}
+
+	switch resp.Status {
+	case ocsp.Good:
+		tlsCert := *managed.tlsCert
+		tlsCert.OCSPStaple = raw
+		next.tlsCert = &tlsCert
+
+		// refreshes the staple at the half of its validity.
+		if !resp.NextUpdate.IsZero() {
+			next.ocspRefresh = resp.ThisUpdate.Add(resp.NextUpdate.Sub(resp.ThisUpdate) / 2)
+		}
+
+	case ocsp.Revoked:
+		next.revoked = true
+	}
+
+	return &next
+}
+
+// GetCertificate returns the certificate matching the server name of the ClientHello.
+// Can be used as tls.Config.GetCertificate.
+func (m *Manager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	serverName := normalizeDomain(hello.ServerName)
+	if serverName == "" {
+		return nil, errors.New("manager: missing server name")
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	name, ok := m.domains[serverName]
+	if !ok {
+		// wildcard: *.example.com matches foo.example.com.
+		if i := strings.Index(serverName, "."); i > 0 {
+			name, ok = m.domains["*"+serverName[i:]]
+		}
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("manager: no certificate for %s", hello.ServerName)
+	}
+
+	managed := m.certs[name]
+	if managed == nil {
+		return nil, fmt.Errorf("manager: the certificate %s is not yet available", name)
+	}
+
+	return managed.tlsCert, nil
+}
+
+// TLSConfig returns a tls.Config using the certificates of the Manager.
+func (m *Manager) TLSConfig() *tls.Config {
+	return &tls.Config{
+		GetCertificate: m.GetCertificate,
+		MinVersion:     tls.VersionTLS12,
+	}
+}
+
+// Certificate returns the current certificate resource for the name, or nil.
+func (m *Manager) Certificate(name string) *certificate.Resource {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	managed := m.certs[name]
+	if managed == nil {
+		return nil
+	}
+
+	return managed.resource
+}
+
+func newManagedCertificate(certRes *certificate.Resource) (*managedCertificate, error) {
+	tlsCert, err := tls.X509KeyPair(certRes.Certificate, certRes.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	leaf, err := certcrypto.ParsePEMCertificate(certRes.Certificate)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsCert.Leaf = leaf
+
+	return &managedCertificate{resource: certRes, leaf: leaf, tlsCert: &tlsCert}, nil
+}
+
+func normalizeDomain(domain string) string {
+	return strings.ToLower(strings.TrimSuffix(domain, "."))
+}
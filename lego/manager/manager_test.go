@@ -0,0 +1,220 @@
+package manager
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ocsp"
+)
+
+type fakeCertifier struct {
+	mu         sync.Mutex
+	validity   time.Duration
+	obtained   int
+	ocspStatus int
+	// revoked the serial numbers of the revoked certificates (overrides ocspStatus).
+	revoked map[int64]bool
+}
+
+func (f *fakeCertifier) Obtain(request certificate.ObtainRequest) (*certificate.Resource, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.obtained++
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(int64(f.obtained)),
+		Subject:      pkix.Name{CommonName: request.Domains[0]},
+		DNSNames:     request.Domains,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(f.validity),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, privateKey.Public(), privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &certificate.Resource{
+		Domain:      request.Domains[0],
+		Certificate: certcrypto.PEMEncode(certcrypto.DERCertificateBytes(der)),
+		PrivateKey:  certcrypto.PEMEncode(privateKey),
+	}, nil
+}
+
+func (f *fakeCertifier) GetOCSP(bundle []byte) ([]byte, *ocsp.Response, error) {
+	cert, err := certcrypto.ParsePEMCertificate(bundle)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	status := f.ocspStatus
+	if f.revoked[cert.SerialNumber.Int64()] {
+		status = ocsp.Revoked
+	}
+
+	return []byte("staple"), &ocsp.Response{
+		Status:     status,
+		ThisUpdate: time.Now(),
+		NextUpdate: time.Now().Add(4 * 24 * time.Hour),
+	}, nil
+}
+
+func TestManager_GetCertificate(t *testing.T) {
+	certifier := &fakeCertifier{validity: 90 * 24 * time.Hour}
+
+	manager := New(certifier, DirStorage(t.TempDir()), Options{})
+
+	err := manager.Add(Spec{Domains: []string{"example.com", "*.example.com"}})
+	require.NoError(t, err)
+
+	err = manager.Add(Spec{Domains: []string{"example.org"}})
+	require.NoError(t, err)
+
+	err = manager.Add(Spec{Name: "other", Domains: []string{"example.org"}})
+	require.Error(t, err)
+
+	_, err = manager.GetCertificate(&tls.ClientHelloInfo{ServerName: "example.com"})
+	require.Error(t, err, "not yet obtained")
+
+	err = manager.Sync()
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, certifier.obtained)
+
+	testCases := []struct {
+		serverName string
+		expected   string
+	}{
+		{serverName: "example.com", expected: "example.com"},
+		{serverName: "EXAMPLE.com.", expected: "example.com"},
+		{serverName: "foo.example.com", expected: "example.com"},
+		{serverName: "example.org", expected: "example.org"},
+	}
+
+	for _, test := range testCases {
+		cert, errG := manager.GetCertificate(&tls.ClientHelloInfo{ServerName: test.serverName})
+		require.NoError(t, errG, test.serverName)
+
+		assert.Equal(t, test.expected, cert.Leaf.Subject.CommonName, test.serverName)
+	}
+
+	for _, serverName := range []string{"", "foo.bar.example.com", "example.net"} {
+		_, err = manager.GetCertificate(&tls.ClientHelloInfo{ServerName: serverName})
+		require.Error(t, err, serverName)
+	}
+
+	// no renewal needed.
+	err = manager.Sync()
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, certifier.obtained)
+}
+
+func TestManager_Sync_renewal(t *testing.T) {
+	storage := DirStorage(t.TempDir())
+
+	certifier := &fakeCertifier{validity: 10 * 24 * time.Hour}
+
+	manager := New(certifier, storage, Options{})
+
+	err := manager.Add(Spec{Domains: []string{"example.com"}})
+	require.NoError(t, err)
+
+	err = manager.Sync()
+	require.NoError(t, err)
+
+	first, err := manager.GetCertificate(&tls.ClientHelloInfo{ServerName: "example.com"})
+	require.NoError(t, err)
+
+	// the certificate expires in less than 30 days: renewal.
+	certifier.validity = 90 * 24 * time.Hour
+
+	err = manager.Sync()
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, certifier.obtained)
+
+	second, err := manager.GetCertificate(&tls.ClientHelloInfo{ServerName: "example.com"})
+	require.NoError(t, err)
+
+	assert.NotEqual(t, first.Leaf.SerialNumber, second.Leaf.SerialNumber)
+
+	// the certificate is loaded from the storage.
+	manager = New(certifier, storage, Options{})
+
+	err = manager.Add(Spec{Domains: []string{"example.com"}})
+	require.NoError(t, err)
+
+	loaded, err := manager.GetCertificate(&tls.ClientHelloInfo{ServerName: "example.com"})
+	require.NoError(t, err)
+
+	assert.Equal(t, second.Leaf.SerialNumber, loaded.Leaf.SerialNumber)
+
+	err = manager.Sync()
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, certifier.obtained)
+}
+
+func TestManager_Sync_ocspStapling(t *testing.T) {
+	certifier := &fakeCertifier{validity: 90 * 24 * time.Hour, ocspStatus: ocsp.Good}
+
+	manager := New(certifier, DirStorage(t.TempDir()), Options{OCSPStapling: true})
+
+	err := manager.Add(Spec{Domains: []string{"example.com"}})
+	require.NoError(t, err)
+
+	err = manager.Sync()
+	require.NoError(t, err)
+
+	cert, err := manager.GetCertificate(&tls.ClientHelloInfo{ServerName: "example.com"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []byte("staple"), cert.OCSPStaple)
+
+	// revoked: the certificate is renewed by the same synchronization.
+	certifier.revoked = map[int64]bool{1: true}
+	manager.certs["example.com"].ocspRefresh = time.Time{}
+
+	err = manager.Sync()
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, certifier.obtained)
+
+	cert, err = manager.GetCertificate(&tls.ClientHelloInfo{ServerName: "example.com"})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(2), cert.Leaf.SerialNumber.Int64())
+	assert.Equal(t, []byte("staple"), cert.OCSPStaple)
+
+	// the renewed certificate is revoked too: the next synchronization renews it.
+	certifier.revoked[2] = true
+	certifier.revoked[3] = true
+	manager.certs["example.com"].ocspRefresh = time.Time{}
+
+	err = manager.Sync()
+	require.Error(t, err)
+
+	err = manager.Sync()
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, certifier.obtained)
+}
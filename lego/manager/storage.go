@@ -0,0 +1,109 @@
+package manager
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-acme/lego/v4/certificate"
+	"golang.org/x/net/idna"
+)
+
+// DirStorage stores the certificates in a directory,
+// with the same layout as the certificates directory of the CLI:
+//
+//	<name>.crt         the certificate (bundle)
+//	<name>.issuer.crt  the issuer certificate
+//	<name>.key         the private key
+//	<name>.json        the metadata
+//
+// The wildcard character of a name is replaced by an underscore.
+type DirStorage string
+
+// Load loads a certificate from the directory.
+func (d DirStorage) Load(name string) (*certificate.Resource, error) {
+	base, err := d.baseFilename(name)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := ioutil.ReadFile(base + ".json")
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	certRes := &certificate.Resource{}
+	err = json.Unmarshal(raw, certRes)
+	if err != nil {
+		return nil, err
+	}
+
+	certRes.Certificate, err = ioutil.ReadFile(base + ".crt")
+	if err != nil {
+		return nil, err
+	}
+
+	certRes.PrivateKey, err = ioutil.ReadFile(base + ".key")
+	if err != nil {
+		return nil, err
+	}
+
+	certRes.IssuerCertificate, err = ioutil.ReadFile(base + ".issuer.crt")
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return certRes, nil
+}
+
+// Save saves a certificate in the directory.
+// The metadata file is written last: a certificate is only loaded if all its files have been written.
+func (d DirStorage) Save(name string, certRes *certificate.Resource) error {
+	err := os.MkdirAll(string(d), 0o700)
+	if err != nil {
+		return err
+	}
+
+	base, err := d.baseFilename(name)
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(base+".crt", certRes.Certificate, 0o600)
+	if err != nil {
+		return err
+	}
+
+	if certRes.IssuerCertificate != nil {
+		err = ioutil.WriteFile(base+".issuer.crt", certRes.IssuerCertificate, 0o600)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = ioutil.WriteFile(base+".key", certRes.PrivateKey, 0o600)
+	if err != nil {
+		return err
+	}
+
+	raw, err := json.MarshalIndent(certRes, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(base+".json", raw, 0o600)
+}
+
+func (d DirStorage) baseFilename(name string) (string, error) {
+	safe, err := idna.ToASCII(strings.ReplaceAll(name, "*", "_"))
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(string(d), safe), nil
+}
@@ -7,11 +7,52 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-acme/lego/v4/log"
 )
 
+var (
+	// overrides the values which take precedence over the environment variables (see WithValues).
+	overrides   map[string]string
+	overridesMu sync.RWMutex
+
+	// withValuesMu serializes the calls to WithValues.
+	withValuesMu sync.Mutex
+)
+
+// WithValues calls fn with values which take precedence over the environment variables.
+// The environment of the process is not modified: the values are not inherited by the child processes.
+func WithValues(values map[string]string, fn func() error) error {
+	withValuesMu.Lock()
+	defer withValuesMu.Unlock()
+
+	overridesMu.Lock()
+	overrides = values
+	overridesMu.Unlock()
+
+	defer func() {
+		overridesMu.Lock()
+		overrides = nil
+		overridesMu.Unlock()
+	}()
+
+	return fn()
+}
+
+// getenv returns the value defined by WithValues, or the value of the environment variable.
+func getenv(name string) string {
+	overridesMu.RLock()
+	defer overridesMu.RUnlock()
+
+	if value, ok := overrides[name]; ok {
+		return value
+	}
+
+	return os.Getenv(name)
+}
+
 // Get environment variables.
 func Get(names ...string) (map[string]string, error) {
 	values := map[string]string{}
@@ -144,13 +185,13 @@ func GetOrDefaultBool(envVar string, defaultValue bool) bool {
 // Failing that, it will check to see if '<key>_FILE' exists.
 // If so, it will attempt to read from the referenced file to populate a value.
 func GetOrFile(envVar string) string {
-	envVarValue := os.Getenv(envVar)
+	envVarValue := getenv(envVar)
 	if envVarValue != "" {
 		return envVarValue
 	}
 
 	fileVar := envVar + "_FILE"
-	fileVarValue := os.Getenv(fileVar)
+	fileVarValue := getenv(fileVar)
 	if fileVarValue == "" {
 		return envVarValue
 	}
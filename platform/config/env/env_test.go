@@ -355,3 +355,21 @@ func TestGetOrFile_PrefersEnvVars(t *testing.T) {
 
 	assert.Equal(t, "lego_env", value)
 }
+
+func TestWithValues(t *testing.T) {
+	t.Setenv("TEST_LEGO_ENV_VAR", "lego_env")
+	t.Setenv("TEST_LEGO_ENV_OTHER", "lego_other")
+
+	err := WithValues(map[string]string{"TEST_LEGO_ENV_VAR": "lego_value"}, func() error {
+		assert.Equal(t, "lego_value", GetOrFile("TEST_LEGO_ENV_VAR"))
+		assert.Equal(t, "lego_other", GetOrFile("TEST_LEGO_ENV_OTHER"))
+
+		// the environment is not modified.
+		assert.Equal(t, "lego_env", os.Getenv("TEST_LEGO_ENV_VAR"))
+
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "lego_env", GetOrFile("TEST_LEGO_ENV_VAR"))
+}
@@ -0,0 +1,225 @@
+package acmeserver
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-acme/lego/v4/acme"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+type account struct {
+	id   string
+	url  string
+	key  *jose.JSONWebKey
+	body acme.Account
+
+	// orders the URLs of the orders of the account (in the creation order).
+	orders []string
+}
+
+func (s *Server) getAccountByURL(u string) *account {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.accounts[strings.TrimPrefix(u, s.url(accountPath))]
+}
+
+// handleNewAccount creates an account, or returns the existing account of the key.
+// - https://tools.ietf.org/html/rfc8555#section-7.3
+func (s *Server) handleNewAccount(w http.ResponseWriter, r *http.Request) {
+	req, problem := s.verifyRequest(r, true)
+	if problem != nil {
+		s.writeError(w, problem)
+		return
+	}
+
+	// a request signed by an existing account returns the account.
+	if req.account != nil {
+		s.mu.Lock()
+		body := req.account.body
+		s.mu.Unlock()
+
+		w.Header().Set("Location", req.account.url)
+		s.writeJSON(w, http.StatusOK, body)
+
+		return
+	}
+
+	var msg acme.Account
+	if problem = req.unmarshal(&msg); problem != nil {
+		s.writeError(w, problem)
+		return
+	}
+
+	keyID := base64.RawURLEncoding.EncodeToString(thumbprint(req.jwk))
+
+	s.mu.Lock()
+	existing := s.accountsByKey[keyID]
+	s.mu.Unlock()
+
+	if existing != nil {
+		w.Header().Set("Location", existing.url)
+		s.writeJSON(w, http.StatusOK, existing.body)
+		return
+	}
+
+	if msg.OnlyReturnExisting {
+		s.writeError(w, newProblem(http.StatusBadRequest, errAccountDoesNotExist, "no account for this key"))
+		return
+	}
+
+	if len(s.options.ExternalAccountBinding) > 0 {
+		if problem = s.verifyExternalAccountBinding(msg.ExternalAccountBinding, req.jwk); problem != nil {
+			s.writeError(w, problem)
+			return
+		}
+	}
+
+	if problem = checkContact(msg.Contact); problem != nil {
+		s.writeError(w, problem)
+		return
+	}
+
+	id := newID()
+
+	acc := &account{
+		id:  id,
+		url: s.url(accountPath + id),
+		key: req.jwk,
+		body: acme.Account{
+			Status:               acme.StatusValid,
+			Contact:              msg.Contact,
+			TermsOfServiceAgreed: msg.TermsOfServiceAgreed,
+			Orders:               s.url(accountPath + id + "/orders"),
+		},
+	}
+
+	s.mu.Lock()
+	s.accounts[id] = acc
+	s.accountsByKey[keyID] = acc
+	s.mu.Unlock()
+
+	w.Header().Set("Location", acc.url)
+	s.writeJSON(w, http.StatusCreated, acc.body)
+}
+
+// handleAccount returns, updates, or deactivates an account, or lists its orders.
+// - https://tools.ietf.org/html/rfc8555#section-7.3.2
+// - https://tools.ietf.org/html/rfc8555#section-7.3.6
+// - https://tools.ietf.org/html/rfc8555#section-7.1.2.1
+func (s *Server) handleAccount(w http.ResponseWriter, r *http.Request) {
+	req, problem := s.verifyRequest(r, false)
+	if problem != nil {
+		s.writeError(w, problem)
+		return
+	}
+
+	id, rest := resourceID(r, accountPath)
+	if req.account.id != id {
+		s.writeError(w, unauthorized("the request is not signed by the account"))
+		return
+	}
+
+	switch {
+	case rest == "orders":
+		s.writeOrdersList(w, r, req.account)
+
+	case rest != "":
+		s.writeError(w, notFound("unknown resource: %s", r.URL.Path))
+
+	case req.isPostAsGet():
+		s.mu.Lock()
+		body := req.account.body
+		s.mu.Unlock()
+
+		s.writeJSON(w, http.StatusOK, body)
+
+	default:
+		// the contact is a pointer to make the difference between an absent and an empty contact.
+		var msg struct {
+			Status  string    `json:"status"`
+			Contact *[]string `json:"contact"`
+		}
+
+		if problem = req.unmarshal(&msg); problem != nil {
+			s.writeError(w, problem)
+			return
+		}
+
+		if msg.Contact != nil {
+			if problem = checkContact(*msg.Contact); problem != nil {
+				s.writeError(w, problem)
+				return
+			}
+		}
+
+		s.mu.Lock()
+
+		if msg.Contact != nil {
+			req.account.body.Contact = *msg.Contact
+		}
+
+		switch msg.Status {
+		case "":
+		case acme.StatusDeactivated:
+			req.account.body.Status = acme.StatusDeactivated
+		default:
+			s.mu.Unlock()
+			s.writeError(w, malformed("invalid status: %q", msg.Status))
+
+			return
+		}
+
+		body := req.account.body
+		s.mu.Unlock()
+
+		s.writeJSON(w, http.StatusOK, body)
+	}
+}
+
+func (s *Server) accountOrders(acc *account) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string{}, acc.orders...)
+}
+
+// writeOrdersList writes a page of the orders list of the account (Options.OrdersPageSize),
+// the page is selected by the cursor parameter (the index of the first order).
+func (s *Server) writeOrdersList(w http.ResponseWriter, r *http.Request, acc *account) {
+	orders := s.accountOrders(acc)
+
+	cursor := 0
+	if raw := r.URL.Query().Get("cursor"); raw != "" {
+		var err error
+		cursor, err = strconv.Atoi(raw)
+		if err != nil || cursor < 0 || cursor > len(orders) {
+			s.writeError(w, malformed("invalid cursor: %s", raw))
+			return
+		}
+	}
+
+	orders = orders[cursor:]
+
+	if size := s.options.OrdersPageSize; size > 0 && len(orders) > size {
+		orders = orders[:size]
+
+		next := s.url(r.URL.Path + "?cursor=" + strconv.Itoa(cursor+size))
+		w.Header().Add("Link", `<`+next+`>;rel="next"`)
+	}
+
+	s.writeJSON(w, http.StatusOK, acme.OrdersList{Orders: orders})
+}
+
+func checkContact(contacts []string) *acme.ProblemDetails {
+	for _, contact := range contacts {
+		if !strings.HasPrefix(contact, "mailto:") || len(contact) == len("mailto:") {
+			return newProblem(http.StatusBadRequest, errInvalidContact, "invalid contact: %q", contact)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,151 @@
+package acmeserver
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"time"
+)
+
+// ocspMustStaple the TLS Feature extension (status_request).
+// - https://tools.ietf.org/html/rfc7633
+var ocspMustStaple = []int{1, 3, 6, 1, 5, 5, 7, 1, 24}
+
+// certificateAuthority issues the certificates.
+// All the chains share the same intermediate key: a certificate can be verified with any of the chains.
+type certificateAuthority struct {
+	key    crypto.Signer
+	chains []*chain
+}
+
+// chain a root and an intermediate certificate signed by the root.
+type chain struct {
+	root         *x509.Certificate
+	intermediate *x509.Certificate
+}
+
+func newCertificateAuthority(alternates int) (*certificateAuthority, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	ca := &certificateAuthority{key: key}
+
+	for i := 0; i <= alternates; i++ {
+		c, err := newChain(i, key)
+		if err != nil {
+			return nil, err
+		}
+
+		ca.chains = append(ca.chains, c)
+	}
+
+	return ca, nil
+}
+
+func newChain(index int, intermediateKey crypto.Signer) (*chain, error) {
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	rootTemplate, err := caTemplate(fmt.Sprintf("acmeserver root %d", index))
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := createCertificate(rootTemplate, rootTemplate, rootKey.Public(), rootKey)
+	if err != nil {
+		return nil, err
+	}
+
+	intermediateTemplate, err := caTemplate("acmeserver intermediate")
+	if err != nil {
+		return nil, err
+	}
+
+	intermediateTemplate.MaxPathLenZero = true
+
+	intermediate, err := createCertificate(intermediateTemplate, root, intermediateKey.Public(), rootKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &chain{root: root, intermediate: intermediate}, nil
+}
+
+// issue issues a certificate for a CSR.
+func (ca *certificateAuthority) issue(csr *x509.CertificateRequest, validity time.Duration) (*x509.Certificate, error) {
+	serial, err := newSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: csr.Subject.CommonName},
+		NotBefore:             now.Add(-time.Minute),
+		NotAfter:              now.Add(validity),
+		DNSNames:              csr.DNSNames,
+		IPAddresses:           csr.IPAddresses,
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+	}
+
+	if _, ok := csr.PublicKey.(*rsa.PublicKey); ok {
+		template.KeyUsage |= x509.KeyUsageKeyEncipherment
+	}
+
+	for _, ext := range csr.Extensions {
+		if ext.Id.Equal(ocspMustStaple) {
+			template.ExtraExtensions = append(template.ExtraExtensions, ext)
+		}
+	}
+
+	return createCertificate(template, ca.chains[0].intermediate, csr.PublicKey, ca.key)
+}
+
+// bundle returns the PEM bundle of a certificate with the chain at the index.
+func (ca *certificateAuthority) bundle(cert *x509.Certificate, index int) []byte {
+	bundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+
+	return append(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.chains[index].intermediate.Raw})...)
+}
+
+func caTemplate(name string) (*x509.Certificate, error) {
+	serial, err := newSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	return &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: name, Organization: []string{"lego"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(10 * 365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}, nil
+}
+
+func createCertificate(template, parent *x509.Certificate, pub interface{}, key crypto.Signer) (*x509.Certificate, error) {
+	raw, err := x509.CreateCertificate(rand.Reader, template, parent, pub, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return x509.ParseCertificate(raw)
+}
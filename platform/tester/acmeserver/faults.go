@@ -0,0 +1,52 @@
+package acmeserver
+
+// Fault a fault injected in the responses of the server.
+type Fault string
+
+// Faults.
+const (
+	// FaultBadNonce the signed requests are rejected with a badNonce error (the client retries them).
+	FaultBadNonce Fault = "badNonce"
+	// FaultRateLimited the new orders are rejected with a rateLimited error (the client returns it, the reset is in one hour).
+	FaultRateLimited Fault = "rateLimited"
+	// FaultServerInternal the signed requests are rejected with a serverInternal error.
+	FaultServerInternal Fault = "serverInternal"
+	// FaultFinalizeServerInternal the finalize requests are rejected with a serverInternal error.
+	FaultFinalizeServerInternal Fault = "finalizeServerInternal"
+	// FaultInvalidChallenge the challenges are invalid, whatever the response of the client.
+	FaultInvalidChallenge Fault = "invalidChallenge"
+)
+
+// InjectFault injects a fault in the next count responses concerned by the fault.
+// A negative count injects the fault until ClearFaults is called.
+func (s *Server) InjectFault(fault Fault, count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults[fault] = count
+}
+
+// ClearFaults removes all the injected faults.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = map[Fault]int{}
+}
+
+// consumeFault reports whether a fault must be injected in the current response.
+func (s *Server) consumeFault(fault Fault) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count, ok := s.faults[fault]
+	if !ok || count == 0 {
+		return false
+	}
+
+	if count > 0 {
+		s.faults[fault] = count - 1
+	}
+
+	return true
+}
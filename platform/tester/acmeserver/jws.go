@@ -0,0 +1,200 @@
+package acmeserver
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/go-acme/lego/v4/acme"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// request a verified signed request.
+type request struct {
+	// payload the payload of the JWS (empty for a POST-as-GET).
+	payload []byte
+	// account the account which has signed the request (nil if the request is signed with a JWK).
+	account *account
+	// jwk the key embedded in the request (nil if the request is signed by an account).
+	jwk *jose.JSONWebKey
+}
+
+func (r *request) isPostAsGet() bool {
+	return len(r.payload) == 0
+}
+
+func (r *request) unmarshal(v interface{}) *acme.ProblemDetails {
+	if err := json.Unmarshal(r.payload, v); err != nil {
+		return malformed("invalid payload: %v", err)
+	}
+
+	return nil
+}
+
+// verifyRequest verifies a signed request (nonce, url, key, signature).
+// - https://tools.ietf.org/html/rfc8555#section-6.2
+func (s *Server) verifyRequest(r *http.Request, allowJWK bool) (*request, *acme.ProblemDetails) {
+	if r.Method != http.MethodPost {
+		return nil, newProblem(http.StatusMethodNotAllowed, errMalformed, "method not allowed: %s", r.Method)
+	}
+
+	if s.consumeFault(FaultServerInternal) {
+		return nil, newProblem(http.StatusInternalServerError, errServerInternal, "injected fault")
+	}
+
+	if s.consumeFault(FaultBadNonce) {
+		return nil, newProblem(http.StatusBadRequest, errBadNonce, "injected fault")
+	}
+
+	if ct := r.Header.Get("Content-Type"); ct != "application/jose+json" {
+		return nil, newProblem(http.StatusUnsupportedMediaType, errMalformed, "invalid content type: %q", ct)
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, malformed("unable to read the request body: %v", err)
+	}
+
+	jws, err := jose.ParseSigned(string(body))
+	if err != nil {
+		return nil, malformed("invalid JWS: %v", err)
+	}
+
+	if len(jws.Signatures) != 1 {
+		return nil, malformed("the JWS must have exactly one signature")
+	}
+
+	header := jws.Signatures[0].Protected
+
+	if !s.isAllowedAlgorithm(header.Algorithm) {
+		problem := newProblem(http.StatusBadRequest, errBadSignatureAlgorithm, "unsupported signature algorithm: %q", header.Algorithm)
+		problem.Algorithms = s.options.SignatureAlgorithms
+
+		return nil, problem
+	}
+
+	if header.Nonce == "" || !s.useNonce(header.Nonce) {
+		return nil, newProblem(http.StatusBadRequest, errBadNonce, "invalid nonce: %q", header.Nonce)
+	}
+
+	if u, _ := header.ExtraHeaders[jose.HeaderKey("url")].(string); u != s.url(r.URL.RequestURI()) {
+		return nil, unauthorized("the url header %q doesn't match the request URL", u)
+	}
+
+	req := &request{}
+
+	var key interface{}
+
+	switch {
+	case header.JSONWebKey != nil && header.KeyID != "":
+		return nil, malformed("the JWS must contain either a jwk or a kid, not both")
+
+	case header.JSONWebKey != nil:
+		if !allowJWK {
+			return nil, malformed("the JWS must be signed by an account (kid)")
+		}
+
+		req.jwk = header.JSONWebKey
+		key = header.JSONWebKey
+
+	case header.KeyID != "":
+		account := s.getAccountByURL(header.KeyID)
+		if account == nil {
+			return nil, newProblem(http.StatusBadRequest, errAccountDoesNotExist, "unknown account: %s", header.KeyID)
+		}
+
+		if account.body.Status != acme.StatusValid {
+			return nil, unauthorized("the account is %s", account.body.Status)
+		}
+
+		req.account = account
+		key = account.key
+
+	default:
+		return nil, malformed("the JWS must contain a jwk or a kid")
+	}
+
+	req.payload, err = jws.Verify(key)
+	if err != nil {
+		return nil, malformed("JWS verification error: %v", err)
+	}
+
+	return req, nil
+}
+
+func (s *Server) isAllowedAlgorithm(alg string) bool {
+	for _, allowed := range s.options.SignatureAlgorithms {
+		if alg == allowed {
+			return true
+		}
+	}
+
+	return false
+}
+
+// verifyExternalAccountBinding verifies the EAB of a new account request.
+// - https://tools.ietf.org/html/rfc8555#section-7.3.4
+func (s *Server) verifyExternalAccountBinding(raw json.RawMessage, accountKey *jose.JSONWebKey) *acme.ProblemDetails {
+	if len(raw) == 0 {
+		return newProblem(http.StatusUnauthorized, errExternalAccountRequired, "an external account binding is required")
+	}
+
+	jws, err := jose.ParseSigned(string(raw))
+	if err != nil {
+		return malformed("invalid external account binding: %v", err)
+	}
+
+	if len(jws.Signatures) != 1 {
+		return malformed("the external account binding must have exactly one signature")
+	}
+
+	header := jws.Signatures[0].Protected
+
+	if !strings.HasPrefix(header.Algorithm, "HS") {
+		return malformed("the external account binding must use a MAC algorithm: %q", header.Algorithm)
+	}
+
+	if u, _ := header.ExtraHeaders[jose.HeaderKey("url")].(string); u != s.url(newAccountPath) {
+		return malformed("the url header of the external account binding must be the newAccount URL")
+	}
+
+	hmacKey, ok := s.options.ExternalAccountBinding[header.KeyID]
+	if !ok {
+		return unauthorized("unknown external account: %q", header.KeyID)
+	}
+
+	payload, err := jws.Verify(hmacKey)
+	if err != nil {
+		return unauthorized("external account binding verification error: %v", err)
+	}
+
+	var eabKey jose.JSONWebKey
+	if err = json.Unmarshal(payload, &eabKey); err != nil {
+		return malformed("invalid external account binding payload: %v", err)
+	}
+
+	if !bytes.Equal(thumbprint(&eabKey), thumbprint(accountKey)) {
+		return unauthorized("the external account binding doesn't match the account key")
+	}
+
+	return nil
+}
+
+func thumbprint(key *jose.JSONWebKey) []byte {
+	raw, err := key.Thumbprint(crypto.SHA256)
+	if err != nil {
+		return nil
+	}
+
+	return raw
+}
+
+// keyAuthorization returns the key authorization of a challenge token.
+// - https://tools.ietf.org/html/rfc8555#section-8.1
+func keyAuthorization(token string, key *jose.JSONWebKey) string {
+	return token + "." + base64.RawURLEncoding.EncodeToString(thumbprint(key))
+}
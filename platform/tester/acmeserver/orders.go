@@ -0,0 +1,659 @@
+package acmeserver
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-acme/lego/v4/acme"
+	"github.com/go-acme/lego/v4/challenge"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+const (
+	orderLifetime = 7 * 24 * time.Hour
+	authzLifetime = 30 * 24 * time.Hour
+)
+
+type order struct {
+	id            string
+	url           string
+	accountID     string
+	body          acme.Order
+	authzs        []*authorization
+	certificateID string
+}
+
+type authorization struct {
+	id        string
+	url       string
+	accountID string
+	body      acme.Authorization
+}
+
+type challengeRef struct {
+	authz *authorization
+	index int
+}
+
+type issuedCertificate struct {
+	id        string
+	accountID string
+	cert      *x509.Certificate
+	revoked   bool
+	reason    uint
+}
+
+// handleNewOrder creates an order.
+// - https://tools.ietf.org/html/rfc8555#section-7.4
+func (s *Server) handleNewOrder(w http.ResponseWriter, r *http.Request) {
+	req, problem := s.verifyRequest(r, false)
+	if problem != nil {
+		s.writeError(w, problem)
+		return
+	}
+
+	if s.consumeFault(FaultRateLimited) {
+		w.Header().Set("Retry-After", "3600")
+		s.writeError(w, newProblem(http.StatusTooManyRequests, errRateLimited, "injected fault"))
+
+		return
+	}
+
+	var msg acme.Order
+	if problem = req.unmarshal(&msg); problem != nil {
+		s.writeError(w, problem)
+		return
+	}
+
+	if len(msg.Identifiers) == 0 {
+		s.writeError(w, malformed("the order must contain at least one identifier"))
+		return
+	}
+
+	for _, ident := range msg.Identifiers {
+		if problem = checkIdentifier(ident); problem != nil {
+			s.writeError(w, problem)
+			return
+		}
+	}
+
+	id := newID()
+
+	s.mu.Lock()
+
+	o := &order{
+		id:        id,
+		url:       s.url(orderPath + id),
+		accountID: req.account.id,
+		body: acme.Order{
+			Status:      acme.StatusPending,
+			Expires:     time.Now().Add(orderLifetime).UTC().Format(time.RFC3339),
+			Identifiers: msg.Identifiers,
+			NotBefore:   msg.NotBefore,
+			NotAfter:    msg.NotAfter,
+			Finalize:    s.url(orderPath + id + "/finalize"),
+		},
+	}
+
+	for _, ident := range msg.Identifiers {
+		authz := s.findValidAuthz(req.account, ident)
+		if authz == nil {
+			authz = s.createAuthz(req.account, ident)
+		}
+
+		o.authzs = append(o.authzs, authz)
+		o.body.Authorizations = append(o.body.Authorizations, authz.url)
+	}
+
+	s.refreshOrder(o)
+
+	s.orders[id] = o
+	req.account.orders = append(req.account.orders, o.url)
+
+	body := o.body
+	s.mu.Unlock()
+
+	w.Header().Set("Location", o.url)
+	s.writeJSON(w, http.StatusCreated, body)
+}
+
+// handleNewAuthz creates a pre-authorization.
+// - https://tools.ietf.org/html/rfc8555#section-7.4.1
+func (s *Server) handleNewAuthz(w http.ResponseWriter, r *http.Request) {
+	req, problem := s.verifyRequest(r, false)
+	if problem != nil {
+		s.writeError(w, problem)
+		return
+	}
+
+	var msg acme.NewAuthzMessage
+	if problem = req.unmarshal(&msg); problem != nil {
+		s.writeError(w, problem)
+		return
+	}
+
+	if problem = checkIdentifier(msg.Identifier); problem != nil {
+		s.writeError(w, problem)
+		return
+	}
+
+	if strings.HasPrefix(msg.Identifier.Value, "*.") {
+		s.writeError(w, newProblem(http.StatusBadRequest, errRejectedIdentifier, "the pre-authorizations of wildcard identifiers are not supported"))
+		return
+	}
+
+	s.mu.Lock()
+	authz := s.createAuthz(req.account, msg.Identifier)
+	body := authz.body
+	s.mu.Unlock()
+
+	w.Header().Set("Location", authz.url)
+	s.writeJSON(w, http.StatusCreated, body)
+}
+
+// handleOrder returns or finalizes an order.
+// - https://tools.ietf.org/html/rfc8555#section-7.4
+func (s *Server) handleOrder(w http.ResponseWriter, r *http.Request) {
+	req, problem := s.verifyRequest(r, false)
+	if problem != nil {
+		s.writeError(w, problem)
+		return
+	}
+
+	id, rest := resourceID(r, orderPath)
+
+	s.mu.Lock()
+	o := s.orders[id]
+	s.mu.Unlock()
+
+	if o == nil || o.accountID != req.account.id {
+		s.writeError(w, notFound("unknown order: %s", id))
+		return
+	}
+
+	switch rest {
+	case "":
+		s.mu.Lock()
+		s.refreshOrder(o)
+		body := o.body
+		s.mu.Unlock()
+
+		s.writeJSON(w, http.StatusOK, body)
+
+	case "finalize":
+		s.finalize(w, req, o)
+
+	default:
+		s.writeError(w, notFound("unknown resource: %s", r.URL.Path))
+	}
+}
+
+// finalize issues the certificate of an order.
+// - https://tools.ietf.org/html/rfc8555#section-7.4
+func (s *Server) finalize(w http.ResponseWriter, req *request, o *order) {
+	if s.consumeFault(FaultFinalizeServerInternal) {
+		s.writeError(w, newProblem(http.StatusInternalServerError, errServerInternal, "injected fault"))
+		return
+	}
+
+	var msg acme.CSRMessage
+	if problem := req.unmarshal(&msg); problem != nil {
+		s.writeError(w, problem)
+		return
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(msg.Csr)
+	if err != nil {
+		s.writeError(w, newProblem(http.StatusBadRequest, errBadCSR, "invalid CSR encoding: %v", err))
+		return
+	}
+
+	csr, err := x509.ParseCertificateRequest(raw)
+	if err != nil {
+		s.writeError(w, newProblem(http.StatusBadRequest, errBadCSR, "invalid CSR: %v", err))
+		return
+	}
+
+	if err = csr.CheckSignature(); err != nil {
+		s.writeError(w, newProblem(http.StatusBadRequest, errBadCSR, "invalid CSR signature: %v", err))
+		return
+	}
+
+	if bytes.Equal(thumbprint(&jose.JSONWebKey{Key: csr.PublicKey}), thumbprint(req.account.key)) {
+		s.writeError(w, newProblem(http.StatusBadRequest, errBadCSR, "the certificate key must not be the account key"))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.refreshOrder(o)
+
+	if o.body.Status != acme.StatusReady {
+		s.writeErrorLocked(w, newProblem(http.StatusForbidden, errOrderNotReady, "the order is %s", o.body.Status))
+		return
+	}
+
+	if !equalIdentifiers(o.body.Identifiers, csrIdentifiers(csr)) {
+		s.writeErrorLocked(w, newProblem(http.StatusBadRequest, errBadCSR, "the CSR identifiers don't match the order identifiers"))
+		return
+	}
+
+	cert, err := s.ca.issue(csr, s.options.CertificateValidity)
+	if err != nil {
+		s.writeErrorLocked(w, newProblem(http.StatusInternalServerError, errServerInternal, "unable to issue the certificate: %v", err))
+		return
+	}
+
+	issued := &issuedCertificate{id: newID(), accountID: o.accountID, cert: cert}
+	s.certificates[issued.id] = issued
+
+	o.certificateID = issued.id
+	o.body.Status = acme.StatusValid
+	o.body.Certificate = s.url(certificatePath + issued.id)
+
+	w.Header().Set("Location", o.url)
+	s.writeJSONLocked(w, http.StatusOK, o.body)
+}
+
+// handleAuthz returns or deactivates an authorization.
+// - https://tools.ietf.org/html/rfc8555#section-7.5
+// - https://tools.ietf.org/html/rfc8555#section-7.5.2
+func (s *Server) handleAuthz(w http.ResponseWriter, r *http.Request) {
+	req, problem := s.verifyRequest(r, false)
+	if problem != nil {
+		s.writeError(w, problem)
+		return
+	}
+
+	id, _ := resourceID(r, authzPath)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	authz := s.authzs[id]
+	if authz == nil || authz.accountID != req.account.id {
+		s.writeErrorLocked(w, notFound("unknown authorization: %s", id))
+		return
+	}
+
+	if !req.isPostAsGet() {
+		var msg struct {
+			Status string `json:"status"`
+		}
+
+		if problem = req.unmarshal(&msg); problem != nil {
+			s.writeErrorLocked(w, problem)
+			return
+		}
+
+		if msg.Status != acme.StatusDeactivated {
+			s.writeErrorLocked(w, malformed("invalid status: %q", msg.Status))
+			return
+		}
+
+		if authz.body.Status != acme.StatusPending && authz.body.Status != acme.StatusValid {
+			s.writeErrorLocked(w, malformed("the authorization is %s", authz.body.Status))
+			return
+		}
+
+		authz.body.Status = acme.StatusDeactivated
+	}
+
+	s.writeJSONLocked(w, http.StatusOK, authz.body)
+}
+
+// handleChallenge returns or validates a challenge.
+// - https://tools.ietf.org/html/rfc8555#section-7.5.1
+func (s *Server) handleChallenge(w http.ResponseWriter, r *http.Request) {
+	req, problem := s.verifyRequest(r, false)
+	if problem != nil {
+		s.writeError(w, problem)
+		return
+	}
+
+	id, _ := resourceID(r, challengePath)
+
+	s.mu.Lock()
+	ref := s.challenges[id]
+
+	if ref == nil || ref.authz.accountID != req.account.id {
+		s.mu.Unlock()
+		s.writeError(w, notFound("unknown challenge: %s", id))
+
+		return
+	}
+
+	authz := ref.authz
+	chlg := authz.body.Challenges[ref.index]
+	ident := authz.body.Identifier
+	wildcard := authz.body.Wildcard
+	pending := authz.body.Status == acme.StatusPending && chlg.Status == acme.StatusPending
+
+	if pending && !req.isPostAsGet() {
+		authz.body.Challenges[ref.index].Status = acme.StatusProcessing
+	}
+	s.mu.Unlock()
+
+	if pending && !req.isPostAsGet() {
+		problem = s.validate(chlg, ident, wildcard, req.account)
+
+		s.mu.Lock()
+
+		if problem == nil {
+			authz.body.Challenges[ref.index].Status = acme.StatusValid
+			authz.body.Challenges[ref.index].Validated = time.Now().UTC()
+			authz.body.Status = acme.StatusValid
+		} else {
+			authz.body.Challenges[ref.index].Status = acme.StatusInvalid
+			authz.body.Challenges[ref.index].Error = problem
+			authz.body.Status = acme.StatusInvalid
+		}
+
+		s.mu.Unlock()
+	}
+
+	s.mu.Lock()
+	chlg = authz.body.Challenges[ref.index]
+	s.mu.Unlock()
+
+	w.Header().Add("Link", `<`+authz.url+`>;rel="up"`)
+	s.writeJSON(w, http.StatusOK, chlg)
+}
+
+// handleCertificate returns a certificate with the default chain or with an alternate chain.
+// - https://tools.ietf.org/html/rfc8555#section-7.4.2
+func (s *Server) handleCertificate(w http.ResponseWriter, r *http.Request) {
+	req, problem := s.verifyRequest(r, false)
+	if problem != nil {
+		s.writeError(w, problem)
+		return
+	}
+
+	id, rest := resourceID(r, certificatePath)
+
+	s.mu.Lock()
+	issued := s.certificates[id]
+	s.mu.Unlock()
+
+	if issued == nil || issued.accountID != req.account.id {
+		s.writeError(w, notFound("unknown certificate: %s", id))
+		return
+	}
+
+	index := 0
+	if rest != "" {
+		var err error
+
+		index, err = strconv.Atoi(rest)
+		if err != nil || index < 1 || index >= len(s.ca.chains) {
+			s.writeError(w, notFound("unknown chain: %s", rest))
+			return
+		}
+	}
+
+	for i := range s.ca.chains {
+		if i != index && i != 0 {
+			w.Header().Add("Link", `<`+s.url(certificatePath+id+"/"+strconv.Itoa(i))+`>;rel="alternate"`)
+		}
+	}
+
+	if index != 0 {
+		w.Header().Add("Link", `<`+s.url(certificatePath+id)+`>;rel="alternate"`)
+	}
+
+	s.addNonce(w)
+	w.Header().Add("Link", `<`+s.URL()+`>;rel="index"`)
+	w.Header().Set("Content-Type", "application/pem-certificate-chain")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(s.ca.bundle(issued.cert, index))
+}
+
+// handleRevokeCert revokes a certificate,
+// the request is signed by the account which has issued the certificate or by the certificate key.
+// - https://tools.ietf.org/html/rfc8555#section-7.6
+func (s *Server) handleRevokeCert(w http.ResponseWriter, r *http.Request) {
+	req, problem := s.verifyRequest(r, true)
+	if problem != nil {
+		s.writeError(w, problem)
+		return
+	}
+
+	var msg acme.RevokeCertMessage
+	if problem = req.unmarshal(&msg); problem != nil {
+		s.writeError(w, problem)
+		return
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(msg.Certificate)
+	if err != nil {
+		s.writeError(w, malformed("invalid certificate encoding: %v", err))
+		return
+	}
+
+	cert, err := x509.ParseCertificate(raw)
+	if err != nil {
+		s.writeError(w, malformed("invalid certificate: %v", err))
+		return
+	}
+
+	var reason uint
+	if msg.Reason != nil {
+		reason = *msg.Reason
+	}
+
+	// the reason 7 is not used.
+	// - https://tools.ietf.org/html/rfc5280#section-5.3.1
+	if reason > 10 || reason == 7 {
+		s.writeError(w, newProblem(http.StatusBadRequest, errBadRevocationReason, "invalid revocation reason: %d", reason))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var issued *issuedCertificate
+	for _, c := range s.certificates {
+		if bytes.Equal(c.cert.Raw, cert.Raw) {
+			issued = c
+			break
+		}
+	}
+
+	if issued == nil {
+		s.writeErrorLocked(w, notFound("unknown certificate"))
+		return
+	}
+
+	switch {
+	case req.account != nil && req.account.id != issued.accountID:
+		s.writeErrorLocked(w, unauthorized("the certificate has not been issued by the account"))
+		return
+
+	case req.jwk != nil && !bytes.Equal(thumbprint(req.jwk), thumbprint(&jose.JSONWebKey{Key: cert.PublicKey})):
+		s.writeErrorLocked(w, unauthorized("the request is not signed by the certificate key"))
+		return
+	}
+
+	if issued.revoked {
+		s.writeErrorLocked(w, newProblem(http.StatusBadRequest, errAlreadyRevoked, "the certificate is already revoked"))
+		return
+	}
+
+	issued.revoked = true
+	issued.reason = reason
+
+	s.addNonceLocked(w)
+	w.WriteHeader(http.StatusOK)
+}
+
+// findValidAuthz returns a valid authorization of the account for the identifier, if any (the lock must be held).
+func (s *Server) findValidAuthz(acc *account, ident acme.Identifier) *authorization {
+	wildcard := strings.HasPrefix(ident.Value, "*.")
+	key := identifierKey(acme.Identifier{Type: ident.Type, Value: strings.TrimPrefix(ident.Value, "*.")})
+
+	for _, authz := range s.authzs {
+		if authz.accountID == acc.id && authz.body.Status == acme.StatusValid &&
+			authz.body.Wildcard == wildcard && identifierKey(authz.body.Identifier) == key &&
+			authz.body.Expires.After(time.Now()) {
+			return authz
+		}
+	}
+
+	return nil
+}
+
+// createAuthz creates an authorization and its challenges (the lock must be held).
+func (s *Server) createAuthz(acc *account, ident acme.Identifier) *authorization {
+	wildcard := strings.HasPrefix(ident.Value, "*.")
+
+	id := newID()
+
+	authz := &authorization{
+		id:        id,
+		url:       s.url(authzPath + id),
+		accountID: acc.id,
+		body: acme.Authorization{
+			Status:     acme.StatusPending,
+			Expires:    time.Now().Add(authzLifetime).UTC(),
+			Identifier: acme.Identifier{Type: ident.Type, Value: strings.ToLower(strings.TrimPrefix(ident.Value, "*."))},
+			Wildcard:   wildcard,
+		},
+	}
+
+	for i, chlgType := range s.challengeTypes(ident, wildcard) {
+		chlgID := newID()
+
+		chlg := acme.Challenge{
+			Type:   string(chlgType),
+			URL:    s.url(challengePath + chlgID),
+			Status: acme.StatusPending,
+			Token:  newToken(),
+		}
+
+		if chlgType == challenge.DNSPersist01 {
+			chlg.IssuerDomainNames = []string{s.options.DNSPersist01}
+		}
+
+		authz.body.Challenges = append(authz.body.Challenges, chlg)
+
+		s.challenges[chlgID] = &challengeRef{authz: authz, index: i}
+	}
+
+	s.authzs[id] = authz
+
+	return authz
+}
+
+// refreshOrder updates the status of an order from the status of its authorizations (the lock must be held).
+func (s *Server) refreshOrder(o *order) {
+	if o.body.Status != acme.StatusPending {
+		return
+	}
+
+	ready := true
+
+	for _, authz := range o.authzs {
+		switch authz.body.Status {
+		case acme.StatusValid:
+		case acme.StatusPending:
+			ready = false
+		default:
+			o.body.Status = acme.StatusInvalid
+
+			for _, chlg := range authz.body.Challenges {
+				if chlg.Error != nil {
+					o.body.Error = chlg.Error
+				}
+			}
+
+			return
+		}
+	}
+
+	if ready {
+		o.body.Status = acme.StatusReady
+	}
+}
+
+func (s *Server) writeJSONLocked(w http.ResponseWriter, status int, body interface{}) {
+	s.addNonceLocked(w)
+	w.Header().Set("Link", `<`+s.URL()+`>;rel="index"`)
+
+	writeJSON(w, "application/json", status, body)
+}
+
+func (s *Server) writeErrorLocked(w http.ResponseWriter, problem *acme.ProblemDetails) {
+	s.addNonceLocked(w)
+
+	writeJSON(w, "application/problem+json", problem.HTTPStatus, problem)
+}
+
+// csrIdentifiers returns the identifiers of a CSR (common name, DNS names, and IP addresses).
+func csrIdentifiers(csr *x509.CertificateRequest) []acme.Identifier {
+	var idents []acme.Identifier
+
+	if csr.Subject.CommonName != "" {
+		idents = append(idents, acme.Identifier{Type: "dns", Value: csr.Subject.CommonName})
+	}
+
+	for _, name := range csr.DNSNames {
+		idents = append(idents, acme.Identifier{Type: "dns", Value: name})
+	}
+
+	for _, ip := range csr.IPAddresses {
+		idents = append(idents, acme.Identifier{Type: "ip", Value: ip.String()})
+	}
+
+	return idents
+}
+
+func equalIdentifiers(a, b []acme.Identifier) bool {
+	keys := func(idents []acme.Identifier) []string {
+		set := map[string]bool{}
+		for _, ident := range idents {
+			if ident.Type == "dns" && net.ParseIP(ident.Value) != nil {
+				ident.Type = "ip"
+			}
+
+			set[identifierKey(ident)] = true
+		}
+
+		var result []string
+		for k := range set {
+			result = append(result, k)
+		}
+
+		sort.Strings(result)
+
+		return result
+	}
+
+	ka, kb := keys(a), keys(b)
+	if len(ka) != len(kb) {
+		return false
+	}
+
+	for i := range ka {
+		if ka[i] != kb[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newToken() string {
+	raw := make([]byte, 32)
+	_, _ = rand.Read(raw)
+
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
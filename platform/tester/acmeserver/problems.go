@@ -0,0 +1,66 @@
+package acmeserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-acme/lego/v4/acme"
+)
+
+// Errors types.
+// - https://tools.ietf.org/html/rfc8555#section-6.7
+const (
+	errNS                      = "urn:ietf:params:acme:error:"
+	errAccountDoesNotExist     = errNS + "accountDoesNotExist"
+	errAlreadyRevoked          = errNS + "alreadyRevoked"
+	errBadCSR                  = acme.BadCSRErr
+	errBadNonce                = acme.BadNonceErr
+	errBadRevocationReason     = errNS + "badRevocationReason"
+	errBadSignatureAlgorithm   = acme.BadSignatureAlgorithmErr
+	errConnection              = errNS + "connection"
+	errDNS                     = errNS + "dns"
+	errExternalAccountRequired = errNS + "externalAccountRequired"
+	errIncorrectResponse       = errNS + "incorrectResponse"
+	errInvalidContact          = errNS + "invalidContact"
+	errMalformed               = errNS + "malformed"
+	errOrderNotReady           = errNS + "orderNotReady"
+	errRateLimited             = acme.RateLimitedErr
+	errRejectedIdentifier      = errNS + "rejectedIdentifier"
+	errServerInternal          = acme.ServerInternalErr
+	errTLS                     = errNS + "tls"
+	errUnauthorized            = errNS + "unauthorized"
+	errUnsupportedIdentifier   = errNS + "unsupportedIdentifier"
+)
+
+func newProblem(status int, errType, format string, args ...interface{}) *acme.ProblemDetails {
+	return &acme.ProblemDetails{
+		Type:       errType,
+		Detail:     fmt.Sprintf(format, args...),
+		HTTPStatus: status,
+	}
+}
+
+func malformed(format string, args ...interface{}) *acme.ProblemDetails {
+	return newProblem(http.StatusBadRequest, errMalformed, format, args...)
+}
+
+func unauthorized(format string, args ...interface{}) *acme.ProblemDetails {
+	return newProblem(http.StatusForbidden, errUnauthorized, format, args...)
+}
+
+func notFound(format string, args ...interface{}) *acme.ProblemDetails {
+	return newProblem(http.StatusNotFound, errMalformed, format, args...)
+}
+
+func writeJSON(w http.ResponseWriter, contentType string, status int, body interface{}) {
+	raw, err := json.Marshal(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	_, _ = w.Write(raw)
+}
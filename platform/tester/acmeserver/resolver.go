@@ -0,0 +1,134 @@
+package acmeserver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/go-acme/lego/v4/challenge/dns01"
+)
+
+// Resolver resolves the hosts and the TXT records used to validate the challenges.
+// *net.Resolver implements Resolver.
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// LocalResolver an in-memory Resolver.
+// The hosts resolve to 127.0.0.1, unless their addresses are defined with SetHost.
+//
+// LocalResolver implements challenge.Provider:
+// the dns-01 challenges can be solved by adding the TXT records to the resolver itself.
+type LocalResolver struct {
+	mu    sync.Mutex
+	hosts map[string][]string
+	txt   map[string][]string
+}
+
+// NewLocalResolver creates a LocalResolver.
+func NewLocalResolver() *LocalResolver {
+	return &LocalResolver{
+		hosts: map[string][]string{},
+		txt:   map[string][]string{},
+	}
+}
+
+// SetHost defines the addresses of a host.
+func (r *LocalResolver) SetHost(host string, addresses ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.hosts[normalizeName(host)] = addresses
+}
+
+// AddTXT adds a TXT record.
+func (r *LocalResolver) AddTXT(name, value string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	name = normalizeName(name)
+	r.txt[name] = append(r.txt[name], value)
+}
+
+// RemoveTXT removes a TXT record.
+func (r *LocalResolver) RemoveTXT(name, value string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	name = normalizeName(name)
+
+	var values []string
+	for _, v := range r.txt[name] {
+		if v != value {
+			values = append(values, v)
+		}
+	}
+
+	if len(values) == 0 {
+		delete(r.txt, name)
+		return
+	}
+
+	r.txt[name] = values
+}
+
+// LookupHost returns the addresses of a host.
+func (r *LocalResolver) LookupHost(_ context.Context, host string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	addresses, ok := r.hosts[normalizeName(host)]
+	if !ok {
+		return []string{"127.0.0.1"}, nil
+	}
+
+	if len(addresses) == 0 {
+		return nil, fmt.Errorf("no such host: %s", host)
+	}
+
+	return append([]string{}, addresses...), nil
+}
+
+// LookupTXT returns the TXT records of a name.
+func (r *LocalResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string{}, r.txt[normalizeName(name)]...), nil
+}
+
+// Present adds the TXT record of a dns-01 challenge.
+func (r *LocalResolver) Present(domain, _, keyAuth string) error {
+	fqdn, value := dns01.GetRecord(domain, keyAuth)
+	r.AddTXT(fqdn, value)
+
+	return nil
+}
+
+// CleanUp removes the TXT record of a dns-01 challenge.
+func (r *LocalResolver) CleanUp(domain, _, keyAuth string) error {
+	fqdn, value := dns01.GetRecord(domain, keyAuth)
+	r.RemoveTXT(fqdn, value)
+
+	return nil
+}
+
+// PresentRecord adds a TXT record which is not the dns-01 record (dns01.RecordProvider).
+func (r *LocalResolver) PresentRecord(_, fqdn, value string) error {
+	r.AddTXT(fqdn, value)
+
+	return nil
+}
+
+// CleanUpRecord removes a TXT record added by PresentRecord.
+func (r *LocalResolver) CleanUpRecord(_, fqdn, value string) error {
+	r.RemoveTXT(fqdn, value)
+
+	return nil
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
@@ -0,0 +1,352 @@
+// Package acmeserver an in-process ACME server (RFC 8555) for the integration tests.
+//
+// The server implements the accounts (with External Account Binding), the orders,
+// the authorizations (and the pre-authorizations), the challenges (http-01, tls-alpn-01, dns-01, dns-account-01, dns-persist-01),
+// the finalization, the alternate chains, and the revocation.
+//
+// The challenges are validated against a pluggable Resolver (LocalResolver by default),
+// and faults can be injected in the responses (see Server.InjectFault).
+package acmeserver
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-acme/lego/v4/acme"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// The paths of the ACME resources.
+const (
+	directoryPath   = "/directory"
+	newNoncePath    = "/nonce"
+	newAccountPath  = "/new-account"
+	newOrderPath    = "/new-order"
+	newAuthzPath    = "/new-authz"
+	revokeCertPath  = "/revoke-cert"
+	keyChangePath   = "/key-change"
+	accountPath     = "/account/"
+	orderPath       = "/order/"
+	authzPath       = "/authz/"
+	challengePath   = "/challenge/"
+	certificatePath = "/certificate/"
+)
+
+// Options the options of the server.
+type Options struct {
+	// Resolver resolves the hosts and the TXT records used to validate the challenges.
+	// Default: a LocalResolver (all the hosts resolve to 127.0.0.1).
+	Resolver Resolver
+
+	// HTTPPort the port used to validate the http-01 challenges (default: 80).
+	HTTPPort int
+
+	// TLSPort the port used to validate the tls-alpn-01 challenges (default: 443).
+	TLSPort int
+
+	// SkipValidation the challenges are valid without validation.
+	SkipValidation bool
+
+	// DNSAccount01 offers the dns-account-01 challenge (in addition to the dns-01 challenge).
+	DNSAccount01 bool
+
+	// DNSPersist01 the issuer domain name of the server: if set, the dns-persist-01 challenge is offered
+	// for the DNS identifiers (the record is looked up with the Resolver).
+	DNSPersist01 string
+
+	// AlternateChains the number of alternate chains offered for the certificates (each chain has its own root).
+	AlternateChains int
+
+	// ExternalAccountBinding the EAB keys (key ID -> HMAC key), the EAB is required if not empty.
+	ExternalAccountBinding map[string][]byte
+
+	// SignatureAlgorithms the accepted JWS algorithms (default: RS256, ES256, ES384, ES512, EdDSA).
+	SignatureAlgorithms []string
+
+	// OrdersPageSize the maximum number of orders in a page of the orders list of an account,
+	// the next page is linked by a Link header (rel="next") (default: all the orders in one page).
+	OrdersPageSize int
+
+	// TermsOfService the URL of the terms of service.
+	TermsOfService string
+
+	// CertificateValidity the validity of the certificates (default: 90 days).
+	CertificateValidity time.Duration
+
+	// TLS serves the API over HTTPS, the server certificate is trusted by the client returned by Server.HTTPClient.
+	TLS bool
+}
+
+// Server an in-process ACME server.
+type Server struct {
+	options    Options
+	httpServer *httptest.Server
+	ca         *certificateAuthority
+
+	mu            sync.Mutex
+	nonces        map[string]bool
+	accounts      map[string]*account
+	accountsByKey map[string]*account
+	orders        map[string]*order
+	authzs        map[string]*authorization
+	challenges    map[string]*challengeRef
+	certificates  map[string]*issuedCertificate
+	faults        map[Fault]int
+}
+
+// New creates and starts a server.
+func New(options Options) (*Server, error) {
+	if options.Resolver == nil {
+		options.Resolver = NewLocalResolver()
+	}
+
+	if options.HTTPPort == 0 {
+		options.HTTPPort = 80
+	}
+
+	if options.TLSPort == 0 {
+		options.TLSPort = 443
+	}
+
+	if len(options.SignatureAlgorithms) == 0 {
+		options.SignatureAlgorithms = []string{
+			string(jose.RS256), string(jose.ES256), string(jose.ES384), string(jose.ES512), string(jose.EdDSA),
+		}
+	}
+
+	if options.CertificateValidity == 0 {
+		options.CertificateValidity = 90 * 24 * time.Hour
+	}
+
+	ca, err := newCertificateAuthority(options.AlternateChains)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		options:       options,
+		ca:            ca,
+		nonces:        map[string]bool{},
+		accounts:      map[string]*account{},
+		accountsByKey: map[string]*account{},
+		orders:        map[string]*order{},
+		authzs:        map[string]*authorization{},
+		challenges:    map[string]*challengeRef{},
+		certificates:  map[string]*issuedCertificate{},
+		faults:        map[Fault]int{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(directoryPath, s.handleDirectory)
+	mux.HandleFunc(newNoncePath, s.handleNewNonce)
+	mux.HandleFunc(newAccountPath, s.handleNewAccount)
+	mux.HandleFunc(newOrderPath, s.handleNewOrder)
+	mux.HandleFunc(newAuthzPath, s.handleNewAuthz)
+	mux.HandleFunc(revokeCertPath, s.handleRevokeCert)
+	mux.HandleFunc(keyChangePath, s.handleKeyChange)
+	mux.HandleFunc(accountPath, s.handleAccount)
+	mux.HandleFunc(orderPath, s.handleOrder)
+	mux.HandleFunc(authzPath, s.handleAuthz)
+	mux.HandleFunc(challengePath, s.handleChallenge)
+	mux.HandleFunc(certificatePath, s.handleCertificate)
+
+	if options.TLS {
+		s.httpServer = httptest.NewTLSServer(mux)
+	} else {
+		s.httpServer = httptest.NewServer(mux)
+	}
+
+	return s, nil
+}
+
+// NewTestServer creates and starts a server, the server is closed at the end of the test.
+func NewTestServer(tb testing.TB, options Options) *Server {
+	tb.Helper()
+
+	s, err := New(options)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	tb.Cleanup(s.Close)
+
+	return s
+}
+
+// URL returns the URL of the directory.
+func (s *Server) URL() string {
+	return s.httpServer.URL + directoryPath
+}
+
+// HTTPClient returns an HTTP client trusting the server (when the API is served over HTTPS).
+func (s *Server) HTTPClient() *http.Client {
+	return s.httpServer.Client()
+}
+
+// Resolver returns the resolver used to validate the challenges.
+func (s *Server) Resolver() Resolver {
+	return s.options.Resolver
+}
+
+// Roots returns the root certificates of the chains (the first root is the root of the default chain).
+func (s *Server) Roots() []*x509.Certificate {
+	var roots []*x509.Certificate
+	for _, chain := range s.ca.chains {
+		roots = append(roots, chain.root)
+	}
+
+	return roots
+}
+
+// RootPool returns the root certificates of the chains as a pool.
+func (s *Server) RootPool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	for _, root := range s.Roots() {
+		pool.AddCert(root)
+	}
+
+	return pool
+}
+
+// IsRevoked reports whether a certificate issued by the server has been revoked, and the revocation reason.
+func (s *Server) IsRevoked(cert *x509.Certificate) (bool, uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, issued := range s.certificates {
+		if issued.cert.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+			return issued.revoked, issued.reason
+		}
+	}
+
+	return false, 0
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.httpServer.Close()
+}
+
+func (s *Server) handleDirectory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, acme.Directory{
+		NewNonceURL:   s.url(newNoncePath),
+		NewAccountURL: s.url(newAccountPath),
+		NewOrderURL:   s.url(newOrderPath),
+		NewAuthzURL:   s.url(newAuthzPath),
+		RevokeCertURL: s.url(revokeCertPath),
+		KeyChangeURL:  s.url(keyChangePath),
+		Meta: acme.Meta{
+			TermsOfService:          s.options.TermsOfService,
+			ExternalAccountRequired: len(s.options.ExternalAccountBinding) > 0,
+		},
+	})
+}
+
+func (s *Server) handleNewNonce(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodHead:
+		s.addNonce(w)
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet:
+		s.addNonce(w)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleKeyChange(w http.ResponseWriter, _ *http.Request) {
+	s.writeError(w, newProblem(http.StatusNotImplemented, errMalformed, "the key change is not supported"))
+}
+
+func (s *Server) url(path string) string {
+	return s.httpServer.URL + path
+}
+
+// addNonce adds a new nonce to the response.
+func (s *Server) addNonce(w http.ResponseWriter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.addNonceLocked(w)
+}
+
+// addNonceLocked adds a new nonce to the response (the lock must be held).
+func (s *Server) addNonceLocked(w http.ResponseWriter) {
+	nonce := newID()
+	s.nonces[nonce] = true
+
+	w.Header().Set("Replay-Nonce", nonce)
+	w.Header().Set("Cache-Control", "no-store")
+}
+
+// useNonce consumes a nonce.
+func (s *Server) useNonce(nonce string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.nonces[nonce] {
+		return false
+	}
+
+	delete(s.nonces, nonce)
+
+	return true
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	s.addNonce(w)
+	w.Header().Add("Link", `<`+s.URL()+`>;rel="index"`)
+
+	writeJSON(w, "application/json", status, body)
+}
+
+func (s *Server) writeError(w http.ResponseWriter, problem *acme.ProblemDetails) {
+	s.addNonce(w)
+
+	writeJSON(w, "application/problem+json", problem.HTTPStatus, problem)
+}
+
+// resourceID returns the ID of a resource and the rest of the path (ex: /order/<id>/finalize).
+func resourceID(r *http.Request, prefix string) (string, string) {
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, prefix), "/", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+
+	return parts[0], parts[1]
+}
+
+func newID() string {
+	raw := make([]byte, 16)
+	_, _ = rand.Read(raw)
+
+	return hex.EncodeToString(raw)
+}
+
+func newSerialNumber() (*big.Int, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 127))
+	if err != nil {
+		return nil, err
+	}
+
+	if serial.Sign() == 0 {
+		return nil, errors.New("invalid serial number")
+	}
+
+	return serial, nil
+}
@@ -0,0 +1,518 @@
+package acmeserver
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-acme/lego/v4/acme"
+	"github.com/go-acme/lego/v4/certcrypto"
+	"github.com/go-acme/lego/v4/certificate"
+	"github.com/go-acme/lego/v4/challenge/dns01"
+	"github.com/go-acme/lego/v4/challenge/dnspersist01"
+	"github.com/go-acme/lego/v4/challenge/http01"
+	"github.com/go-acme/lego/v4/challenge/tlsalpn01"
+	"github.com/go-acme/lego/v4/lego"
+	"github.com/go-acme/lego/v4/registration"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeUser struct {
+	email        string
+	privateKey   crypto.PrivateKey
+	registration *registration.Resource
+}
+
+func (f *fakeUser) GetEmail() string                        { return f.email }
+func (f *fakeUser) GetRegistration() *registration.Resource { return f.registration }
+func (f *fakeUser) GetPrivateKey() crypto.PrivateKey        { return f.privateKey }
+
+func TestServer_http01(t *testing.T) {
+	port := freePort(t)
+
+	server := NewTestServer(t, Options{HTTPPort: port})
+
+	client := newClient(t, server)
+	register(t, client)
+
+	err := client.Challenge.SetHTTP01Provider(http01.NewProviderServer("127.0.0.1", strconv.Itoa(port)))
+	require.NoError(t, err)
+
+	certRes, err := client.Certificate.Obtain(certificate.ObtainRequest{Domains: []string{"example.com", "www.example.com"}, Bundle: true})
+	require.NoError(t, err)
+
+	cert := verify(t, server, certRes)
+	assert.Equal(t, []string{"example.com", "www.example.com"}, cert.DNSNames)
+
+	err = client.Certificate.Revoke(certRes.Certificate)
+	require.NoError(t, err)
+
+	revoked, _ := server.IsRevoked(cert)
+	assert.True(t, revoked)
+
+	err = client.Certificate.Revoke(certRes.Certificate)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), errAlreadyRevoked)
+}
+
+func TestServer_tlsalpn01(t *testing.T) {
+	port := freePort(t)
+
+	server := NewTestServer(t, Options{TLSPort: port})
+
+	client := newClient(t, server)
+	register(t, client)
+
+	err := client.Challenge.SetTLSALPN01Provider(tlsalpn01.NewProviderServer("127.0.0.1", strconv.Itoa(port)))
+	require.NoError(t, err)
+
+	certRes, err := client.Certificate.Obtain(certificate.ObtainRequest{Domains: []string{"example.com"}, Bundle: true})
+	require.NoError(t, err)
+
+	verify(t, server, certRes)
+}
+
+func TestServer_dns01(t *testing.T) {
+	resolver := NewLocalResolver()
+
+	server := NewTestServer(t, Options{Resolver: resolver, TLS: true})
+
+	client := newClient(t, server)
+	register(t, client)
+
+	setDNS01Provider(t, client, resolver)
+
+	certRes, err := client.Certificate.Obtain(certificate.ObtainRequest{Domains: []string{"*.example.com", "example.com"}, Bundle: true})
+	require.NoError(t, err)
+
+	cert := verify(t, server, certRes)
+	assert.Equal(t, []string{"*.example.com", "example.com"}, cert.DNSNames)
+
+	// the TXT records have been removed.
+	records, err := resolver.LookupTXT(context.Background(), "_acme-challenge.example.com")
+	require.NoError(t, err)
+	assert.Empty(t, records)
+}
+
+func TestServer_dnsAccount01(t *testing.T) {
+	resolver := NewLocalResolver()
+
+	server := NewTestServer(t, Options{Resolver: resolver, DNSAccount01: true})
+
+	client := newClient(t, server)
+	reg := register(t, client)
+
+	var checked []string
+
+	// the dns-account-01 challenge is preferred to the dns-01 challenge.
+	setDNS01Provider(t, client, resolver)
+
+	err := client.Challenge.SetDNSAccount01Provider(resolver, dns01.WrapPreCheck(func(_, fqdn, _ string, _ dns01.PreCheckFunc) (bool, error) {
+		checked = append(checked, fqdn)
+		return true, nil
+	}))
+	require.NoError(t, err)
+
+	certRes, err := client.Certificate.Obtain(certificate.ObtainRequest{Domains: []string{"example.com"}, Bundle: true})
+	require.NoError(t, err)
+
+	verify(t, server, certRes)
+
+	assert.Equal(t, []string{dns01.GetAccountLabel(reg.URI) + "._acme-challenge.example.com."}, checked)
+}
+
+func TestServer_dnsPersist01(t *testing.T) {
+	resolver := NewLocalResolver()
+
+	server := NewTestServer(t, Options{Resolver: resolver, DNSPersist01: "authority.example"})
+
+	client := newClient(t, server)
+	reg := register(t, client)
+
+	err := client.Challenge.SetDNSPersist01(dnspersist01.SetLookup(func(fqdn string) ([]string, error) {
+		return resolver.LookupTXT(context.Background(), fqdn)
+	}))
+	require.NoError(t, err)
+
+	request := certificate.ObtainRequest{Domains: []string{"*.example.com", "example.com"}, Bundle: true}
+
+	// no record.
+	_, err = client.Certificate.Obtain(request)
+	require.Error(t, err)
+
+	// the wildcard requires the wildcard policy.
+	record := dnspersist01.Record{IssuerDomainName: "authority.example", AccountURI: reg.URI}
+
+	require.NoError(t, dnspersist01.Present(resolver, "example.com", record))
+
+	_, err = client.Certificate.Obtain(request)
+	require.Error(t, err)
+
+	record.Policy = dnspersist01.PolicyWildcard
+	record.PersistUntil = time.Now().Add(time.Hour)
+
+	require.NoError(t, dnspersist01.Present(resolver, "example.com", record))
+
+	certRes, err := client.Certificate.Obtain(request)
+	require.NoError(t, err)
+
+	verify(t, server, certRes)
+}
+
+func TestServer_csrTemplate(t *testing.T) {
+	server := NewTestServer(t, Options{SkipValidation: true})
+
+	client := newClient(t, server)
+	register(t, client)
+
+	setDNS01Provider(t, client, NewLocalResolver())
+
+	certRes, err := client.Certificate.Obtain(certificate.ObtainRequest{
+		Domains:        []string{"example.com", "www.example.com"},
+		Bundle:         true,
+		CSRTemplate:    &x509.CertificateRequest{Subject: pkix.Name{Organization: []string{"Lego"}}},
+		OmitCommonName: true,
+		Verify:         &certificate.VerifyOptions{},
+	})
+	require.NoError(t, err)
+
+	cert := verify(t, server, certRes)
+
+	assert.Empty(t, cert.Subject.CommonName)
+	assert.ElementsMatch(t, []string{"example.com", "www.example.com"}, cert.DNSNames)
+}
+
+func TestServer_alternateChains(t *testing.T) {
+	server := NewTestServer(t, Options{SkipValidation: true, AlternateChains: 2})
+
+	client := newClient(t, server)
+	register(t, client)
+
+	setDNS01Provider(t, client, NewLocalResolver())
+
+	certRes, err := client.Certificate.Obtain(certificate.ObtainRequest{
+		Domains:        []string{"example.com"},
+		Bundle:         true,
+		PreferredChain: "acmeserver root 2",
+	})
+	require.NoError(t, err)
+
+	cert := verify(t, server, certRes)
+
+	issuer, err := certcrypto.ParsePEMCertificate(certRes.IssuerCertificate)
+	require.NoError(t, err)
+	assert.Equal(t, "acmeserver root 2", issuer.Issuer.CommonName)
+
+	_, err = cert.Verify(x509.VerifyOptions{Roots: pool(server.Roots()[2]), Intermediates: pool(issuer)})
+	require.NoError(t, err)
+
+	assert.Len(t, certRes.AlternateChains, 2)
+}
+
+func TestServer_externalAccountBinding(t *testing.T) {
+	hmacKey := []byte("secret-hmac-key-for-the-account")
+
+	server := NewTestServer(t, Options{ExternalAccountBinding: map[string][]byte{"kid-1": hmacKey}})
+
+	client := newClient(t, server)
+
+	_, err := client.Registration.Register(registration.RegisterOptions{TermsOfServiceAgreed: true})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), errExternalAccountRequired)
+
+	_, err = client.Registration.RegisterWithExternalAccountBinding(registration.RegisterEABOptions{
+		TermsOfServiceAgreed: true,
+		Kid:                  "kid-1",
+		HmacEncoded:          base64.RawURLEncoding.EncodeToString([]byte("invalid")),
+	})
+	require.Error(t, err)
+
+	reg, err := client.Registration.RegisterWithExternalAccountBinding(registration.RegisterEABOptions{
+		TermsOfServiceAgreed: true,
+		Kid:                  "kid-1",
+		HmacEncoded:          base64.RawURLEncoding.EncodeToString(hmacKey),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, acme.StatusValid, reg.Body.Status)
+}
+
+func TestServer_account(t *testing.T) {
+	server := NewTestServer(t, Options{})
+
+	client, user := newClientUser(t, server)
+
+	_, err := client.Registration.ResolveAccountByKey()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), errAccountDoesNotExist)
+
+	user.registration = register(t, client)
+
+	reg, err := client.Registration.ResolveAccountByKey()
+	require.NoError(t, err)
+	assert.Equal(t, user.registration.URI, reg.URI)
+
+	user.email = "bar@example.com"
+
+	reg, err = client.Registration.UpdateRegistration(registration.RegisterOptions{TermsOfServiceAgreed: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mailto:bar@example.com"}, reg.Body.Contact)
+
+	err = client.Registration.DeleteRegistration()
+	require.NoError(t, err)
+
+	_, err = client.Registration.QueryRegistration()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), errUnauthorized)
+}
+
+func TestServer_preAuthorizations(t *testing.T) {
+	server := NewTestServer(t, Options{SkipValidation: true})
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	user := &fakeUser{email: "foo@example.com", privateKey: privateKey}
+
+	storage := &memoryAuthorizationStorage{authorizations: map[string]certificate.AuthorizationResource{
+		"expired.example.com": {Domain: "expired.example.com", URL: server.URL() + "/authz/expired", Expires: time.Now().Add(-time.Hour)},
+	}}
+
+	config := lego.NewConfig(user)
+	config.CADirURL = server.URL()
+	config.HTTPClient = server.HTTPClient()
+	config.Certificate.AuthorizationStorage = storage
+
+	client, err := lego.NewClient(config)
+	require.NoError(t, err)
+
+	user.registration = register(t, client)
+
+	setDNS01Provider(t, client, NewLocalResolver())
+
+	resources, err := client.Certificate.Authorize([]string{"example.com", "bücher.example"})
+	require.NoError(t, err)
+	require.Len(t, resources, 2)
+
+	for _, res := range resources {
+		assert.Equal(t, acme.StatusValid, res.Status)
+	}
+
+	// the domains are stored in their ASCII form, the expired pre-authorizations are removed.
+	assert.Len(t, storage.authorizations, 2)
+	assert.Contains(t, storage.authorizations, "example.com")
+	assert.Contains(t, storage.authorizations, "xn--bcher-kva.example")
+
+	// the CA reuses the pre-authorization.
+	_, err = client.Certificate.Obtain(certificate.ObtainRequest{Domains: []string{"example.com"}, Bundle: true})
+	require.NoError(t, err)
+	assert.Contains(t, storage.authorizations, "example.com")
+
+	// the pre-authorization is not valid anymore (unknown for the CA).
+	storage.authorizations["example.org"] = certificate.AuthorizationResource{Domain: "example.org", URL: server.URL() + "/authz/unknown"}
+
+	_, err = client.Certificate.Obtain(certificate.ObtainRequest{Domains: []string{"example.org"}, Bundle: true})
+	require.NoError(t, err)
+	assert.NotContains(t, storage.authorizations, "example.org")
+
+	_, err = client.Certificate.Authorize([]string{"*.example.com"})
+	require.Error(t, err)
+
+	err = client.Certificate.DeactivatePreAuthorizations([]string{"bücher.example"})
+	require.NoError(t, err)
+	assert.NotContains(t, storage.authorizations, "xn--bcher-kva.example")
+
+	err = client.Certificate.DeactivatePreAuthorizations([]string{"example.com"})
+	require.NoError(t, err)
+	assert.Empty(t, storage.authorizations)
+}
+
+type memoryAuthorizationStorage struct {
+	authorizations map[string]certificate.AuthorizationResource
+}
+
+func (s *memoryAuthorizationStorage) LoadAuthorizations() (map[string]certificate.AuthorizationResource, error) {
+	authorizations := make(map[string]certificate.AuthorizationResource)
+	for domain, authz := range s.authorizations {
+		authorizations[domain] = authz
+	}
+
+	return authorizations, nil
+}
+
+func (s *memoryAuthorizationStorage) SaveAuthorizations(authorizations map[string]certificate.AuthorizationResource) error {
+	s.authorizations = authorizations
+	return nil
+}
+
+func TestServer_orders(t *testing.T) {
+	server := NewTestServer(t, Options{SkipValidation: true, OrdersPageSize: 1})
+
+	client := newClient(t, server)
+	register(t, client)
+
+	setDNS01Provider(t, client, NewLocalResolver())
+
+	for _, domain := range []string{"example.com", "example.org"} {
+		_, err := client.Certificate.Obtain(certificate.ObtainRequest{Domains: []string{domain}, Bundle: true})
+		require.NoError(t, err)
+	}
+
+	orders, err := client.Certificate.ListOrders()
+	require.NoError(t, err)
+	require.Len(t, orders, 2)
+
+	details, err := client.Certificate.GetOrderDetails(orders[1])
+	require.NoError(t, err)
+
+	assert.Equal(t, orders[1], details.Location)
+	assert.Equal(t, acme.StatusValid, details.Status)
+	assert.Equal(t, []acme.Identifier{{Type: "dns", Value: "example.org"}}, details.Identifiers)
+
+	require.Len(t, details.Authorizations, 1)
+	assert.Equal(t, acme.StatusValid, details.Authorizations[0].Status)
+	assert.Equal(t, "example.org", details.Authorizations[0].Identifier.Value)
+}
+
+func TestServer_faults(t *testing.T) {
+	server := NewTestServer(t, Options{SkipValidation: true})
+
+	client := newClient(t, server)
+
+	// the client retries the badNonce errors.
+	server.InjectFault(FaultBadNonce, 2)
+
+	register(t, client)
+
+	setDNS01Provider(t, client, NewLocalResolver())
+
+	request := certificate.ObtainRequest{Domains: []string{"example.com"}, Bundle: true}
+
+	server.InjectFault(FaultRateLimited, 1)
+
+	_, err := client.Certificate.Obtain(request)
+	require.Error(t, err)
+
+	var rateLimitErr *acme.RateLimitError
+	require.True(t, errors.As(err, &rateLimitErr))
+	assert.True(t, rateLimitErr.RetryAfter.After(time.Now()))
+
+	server.InjectFault(FaultInvalidChallenge, 1)
+
+	_, err = client.Certificate.Obtain(request)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), errIncorrectResponse)
+
+	certRes, err := client.Certificate.Obtain(request)
+	require.NoError(t, err)
+
+	verify(t, server, certRes)
+}
+
+func TestServer_badSignatureAlgorithm(t *testing.T) {
+	server := NewTestServer(t, Options{SignatureAlgorithms: []string{"RS256"}})
+
+	client := newClient(t, server)
+
+	_, err := client.Registration.Register(registration.RegisterOptions{TermsOfServiceAgreed: true})
+	require.Error(t, err)
+
+	var algErr *acme.BadSignatureAlgorithmError
+	require.True(t, errors.As(err, &algErr))
+	assert.Equal(t, []string{"RS256"}, algErr.Algorithms)
+}
+
+func newClient(t *testing.T, server *Server) *lego.Client {
+	t.Helper()
+
+	client, _ := newClientUser(t, server)
+
+	return client
+}
+
+func newClientUser(t *testing.T, server *Server) (*lego.Client, *fakeUser) {
+	t.Helper()
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	user := &fakeUser{email: "foo@example.com", privateKey: privateKey}
+
+	config := lego.NewConfig(user)
+	config.CADirURL = server.URL()
+	config.HTTPClient = server.HTTPClient()
+
+	client, err := lego.NewClient(config)
+	require.NoError(t, err)
+
+	return client, user
+}
+
+func register(t *testing.T, client *lego.Client) *registration.Resource {
+	t.Helper()
+
+	reg, err := client.Registration.Register(registration.RegisterOptions{TermsOfServiceAgreed: true})
+	require.NoError(t, err)
+
+	return reg
+}
+
+// setDNS01Provider sets a dns-01 provider without propagation check.
+func setDNS01Provider(t *testing.T, client *lego.Client, resolver *LocalResolver) {
+	t.Helper()
+
+	err := client.Challenge.SetDNS01Provider(resolver, dns01.WrapPreCheck(func(_, _, _ string, _ dns01.PreCheckFunc) (bool, error) {
+		return true, nil
+	}))
+	require.NoError(t, err)
+}
+
+// verify verifies the certificate of a resource with the default chain.
+func verify(t *testing.T, server *Server, certRes *certificate.Resource) *x509.Certificate {
+	t.Helper()
+
+	block, rest := pem.Decode(certRes.Certificate)
+	require.NotNil(t, block)
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+
+	intermediates := x509.NewCertPool()
+	require.True(t, intermediates.AppendCertsFromPEM(rest))
+
+	_, err = cert.Verify(x509.VerifyOptions{Roots: server.RootPool(), Intermediates: intermediates})
+	require.NoError(t, err)
+
+	return cert
+}
+
+func pool(certs ...*x509.Certificate) *x509.CertPool {
+	p := x509.NewCertPool()
+	for _, cert := range certs {
+		p.AddCert(cert)
+	}
+
+	return p
+}
+
+func freePort(t *testing.T) int {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	defer func() { _ = listener.Close() }()
+
+	return listener.Addr().(*net.TCPAddr).Port
+}
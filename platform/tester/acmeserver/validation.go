@@ -0,0 +1,275 @@
+package acmeserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/asn1"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-acme/lego/v4/acme"
+	"github.com/go-acme/lego/v4/challenge"
+	"github.com/go-acme/lego/v4/challenge/dns01"
+	"github.com/go-acme/lego/v4/challenge/dnspersist01"
+)
+
+const validationTimeout = 10 * time.Second
+
+// idPeAcmeIdentifier the id-pe-acmeIdentifier extension of the tls-alpn-01 certificates.
+// - https://tools.ietf.org/html/rfc8737#section-6.1
+var idPeAcmeIdentifier = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 31}
+
+// validate validates a challenge.
+// - https://tools.ietf.org/html/rfc8555#section-8
+func (s *Server) validate(chlg acme.Challenge, ident acme.Identifier, wildcard bool, acc *account) *acme.ProblemDetails {
+	if s.consumeFault(FaultInvalidChallenge) {
+		return newProblem(http.StatusForbidden, errIncorrectResponse, "injected fault")
+	}
+
+	if s.options.SkipValidation {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), validationTimeout)
+	defer cancel()
+
+	keyAuth := keyAuthorization(chlg.Token, acc.key)
+
+	switch challenge.Type(chlg.Type) {
+	case challenge.HTTP01:
+		return s.validateHTTP01(ctx, ident, chlg.Token, keyAuth)
+	case challenge.TLSALPN01:
+		return s.validateTLSALPN01(ctx, ident, keyAuth)
+	case challenge.DNS01:
+		return s.validateDNS01(ctx, "_acme-challenge."+ident.Value, keyAuth)
+	case challenge.DNSAccount01:
+		return s.validateDNS01(ctx, dns01.GetAccountLabel(acc.url)+"._acme-challenge."+ident.Value, keyAuth)
+	case challenge.DNSPersist01:
+		return s.validateDNSPersist01(ctx, ident, wildcard, acc)
+	default:
+		return malformed("unsupported challenge type: %s", chlg.Type)
+	}
+}
+
+// validateHTTP01 validates a http-01 challenge.
+// - https://tools.ietf.org/html/rfc8555#section-8.3
+func (s *Server) validateHTTP01(ctx context.Context, ident acme.Identifier, token, keyAuth string) *acme.ProblemDetails {
+	address, problem := s.resolve(ctx, ident)
+	if problem != nil {
+		return problem
+	}
+
+	u := "http://" + net.JoinHostPort(address, strconv.Itoa(s.options.HTTPPort)) + "/.well-known/acme-challenge/" + token
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return newProblem(http.StatusBadRequest, errConnection, "unable to create the request: %v", err)
+	}
+
+	req.Host = ident.Value
+
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return newProblem(http.StatusBadRequest, errConnection, "unable to fetch %s: %v", u, err)
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return unauthorized("invalid response from %s: %d", u, resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if err != nil {
+		return newProblem(http.StatusBadRequest, errConnection, "unable to read the response from %s: %v", u, err)
+	}
+
+	if strings.TrimSpace(string(body)) != keyAuth {
+		return newProblem(http.StatusForbidden, errIncorrectResponse, "the key authorization from %s doesn't match: %q", u, body)
+	}
+
+	return nil
+}
+
+// validateTLSALPN01 validates a tls-alpn-01 challenge.
+// - https://tools.ietf.org/html/rfc8737#section-3
+func (s *Server) validateTLSALPN01(ctx context.Context, ident acme.Identifier, keyAuth string) *acme.ProblemDetails {
+	address, problem := s.resolve(ctx, ident)
+	if problem != nil {
+		return problem
+	}
+
+	dialer := &tls.Dialer{
+		Config: &tls.Config{
+			ServerName:         ident.Value,
+			NextProtos:         []string{"acme-tls/1"},
+			InsecureSkipVerify: true,
+		},
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(address, strconv.Itoa(s.options.TLSPort)))
+	if err != nil {
+		return newProblem(http.StatusBadRequest, errConnection, "unable to connect to %s: %v", address, err)
+	}
+
+	defer func() { _ = conn.Close() }()
+
+	state := conn.(*tls.Conn).ConnectionState()
+
+	if state.NegotiatedProtocol != "acme-tls/1" {
+		return newProblem(http.StatusForbidden, errTLS, "the acme-tls/1 protocol has not been negotiated")
+	}
+
+	if len(state.PeerCertificates) == 0 {
+		return newProblem(http.StatusForbidden, errTLS, "no certificate")
+	}
+
+	cert := state.PeerCertificates[0]
+
+	if len(cert.DNSNames) != 1 || !strings.EqualFold(cert.DNSNames[0], ident.Value) {
+		return newProblem(http.StatusForbidden, errIncorrectResponse, "the certificate must contain only %s: %v", ident.Value, cert.DNSNames)
+	}
+
+	expected := sha256.Sum256([]byte(keyAuth))
+
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(idPeAcmeIdentifier) {
+			continue
+		}
+
+		if !ext.Critical {
+			return newProblem(http.StatusForbidden, errIncorrectResponse, "the acmeIdentifier extension must be critical")
+		}
+
+		var value []byte
+		if _, err = asn1.Unmarshal(ext.Value, &value); err != nil {
+			return newProblem(http.StatusForbidden, errIncorrectResponse, "invalid acmeIdentifier extension: %v", err)
+		}
+
+		if !bytes.Equal(value, expected[:]) {
+			return newProblem(http.StatusForbidden, errIncorrectResponse, "the key authorization of the acmeIdentifier extension doesn't match")
+		}
+
+		return nil
+	}
+
+	return newProblem(http.StatusForbidden, errIncorrectResponse, "the certificate doesn't contain the acmeIdentifier extension")
+}
+
+// validateDNS01 validates a dns-01 (or dns-account-01) challenge.
+// - https://tools.ietf.org/html/rfc8555#section-8.4
+// - https://datatracker.ietf.org/doc/draft-ietf-acme-dns-account-label/
+func (s *Server) validateDNS01(ctx context.Context, name, keyAuth string) *acme.ProblemDetails {
+	records, err := s.options.Resolver.LookupTXT(ctx, name)
+	if err != nil {
+		return newProblem(http.StatusBadRequest, errDNS, "unable to lookup the TXT records of %s: %v", name, err)
+	}
+
+	digest := sha256.Sum256([]byte(keyAuth))
+	expected := base64.RawURLEncoding.EncodeToString(digest[:])
+
+	for _, record := range records {
+		if record == expected {
+			return nil
+		}
+	}
+
+	return unauthorized("no TXT record of %s matches the key authorization (found %d records)", name, len(records))
+}
+
+// validateDNSPersist01 validates a dns-persist-01 challenge.
+// - https://datatracker.ietf.org/doc/draft-ietf-acme-dns-persist/
+func (s *Server) validateDNSPersist01(ctx context.Context, ident acme.Identifier, wildcard bool, acc *account) *acme.ProblemDetails {
+	fqdn, _ := dnspersist01.GetRecord(ident.Value, dnspersist01.Record{})
+
+	records, err := s.options.Resolver.LookupTXT(ctx, fqdn)
+	if err != nil {
+		return newProblem(http.StatusBadRequest, errDNS, "unable to lookup the TXT records of %s: %v", fqdn, err)
+	}
+
+	_, err = dnspersist01.FindRecord(records, []string{s.options.DNSPersist01}, acc.url, wildcard, time.Now())
+	if err != nil {
+		return unauthorized("invalid dns-persist-01 record %s: %v", fqdn, err)
+	}
+
+	return nil
+}
+
+// resolve returns the address used to validate an identifier.
+func (s *Server) resolve(ctx context.Context, ident acme.Identifier) (string, *acme.ProblemDetails) {
+	if ident.Type == "ip" {
+		return ident.Value, nil
+	}
+
+	addresses, err := s.options.Resolver.LookupHost(ctx, ident.Value)
+	if err != nil {
+		return "", newProblem(http.StatusBadRequest, errDNS, "unable to resolve %s: %v", ident.Value, err)
+	}
+
+	if len(addresses) == 0 {
+		return "", newProblem(http.StatusBadRequest, errDNS, "no address for %s", ident.Value)
+	}
+
+	return addresses[0], nil
+}
+
+func checkIdentifier(ident acme.Identifier) *acme.ProblemDetails {
+	switch ident.Type {
+	case "dns":
+		value := strings.TrimPrefix(ident.Value, "*.")
+		if value == "" || strings.Contains(value, "*") || strings.HasSuffix(value, ".") || net.ParseIP(value) != nil {
+			return newProblem(http.StatusBadRequest, errRejectedIdentifier, "invalid DNS identifier: %q", ident.Value)
+		}
+
+		return nil
+
+	case "ip":
+		if net.ParseIP(ident.Value) == nil {
+			return newProblem(http.StatusBadRequest, errRejectedIdentifier, "invalid IP identifier: %q", ident.Value)
+		}
+
+		return nil
+
+	default:
+		return newProblem(http.StatusBadRequest, errUnsupportedIdentifier, "unsupported identifier type: %q", ident.Type)
+	}
+}
+
+// challengeTypes returns the types of the challenges offered for an identifier.
+func (s *Server) challengeTypes(ident acme.Identifier, wildcard bool) []challenge.Type {
+	var types []challenge.Type
+
+	switch {
+	case wildcard:
+		types = []challenge.Type{challenge.DNS01}
+	case ident.Type == "ip":
+		return []challenge.Type{challenge.HTTP01}
+	default:
+		types = []challenge.Type{challenge.HTTP01, challenge.TLSALPN01, challenge.DNS01}
+	}
+
+	if s.options.DNSAccount01 {
+		types = append(types, challenge.DNSAccount01)
+	}
+
+	if s.options.DNSPersist01 != "" {
+		types = append(types, challenge.DNSPersist01)
+	}
+
+	return types
+}
+
+func identifierKey(ident acme.Identifier) string {
+	return fmt.Sprintf("%s:%s", ident.Type, strings.ToLower(ident.Value))
+}
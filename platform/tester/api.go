@@ -23,6 +23,7 @@ func SetupFakeAPI() (*http.ServeMux, string, func()) {
 			NewNonceURL:   ts.URL + "/nonce",
 			NewAccountURL: ts.URL + "/account",
 			NewOrderURL:   ts.URL + "/newOrder",
+			NewAuthzURL:   ts.URL + "/newAuthz",
 			RevokeCertURL: ts.URL + "/revokeCert",
 			KeyChangeURL:  ts.URL + "/keyChange",
 		})
@@ -0,0 +1,216 @@
+// Package dnsconformance a conformance test suite for the DNS providers.
+//
+// The suite checks the semantics expected by the dns-01 solver:
+// Present/CleanUp on the apex and on a subdomain of a zone,
+// two records for the same FQDN (wildcard and apex of the same certificate),
+// idempotent CleanUp, the values returned by Timeout and Sequential,
+// and the explicit records of the providers which implement dns01.RecordProvider.
+package dnsconformance
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-acme/lego/v4/challenge"
+	"github.com/go-acme/lego/v4/challenge/dns01"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// NewProvider creates the provider under test, it's called for each test of the suite.
+type NewProvider func(t *testing.T) challenge.Provider
+
+// Lookup returns the values of the TXT records of a FQDN.
+type Lookup func(fqdn string) ([]string, error)
+
+// Options the options of the suite.
+type Options struct {
+	// Domain the apex of the zone (required).
+	Domain string
+
+	// SubDomain a subdomain of the zone (default: "sub." + Domain).
+	SubDomain string
+
+	// Lookup is used to check the records after Present and CleanUp (optional).
+	Lookup Lookup
+
+	// SkipIdempotentCleanUp skips the tests of CleanUp on a missing record
+	// (the provider returns an error when the record doesn't exist).
+	SkipIdempotentCleanUp bool
+}
+
+type sequential interface {
+	Sequential() time.Duration
+}
+
+// Run runs the conformance suite.
+func Run(t *testing.T, newProvider NewProvider, options Options) {
+	t.Helper()
+
+	require.NotEmpty(t, options.Domain, "the domain is required")
+
+	if options.SubDomain == "" {
+		options.SubDomain = "sub." + options.Domain
+	}
+
+	s := &suite{newProvider: newProvider, options: options}
+
+	t.Run("Timeout", s.testTimeout)
+	t.Run("Sequential", s.testSequential)
+	t.Run("PresentCleanUp apex", func(t *testing.T) { s.testPresentCleanUp(t, options.Domain) })
+	t.Run("PresentCleanUp subdomain", func(t *testing.T) { s.testPresentCleanUp(t, options.SubDomain) })
+	t.Run("wildcard and apex", s.testWildcardAndApex)
+
+	if !options.SkipIdempotentCleanUp {
+		t.Run("idempotent CleanUp", s.testIdempotentCleanUp)
+	}
+
+	t.Run("PresentRecord CleanUpRecord", s.testPresentCleanUpRecord)
+}
+
+type suite struct {
+	newProvider NewProvider
+	options     Options
+}
+
+func (s *suite) testTimeout(t *testing.T) {
+	provider, ok := s.newProvider(t).(challenge.ProviderTimeout)
+	if !ok {
+		t.Skip("the provider doesn't implement challenge.ProviderTimeout")
+	}
+
+	timeout, interval := provider.Timeout()
+
+	assert.Positive(t, int64(timeout), "the timeout must be positive")
+	assert.Positive(t, int64(interval), "the interval must be positive")
+	assert.LessOrEqual(t, int64(interval), int64(timeout), "the interval must not exceed the timeout")
+}
+
+func (s *suite) testSequential(t *testing.T) {
+	provider, ok := s.newProvider(t).(sequential)
+	if !ok {
+		t.Skip("the provider doesn't implement Sequential")
+	}
+
+	assert.GreaterOrEqual(t, int64(provider.Sequential()), int64(0), "the sequential interval must not be negative")
+}
+
+func (s *suite) testPresentCleanUp(t *testing.T, domain string) {
+	provider := s.newProvider(t)
+
+	keyAuth := keyAuthorization(domain, 1)
+
+	require.NoError(t, provider.Present(domain, "", keyAuth))
+	s.assertRecord(t, domain, keyAuth, true)
+
+	require.NoError(t, provider.CleanUp(domain, "", keyAuth))
+	s.assertRecord(t, domain, keyAuth, false)
+}
+
+// testWildcardAndApex the wildcard and the apex of a certificate use the same FQDN with two values,
+// the records are created concurrently (unless the provider is sequential), and removed one by one.
+func (s *suite) testWildcardAndApex(t *testing.T) {
+	provider := s.newProvider(t)
+
+	domain := s.options.Domain
+	keyAuths := []string{keyAuthorization(domain, 1), keyAuthorization(domain, 2)}
+
+	if _, ok := provider.(sequential); ok {
+		for _, keyAuth := range keyAuths {
+			require.NoError(t, provider.Present(domain, "", keyAuth))
+		}
+	} else {
+		errs := make([]error, len(keyAuths))
+
+		var wg sync.WaitGroup
+		for i, keyAuth := range keyAuths {
+			wg.Add(1)
+
+			go func(i int, keyAuth string) {
+				defer wg.Done()
+				errs[i] = provider.Present(domain, "", keyAuth)
+			}(i, keyAuth)
+		}
+
+		wg.Wait()
+
+		for _, err := range errs {
+			require.NoError(t, err)
+		}
+	}
+
+	s.assertRecord(t, domain, keyAuths[0], true)
+	s.assertRecord(t, domain, keyAuths[1], true)
+
+	require.NoError(t, provider.CleanUp(domain, "", keyAuths[0]))
+	s.assertRecord(t, domain, keyAuths[0], false)
+	s.assertRecord(t, domain, keyAuths[1], true)
+
+	require.NoError(t, provider.CleanUp(domain, "", keyAuths[1]))
+	s.assertRecord(t, domain, keyAuths[1], false)
+}
+
+func (s *suite) testIdempotentCleanUp(t *testing.T) {
+	provider := s.newProvider(t)
+
+	domain := s.options.Domain
+	keyAuth := keyAuthorization(domain, 3)
+
+	require.NoError(t, provider.CleanUp(domain, "", keyAuth), "CleanUp of a missing record")
+
+	require.NoError(t, provider.Present(domain, "", keyAuth))
+	require.NoError(t, provider.CleanUp(domain, "", keyAuth))
+	require.NoError(t, provider.CleanUp(domain, "", keyAuth), "second CleanUp")
+
+	s.assertRecord(t, domain, keyAuth, false)
+}
+
+// testPresentCleanUpRecord the records which are not the dns-01 record (ex: dns-account-01) are created from their FQDN and their value.
+func (s *suite) testPresentCleanUpRecord(t *testing.T) {
+	provider, ok := s.newProvider(t).(dns01.RecordProvider)
+	if !ok {
+		t.Skip("the provider doesn't implement dns01.RecordProvider")
+	}
+
+	domain := s.options.Domain
+	fqdn := "_conformance._acme-challenge." + dns01.ToFqdn(domain)
+	value := keyAuthorization(domain, 4)
+
+	require.NoError(t, provider.PresentRecord(domain, fqdn, value))
+	s.assertFQDNRecord(t, fqdn, value, true)
+
+	require.NoError(t, provider.CleanUpRecord(domain, fqdn, value))
+	s.assertFQDNRecord(t, fqdn, value, false)
+}
+
+func (s *suite) assertRecord(t *testing.T, domain, keyAuth string, present bool) {
+	t.Helper()
+
+	fqdn, value := dns01.GetRecord(domain, keyAuth)
+
+	s.assertFQDNRecord(t, fqdn, value, present)
+}
+
+func (s *suite) assertFQDNRecord(t *testing.T, fqdn, value string, present bool) {
+	t.Helper()
+
+	if s.options.Lookup == nil {
+		return
+	}
+
+	values, err := s.options.Lookup(fqdn)
+	require.NoError(t, err)
+
+	if present {
+		assert.Contains(t, values, value, "the TXT record of %s is missing", fqdn)
+	} else {
+		assert.NotContains(t, values, value, "the TXT record of %s has not been removed", fqdn)
+	}
+}
+
+// keyAuthorization returns a deterministic key authorization, so the requests can be replayed.
+func keyAuthorization(domain string, index int) string {
+	return fmt.Sprintf("conformance-%s-%d", domain, index)
+}
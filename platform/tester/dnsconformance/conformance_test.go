@@ -0,0 +1,90 @@
+package dnsconformance
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-acme/lego/v4/challenge"
+	"github.com/go-acme/lego/v4/challenge/dns01"
+)
+
+// memoryProvider an in-memory provider conforming to the suite.
+type memoryProvider struct {
+	mu      sync.Mutex
+	records map[string][]string
+}
+
+func (p *memoryProvider) Present(domain, _, keyAuth string) error {
+	fqdn, value := dns01.GetRecord(domain, keyAuth)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.records[fqdn] = append(p.records[fqdn], value)
+
+	return nil
+}
+
+func (p *memoryProvider) CleanUp(domain, _, keyAuth string) error {
+	fqdn, value := dns01.GetRecord(domain, keyAuth)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var values []string
+	for _, v := range p.records[fqdn] {
+		if v != value {
+			values = append(values, v)
+		}
+	}
+
+	p.records[fqdn] = values
+
+	return nil
+}
+
+func (p *memoryProvider) PresentRecord(_, fqdn, value string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.records[fqdn] = append(p.records[fqdn], value)
+
+	return nil
+}
+
+func (p *memoryProvider) CleanUpRecord(_, fqdn, value string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var values []string
+	for _, v := range p.records[fqdn] {
+		if v != value {
+			values = append(values, v)
+		}
+	}
+
+	p.records[fqdn] = values
+
+	return nil
+}
+
+func (p *memoryProvider) Timeout() (timeout, interval time.Duration) {
+	return time.Minute, time.Second
+}
+
+func (p *memoryProvider) lookup(fqdn string) ([]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]string{}, p.records[fqdn]...), nil
+}
+
+func TestRun(t *testing.T) {
+	provider := &memoryProvider{records: map[string][]string{}}
+
+	Run(t, func(_ *testing.T) challenge.Provider { return provider }, Options{
+		Domain: "example.com",
+		Lookup: provider.lookup,
+	})
+}
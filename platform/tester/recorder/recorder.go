@@ -0,0 +1,371 @@
+// Package recorder a record/replay HTTP transport for the tests of the DNS providers.
+//
+// In record mode, the requests are sent to the real API, and the interactions are saved, sanitized, in a fixture file.
+// In replay mode, the responses are read from the fixture file, so the tests run offline and without credentials.
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// EnvRecord the environment variable used to enable the record mode in the tests (see NewTest).
+const EnvRecord = "LEGO_RECORD"
+
+// redacted the value of the sanitized headers.
+const redacted = "[REDACTED]"
+
+// Mode the mode of a Recorder.
+type Mode int
+
+// Modes.
+const (
+	// ModeReplay the responses are read from the fixture file.
+	ModeReplay Mode = iota
+	// ModeRecord the requests are sent to the real API, and the interactions are saved in the fixture file.
+	ModeRecord
+)
+
+// Request a recorded request.
+type Request struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+// Response a recorded response.
+type Response struct {
+	StatusCode int         `json:"status"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Interaction a recorded request and its response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Matcher reports whether a recorded request matches a request.
+type Matcher func(recorded, req *Request) bool
+
+// Options the options of a Recorder.
+type Options struct {
+	// Mode the mode of the recorder (default: ModeReplay).
+	Mode Mode
+
+	// Transport the transport used to send the requests in record mode (default: http.DefaultTransport).
+	Transport http.RoundTripper
+
+	// Secrets the values replaced by placeholders (value -> placeholder) in the URLs, the headers, and the bodies.
+	// The same replacements are applied to the requests in replay mode.
+	Secrets map[string]string
+
+	// SensitiveHeaders additional headers removed from the fixtures.
+	// The headers Authorization, Cookie, Set-Cookie, and the headers containing "token", "key", "secret", "auth", or "password" are always removed.
+	SensitiveHeaders []string
+
+	// Matcher matches the requests with the recorded requests in replay mode (default: MatchMethodURLBody).
+	Matcher Matcher
+}
+
+// Recorder a record/replay http.RoundTripper.
+type Recorder struct {
+	fixture string
+	options Options
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// New creates a Recorder.
+// In replay mode, the fixture file must exist.
+func New(fixture string, options Options) (*Recorder, error) {
+	if options.Transport == nil {
+		options.Transport = http.DefaultTransport
+	}
+
+	if options.Matcher == nil {
+		options.Matcher = MatchMethodURLBody
+	}
+
+	r := &Recorder{fixture: fixture, options: options}
+
+	if options.Mode == ModeRecord {
+		return r, nil
+	}
+
+	raw, err := ioutil.ReadFile(fixture)
+	if err != nil {
+		return nil, fmt.Errorf("recorder: %w", err)
+	}
+
+	if err = json.Unmarshal(raw, &r.interactions); err != nil {
+		return nil, fmt.Errorf("recorder: invalid fixture %s: %w", fixture, err)
+	}
+
+	r.used = make([]bool, len(r.interactions))
+
+	return r, nil
+}
+
+// NewTest creates a Recorder for a test, the mode is defined by the environment variable LEGO_RECORD.
+// At the end of the test, the fixture is saved (record mode), or the test fails if some interactions have not been replayed (replay mode).
+func NewTest(tb testing.TB, fixture string, options Options) *Recorder {
+	tb.Helper()
+
+	options.Mode = ModeFromEnv()
+
+	r, err := New(fixture, options)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	tb.Cleanup(func() {
+		if err := r.Stop(); err != nil {
+			tb.Error(err)
+		}
+	})
+
+	return r
+}
+
+// ModeFromEnv returns ModeRecord if the environment variable LEGO_RECORD is true.
+func ModeFromEnv() Mode {
+	if ok, _ := strconv.ParseBool(os.Getenv(EnvRecord)); ok {
+		return ModeRecord
+	}
+
+	return ModeReplay
+}
+
+// Mode returns the mode of the recorder.
+func (r *Recorder) Mode() Mode {
+	return r.options.Mode
+}
+
+// Client returns an HTTP client using the recorder.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	recReq := r.sanitizeRequest(req, body)
+
+	if r.options.Mode == ModeReplay {
+		return r.replay(req, recReq)
+	}
+
+	resp, err := r.options.Transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, Interaction{
+		Request: *recReq,
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Headers:    r.sanitizeHeaders(resp.Header),
+			Body:       r.replaceSecrets(string(respBody)),
+		},
+	})
+	r.mu.Unlock()
+
+	return resp, nil
+}
+
+// Stop saves the fixture (record mode),
+// or returns an error if some interactions have not been replayed (replay mode).
+func (r *Recorder) Stop() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.options.Mode == ModeRecord {
+		raw, err := json.MarshalIndent(r.interactions, "", "  ")
+		if err != nil {
+			return fmt.Errorf("recorder: %w", err)
+		}
+
+		if err = os.MkdirAll(filepath.Dir(r.fixture), 0o755); err != nil {
+			return fmt.Errorf("recorder: %w", err)
+		}
+
+		if err = ioutil.WriteFile(r.fixture, append(raw, '\n'), 0o644); err != nil {
+			return fmt.Errorf("recorder: %w", err)
+		}
+
+		return nil
+	}
+
+	var unused []string
+	for i, used := range r.used {
+		if !used {
+			unused = append(unused, r.interactions[i].Request.Method+" "+r.interactions[i].Request.URL)
+		}
+	}
+
+	if len(unused) > 0 {
+		return fmt.Errorf("recorder: %d interactions of %s have not been replayed: %s", len(unused), r.fixture, strings.Join(unused, ", "))
+	}
+
+	return nil
+}
+
+func (r *Recorder) replay(req *http.Request, recReq *Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.interactions {
+		if r.used[i] || !r.options.Matcher(&interaction.Request, recReq) {
+			continue
+		}
+
+		r.used[i] = true
+
+		header := http.Header{}
+		for k, v := range interaction.Response.Headers {
+			header[k] = append([]string{}, v...)
+		}
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          ioutil.NopCloser(strings.NewReader(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("recorder: no interaction of %s matches the request %s %s", r.fixture, recReq.Method, recReq.URL)
+}
+
+func (r *Recorder) sanitizeRequest(req *http.Request, body []byte) *Request {
+	return &Request{
+		Method:  req.Method,
+		URL:     r.replaceSecrets(req.URL.String()),
+		Headers: r.sanitizeHeaders(req.Header),
+		Body:    r.replaceSecrets(string(body)),
+	}
+}
+
+func (r *Recorder) sanitizeHeaders(headers http.Header) http.Header {
+	if len(headers) == 0 {
+		return nil
+	}
+
+	sanitized := http.Header{}
+
+	for key, values := range headers {
+		if r.isSensitiveHeader(key) {
+			sanitized[key] = []string{redacted}
+			continue
+		}
+
+		for _, value := range values {
+			sanitized[key] = append(sanitized[key], r.replaceSecrets(value))
+		}
+	}
+
+	return sanitized
+}
+
+func (r *Recorder) isSensitiveHeader(key string) bool {
+	key = strings.ToLower(key)
+
+	switch key {
+	case "authorization", "proxy-authorization", "cookie", "set-cookie":
+		return true
+	}
+
+	for _, word := range []string{"token", "key", "secret", "auth", "password"} {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+
+	for _, header := range r.options.SensitiveHeaders {
+		if strings.EqualFold(key, header) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// replaceSecrets replaces the secrets by their placeholders (the longest secrets first).
+func (r *Recorder) replaceSecrets(value string) string {
+	secrets := make([]string, 0, len(r.options.Secrets))
+	for secret := range r.options.Secrets {
+		if secret != "" {
+			secrets = append(secrets, secret)
+		}
+	}
+
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+
+	for _, secret := range secrets {
+		value = strings.ReplaceAll(value, secret, r.options.Secrets[secret])
+	}
+
+	return value
+}
+
+// MatchMethodURLBody matches the requests by method, URL, and body.
+func MatchMethodURLBody(recorded, req *Request) bool {
+	return MatchMethodURL(recorded, req) && recorded.Body == req.Body
+}
+
+// MatchMethodURL matches the requests by method and URL.
+func MatchMethodURL(recorded, req *Request) bool {
+	return recorded.Method == req.Method && recorded.URL == req.URL
+}
+
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	_ = req.Body.Close()
+
+	if err != nil {
+		return nil, errors.New("recorder: unable to read the request body")
+	}
+
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
@@ -0,0 +1,98 @@
+package recorder
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+
+		rw.Header().Set("X-Auth-Token", "session-token")
+		rw.Header().Set("Content-Type", "application/json")
+		_, _ = rw.Write([]byte(`{"path":"` + req.URL.Path + `","body":"` + strings.TrimSpace(string(body)) + `","token":"api-secret"}`))
+	}))
+	defer server.Close()
+
+	fixture := filepath.Join(t.TempDir(), "fixtures", "records.json")
+
+	secrets := map[string]string{server.URL: "https://api.example.com", "api-secret": "API_SECRET"}
+
+	// record.
+	rec, err := New(fixture, Options{Mode: ModeRecord, Secrets: secrets})
+	require.NoError(t, err)
+
+	body := doRequest(t, rec.Client(), server.URL+"/records", "api-secret", "one")
+	assert.JSONEq(t, `{"path":"/records","body":"one","token":"api-secret"}`, body)
+
+	doRequest(t, rec.Client(), server.URL+"/records", "api-secret", "two")
+
+	require.NoError(t, rec.Stop())
+
+	raw, err := ioutil.ReadFile(fixture)
+	require.NoError(t, err)
+
+	assert.NotContains(t, string(raw), "api-secret")
+	assert.NotContains(t, string(raw), "session-token")
+	assert.NotContains(t, string(raw), server.URL)
+	assert.Contains(t, string(raw), "https://api.example.com/records")
+
+	// replay: the requests are matched by method, URL, and body, and not by order.
+	server.Close()
+
+	rep, err := New(fixture, Options{Secrets: map[string]string{"other-secret": "API_SECRET"}})
+	require.NoError(t, err)
+
+	body = doRequest(t, rep.Client(), "https://api.example.com/records", "other-secret", "two")
+	assert.JSONEq(t, `{"path":"/records","body":"two","token":"API_SECRET"}`, body)
+
+	err = rep.Stop()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "1 interactions")
+
+	doRequest(t, rep.Client(), "https://api.example.com/records", "other-secret", "one")
+
+	require.NoError(t, rep.Stop())
+
+	// the interactions are replayed only once.
+	req, err := http.NewRequest(http.MethodPost, "https://api.example.com/records", strings.NewReader("one"))
+	require.NoError(t, err)
+
+	_, err = rep.Client().Do(req)
+	require.Error(t, err)
+}
+
+func TestNew_missingFixture(t *testing.T) {
+	_, err := New(filepath.Join(t.TempDir(), "missing.json"), Options{})
+	require.Error(t, err)
+}
+
+func doRequest(t *testing.T, client *http.Client, u, secret, body string) string {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, u, strings.NewReader(body))
+	require.NoError(t, err)
+
+	req.Header.Set("Authorization", "Bearer "+secret)
+	req.Header.Set("X-Request", secret)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+
+	defer func() { _ = resp.Body.Close() }()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	raw, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	return string(raw)
+}
@@ -3,8 +3,11 @@ package wait
 import (
 	"errors"
 	"fmt"
+	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/cenkalti/backoff/v4"
 	"github.com/go-acme/lego/v4/log"
 )
 
@@ -35,3 +38,129 @@ func For(msg string, timeout, interval time.Duration, f func() (bool, error)) er
 		time.Sleep(interval)
 	}
 }
+
+// ForRetryAfter polls the given function 'f' up to 'timeout'.
+// The delay before the next call is the 'retryAfter' value returned by 'f' (ex: the value of a Retry-After header),
+// or 'interval' if 'retryAfter' is zero.
+// The polling stops immediately if 'f' returns a permanent error (see backoff.Permanent).
+func ForRetryAfter(msg string, timeout, interval time.Duration, f func() (bool, time.Duration, error)) error {
+	log.Infof("Wait for %s [timeout: %s, interval: %s]", msg, timeout, interval)
+
+	var lastErr error
+	deadline := time.Now().Add(timeout)
+	for {
+		stop, retryAfter, err := f()
+		if stop {
+			return nil
+		}
+
+		var permanent *backoff.PermanentError
+		if errors.As(err, &permanent) {
+			return permanent.Err
+		}
+
+		if err != nil {
+			lastErr = err
+		}
+
+		delay := interval
+		if retryAfter > 0 {
+			delay = retryAfter
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 || delay > remaining {
+			if lastErr == nil {
+				return errors.New("time limit exceeded")
+			}
+			return fmt.Errorf("time limit exceeded: last error: %w", lastErr)
+		}
+
+		time.Sleep(delay)
+	}
+}
+
+// ParseRetryAfter parses the value of a Retry-After header (a delay in seconds or an HTTP date)
+// and returns the delay, or zero if the value is empty or invalid.
+// - https://tools.ietf.org/html/rfc7231#section-7.1.3
+func ParseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0
+	}
+
+	delay := time.Until(date)
+	if delay < 0 {
+		return 0
+	}
+
+	return delay
+}
+
+// retryAfterError asks Retry to wait for a given delay before the next attempt.
+type retryAfterError struct {
+	err   error
+	delay time.Duration
+}
+
+func (e *retryAfterError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryAfterError) Unwrap() error {
+	return e.err
+}
+
+// RetryAfter wraps the error 'err' of an operation to ask Retry to wait for 'delay' before the next attempt
+// (ex: the value of a Retry-After header) instead of the next backoff interval.
+func RetryAfter(err error, delay time.Duration) error {
+	return &retryAfterError{err: err, delay: delay}
+}
+
+// Retry calls the operation until it succeeds, returns a permanent error (see backoff.Permanent), or the backoff 'bo' stops.
+// The delay before the next attempt is the delay of an error wrapped with RetryAfter,
+// or the next interval of 'bo' otherwise.
+func Retry(operation backoff.Operation, bo backoff.BackOff, notify backoff.Notify) error {
+	b := &retryAfterBackOff{BackOff: bo}
+
+	return backoff.RetryNotify(func() error {
+		err := operation()
+
+		b.delay = 0
+
+		var e *retryAfterError
+		if errors.As(err, &e) {
+			b.delay = e.delay
+			return e.err
+		}
+
+		return err
+	}, b, notify)
+}
+
+// retryAfterBackOff replaces the next interval of a backoff by the delay asked by the last attempt.
+type retryAfterBackOff struct {
+	backoff.BackOff
+
+	delay time.Duration
+}
+
+func (b *retryAfterBackOff) NextBackOff() time.Duration {
+	next := b.BackOff.NextBackOff()
+	if next == backoff.Stop || b.delay <= 0 {
+		return next
+	}
+
+	return b.delay
+}
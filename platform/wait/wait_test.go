@@ -1,8 +1,12 @@
 package wait
 
 import (
+	"errors"
+	"net/http"
 	"testing"
 	"time"
+
+	"github.com/cenkalti/backoff/v4"
 )
 
 func TestForTimeout(t *testing.T) {
@@ -24,3 +28,96 @@ func TestForTimeout(t *testing.T) {
 		t.Logf("%v", err)
 	}
 }
+
+func TestForRetryAfter(t *testing.T) {
+	var calls int
+	err := ForRetryAfter("", 3*time.Second, 1*time.Second, func() (bool, time.Duration, error) {
+		calls++
+		return calls == 3, 10 * time.Millisecond, nil
+	})
+	if err != nil {
+		t.Errorf("expected no error; got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 calls; got %d", calls)
+	}
+}
+
+func TestForRetryAfter_permanent(t *testing.T) {
+	var calls int
+	err := ForRetryAfter("", 3*time.Second, 10*time.Millisecond, func() (bool, time.Duration, error) {
+		calls++
+		return false, 0, backoff.Permanent(errors.New("permanent"))
+	})
+	if err == nil || err.Error() != "permanent" {
+		t.Errorf("expected permanent error; got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 call; got %d", calls)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		value    string
+		expected time.Duration
+	}{
+		{desc: "empty", value: "", expected: 0},
+		{desc: "seconds", value: "120", expected: 120 * time.Second},
+		{desc: "negative", value: "-1", expected: 0},
+		{desc: "invalid", value: "foo", expected: 0},
+		{desc: "past date", value: "Wed, 21 Oct 2015 07:28:00 GMT", expected: 0},
+	}
+
+	for _, test := range testCases {
+		test := test
+		t.Run(test.desc, func(t *testing.T) {
+			if delay := ParseRetryAfter(test.value); delay != test.expected {
+				t.Errorf("expected %s; got %s", test.expected, delay)
+			}
+		})
+	}
+
+	delay := ParseRetryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	if delay <= 59*time.Minute || delay > time.Hour {
+		t.Errorf("expected about 1h; got %s", delay)
+	}
+}
+
+func TestRetry_retryAfter(t *testing.T) {
+	bo := backoff.NewConstantBackOff(time.Minute)
+
+	var calls int
+	start := time.Now()
+	err := Retry(func() error {
+		calls++
+		if calls < 3 {
+			return RetryAfter(errors.New("retry"), 10*time.Millisecond)
+		}
+		return nil
+	}, backoff.WithMaxRetries(bo, 5), nil)
+	if err != nil {
+		t.Errorf("expected no error; got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 calls; got %d", calls)
+	}
+	if elapsed := time.Since(start); elapsed >= time.Minute {
+		t.Errorf("expected the Retry-After delay; got %s", elapsed)
+	}
+}
+
+func TestRetry_stop(t *testing.T) {
+	var calls int
+	err := Retry(func() error {
+		calls++
+		return RetryAfter(errors.New("retry"), 10*time.Millisecond)
+	}, backoff.WithMaxRetries(&backoff.ZeroBackOff{}, 2), nil)
+	if err == nil || err.Error() != "retry" {
+		t.Errorf("expected the operation error; got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 calls; got %d", calls)
+	}
+}
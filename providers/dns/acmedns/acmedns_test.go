@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/cpu/goacmedns"
+	"github.com/go-acme/lego/v4/challenge/dns01"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -286,3 +287,12 @@ func TestRegister(t *testing.T) {
 		})
 	}
 }
+
+func TestPresentRecord(t *testing.T) {
+	dp, err := NewDNSProviderClient(mockClient{egTestAccount}, mockStorage{make(map[string]goacmedns.Account)})
+	require.NoError(t, err)
+
+	// the ACME-DNS server only serves the dns-01 record of the domain.
+	err = dns01.PresentRecord(dp, egDomain, "_validation-persist."+egDomain+".", "authority.example")
+	require.ErrorIs(t, err, dns01.ErrRecordNotSupported)
+}
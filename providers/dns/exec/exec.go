@@ -4,7 +4,6 @@ package exec
 import (
 	"errors"
 	"fmt"
-	"os"
 	"os/exec"
 	"time"
 
@@ -55,7 +54,7 @@ func NewDNSProvider() (*DNSProvider, error) {
 
 	config := NewDefaultConfig()
 	config.Program = values[EnvPath]
-	config.Mode = os.Getenv(EnvMode)
+	config.Mode = env.GetOrFile(EnvMode)
 
 	return NewDNSProviderConfig(config)
 }
@@ -72,34 +71,45 @@ func NewDNSProviderConfig(config *Config) (*DNSProvider, error) {
 
 // Present creates a TXT record to fulfill the dns-01 challenge.
 func (d *DNSProvider) Present(domain, token, keyAuth string) error {
-	var args []string
 	if d.config.Mode == "RAW" {
-		args = []string{"present", "--", domain, token, keyAuth}
-	} else {
-		fqdn, value := dns01.GetRecord(domain, keyAuth)
-		args = []string{"present", fqdn, value}
+		return d.run("present", "--", domain, token, keyAuth)
 	}
 
-	cmd := exec.Command(d.config.Program, args...)
-
-	output, err := cmd.CombinedOutput()
-	if len(output) > 0 {
-		log.Println(string(output))
-	}
+	fqdn, value := dns01.GetRecord(domain, keyAuth)
 
-	return err
+	return d.run("present", fqdn, value)
 }
 
 // CleanUp removes the TXT record matching the specified parameters.
 func (d *DNSProvider) CleanUp(domain, token, keyAuth string) error {
-	var args []string
 	if d.config.Mode == "RAW" {
-		args = []string{"cleanup", "--", domain, token, keyAuth}
-	} else {
-		fqdn, value := dns01.GetRecord(domain, keyAuth)
-		args = []string{"cleanup", fqdn, value}
+		return d.run("cleanup", "--", domain, token, keyAuth)
+	}
+
+	fqdn, value := dns01.GetRecord(domain, keyAuth)
+
+	return d.run("cleanup", fqdn, value)
+}
+
+// PresentRecord creates a TXT record which is not the dns-01 record (not supported by the RAW mode).
+func (d *DNSProvider) PresentRecord(_, fqdn, value string) error {
+	if d.config.Mode == "RAW" {
+		return fmt.Errorf("exec: RAW mode: %w", dns01.ErrRecordNotSupported)
+	}
+
+	return d.run("present", fqdn, value)
+}
+
+// CleanUpRecord removes a TXT record created by PresentRecord.
+func (d *DNSProvider) CleanUpRecord(_, fqdn, value string) error {
+	if d.config.Mode == "RAW" {
+		return fmt.Errorf("exec: RAW mode: %w", dns01.ErrRecordNotSupported)
 	}
 
+	return d.run("cleanup", fqdn, value)
+}
+
+func (d *DNSProvider) run(args ...string) error {
 	cmd := exec.Command(d.config.Program, args...)
 
 	output, err := cmd.CombinedOutput()
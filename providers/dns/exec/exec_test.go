@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/go-acme/lego/v4/challenge/dns01"
 	"github.com/go-acme/lego/v4/log"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -157,3 +158,36 @@ func TestDNSProvider_CleanUp(t *testing.T) {
 		})
 	}
 }
+
+func TestDNSProvider_PresentRecord(t *testing.T) {
+	backupLogger := log.Logger
+	defer func() {
+		log.Logger = backupLogger
+	}()
+
+	logRecorder := &LogRecorder{}
+	log.Logger = logRecorder
+
+	var message string
+	logRecorder.On("Println", mock.Anything).Run(func(args mock.Arguments) {
+		message = args.String(0)
+	})
+
+	provider, err := NewDNSProviderConfig(&Config{Program: "echo"})
+	require.NoError(t, err)
+
+	err = provider.PresentRecord("domain", "_validation-persist.domain.", "authority.example")
+	require.NoError(t, err)
+	assert.Equal(t, "present _validation-persist.domain. authority.example", strings.TrimSpace(message))
+
+	err = provider.CleanUpRecord("domain", "_validation-persist.domain.", "authority.example")
+	require.NoError(t, err)
+	assert.Equal(t, "cleanup _validation-persist.domain. authority.example", strings.TrimSpace(message))
+
+	// the RAW mode only receives the key authorization.
+	provider, err = NewDNSProviderConfig(&Config{Program: "echo", Mode: "RAW"})
+	require.NoError(t, err)
+
+	err = provider.PresentRecord("domain", "_validation-persist.domain.", "authority.example")
+	require.ErrorIs(t, err, dns01.ErrRecordNotSupported)
+}
@@ -165,6 +165,32 @@ func (d *DNSProvider) CleanUp(domain, token, keyAuth string) error {
 	return nil
 }
 
+// PresentRecord creates a TXT record which is not the dns-01 record (not supported by the RAW mode).
+func (d *DNSProvider) PresentRecord(_, fqdn, value string) error {
+	if d.config.Mode == "RAW" {
+		return fmt.Errorf("httpreq: RAW mode: %w", dns01.ErrRecordNotSupported)
+	}
+
+	err := d.doPost("/present", &message{FQDN: fqdn, Value: value})
+	if err != nil {
+		return fmt.Errorf("httpreq: %w", err)
+	}
+	return nil
+}
+
+// CleanUpRecord removes a TXT record created by PresentRecord.
+func (d *DNSProvider) CleanUpRecord(_, fqdn, value string) error {
+	if d.config.Mode == "RAW" {
+		return fmt.Errorf("httpreq: RAW mode: %w", dns01.ErrRecordNotSupported)
+	}
+
+	err := d.doPost("/cleanup", &message{FQDN: fqdn, Value: value})
+	if err != nil {
+		return fmt.Errorf("httpreq: %w", err)
+	}
+	return nil
+}
+
 func (d *DNSProvider) doPost(uri string, msg interface{}) error {
 	reqBody := &bytes.Buffer{}
 	err := json.NewEncoder(reqBody).Encode(msg)
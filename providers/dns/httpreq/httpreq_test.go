@@ -6,13 +6,23 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
 	"path"
+	"sync"
 	"testing"
 
+	"github.com/go-acme/lego/v4/challenge"
+	"github.com/go-acme/lego/v4/challenge/dns01"
 	"github.com/go-acme/lego/v4/platform/tester"
+	"github.com/go-acme/lego/v4/platform/tester/dnsconformance"
+	"github.com/go-acme/lego/v4/platform/tester/recorder"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// fixtureEndpoint the endpoint of the recorded interactions.
+const fixtureEndpoint = "https://httpreq.example.com"
+
 var envTest = tester.NewEnvTest(EnvEndpoint, EnvMode, EnvUsername, EnvPassword)
 
 func TestNewDNSProvider(t *testing.T) {
@@ -254,6 +264,101 @@ func TestNewDNSProvider_Cleanup(t *testing.T) {
 	}
 }
 
+func TestConformance(t *testing.T) {
+	records := &recordsHandler{records: map[string][]string{}}
+
+	server := httptest.NewServer(records)
+	t.Cleanup(server.Close)
+
+	dnsconformance.Run(t, func(t *testing.T) challenge.Provider {
+		t.Helper()
+
+		config := NewDefaultConfig()
+		config.Endpoint = mustParse(server.URL)
+
+		p, err := NewDNSProviderConfig(config)
+		require.NoError(t, err)
+
+		return p
+	}, dnsconformance.Options{Domain: "example.com", Lookup: records.lookup})
+}
+
+// TestConformance_replay replays the interactions of fixtures/conformance.json.
+// To record the fixture: LEGO_RECORD=true HTTPREQ_ENDPOINT=<endpoint> go test -run TestConformance_replay.
+func TestConformance_replay(t *testing.T) {
+	endpoint := fixtureEndpoint
+
+	var secrets map[string]string
+
+	if recorder.ModeFromEnv() == recorder.ModeRecord {
+		endpoint = os.Getenv(EnvEndpoint)
+		if endpoint == "" {
+			t.Skip("the record mode requires " + EnvEndpoint)
+		}
+
+		secrets = map[string]string{endpoint: fixtureEndpoint}
+	}
+
+	rec := recorder.NewTest(t, "fixtures/conformance.json", recorder.Options{Secrets: secrets})
+
+	dnsconformance.Run(t, func(t *testing.T) challenge.Provider {
+		t.Helper()
+
+		config := NewDefaultConfig()
+		config.Endpoint = mustParse(endpoint)
+		config.HTTPClient = rec.Client()
+
+		p, err := NewDNSProviderConfig(config)
+		require.NoError(t, err)
+
+		return p
+	}, dnsconformance.Options{Domain: "example.com"})
+}
+
+// recordsHandler an in-memory httpreq endpoint.
+type recordsHandler struct {
+	mu      sync.Mutex
+	records map[string][]string
+}
+
+func (h *recordsHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	msg := &message{}
+	err := json.NewDecoder(req.Body).Decode(msg)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	switch req.URL.Path {
+	case "/present":
+		h.records[msg.FQDN] = append(h.records[msg.FQDN], msg.Value)
+	case "/cleanup":
+		var values []string
+		for _, value := range h.records[msg.FQDN] {
+			if value != msg.Value {
+				values = append(values, value)
+			}
+		}
+
+		h.records[msg.FQDN] = values
+	default:
+		http.NotFound(rw, req)
+		return
+	}
+
+	fmt.Fprint(rw, "lego")
+}
+
+func (h *recordsHandler) lookup(fqdn string) ([]string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return append([]string{}, h.records[fqdn]...), nil
+}
+
 func successHandler(rw http.ResponseWriter, req *http.Request) {
 	if req.Method != http.MethodPost {
 		http.Error(rw, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
@@ -293,3 +398,39 @@ func mustParse(rawURL string) *url.URL {
 	}
 	return uri
 }
+
+func TestDNSProvider_PresentRecord(t *testing.T) {
+	handler := &recordsHandler{records: map[string][]string{}}
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	config := NewDefaultConfig()
+	config.Endpoint = mustParse(server.URL)
+
+	p, err := NewDNSProviderConfig(config)
+	require.NoError(t, err)
+
+	err = p.PresentRecord("example.com", "_validation-persist.example.com.", "authority.example")
+	require.NoError(t, err)
+
+	values, _ := handler.lookup("_validation-persist.example.com.")
+	assert.Equal(t, []string{"authority.example"}, values)
+
+	err = p.CleanUpRecord("example.com", "_validation-persist.example.com.", "authority.example")
+	require.NoError(t, err)
+
+	values, _ = handler.lookup("_validation-persist.example.com.")
+	assert.Empty(t, values)
+}
+
+func TestDNSProvider_PresentRecord_rawMode(t *testing.T) {
+	config := NewDefaultConfig()
+	config.Endpoint = mustParse("http://localhost:8090")
+	config.Mode = "RAW"
+
+	p, err := NewDNSProviderConfig(config)
+	require.NoError(t, err)
+
+	err = p.PresentRecord("example.com", "_validation-persist.example.com.", "authority.example")
+	require.ErrorIs(t, err, dns01.ErrRecordNotSupported)
+}
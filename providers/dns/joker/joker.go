@@ -3,7 +3,6 @@ package joker
 
 import (
 	"net/http"
-	"os"
 	"time"
 
 	"github.com/go-acme/lego/v4/challenge"
@@ -65,7 +64,7 @@ func NewDefaultConfig() *Config {
 // NewDNSProvider returns a DNSProvider instance configured for Joker.
 // Credentials must be passed in the environment variable JOKER_API_KEY.
 func NewDNSProvider() (challenge.ProviderTimeout, error) {
-	if os.Getenv(EnvMode) == modeSVC {
+	if env.GetOrFile(EnvMode) == modeSVC {
 		return newSvcProvider()
 	}
 
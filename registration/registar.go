@@ -141,6 +141,25 @@ func (r *Registrar) UpdateRegistration(options RegisterOptions) (*Resource, erro
 	return &Resource{URI: accountURL, Body: account}, nil
 }
 
+// AgreeToTermsOfService agrees to the current terms of service of the ACME server (ex: after a change of the terms of service).
+// - https://tools.ietf.org/html/rfc8555#section-7.3.3
+func (r *Registrar) AgreeToTermsOfService() (*Resource, error) {
+	if r == nil || r.user == nil || r.user.GetRegistration() == nil {
+		return nil, errors.New("acme: cannot agree to the terms of service with a nil client or user")
+	}
+
+	accountURL := r.user.GetRegistration().URI
+
+	log.Infof("acme: Agreeing to the terms of service for %s", accountURL)
+
+	account, err := r.core.Accounts.Update(accountURL, acme.Account{TermsOfServiceAgreed: true})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Resource{URI: accountURL, Body: account}, nil
+}
+
 // DeleteRegistration deletes the client's user registration from the ACME server.
 func (r *Registrar) DeleteRegistration() error {
 	if r == nil || r.user == nil {
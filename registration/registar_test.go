@@ -3,6 +3,7 @@ package registration
 import (
 	"crypto/rand"
 	"crypto/rsa"
+	"io/ioutil"
 	"net/http"
 	"testing"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/go-acme/lego/v4/platform/tester"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	jose "gopkg.in/square/go-jose.v2"
 )
 
 func TestRegistrar_ResolveAccountByKey(t *testing.T) {
@@ -47,3 +49,59 @@ func TestRegistrar_ResolveAccountByKey(t *testing.T) {
 
 	assert.Equal(t, "valid", res.Body.Status, "Unexpected account status")
 }
+
+func TestRegistrar_AgreeToTermsOfService(t *testing.T) {
+	mux, apiURL, tearDown := tester.SetupFakeAPI()
+	defer tearDown()
+
+	key, err := rsa.GenerateKey(rand.Reader, 512)
+	require.NoError(t, err, "Could not generate test key")
+
+	var payload string
+
+	mux.HandleFunc("/account", func(w http.ResponseWriter, r *http.Request) {
+		body, errR := ioutil.ReadAll(r.Body)
+		if errR != nil {
+			http.Error(w, errR.Error(), http.StatusBadRequest)
+			return
+		}
+
+		jws, errR := jose.ParseSigned(string(body))
+		if errR != nil {
+			http.Error(w, errR.Error(), http.StatusBadRequest)
+			return
+		}
+
+		content, errR := jws.Verify(&jose.JSONWebKey{Key: key.Public(), Algorithm: "RSA"})
+		if errR != nil {
+			http.Error(w, errR.Error(), http.StatusBadRequest)
+			return
+		}
+
+		payload = string(content)
+
+		errR = tester.WriteJSONResponse(w, acme.Account{Status: "valid", TermsOfServiceAgreed: true})
+		if errR != nil {
+			http.Error(w, errR.Error(), http.StatusInternalServerError)
+			return
+		}
+	})
+
+	user := mockUser{
+		email:      "test@test.com",
+		regres:     &Resource{URI: apiURL + "/account"},
+		privatekey: key,
+	}
+
+	core, err := api.New(http.DefaultClient, "lego-test", apiURL+"/dir", apiURL+"/account", key)
+	require.NoError(t, err)
+
+	registrar := NewRegistrar(core, user)
+
+	res, err := registrar.AgreeToTermsOfService()
+	require.NoError(t, err)
+
+	assert.Equal(t, `{"termsOfServiceAgreed":true}`, payload)
+	assert.Equal(t, apiURL+"/account", res.URI)
+	assert.True(t, res.Body.TermsOfServiceAgreed)
+}
@@ -9,5 +9,7 @@ import (
 type User interface {
 	GetEmail() string
 	GetRegistration() *Resource
+	// GetPrivateKey returns the account key: *rsa.PrivateKey, *ecdsa.PrivateKey,
+	// or any crypto.Signer (ex: a key stored in an HSM).
 	GetPrivateKey() crypto.PrivateKey
 }